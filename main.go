@@ -47,6 +47,9 @@ func main() {
 		Commands: []*cli.Command{
 			cmd.BlocksCommand,
 			cmd.ValidatorWindowCommand,
+			cmd.LabelsCommand,
+			cmd.ServeCommand,
+			cmd.RepairCommand,
 		},
 	}
 
@@ -0,0 +1,347 @@
+package alerts
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/migalabs/goteth/pkg/utils"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	log = logrus.WithField(
+		"module", "alerts",
+	)
+
+	webhookTimeout = 10 * time.Second
+
+	// epochs held while waiting for an earlier one, after that the missing epoch is skipped
+	maxPendingEpochs = 8
+)
+
+// ValidatorEpoch is the performance of a validator in an epoch, as computed by the analyzer
+type ValidatorEpoch struct {
+	ValidatorIndex    phase0.ValidatorIndex
+	PublicKey         phase0.BLSPubKey
+	Active            bool
+	MissedAttestation bool // active and no timely source vote included
+	Reward            int64
+	MaxReward         phase0.Gwei
+	MissedProposals   []phase0.Slot
+	Slashed           bool        // slashed during the epoch
+	ExitInitiated     bool        // exit epoch set during the epoch
+	BalanceDrop       phase0.Gwei // balance lost during the epoch, withdrawals excluded
+}
+
+// EpochReport has the validators watched by any rule
+type EpochReport struct {
+	Epoch      phase0.Epoch
+	Validators []ValidatorEpoch
+}
+
+// Alert is a rule triggered by a validator
+type Alert struct {
+	Rule           string                `json:"rule"`
+	Type           string                `json:"type"`
+	Epoch          phase0.Epoch          `json:"epoch"`
+	ValidatorIndex phase0.ValidatorIndex `json:"validator_index"`
+	Slot           *phase0.Slot          `json:"slot,omitempty"` // missed proposals
+	Message        string                `json:"message"`
+}
+
+// Notification is the body posted to the webhook
+type Notification struct {
+	Epoch  phase0.Epoch `json:"epoch"`
+	Alerts []Alert      `json:"alerts"`
+}
+
+// Engine evaluates the rules after each epoch and notifies the alerts to the webhook
+type Engine struct {
+	ctx     context.Context
+	config  *Config
+	tracked *utils.TrackedValidators
+	client  *http.Client
+
+	mu        sync.Mutex
+	evaluated bool
+	nextEpoch phase0.Epoch                              // the next epoch to evaluate, once one was
+	pending   map[phase0.Epoch]EpochReport              // epochs processed after nextEpoch, waiting for it
+	streaks   map[string]map[phase0.ValidatorIndex]int  // rule -> attestations missed in a row
+	firing    map[string]map[phase0.ValidatorIndex]bool // rule -> validators failing a stateful rule
+	wg        sync.WaitGroup
+}
+
+func NewEngine(ctx context.Context, config *Config, tracked *utils.TrackedValidators) *Engine {
+	e := &Engine{
+		ctx:     ctx,
+		config:  config,
+		tracked: tracked,
+		client:  &http.Client{Timeout: webhookTimeout},
+		pending: make(map[phase0.Epoch]EpochReport),
+		streaks: make(map[string]map[phase0.ValidatorIndex]int),
+		firing:  make(map[string]map[phase0.ValidatorIndex]bool),
+	}
+	for _, rule := range config.Rules {
+		e.streaks[rule.Name] = make(map[phase0.ValidatorIndex]int)
+		e.firing[rule.Name] = make(map[phase0.ValidatorIndex]bool)
+		if len(rule.validators) == 0 && tracked == nil {
+			log.Warnf("alert rule %s is evaluated on every validator, set its validators or --validators-file", rule.Name)
+		}
+	}
+	return e
+}
+
+// NewRangeEngine returns an engine with the rules of e and none of its state, for a
+// range of epochs evaluated apart (i.e. a backfill chunk). Nil if e is nil
+func (e *Engine) NewRangeEngine() *Engine {
+	if e == nil {
+		return nil
+	}
+	return NewEngine(e.ctx, e.config, e.tracked)
+}
+
+// Watches returns whether any rule evaluates the validator
+func (e *Engine) Watches(valIdx phase0.ValidatorIndex, pubkey phase0.BLSPubKey) bool {
+	if e == nil {
+		return false
+	}
+	for _, rule := range e.config.Rules {
+		if e.ruleWatches(rule, valIdx, pubkey) {
+			return true
+		}
+	}
+	return false
+}
+
+func (e *Engine) ruleWatches(rule Rule, valIdx phase0.ValidatorIndex, pubkey phase0.BLSPubKey) bool {
+	if len(rule.validators) > 0 {
+		return rule.validators[valIdx]
+	}
+	return e.tracked.Contains(valIdx, pubkey)
+}
+
+// Process evaluates the epoch and posts the alerts to the webhook in the background
+func (e *Engine) Process(report EpochReport) {
+	if e == nil {
+		return
+	}
+	e.notifyAll(e.Evaluate(report))
+}
+
+// notifyAll posts the alerts of each epoch to the webhook in the background
+func (e *Engine) notifyAll(alerts []Alert) {
+	if len(alerts) == 0 || e.config.Webhook == "" {
+		return
+	}
+	for len(alerts) > 0 {
+		end := 1
+		for end < len(alerts) && alerts[end].Epoch == alerts[0].Epoch {
+			end++
+		}
+		notification := Notification{Epoch: alerts[0].Epoch, Alerts: alerts[:end]}
+		alerts = alerts[end:]
+
+		e.wg.Add(1)
+		go func() {
+			defer e.wg.Done()
+			if err := e.notify(notification); err != nil {
+				log.Errorf("could not notify %d alerts of epoch %d: %s", len(notification.Alerts), notification.Epoch, err)
+			}
+		}()
+	}
+}
+
+// Evaluate returns the alerts triggered in the epochs evaluated with the report.
+// Epochs finish processing out of order, so they are evaluated in order: an epoch is
+// held until the ones before it are evaluated, or until maxPendingEpochs are held, then
+// the missing ones are skipped. An epoch already evaluated (i.e. reprocessed after a
+// reorg) is skipped so streaks are not counted twice
+func (e *Engine) Evaluate(report EpochReport) []Alert {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if !e.evaluated {
+		e.evaluated = true
+		e.nextEpoch = report.Epoch
+	}
+	if report.Epoch < e.nextEpoch {
+		log.Debugf("epoch %d already evaluated, skipping alerts", report.Epoch)
+		return nil
+	}
+	e.pending[report.Epoch] = report
+	return e.evaluatePending(false)
+}
+
+// evaluatePending evaluates the epochs held in order, while there is no epoch missing
+// (unless all is set) or too many are held. Must be called holding the lock
+func (e *Engine) evaluatePending(all bool) []Alert {
+	alerts := make([]Alert, 0)
+	for len(e.pending) > 0 {
+		if _, ok := e.pending[e.nextEpoch]; !ok {
+			if !all && len(e.pending) <= maxPendingEpochs {
+				break
+			}
+			// the missing epochs are skipped
+			first := e.nextEpoch
+			for epoch := range e.pending {
+				if first == e.nextEpoch || epoch < first {
+					first = epoch
+				}
+			}
+			log.Warnf("epochs %d to %d were not processed, evaluating the alerts from epoch %d", e.nextEpoch, first-1, first)
+			e.nextEpoch = first
+		}
+		alerts = append(alerts, e.evaluateEpoch(e.pending[e.nextEpoch])...)
+		delete(e.pending, e.nextEpoch)
+		e.nextEpoch++
+	}
+	return alerts
+}
+
+// evaluateEpoch returns the alerts triggered in the epoch. Must be called holding the lock
+func (e *Engine) evaluateEpoch(report EpochReport) []Alert {
+	alerts := make([]Alert, 0)
+	for _, rule := range e.config.Rules {
+		for _, val := range report.Validators {
+			if !e.ruleWatches(rule, val.ValidatorIndex, val.PublicKey) {
+				continue
+			}
+			alerts = append(alerts, e.evaluateRule(rule, report.Epoch, val)...)
+		}
+	}
+	for _, alert := range alerts {
+		AlertsFired.WithLabelValues(alert.Rule, alert.Type).Inc()
+	}
+	LastEvaluatedEpoch.Set(float64(report.Epoch))
+	return alerts
+}
+
+func (e *Engine) evaluateRule(rule Rule, epoch phase0.Epoch, val ValidatorEpoch) []Alert {
+	newAlert := func(format string, args ...any) Alert {
+		return Alert{
+			Rule:           rule.Name,
+			Type:           rule.Type,
+			Epoch:          epoch,
+			ValidatorIndex: val.ValidatorIndex,
+			Message:        fmt.Sprintf("validator %d ", val.ValidatorIndex) + fmt.Sprintf(format, args...),
+		}
+	}
+
+	var failing bool
+	var alert Alert
+	switch rule.Type {
+	case MissedAttestations:
+		if val.MissedAttestation {
+			e.streaks[rule.Name][val.ValidatorIndex]++
+		} else if val.Active {
+			delete(e.streaks[rule.Name], val.ValidatorIndex)
+		}
+		streak := e.streaks[rule.Name][val.ValidatorIndex]
+		failing = streak >= rule.Count
+		alert = newAlert("missed %d attestations in a row", streak)
+	case LowEffectiveness:
+		if !val.Active || val.MaxReward == 0 {
+			return nil
+		}
+		effectiveness := float64(val.Reward) * 100 / float64(val.MaxReward)
+		failing = effectiveness < rule.Threshold
+		alert = newAlert("earned %.2f%% of its max reward, below %.2f%%", effectiveness, rule.Threshold)
+	case MissedProposal:
+		alerts := make([]Alert, 0, len(val.MissedProposals))
+		for _, slot := range val.MissedProposals {
+			alert := newAlert("missed the proposal of slot %d", slot)
+			alert.Slot = &slot
+			alerts = append(alerts, alert)
+		}
+		return alerts
+	case Slashed:
+		if val.Slashed {
+			return []Alert{newAlert("was slashed")}
+		}
+		return nil
+	case ExitInitiated:
+		if val.ExitInitiated {
+			return []Alert{newAlert("initiated its exit")}
+		}
+		return nil
+	case BalanceDrop:
+		if uint64(val.BalanceDrop) >= rule.MinDropGwei {
+			return []Alert{newAlert("lost %d gwei", val.BalanceDrop)}
+		}
+		return nil
+	}
+
+	// stateful rules alert once, until the validator recovers
+	wasFiring := e.firing[rule.Name][val.ValidatorIndex]
+	if !failing {
+		delete(e.firing[rule.Name], val.ValidatorIndex)
+		return nil
+	}
+	e.firing[rule.Name][val.ValidatorIndex] = true
+	if wasFiring {
+		return nil
+	}
+	return []Alert{alert}
+}
+
+// Firing returns the number of validators failing each stateful rule
+func (e *Engine) Firing() map[string]int {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	firing := make(map[string]int)
+	for _, rule := range e.config.Rules {
+		if rule.stateful() {
+			firing[rule.Name] = len(e.firing[rule.Name])
+		}
+	}
+	return firing
+}
+
+func (e *Engine) notify(notification Notification) error {
+	body, err := json.Marshal(notification)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(e.ctx, webhookTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.config.Webhook, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range e.config.WebhookHeaders {
+		req.Header.Set(key, value)
+	}
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return errors.Errorf("webhook answered %s", resp.Status)
+	}
+	return nil
+}
+
+// Wait evaluates the epochs still held, in order, and waits until the pending
+// notifications are sent
+func (e *Engine) Wait() {
+	if e == nil {
+		return
+	}
+	e.mu.Lock()
+	alerts := e.evaluatePending(true)
+	e.mu.Unlock()
+	e.notifyAll(alerts)
+
+	e.wg.Wait()
+}
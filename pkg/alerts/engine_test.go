@@ -0,0 +1,171 @@
+package alerts
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/migalabs/goteth/pkg/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeConfig(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), "alerts.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	return path
+}
+
+func alertTypes(alerts []Alert) []string {
+	types := make([]string, 0, len(alerts))
+	for _, alert := range alerts {
+		types = append(types, alert.Type)
+	}
+	return types
+}
+
+func TestReadConfigFile(t *testing.T) {
+	config, err := ReadConfigFile(writeConfig(t, `
+webhook: http://localhost:9000/alerts
+rules:
+  - type: missed_attestations
+  - name: low
+    type: low_effectiveness
+    threshold: 80
+    validators: [1, 2]
+`))
+	require.NoError(t, err)
+	require.Len(t, config.Rules, 2)
+	assert.Equal(t, MissedAttestations, config.Rules[0].Name)
+	assert.Equal(t, 1, config.Rules[0].Count)
+	assert.True(t, config.Rules[1].validators[2])
+
+	invalid := map[string]string{
+		"unknown type":      "rules: [{type: late}]",
+		"no threshold":      "rules: [{type: low_effectiveness}]",
+		"no min drop":       "rules: [{type: balance_drop}]",
+		"duplicated rule":   "rules: [{type: slashed}, {type: slashed}]",
+		"no rules":          "webhook: http://localhost",
+		"threshold above %": "rules: [{type: low_effectiveness, threshold: 120}]",
+	}
+	for name, content := range invalid {
+		_, err := ReadConfigFile(writeConfig(t, content))
+		assert.Error(t, err, name)
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	config := &Config{Rules: []Rule{
+		{Type: MissedAttestations, Count: 2},
+		{Type: LowEffectiveness, Threshold: 80},
+		{Type: MissedProposal},
+		{Type: Slashed},
+		{Type: ExitInitiated},
+		{Type: BalanceDrop, MinDropGwei: 1_000_000_000},
+		{Name: "other", Type: Slashed, Validators: []uint64{9}},
+	}}
+	require.NoError(t, config.validate())
+	tracked := &utils.TrackedValidators{Indexes: map[phase0.ValidatorIndex]bool{1: true}}
+	engine := NewEngine(context.Background(), config, tracked)
+
+	assert.True(t, engine.Watches(1, phase0.BLSPubKey{}))
+	assert.True(t, engine.Watches(9, phase0.BLSPubKey{}))
+	assert.False(t, engine.Watches(2, phase0.BLSPubKey{}))
+
+	missed := ValidatorEpoch{ValidatorIndex: 1, Active: true, MissedAttestation: true, Reward: -100, MaxReward: 1000}
+	ok := ValidatorEpoch{ValidatorIndex: 1, Active: true, Reward: 1000, MaxReward: 1000}
+
+	// low effectiveness alerts once, missed attestations once the streak reaches the count
+	assert.Equal(t, []string{LowEffectiveness}, alertTypes(engine.Evaluate(EpochReport{Epoch: 10, Validators: []ValidatorEpoch{missed}})))
+	assert.Equal(t, []string{MissedAttestations}, alertTypes(engine.Evaluate(EpochReport{Epoch: 11, Validators: []ValidatorEpoch{missed}})))
+	assert.Empty(t, engine.Evaluate(EpochReport{Epoch: 12, Validators: []ValidatorEpoch{missed}}))
+	assert.Equal(t, map[string]int{MissedAttestations: 1, LowEffectiveness: 1}, engine.Firing())
+
+	// reprocessed epochs are not evaluated again
+	assert.Empty(t, engine.Evaluate(EpochReport{Epoch: 12, Validators: []ValidatorEpoch{missed}}))
+
+	// recovering resets the streak
+	assert.Empty(t, engine.Evaluate(EpochReport{Epoch: 13, Validators: []ValidatorEpoch{ok}}))
+	assert.Equal(t, map[string]int{MissedAttestations: 0, LowEffectiveness: 0}, engine.Firing())
+	assert.Equal(t, []string{LowEffectiveness}, alertTypes(engine.Evaluate(EpochReport{Epoch: 14, Validators: []ValidatorEpoch{missed}})))
+
+	// event rules alert every time, only on the validators of their set
+	events := ok
+	events.MissedProposals = []phase0.Slot{480, 481}
+	events.Slashed = true
+	events.ExitInitiated = true
+	events.BalanceDrop = 1_500_000_000
+	slashed := ValidatorEpoch{ValidatorIndex: 9, Active: true, Slashed: true}
+	alerts := engine.Evaluate(EpochReport{Epoch: 15, Validators: []ValidatorEpoch{events, slashed}})
+	assert.Equal(t, []string{MissedProposal, MissedProposal, Slashed, ExitInitiated, BalanceDrop, Slashed}, alertTypes(alerts))
+	require.NotNil(t, alerts[1].Slot)
+	assert.Equal(t, phase0.Slot(481), *alerts[1].Slot)
+	assert.Equal(t, "other", alerts[5].Rule)
+	assert.Equal(t, phase0.ValidatorIndex(9), alerts[5].ValidatorIndex)
+}
+
+func TestEvaluateOutOfOrder(t *testing.T) {
+	config := &Config{Rules: []Rule{{Type: MissedAttestations, Count: 3}}}
+	require.NoError(t, config.validate())
+	engine := NewEngine(context.Background(), config, nil)
+	missed := EpochReport{Validators: []ValidatorEpoch{{ValidatorIndex: 1, Active: true, MissedAttestation: true}}}
+	ok := EpochReport{Validators: []ValidatorEpoch{{ValidatorIndex: 1, Active: true}}}
+	report := func(epoch phase0.Epoch, r EpochReport) EpochReport {
+		r.Epoch = epoch
+		return r
+	}
+
+	// epoch 12 finishes before 11, it waits so the streak is not reset by 12 first
+	assert.Empty(t, engine.Evaluate(report(10, missed)))
+	assert.Empty(t, engine.Evaluate(report(12, ok)))
+	assert.Empty(t, engine.Evaluate(report(11, missed)))
+	assert.Equal(t, map[string]int{MissedAttestations: 0}, engine.Firing())
+	assert.Empty(t, engine.Evaluate(report(11, missed)), "already evaluated")
+
+	// 13 is never processed: once too many epochs are held, it is skipped
+	for epoch := phase0.Epoch(14); epoch < 14+phase0.Epoch(maxPendingEpochs); epoch++ {
+		assert.Empty(t, engine.Evaluate(report(epoch, missed)))
+	}
+	alerts := engine.Evaluate(report(14+phase0.Epoch(maxPendingEpochs), missed))
+	require.Len(t, alerts, 1)
+	assert.Equal(t, phase0.Epoch(16), alerts[0].Epoch)
+
+	// a range evaluated apart starts with no streaks
+	rangeEngine := engine.NewRangeEngine()
+	assert.Empty(t, rangeEngine.Evaluate(report(5, missed)))
+	assert.Equal(t, map[string]int{MissedAttestations: 0}, rangeEngine.Firing())
+}
+
+func TestWebhook(t *testing.T) {
+	received := make(chan Notification, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+		var notification Notification
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&notification))
+		received <- notification
+	}))
+	defer srv.Close()
+
+	config := &Config{
+		Webhook:        srv.URL,
+		WebhookHeaders: map[string]string{"Authorization": "Bearer secret"},
+		Rules:          []Rule{{Type: Slashed}},
+	}
+	require.NoError(t, config.validate())
+	engine := NewEngine(context.Background(), config, nil)
+
+	engine.Process(EpochReport{Epoch: 20, Validators: []ValidatorEpoch{{ValidatorIndex: 4}}})
+	engine.Process(EpochReport{Epoch: 21, Validators: []ValidatorEpoch{{ValidatorIndex: 4, Slashed: true}}})
+	engine.Wait()
+
+	require.Len(t, received, 1)
+	notification := <-received
+	assert.Equal(t, phase0.Epoch(21), notification.Epoch)
+	require.Len(t, notification.Alerts, 1)
+	assert.Equal(t, "validator 4 was slashed", notification.Alerts[0].Message)
+}
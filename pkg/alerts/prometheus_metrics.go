@@ -0,0 +1,83 @@
+package alerts
+
+import (
+	"strings"
+
+	"github.com/migalabs/goteth/pkg/metrics"
+	"github.com/migalabs/goteth/pkg/utils"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	modName    = "alerts"
+	modDetails = "alerts triggered by the validators"
+
+	AlertsFired = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: strings.ToLower(utils.CliName),
+			Subsystem: modName,
+			Name:      "fired_total",
+			Help:      "Number of alerts triggered",
+		},
+		[]string{
+			"rule",
+			"type",
+		},
+	)
+	AlertsFiring = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: strings.ToLower(utils.CliName),
+			Subsystem: modName,
+			Name:      "firing_validators",
+			Help:      "Validators currently failing the missed_attestations and low_effectiveness rules",
+		},
+		[]string{
+			"rule",
+		},
+	)
+	LastEvaluatedEpoch = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: strings.ToLower(utils.CliName),
+		Subsystem: modName,
+		Name:      "last_evaluated_epoch",
+		Help:      "Last epoch evaluated by the alert rules",
+	})
+)
+
+func (e *Engine) GetPrometheusMetrics() *metrics.MetricsModule {
+	metricsMod := metrics.NewMetricsModule(
+		modName,
+		modDetails,
+	)
+	metricsMod.AddIndvMetric(e.getAlertsMetrics())
+	return metricsMod
+}
+
+func (e *Engine) getAlertsMetrics() *metrics.IndvMetrics {
+	initFn := func() error {
+		prometheus.MustRegister(AlertsFired)
+		prometheus.MustRegister(AlertsFiring)
+		prometheus.MustRegister(LastEvaluatedEpoch)
+		return nil
+	}
+
+	updateFn := func() (interface{}, error) {
+		firing := e.Firing()
+		for rule, validators := range firing {
+			AlertsFiring.WithLabelValues(rule).Set(float64(validators))
+		}
+		return firing, nil
+	}
+
+	indvMetr, err := metrics.NewIndvMetrics(
+		"alerts",
+		initFn,
+		updateFn,
+	)
+	if err != nil {
+		log.Error(errors.Wrap(err, "unable to init alerts"))
+		return nil
+	}
+
+	return indvMetr
+}
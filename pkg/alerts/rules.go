@@ -0,0 +1,109 @@
+package alerts
+
+import (
+	"os"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// Rule types
+const (
+	MissedAttestations = "missed_attestations" // count attestations missed in a row
+	LowEffectiveness   = "low_effectiveness"   // reward/max_reward below threshold (%)
+	MissedProposal     = "missed_proposal"
+	Slashed            = "slashed"
+	ExitInitiated      = "exit_initiated"
+	BalanceDrop        = "balance_drop" // balance lost in an epoch above min_drop_gwei, withdrawals excluded
+)
+
+var ruleTypes = []string{MissedAttestations, LowEffectiveness, MissedProposal, Slashed, ExitInitiated, BalanceDrop}
+
+// Rule is evaluated on every validator of its set after each epoch is processed.
+// An empty set evaluates the tracked validators (see --validators-file)
+type Rule struct {
+	Name        string   `yaml:"name"`
+	Type        string   `yaml:"type"`
+	Count       int      `yaml:"count"`
+	Threshold   float64  `yaml:"threshold"`
+	MinDropGwei uint64   `yaml:"min_drop_gwei"`
+	Validators  []uint64 `yaml:"validators"`
+
+	validators map[phase0.ValidatorIndex]bool
+}
+
+// Config is the alerts file, i.e.:
+//
+//	webhook: https://hooks.example.com/goteth
+//	rules:
+//	  - name: offline
+//	    type: missed_attestations
+//	    count: 3
+type Config struct {
+	Webhook        string            `yaml:"webhook"`
+	WebhookHeaders map[string]string `yaml:"webhook_headers"`
+	Rules          []Rule            `yaml:"rules"`
+}
+
+// ReadConfigFile reads and validates the rules of the alerts file
+func ReadConfigFile(path string) (*Config, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to read alerts file")
+	}
+	var config Config
+	if err := yaml.Unmarshal(content, &config); err != nil {
+		return nil, errors.Wrap(err, "unable to parse alerts file")
+	}
+	if err := config.validate(); err != nil {
+		return nil, err
+	}
+	return &config, nil
+}
+
+func (c *Config) validate() error {
+	if len(c.Rules) == 0 {
+		return errors.New("no alert rules defined")
+	}
+	names := make(map[string]bool)
+	for i := range c.Rules {
+		rule := &c.Rules[i]
+		if rule.Name == "" {
+			rule.Name = rule.Type
+		}
+		if names[rule.Name] {
+			return errors.Errorf("duplicated alert rule %s", rule.Name)
+		}
+		names[rule.Name] = true
+
+		switch rule.Type {
+		case MissedAttestations:
+			if rule.Count <= 0 {
+				rule.Count = 1
+			}
+		case LowEffectiveness:
+			if rule.Threshold <= 0 || rule.Threshold > 100 {
+				return errors.Errorf("rule %s: threshold must be a percentage between 0 and 100", rule.Name)
+			}
+		case BalanceDrop:
+			if rule.MinDropGwei == 0 {
+				return errors.Errorf("rule %s: min_drop_gwei is required", rule.Name)
+			}
+		case MissedProposal, Slashed, ExitInitiated:
+		default:
+			return errors.Errorf("rule %s: unknown type %s, expected one of %v", rule.Name, rule.Type, ruleTypes)
+		}
+
+		rule.validators = make(map[phase0.ValidatorIndex]bool, len(rule.Validators))
+		for _, valIdx := range rule.Validators {
+			rule.validators[phase0.ValidatorIndex(valIdx)] = true
+		}
+	}
+	return nil
+}
+
+// stateful rules only alert when a validator starts failing them, not on every epoch
+func (r Rule) stateful() bool {
+	return r.Type == MissedAttestations || r.Type == LowEffectiveness
+}
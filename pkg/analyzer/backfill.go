@@ -0,0 +1,145 @@
+package analyzer
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/migalabs/goteth/pkg/clientapi"
+	"github.com/migalabs/goteth/pkg/spec"
+	"github.com/migalabs/goteth/pkg/utils"
+)
+
+// chunkWarmUpEpochs are the epochs downloaded before the first epoch of a chunk: the
+// transition of its first epoch needs the two previous states, and the transition before
+// fills the rewards of the blocks read by the block rewards of the first epoch
+const chunkWarmUpEpochs = 3
+
+// backfillChunk is a part of a historical range processed concurrently with the rest
+type backfillChunk struct {
+	slotRange
+	processFrom phase0.Epoch // first epoch persisted, 0 to persist every epoch downloaded
+}
+
+// splitChunks splits the range in up to n chunks of whole epochs. The epochs of each
+// chunk are a multiple of the rewards aggregation window, so no window is split.
+// Every chunk but the first one starts with its warm-up epochs, already persisted by the
+// previous chunk.
+func splitChunks(pending slotRange, n int, aggregationEpochs int) []backfillChunk {
+	// the first epoch transition processed needs the two previous states, the last
+	// slot of the range only adds its block
+	first := spec.EpochAtSlot(pending.init) + 2
+	last := spec.EpochAtSlot(pending.end)
+	if spec.ComputeStartSlotAtEpoch(last+1)-1 != pending.end {
+		last--
+	}
+	if n <= 1 || last < first {
+		return []backfillChunk{{slotRange: pending}}
+	}
+
+	window := phase0.Epoch(max(aggregationEpochs, 1))
+	size := (last - first + phase0.Epoch(n)) / phase0.Epoch(n) // rounded up
+	size = (size + window - 1) / window * window
+	// a chunk shorter than its warm-up is not worth downloading
+	size = max(size, (chunkWarmUpEpochs+window-1)/window*window)
+
+	chunks := []backfillChunk{{slotRange: pending}}
+	for from := first + size; from <= last; from += size {
+		chunks[len(chunks)-1].end = spec.ComputeStartSlotAtEpoch(from) - 1
+		chunks = append(chunks, backfillChunk{
+			slotRange: slotRange{
+				init: spec.ComputeStartSlotAtEpoch(from - chunkWarmUpEpochs),
+				end:  pending.end,
+			},
+			processFrom: from,
+		})
+	}
+	return chunks
+}
+
+// runChunks processes the chunks of the range concurrently and waits for all of them
+func (s *ChainAnalyzer) runChunks(pending slotRange) {
+	chunks := splitChunks(pending, s.backfillChunks, s.rewardsAggregationEpochs)
+	log.Infof("backfilling slots %d to %d in %d chunks", pending.init, pending.end, len(chunks))
+
+	var wg sync.WaitGroup
+	for i, chunk := range chunks {
+		wg.Add(1)
+		go func(worker *ChainAnalyzer) {
+			defer wg.Done()
+			worker.runChunk()
+		}(s.newChunkAnalyzer(i, chunk))
+	}
+	wg.Wait()
+}
+
+// newChunkAnalyzer returns the analyzer of a chunk: it shares the clients and the storage
+// with s, and keeps its own cache, processer book, rewards aggregation window and alert state
+func (s *ChainAnalyzer) newChunkAnalyzer(i int, chunk backfillChunk) *ChainAnalyzer {
+	startEpochAggregation, endEpochAggregation := s.startEpochAggregation, s.endEpochAggregation
+	if chunk.processFrom > 0 {
+		startEpochAggregation = chunk.processFrom
+		endEpochAggregation = chunk.processFrom + phase0.Epoch(s.rewardsAggregationEpochs-1)
+	}
+	var statesCli *clientapi.APIClient
+	if len(s.statesClis) > 0 {
+		statesCli = s.statesClis[i%len(s.statesClis)]
+	}
+
+	return &ChainAnalyzer{
+		ctx:                           s.ctx,
+		cancel:                        s.cancel, // a failing chunk stops the whole backfill
+		beaconContractAddress:         s.beaconContractAddress,
+		initSlot:                      chunk.init,
+		finalSlot:                     chunk.end,
+		downloadTaskChan:              make(chan phase0.Slot, rateLimit),
+		cli:                           s.cli,
+		relayCli:                      s.relayCli,
+		statesCli:                     statesCli,
+		eventsObj:                     s.eventsObj,
+		dbClient:                      s.dbClient,
+		stream:                        s.stream,
+		alerts:                        s.alerts.NewRangeEngine(), // the streaks of a chunk are its own
+		progress:                      s.progress,
+		routineClosed:                 make(chan struct{}, 1),
+		downloadMode:                  s.downloadMode,
+		rewardsAggregationEpochs:      s.rewardsAggregationEpochs,
+		startEpochAggregation:         startEpochAggregation,
+		endEpochAggregation:           endEpochAggregation,
+		processFrom:                   chunk.processFrom,
+		metrics:                       s.metrics,
+		trackedValidators:             s.trackedValidators,
+		PromMetrics:                   s.PromMetrics,
+		downloadCache:                 NewQueue(),
+		validatorsRewardsAggregations: make(map[phase0.ValidatorIndex]*spec.ValidatorRewardsAggregation),
+		aggregatedEpochsInWindow:      make(map[phase0.Epoch]bool),
+		processerBook:                 utils.NewRoutineBook(int(spec.SlotsPerEpoch), fmt.Sprintf("processer-chunk-%d", i)),
+		wgMainRoutine:                 &sync.WaitGroup{},
+		wgDownload:                    &sync.WaitGroup{},
+		initTime:                      s.initTime,
+	}
+}
+
+// runChunk downloads and processes the slots of the chunk
+func (s *ChainAnalyzer) runChunk() {
+	log.Infof("chunk %d - %d: persisting from epoch %d", s.initSlot, s.finalSlot, s.processFrom)
+
+	s.wgDownload.Add(1)
+	go s.runDownloadBlocks()
+
+	s.rangeInitSlot = s.initSlot
+	s.wgMainRoutine.Add(1) // add because historical will defer it
+	s.runHistorical(s.initSlot, s.finalSlot)
+
+	s.stop = true
+	s.wgDownload.Wait()
+	s.alerts.Wait()
+}
+
+// statesClient returns the client the states are downloaded from
+func (s *ChainAnalyzer) statesClient() *clientapi.APIClient {
+	if s.statesCli != nil {
+		return s.statesCli
+	}
+	return s.cli
+}
@@ -0,0 +1,84 @@
+package analyzer
+
+import (
+	"testing"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/migalabs/goteth/pkg/spec"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitChunks(t *testing.T) {
+	epoch := spec.ComputeStartSlotAtEpoch
+	// historical ranges start 2 epochs before the first epoch and finish at the first slot after the last one
+	pending := slotRange{init: epoch(8), end: epoch(30)}
+
+	assert.Equal(t, []backfillChunk{
+		{slotRange: slotRange{init: epoch(8), end: epoch(15) - 1}},
+		{slotRange: slotRange{init: epoch(12), end: epoch(20) - 1}, processFrom: 15},
+		{slotRange: slotRange{init: epoch(17), end: epoch(25) - 1}, processFrom: 20},
+		{slotRange: slotRange{init: epoch(22), end: epoch(30)}, processFrom: 25},
+	}, splitChunks(pending, 4, 1))
+
+	// aggregation windows are never split
+	assert.Equal(t, []backfillChunk{
+		{slotRange: slotRange{init: epoch(8), end: epoch(18) - 1}},
+		{slotRange: slotRange{init: epoch(15), end: epoch(26) - 1}, processFrom: 18},
+		{slotRange: slotRange{init: epoch(23), end: epoch(30)}, processFrom: 26},
+	}, splitChunks(pending, 4, 4))
+
+	// ranges shorter than the warm-up of a chunk are not split
+	assert.Equal(t, []backfillChunk{{slotRange: slotRange{init: 0, end: epoch(4)}}}, splitChunks(slotRange{init: 0, end: epoch(4)}, 4, 1))
+	assert.Equal(t, []backfillChunk{{slotRange: pending}}, splitChunks(pending, 1, 1))
+}
+
+func TestChunkWarmUp(t *testing.T) {
+	chain := newTestChain(t)
+	for slot := phase0.Slot(0); slot < spec.ComputeStartSlotAtEpoch(6); slot++ {
+		chain.addBlocks(testGraffiti, slot)
+	}
+	// votes of epoch 3 included in its own blocks, rewarded to their proposers by the transition of epoch 3
+	chain.addAttestation(spec.ComputeStartSlotAtEpoch(3)+1, spec.ComputeStartSlotAtEpoch(3), 0, 1)
+	chain.addAttestation(spec.ComputeStartSlotAtEpoch(3)+5, spec.ComputeStartSlotAtEpoch(3)+2, 0)
+
+	// sequential run
+	analyzer, sink := newTestAnalyzer(t, chain, spec.ComputeStartSlotAtEpoch(6)-1)
+	analyzer.addTestStates(t, chain, 1, 2, 3, 4, 5)
+	for _, epoch := range []phase0.Epoch{3, 4, 5} {
+		analyzer.ProcessStateTransitionMetrics(epoch)
+	}
+
+	// a chunk persisting from epoch 4 downloads epochs 1 to 3 to warm up
+	chunkParent, chunkSink := newTestAnalyzer(t, chain, 0)
+	chunk := chunkParent.newChunkAnalyzer(1, backfillChunk{
+		slotRange:   slotRange{init: spec.ComputeStartSlotAtEpoch(1), end: spec.ComputeStartSlotAtEpoch(6) - 1},
+		processFrom: 4,
+	})
+	for slot := spec.ComputeStartSlotAtEpoch(1); slot < spec.ComputeStartSlotAtEpoch(6); slot++ {
+		chunk.downloadCache.AddNewBlock(chain.agnosticBlock(t, slot))
+	}
+	chunk.addTestStates(t, chain, 1, 2, 3, 4, 5)
+	for slot := spec.ComputeStartSlotAtEpoch(1); slot < spec.ComputeStartSlotAtEpoch(6); slot++ {
+		chunk.ProcessBlock(slot)
+	}
+	for epoch := phase0.Epoch(1); epoch <= 5; epoch++ {
+		chunk.ProcessStateTransitionMetrics(epoch)
+	}
+
+	// the warm-up epochs are not persisted
+	blocks := chunkSink.Rows("t_block_metrics")
+	require.Len(t, blocks, 2*int(spec.SlotsPerEpoch))
+	assert.Equal(t, uint64(spec.ComputeStartSlotAtEpoch(4)), blocks[0].Uint64("f_slot"))
+	assert.Empty(t, chunkSink.Where("t_validator_rewards_summary", "f_epoch", 3))
+	assert.Len(t, chunkSink.Rows("t_epoch_metrics_summary"), 2)
+
+	// the first transition reads the block rewards filled by the warm-up, as in a sequential run
+	expected := sink.Where("t_block_rewards", "f_slot", uint64(spec.ComputeStartSlotAtEpoch(3)+1))
+	require.Len(t, expected, 1)
+	assert.NotZero(t, expected[0].Uint64("f_cl_manual_reward"))
+	for slot := spec.ComputeStartSlotAtEpoch(3); slot < spec.ComputeStartSlotAtEpoch(5); slot++ {
+		assert.Equal(t, sink.Where("t_block_rewards", "f_slot", uint64(slot)), chunkSink.Where("t_block_rewards", "f_slot", uint64(slot)), "slot %d", slot)
+	}
+	assert.Equal(t, sink.Where("t_validator_rewards_summary", "f_epoch", 4), chunkSink.Where("t_validator_rewards_summary", "f_epoch", 4))
+}
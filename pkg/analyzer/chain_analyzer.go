@@ -2,17 +2,20 @@ package analyzer
 
 import (
 	"context"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/attestantio/go-eth2-client/spec/phase0"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/migalabs/goteth/pkg/alerts"
 	"github.com/migalabs/goteth/pkg/clientapi"
 	"github.com/migalabs/goteth/pkg/config"
 	"github.com/migalabs/goteth/pkg/db"
 	prom_metrics "github.com/migalabs/goteth/pkg/metrics"
 	"github.com/migalabs/goteth/pkg/relay"
 	"github.com/migalabs/goteth/pkg/spec"
+	"github.com/migalabs/goteth/pkg/stream"
 	"github.com/migalabs/goteth/pkg/utils"
 
 	"github.com/migalabs/goteth/pkg/events"
@@ -34,10 +37,15 @@ type ChainAnalyzer struct {
 	downloadTaskChan chan phase0.Slot // channel to send download tasks
 
 	// Connections
-	cli       *clientapi.APIClient // client to request data to the CL and EL clients
-	relayCli  *relay.RelaysMonitor // client to monitor all relays in list
-	eventsObj events.Events        // object to receive signals from beacon node
-	dbClient  *db.DBService        // client to communicate with clickhouse
+	cli        *clientapi.APIClient   // client to request data to the CL and EL clients
+	relayCli   *relay.RelaysMonitor   // client to monitor all relays in list
+	statesCli  *clientapi.APIClient   // client the states are downloaded from, the beacon node client if nil
+	statesClis []*clientapi.APIClient // clients the states of the backfill chunks are downloaded from, in turns
+	eventsObj  events.Events          // object to receive signals from beacon node
+	dbClient   db.Sink                // storage backend where metrics are persisted
+	stream     *stream.Publisher      // publishes the persisted data, nil if streaming is disabled
+	alerts     *alerts.Engine         // evaluates the alert rules after each epoch, nil if disabled
+	progress   *backfillProgress      // epochs already processed per metric, resumed on restart
 
 	// Control Variables
 	wgMainRoutine            *sync.WaitGroup    // wait group for main routine (either historical or head)
@@ -45,17 +53,24 @@ type ChainAnalyzer struct {
 	stop                     bool               // flag to notify all routine to finish
 	routineClosed            chan struct{}      // signal that everything was closed succesfully
 	downloadMode             string             // whether to download historical blocks (defined by user) or follow chain head
+	dryRun                   bool               // only report the gaps found in repair mode
+	rangeInitSlot            phase0.Slot        // first slot of the range being downloaded, previous states are not downloaded
+	backfillChunks           int                // number of chunks of the historical range processed concurrently
+	processFrom              phase0.Epoch       // first epoch persisted by a backfill chunk, the previous ones only warm up its cache
 	rewardsAggregationEpochs int                // number of epochs to aggregate rewards
 	startEpochAggregation    phase0.Epoch       // epoch to start rewards aggregation
 	endEpochAggregation      phase0.Epoch       // epoch to end rewards aggregation
 	metrics                  db.DBMetrics       // what metrics to be downloaded / processed
 	processerBook            *utils.RoutineBook // defines slot to process new metrics into the database, good for monitoring
 
-	downloadCache                    ChainCache // store the blocks and states downloaded
+	downloadCache                   ChainCache // store the blocks and states downloaded
 	validatorsRewardsAggregations   map[phase0.ValidatorIndex]*spec.ValidatorRewardsAggregation
 	validatorsRewardsAggregationsMu sync.Mutex
 	aggregatedEpochsInWindow        map[phase0.Epoch]bool // set of unique epochs aggregated in current window; prevents double-counting on reprocessing (#255)
-	epochBoundaryStateRoots       sync.Map   // slot -> phase0.Root, caches state roots from Head SSE events at epoch boundaries
+	epochBoundaryStateRoots         sync.Map              // slot -> phase0.Root, caches state roots from Head SSE events at epoch boundaries
+
+	// validators to persist validator level metrics for, nil for every validator
+	trackedValidators *utils.TrackedValidators
 
 	initTime    time.Time
 	PromMetrics *prom_metrics.PrometheusMetrics // metrics to be stored to prometheus
@@ -74,47 +89,85 @@ func NewChainAnalyzer(
 	startEpochAggregation := phase0.Epoch(0)
 	endEpochAggregation := phase0.Epoch(0)
 
-	// calculate the list of slots that we will analyze
-	if iConfig.DownloadMode == "historical" {
+	metricsObj, err := db.NewMetrics(iConfig.Metrics)
+	if err != nil {
+		return &ChainAnalyzer{
+			ctx:    ctx,
+			cancel: cancel,
+		}, errors.Wrap(err, "unable to read metric.")
+	}
 
-		if iConfig.FinalSlot <= iConfig.InitSlot {
+	var trackedValidators *utils.TrackedValidators
+	if iConfig.ValidatorsFile != "" {
+		trackedValidators, err = utils.ReadValidatorsFile(iConfig.ValidatorsFile)
+		if err != nil {
 			return &ChainAnalyzer{
 				ctx:    ctx,
 				cancel: cancel,
-			}, errors.Errorf("Final Slot cannot be greater than Init Slot")
+			}, errors.Wrap(err, "unable to read validators file.")
 		}
-		// Start 2 epochs before and finish 1 epoch after
-		iConfig.InitSlot = iConfig.InitSlot/spec.SlotsPerEpoch*spec.SlotsPerEpoch - spec.SlotsPerEpoch*2
-		iConfig.FinalSlot = iConfig.FinalSlot/spec.SlotsPerEpoch*spec.SlotsPerEpoch + spec.SlotsPerEpoch
-		log.Infof("generating new Block Analyzer from slots %d:%d", iConfig.InitSlot, iConfig.FinalSlot)
-		// 2 epochs after the start since thats when we start processing rewards
-		startEpochAggregation = phase0.Epoch(spec.EpochAtSlot(iConfig.InitSlot) + 2)
-		endEpochAggregation = startEpochAggregation + phase0.Epoch(iConfig.RewardsAggregationEpochs-1)
+	}
 
+	var alertsEngine *alerts.Engine
+	if iConfig.AlertsFile != "" {
+		alertsConfig, err := alerts.ReadConfigFile(iConfig.AlertsFile)
+		if err != nil {
+			return &ChainAnalyzer{
+				ctx:    ctx,
+				cancel: cancel,
+			}, errors.Wrap(err, "unable to read alerts file.")
+		}
+		alertsEngine = alerts.NewEngine(ctx, alertsConfig, trackedValidators)
 	}
 
-	metricsObj, err := db.NewMetrics(iConfig.Metrics)
+	idbClient, err := db.NewSink(ctx, iConfig.DBUrl)
 	if err != nil {
 		return &ChainAnalyzer{
 			ctx:    ctx,
 			cancel: cancel,
-		}, errors.Wrap(err, "unable to read metric.")
+		}, errors.Wrap(err, "unable to init DB Client.")
+	}
+	if iConfig.DownloadMode == "repair" {
+		// the gaps are found by counting the rows of each epoch
+		if err := db.CanCountRows(idbClient); err != nil {
+			return &ChainAnalyzer{
+				ctx:    ctx,
+				cancel: cancel,
+			}, errors.Wrap(err, "unable to repair the database.")
+		}
+	}
+	if iConfig.PublishUrl != "" {
+		err = db.AttachPublisher(idbClient, iConfig.PublishUrl)
+		if err != nil {
+			return &ChainAnalyzer{
+				ctx:    ctx,
+				cancel: cancel,
+			}, errors.Wrap(err, "unable to init publisher.")
+		}
 	}
 
-	idbClient, err := db.New(ctx, iConfig.DBUrl)
-	if err != nil {
-		return &ChainAnalyzer{
-			ctx:    ctx,
-			cancel: cancel,
-		}, errors.Wrap(err, "unable to generate DB Client.")
+	// the backfill chunks download their states in parallel, from the beacon node
+	// unless other state endpoints are given
+	backfillChunks := 1
+	stateEndpoints := clientapi.SplitEndpoints(iConfig.StateEndpoints)
+	if iConfig.DownloadMode == "historical" && iConfig.BackfillChunks > 1 {
+		backfillChunks = iConfig.BackfillChunks
+	}
+	parallelStates := 1
+	if len(stateEndpoints) == 0 {
+		parallelStates = backfillChunks
 	}
 
-	err = idbClient.Connect()
-	if err != nil {
-		return &ChainAnalyzer{
-			ctx:    ctx,
-			cancel: cancel,
-		}, errors.Wrap(err, "unable to connect DB Client.")
+	// the states and blocks on disk are shared by every API client
+	var diskCache *clientapi.DiskCache
+	if iConfig.DiskCacheDir != "" {
+		diskCache, err = clientapi.NewDiskCache(iConfig.DiskCacheDir, int64(iConfig.DiskCacheSize)<<20)
+		if err != nil {
+			return &ChainAnalyzer{
+				ctx:    ctx,
+				cancel: cancel,
+			}, errors.Wrap(err, "unable to open the disk cache.")
+		}
 	}
 
 	// generate the httpAPI client
@@ -122,6 +175,11 @@ func NewChainAnalyzer(
 		iConfig.BnEndpoint,
 		iConfig.MaxRequestRetries,
 		clientapi.WithELEndpoint(iConfig.ElEndpoint),
+		clientapi.WithRecordDir(iConfig.RecordDir),
+		clientapi.WithMaxParallelStates(parallelStates),
+		clientapi.WithMaxHeadDistance(iConfig.MaxHeadDistance),
+		clientapi.WithCapabilities(iConfig.BnCapabilities),
+		clientapi.WithDiskCache(diskCache),
 		clientapi.WithDBMetrics(metricsObj),
 		clientapi.WithPromMetrics(promethMetrics))
 	if err != nil {
@@ -131,6 +189,72 @@ func NewChainAnalyzer(
 		}, errors.Wrap(err, "unable to generate API Client.")
 	}
 
+	// the states are downloaded from the state endpoints when given, failing over
+	// between them. Each backfill chunk prefers a different endpoint
+	newStatesCli := func(endpoints []string, parallelStates int) (*clientapi.APIClient, error) {
+		statesCli, err := clientapi.NewAPIClient(pCtx,
+			strings.Join(endpoints, ","),
+			iConfig.MaxRequestRetries,
+			clientapi.WithMaxParallelStates(parallelStates),
+			clientapi.WithMaxHeadDistance(iConfig.MaxHeadDistance),
+			clientapi.WithCapabilities(iConfig.BnCapabilities),
+			clientapi.WithDiskCache(diskCache),
+			clientapi.WithDBMetrics(metricsObj))
+		return statesCli, errors.Wrapf(err, "unable to generate API Client for %s.", endpoints[0])
+	}
+	var statesCli *clientapi.APIClient
+	if len(stateEndpoints) > 0 && backfillChunks == 1 {
+		statesCli, err = newStatesCli(stateEndpoints, 1)
+		if err != nil {
+			return &ChainAnalyzer{
+				ctx:    ctx,
+				cancel: cancel,
+			}, err
+		}
+	}
+	statesClis := make([]*clientapi.APIClient, 0, len(stateEndpoints))
+	if backfillChunks > 1 {
+		for i := range stateEndpoints {
+			endpoints := append(append([]string{}, stateEndpoints[i:]...), stateEndpoints[:i]...)
+			chunkCli, err := newStatesCli(endpoints, (backfillChunks+len(stateEndpoints)-1)/len(stateEndpoints))
+			if err != nil {
+				return &ChainAnalyzer{
+					ctx:    ctx,
+					cancel: cancel,
+				}, err
+			}
+			statesClis = append(statesClis, chunkCli)
+		}
+	}
+
+	// the chain spec is needed before any slot or epoch is computed
+	err = cli.LoadChainSpec(iConfig.ChainSpecFile)
+	if err != nil {
+		return &ChainAnalyzer{
+			ctx:    ctx,
+			cancel: cancel,
+		}, errors.Wrap(err, "unable to load chain spec.")
+	}
+
+	// calculate the list of slots that we will analyze
+	if iConfig.DownloadMode == "historical" {
+
+		if iConfig.FinalSlot <= iConfig.InitSlot {
+			return &ChainAnalyzer{
+				ctx:    ctx,
+				cancel: cancel,
+			}, errors.Errorf("Final Slot cannot be greater than Init Slot")
+		}
+		// Start 2 epochs before and finish 1 epoch after
+		iConfig.InitSlot = spec.ComputeStartSlotAtEpoch(spec.EpochAtSlot(iConfig.InitSlot)) - phase0.Slot(spec.SlotsPerEpoch)*2
+		iConfig.FinalSlot = spec.ComputeStartSlotAtEpoch(spec.EpochAtSlot(iConfig.FinalSlot) + 1)
+		log.Infof("generating new Block Analyzer from slots %d:%d", iConfig.InitSlot, iConfig.FinalSlot)
+		// 2 epochs after the start since thats when we start processing rewards
+		startEpochAggregation = phase0.Epoch(spec.EpochAtSlot(iConfig.InitSlot) + 2)
+		endEpochAggregation = startEpochAggregation + phase0.Epoch(iConfig.RewardsAggregationEpochs-1)
+
+	}
+
 	// Parse beacon contract address
 	beaconContractAddressInput := iConfig.BeaconContractAddress
 	// check if input was a network name and the contract address is known
@@ -157,6 +281,11 @@ func NewChainAnalyzer(
 
 	idbClient.InitGenesis(genesisTime)
 
+	var publisher *stream.Publisher
+	if iConfig.StreamPort > 0 {
+		publisher = stream.NewPublisher(ctx, iConfig.StreamPort)
+	}
+
 	analyzer := &ChainAnalyzer{
 		ctx:                           ctx,
 		cancel:                        cancel,
@@ -167,18 +296,26 @@ func NewChainAnalyzer(
 		cli:                           cli,
 		relayCli:                      relayCli,
 		dbClient:                      idbClient,
+		stream:                        publisher,
+		alerts:                        alertsEngine,
+		progress:                      newBackfillProgress(idbClient),
 		routineClosed:                 make(chan struct{}, 1),
 		eventsObj:                     events.NewEventsObj(ctx, cli),
 		downloadMode:                  iConfig.DownloadMode,
+		dryRun:                        iConfig.DryRun,
+		backfillChunks:                backfillChunks,
+		statesCli:                     statesCli,
+		statesClis:                    statesClis,
 		rewardsAggregationEpochs:      iConfig.RewardsAggregationEpochs,
 		startEpochAggregation:         startEpochAggregation,
 		endEpochAggregation:           endEpochAggregation,
 		metrics:                       metricsObj,
+		trackedValidators:             trackedValidators,
 		PromMetrics:                   promethMetrics,
 		downloadCache:                 NewQueue(),
 		validatorsRewardsAggregations: make(map[phase0.ValidatorIndex]*spec.ValidatorRewardsAggregation),
 		aggregatedEpochsInWindow:      make(map[phase0.Epoch]bool),
-		processerBook:                 utils.NewRoutineBook(32, "processer"), // one whole epoch
+		processerBook:                 utils.NewRoutineBook(int(spec.SlotsPerEpoch), "processer"), // one whole epoch
 		wgMainRoutine:                 &sync.WaitGroup{},
 		wgDownload:                    &sync.WaitGroup{},
 	}
@@ -187,6 +324,9 @@ func NewChainAnalyzer(
 	promethMetrics.AddMeticsModule(analyzerMet)
 	promethMetrics.AddMeticsModule(analyzer.processerBook.GetPrometheusMetrics())
 	promethMetrics.AddMeticsModule(idbClient.GetPrometheusMetrics())
+	if alertsEngine != nil {
+		promethMetrics.AddMeticsModule(alertsEngine.GetPrometheusMetrics())
+	}
 
 	return analyzer, nil
 }
@@ -207,7 +347,8 @@ func (s *ChainAnalyzer) Run() {
 		// Block requester + Task generator
 		s.wgMainRoutine.Add(1)
 
-		go s.runHistorical(s.initSlot, s.finalSlot)
+		// the last slot only belongs to the epoch after the range
+		go s.runBackfill(s.initSlot, s.finalSlot, spec.EpochAtSlot(s.finalSlot)-1)
 	}
 
 	if s.downloadMode == "finalized" {
@@ -216,13 +357,23 @@ func (s *ChainAnalyzer) Run() {
 		go s.runHead()
 	}
 
+	if s.downloadMode == "repair" {
+		// Rewrite the gaps found in the database
+		s.wgMainRoutine.Add(1)
+		go s.runRepair()
+	}
+
 	s.PromMetrics.Start()
+	if s.stream != nil {
+		s.stream.Start()
+	}
 
 	s.wgMainRoutine.Wait()
 	s.stop = true
 	log.Infof("main routine finished, waiting for downloader...")
 
 	s.wgDownload.Wait()
+	s.alerts.Wait() // pending webhook notifications
 	s.cancel()
 
 	log.Infof("downloader finished, waiting for db client...")
@@ -32,8 +32,8 @@ func (s *ChainCache) AddNewState(ctx context.Context, newState *spec.AgnosticSta
 	}
 
 	blockList := make([]*spec.AgnosticBlock, 0)
-	epochStartSlot := phase0.Slot(newState.Epoch * spec.SlotsPerEpoch)
-	epochEndSlot := phase0.Slot((newState.Epoch+1)*spec.SlotsPerEpoch - 1)
+	epochStartSlot := spec.ComputeStartSlotAtEpoch(newState.Epoch)
+	epochEndSlot := spec.ComputeStartSlotAtEpoch(newState.Epoch+1) - 1
 
 	for i := epochStartSlot; i <= epochEndSlot; i++ {
 		block, err := s.BlockHistory.Wait(ctx, SlotTo[uint64](i))
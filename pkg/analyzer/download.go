@@ -45,20 +45,22 @@ func (s *ChainAnalyzer) DownloadState(slot phase0.Slot) {
 	// Head event is emitted before canonical_head is updated.
 	// A short delay + retry is needed because the state may not be queryable yet
 	// at the moment the Head event fires.
-	if root, ok := s.takeEpochBoundaryStateRoot(slot); ok {
+	// Only for beacon nodes serving states by root (see clientapi.Capabilities).
+	statesCli := s.statesClient()
+	if root, ok := s.takeEpochBoundaryStateRoot(slot); ok && statesCli.Capabilities().StateByRoot {
 		log.Debugf("using cached state root for slot %d", slot)
 		for attempt := 0; attempt < 3; attempt++ {
 			if attempt > 0 {
 				time.Sleep(1 * time.Second)
 				log.Debugf("retrying state-by-root for slot %d (attempt %d)", slot, attempt+1)
 			}
-			state, err = s.cli.RequestBeaconStateByRoot(slot, root)
+			state, err = statesCli.RequestBeaconStateByRoot(slot, root)
 			if err == nil {
 				break
 			}
 		}
 	} else {
-		state, err = s.cli.RequestBeaconState(slot)
+		state, err = statesCli.RequestBeaconState(slot)
 	}
 
 	if err != nil {
@@ -82,18 +84,18 @@ func (s *ChainAnalyzer) WaitForPrevState(slot phase0.Slot) {
 		return // no states downloaded when epoch metrics are disabled
 	}
 
-	if slot < spec.SlotsPerEpoch*2 {
+	if slot < phase0.Slot(spec.SlotsPerEpoch)*2 {
 		return
 	}
-	prevStateEpoch := slot/spec.SlotsPerEpoch - 2              // epoch to check if state downloaded
-	prevStateSlot := (prevStateEpoch+1)*spec.SlotsPerEpoch - 1 // slot at which the check state was downloaded
+	prevStateEpoch := spec.EpochAtSlot(slot) - 2                        // epoch to check if state downloaded
+	prevStateSlot := spec.ComputeStartSlotAtEpoch(prevStateEpoch+1) - 1 // slot at which the check state was downloaded
 
 	prevStateAvailable := s.downloadCache.StateHistory.Available(uint64(prevStateEpoch))
 	prevStateProcessing := s.processerBook.CheckPageActive(fmt.Sprintf("%s%d", epochProcesserTag, prevStateEpoch))
 
 	// do not continue until previous state is available and is not being processed anymore
-	// also check that prevstate was supposed to be downloaded
-	if (!prevStateAvailable || prevStateProcessing) && prevStateSlot >= s.initSlot {
+	// also check that prevstate was supposed to be downloaded, in this range
+	if (!prevStateAvailable || prevStateProcessing) && prevStateSlot >= max(s.initSlot, s.rangeInitSlot) {
 		ticker := time.NewTicker(4 * time.Second) // average max time for a state to be downloaded
 		defer ticker.Stop()
 	stateWaitLoop:
@@ -103,7 +105,7 @@ func (s *ChainAnalyzer) WaitForPrevState(slot phase0.Slot) {
 				log.Infof("context cancelled while waiting for prev state at slot %d", slot)
 				return
 			case <-ticker.C:
-				if slot%spec.SlotsPerEpoch == 0 { // only print for first slot of epoch
+				if slot%phase0.Slot(spec.SlotsPerEpoch) == 0 { // only print for first slot of epoch
 					log.Debugf("slot %d waiting for state at slot %d (epoch %d) to be downloaded or processed...", slot, prevStateSlot, prevStateEpoch)
 				}
 
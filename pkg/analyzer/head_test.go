@@ -0,0 +1,128 @@
+package analyzer
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	v1 "github.com/attestantio/go-eth2-client/api/v1"
+	eth2_client_spec "github.com/attestantio/go-eth2-client/spec"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/migalabs/goteth/pkg/clientapi"
+	"github.com/migalabs/goteth/pkg/db"
+	"github.com/migalabs/goteth/pkg/events"
+	"github.com/migalabs/goteth/pkg/relay"
+	"github.com/migalabs/goteth/pkg/spec"
+	"github.com/migalabs/goteth/pkg/testutil/mockbeacon"
+	"github.com/migalabs/goteth/pkg/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func addMockBlock(t *testing.T, server *mockbeacon.Server, slot phase0.Slot, graffiti string) phase0.Root {
+	block := &eth2_client_spec.VersionedSignedBeaconBlock{
+		Version: eth2_client_spec.DataVersionDeneb,
+		Deneb:   testBlock(slot, graffiti),
+	}
+	require.NoError(t, server.AddBlock(block))
+	root, err := block.Root()
+	require.NoError(t, err)
+	return root
+}
+
+// newHeadTestAnalyzer returns an analyzer in head mode against the mock beacon node,
+// only block metrics are enabled so no states are needed
+func newHeadTestAnalyzer(t *testing.T, server *mockbeacon.Server) (*ChainAnalyzer, *db.MemorySink) {
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	utils.CheckPageInterval = time.Millisecond
+
+	dbMetrics := db.DBMetrics{Block: true}
+	cli, err := clientapi.NewAPIClient(ctx, server.URL(), 1, clientapi.WithDBMetrics(dbMetrics))
+	require.NoError(t, err)
+
+	sink := db.NewMemorySink()
+	return &ChainAnalyzer{
+		ctx:                           ctx,
+		cancel:                        cancel,
+		cli:                           cli,
+		relayCli:                      &relay.RelaysMonitor{},
+		dbClient:                      sink,
+		downloadMode:                  "finalized",
+		metrics:                       dbMetrics,
+		downloadCache:                 NewQueue(),
+		downloadTaskChan:              make(chan phase0.Slot, rateLimit),
+		eventsObj:                     events.NewEventsObj(ctx, cli),
+		validatorsRewardsAggregations: make(map[phase0.ValidatorIndex]*spec.ValidatorRewardsAggregation),
+		aggregatedEpochsInWindow:      make(map[phase0.Epoch]bool),
+		processerBook:                 utils.NewRoutineBook(32, "processer"),
+		wgMainRoutine:                 &sync.WaitGroup{},
+		wgDownload:                    &sync.WaitGroup{},
+	}, sink
+}
+
+func TestHeadFollowsChainAndReorgs(t *testing.T) {
+	server := mockbeacon.New()
+	t.Cleanup(server.Close)
+
+	// finalized at slot 256, so the analyzer fills from slot 128 to the head at 280
+	for slot := phase0.Slot(0); slot <= 280; slot++ {
+		addMockBlock(t, server, slot, testGraffiti)
+	}
+	finalizedRoot := addMockBlock(t, server, 256, testGraffiti)
+	server.SetFinality(&v1.Finality{
+		Finalized:         &phase0.Checkpoint{Epoch: 8, Root: finalizedRoot},
+		Justified:         &phase0.Checkpoint{Epoch: 8, Root: finalizedRoot},
+		PreviousJustified: &phase0.Checkpoint{},
+	})
+
+	analyzer, sink := newHeadTestAnalyzer(t, server)
+	analyzer.wgDownload.Add(1)
+	go analyzer.runDownloadBlocks()
+	analyzer.wgMainRoutine.Add(1)
+	go analyzer.runHead()
+	t.Cleanup(func() {
+		analyzer.stop = true
+		analyzer.cancel()
+		analyzer.wgMainRoutine.Wait()
+	})
+
+	require.Eventually(t, func() bool {
+		return server.Subscribers("head") == 1 && server.Subscribers("chain_reorg") == 1
+	}, 30*time.Second, 10*time.Millisecond)
+	assert.Equal(t, phase0.Slot(256-epochsToFinalizedTentative*spec.SlotsPerEpoch), analyzer.initSlot)
+	filled := int(280 - analyzer.initSlot + 1)
+	assert.Equal(t, filled, sink.Count("t_block_metrics"))
+
+	// new heads
+	for slot := phase0.Slot(281); slot <= 282; slot++ {
+		addMockBlock(t, server, slot, testGraffiti)
+		require.NoError(t, server.PublishHead(&v1.HeadEvent{Slot: slot}))
+	}
+	require.Eventually(t, func() bool {
+		return sink.Count("t_block_metrics") == filled+2
+	}, 10*time.Second, 10*time.Millisecond)
+	assert.Equal(t, 2, sink.Count("t_head_events"))
+
+	// the last two blocks are replaced
+	reorgRoot := addMockBlock(t, server, 281, testReorgGraffiti)
+	addMockBlock(t, server, 282, testReorgGraffiti)
+	require.NoError(t, server.PublishChainReorg(&v1.ChainReorgEvent{Slot: 282, Depth: 1}))
+	require.Eventually(t, func() bool {
+		return sink.Count("t_orphans") == 2 && len(sink.Where("t_block_metrics", "f_slot", 281)) == 1
+	}, 10*time.Second, 10*time.Millisecond)
+
+	assert.Equal(t, 1, sink.Count("t_reorgs"))
+	assert.ElementsMatch(t, []uint64{282, 281}, deleteArgs(sink.DeletesOn("t_block_metrics")))
+	assert.Equal(t, []string{testGraffiti, testGraffiti}, graffitiOf(sink.Rows("t_orphans")))
+	assert.Equal(t, filled+2, sink.Count("t_block_metrics"))
+	for slot := 281; slot <= 282; slot++ {
+		assert.Equal(t, []string{testReorgGraffiti}, graffitiOf(sink.Where("t_block_metrics", "f_slot", slot)))
+	}
+	assert.Equal(t, []string{testGraffiti}, graffitiOf(sink.Where("t_block_metrics", "f_slot", 280)))
+
+	block, err := analyzer.downloadCache.BlockHistory.Wait(analyzer.ctx, 281)
+	require.NoError(t, err)
+	assert.Equal(t, reorgRoot, block.Root)
+}
@@ -65,12 +65,12 @@ func BuildEpochTask(analyzer *ChainAnalyzer, slot phase0.Slot) (metrics.StateMet
 
 	// Review slot is well positioned
 
-	epoch := slot / spec.SlotsPerEpoch
+	epoch := spec.EpochAtSlot(slot)
 
-	slot = ((epoch + 1) * spec.SlotsPerEpoch) - 1
+	slot = spec.ComputeStartSlotAtEpoch(epoch+1) - 1
 
-	fmt.Printf("downloading state at slot: %d\n", slot-spec.SlotsPerEpoch)
-	prevState, err := analyzer.cli.RequestBeaconState(slot - spec.SlotsPerEpoch)
+	fmt.Printf("downloading state at slot: %d\n", slot-phase0.Slot(spec.SlotsPerEpoch))
+	prevState, err := analyzer.cli.RequestBeaconState(slot - phase0.Slot(spec.SlotsPerEpoch))
 	if err != nil {
 		return metrics.Phase0Metrics{}, fmt.Errorf("could not download state: %s", err)
 
@@ -82,8 +82,8 @@ func BuildEpochTask(analyzer *ChainAnalyzer, slot phase0.Slot) (metrics.StateMet
 		return metrics.Phase0Metrics{}, fmt.Errorf("could not download state: %s", err)
 	}
 
-	fmt.Printf("downloading state at slot: %d\n", slot+spec.SlotsPerEpoch)
-	nextState, err := analyzer.cli.RequestBeaconState(slot + spec.SlotsPerEpoch)
+	fmt.Printf("downloading state at slot: %d\n", slot+phase0.Slot(spec.SlotsPerEpoch))
+	nextState, err := analyzer.cli.RequestBeaconState(slot + phase0.Slot(spec.SlotsPerEpoch))
 	if err != nil {
 		return metrics.Phase0Metrics{}, fmt.Errorf("could not download state: %s", err)
 	}
@@ -0,0 +1,69 @@
+package analyzer
+
+import (
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/migalabs/goteth/pkg/alerts"
+	"github.com/migalabs/goteth/pkg/spec"
+	"github.com/migalabs/goteth/pkg/spec/metrics"
+)
+
+// processAlerts evaluates the alert rules over the validators they watch,
+// comparing nextState with currentState to detect what changed in the epoch
+func (s *ChainAnalyzer) processAlerts(bundle metrics.StateMetrics) {
+	if s.alerts == nil {
+		return
+	}
+	metricsBase := bundle.GetMetricsBase()
+	nextState := metricsBase.NextState
+	currentState := metricsBase.CurrentState
+
+	proposedBySlot := make(map[phase0.Slot]bool)
+	for _, block := range nextState.Blocks {
+		proposedBySlot[block.Slot] = block.Proposed
+	}
+	missedProposals := make(map[phase0.ValidatorIndex][]phase0.Slot)
+	for _, duty := range nextState.EpochStructs.ProposerDuties {
+		if !proposedBySlot[duty.Slot] {
+			missedProposals[duty.ValidatorIndex] = append(missedProposals[duty.ValidatorIndex], duty.Slot)
+		}
+	}
+
+	report := alerts.EpochReport{Epoch: nextState.Epoch}
+	for i, validator := range nextState.Validators {
+		valIdx := phase0.ValidatorIndex(i)
+		if !s.alerts.Watches(valIdx, validator.PublicKey) {
+			continue
+		}
+		rewards, err := bundle.GetMaxReward(valIdx)
+		if err != nil {
+			log.Errorf("could not evaluate alerts of validator %d: %s", valIdx, err)
+			continue
+		}
+		active := spec.IsActive(*validator, metricsBase.PrevState.Epoch)
+		valEpoch := alerts.ValidatorEpoch{
+			ValidatorIndex:    valIdx,
+			PublicKey:         validator.PublicKey,
+			Active:            active,
+			MissedAttestation: active && rewards.MissingSource,
+			Reward:            rewards.Reward,
+			MaxReward:         rewards.MaxReward,
+			MissedProposals:   missedProposals[valIdx],
+		}
+		if i < len(currentState.Validators) {
+			prevValidator := currentState.Validators[i]
+			valEpoch.Slashed = validator.Slashed && !prevValidator.Slashed
+			valEpoch.ExitInitiated = validator.ExitEpoch != phase0.Epoch(spec.FarFutureEpoch) &&
+				prevValidator.ExitEpoch == phase0.Epoch(spec.FarFutureEpoch)
+
+			balance := nextState.Balances[valIdx]
+			if i < len(nextState.Withdrawals) {
+				balance += nextState.Withdrawals[valIdx]
+			}
+			if prevBalance := currentState.Balances[valIdx]; prevBalance > balance {
+				valEpoch.BalanceDrop = prevBalance - balance
+			}
+		}
+		report.Validators = append(report.Validators, valEpoch)
+	}
+	s.alerts.Process(report)
+}
@@ -1,12 +1,15 @@
 package analyzer
 
 import (
+	"errors"
 	"fmt"
 
 	eth2_client_spec "github.com/attestantio/go-eth2-client/spec"
 	"github.com/attestantio/go-eth2-client/spec/phase0"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/migalabs/goteth/pkg/db"
 	"github.com/migalabs/goteth/pkg/spec"
+	"github.com/migalabs/goteth/pkg/stream"
 )
 
 var (
@@ -17,6 +20,14 @@ func (s *ChainAnalyzer) ProcessBlock(slot phase0.Slot) {
 	if !s.metrics.Block {
 		return
 	}
+	if spec.EpochAtSlot(slot) < s.processFrom {
+		return // warm-up of a backfill chunk, persisted by the previous chunk
+	}
+	progressMetrics := s.blockProgressMetrics()
+	if s.progress.allDone(progressMetrics, spec.EpochAtSlot(slot)) {
+		log.Tracef("slot %d already processed, skipping", slot)
+		return
+	}
 	routineKey := fmt.Sprintf("%s%d", slotProcesserTag, slot)
 	s.processerBook.Acquire(routineKey) // register a new slot to process, good for monitoring
 
@@ -29,42 +40,64 @@ func (s *ChainAnalyzer) ProcessBlock(slot phase0.Slot) {
 	err = s.dbClient.PersistBlocks([]spec.AgnosticBlock{*block})
 	if err != nil {
 		log.Errorf("error persisting blocks: %s", err.Error())
+	} else {
+		s.stream.Publish(stream.BlockEvent, func() ([]db.Row, error) {
+			return db.BlockRows([]spec.AgnosticBlock{*block})
+		})
 	}
 
-	s.processWithdrawals(block)
+	blockErr := errors.Join(
+		err,
+		s.processWithdrawals(block))
 
-	s.ProcessETH1Data(block)
+	eth1Err := s.ProcessETH1Data(block)
 
-	s.processBLSToExecutionChanges(block)
-	s.processDeposits(block)
+	blockErr = errors.Join(
+		blockErr,
+		s.processBLSToExecutionChanges(block),
+		s.processDeposits(block))
 	s.processerBook.FreePage(routineKey)
+
+	// the slot counts towards the progress of the metrics fully persisted
+	processed := make([]string, 0, len(progressMetrics))
+	for _, metric := range progressMetrics {
+		if metric == db.BlockProgress && blockErr != nil {
+			continue
+		}
+		if metric != db.BlockProgress && eth1Err != nil {
+			continue
+		}
+		processed = append(processed, metric)
+	}
+	s.progress.slotProcessed(slot, processed...)
 }
 
-func (s *ChainAnalyzer) ProcessETH1Data(block *spec.AgnosticBlock) {
+func (s *ChainAnalyzer) ProcessETH1Data(block *spec.AgnosticBlock) error {
 	if s.metrics.Transactions {
 		receipts, err := s.cli.GetBlockReceipts(*block)
 		if err != nil {
 			log.Errorf("error getting slot %d receipts: %s", block.Slot, err.Error())
-			return
+			return err
 		}
 
 		err = s.processTransactions(block, receipts)
 		if err != nil {
 			log.Errorf("error processing transactions: %s", err.Error())
-			return
+			return err
 		}
 
 		// process eth1 deposits depends on processTransactions storing the receipts on the Agnostic transactions
 		err = s.processETH1Deposits(block)
 		if err != nil {
 			log.Errorf("error processing eth1 deposits: %s", err.Error())
-			return
+			return err
 		}
 	}
 
 	if block.HardForkVersion >= eth2_client_spec.DataVersionDeneb && s.metrics.BlobSidecars {
-		s.processBlobSidecars(block, block.ExecutionPayload.AgnosticTransactions)
+		return s.processBlobSidecars(block, block.ExecutionPayload.AgnosticTransactions)
 	}
+	return nil
 }
 
 func (s *ChainAnalyzer) processETH1Deposits(block *spec.AgnosticBlock) error {
@@ -100,9 +133,9 @@ func (s *ChainAnalyzer) processETH1Deposits(block *spec.AgnosticBlock) error {
 }
 
 // Process consensus layer deposits
-func (s *ChainAnalyzer) processDeposits(block *spec.AgnosticBlock) {
+func (s *ChainAnalyzer) processDeposits(block *spec.AgnosticBlock) error {
 	if len(block.Deposits) == 0 {
-		return
+		return nil
 	}
 	var deposits []spec.Deposit
 	for i, item := range block.Deposits {
@@ -121,12 +154,12 @@ func (s *ChainAnalyzer) processDeposits(block *spec.AgnosticBlock) {
 	if err != nil {
 		log.Errorf("error persisting deposits: %s", err.Error())
 	}
-
+	return err
 }
 
-func (s *ChainAnalyzer) processBLSToExecutionChanges(block *spec.AgnosticBlock) {
+func (s *ChainAnalyzer) processBLSToExecutionChanges(block *spec.AgnosticBlock) error {
 	if len(block.BLSToExecutionChanges) == 0 {
-		return
+		return nil
 	}
 	var blsToExecutionChanges []spec.BLSToExecutionChange
 	for _, item := range block.BLSToExecutionChanges {
@@ -143,9 +176,10 @@ func (s *ChainAnalyzer) processBLSToExecutionChanges(block *spec.AgnosticBlock)
 	if err != nil {
 		log.Errorf("error persisting bls to execution changes: %s", err.Error())
 	}
+	return err
 }
 
-func (s *ChainAnalyzer) processWithdrawals(block *spec.AgnosticBlock) {
+func (s *ChainAnalyzer) processWithdrawals(block *spec.AgnosticBlock) error {
 	var withdrawals []spec.Withdrawal
 	for _, item := range block.ExecutionPayload.Withdrawals {
 		withdrawals = append(withdrawals, spec.Withdrawal{
@@ -161,7 +195,7 @@ func (s *ChainAnalyzer) processWithdrawals(block *spec.AgnosticBlock) {
 	if err != nil {
 		log.Errorf("error persisting withdrawals: %s", err.Error())
 	}
-
+	return err
 }
 
 func (s *ChainAnalyzer) processTransactions(block *spec.AgnosticBlock, receipts []*types.Receipt) error {
@@ -212,7 +246,7 @@ func (s *ChainAnalyzer) recoverBlockReceipts(block *spec.AgnosticBlock) {
 	log.Infof("slot %d: recovered %d transaction receipts for fee calculation", block.Slot, len(txs))
 }
 
-func (s *ChainAnalyzer) processBlobSidecars(block *spec.AgnosticBlock, txs []spec.AgnosticTransaction) {
+func (s *ChainAnalyzer) processBlobSidecars(block *spec.AgnosticBlock, txs []spec.AgnosticTransaction) error {
 	var blobs []*spec.AgnosticBlobSidecar
 	var err error
 
@@ -224,6 +258,7 @@ func (s *ChainAnalyzer) processBlobSidecars(block *spec.AgnosticBlock, txs []spe
 
 	if err != nil {
 		log.Errorf("could not download blobs for slot %d: %s", block.Slot, err)
+		return err
 	}
 	if len(blobs) > 0 {
 		if len(txs) > 0 {
@@ -231,6 +266,7 @@ func (s *ChainAnalyzer) processBlobSidecars(block *spec.AgnosticBlock, txs []spe
 				blob.GetTxHash(txs)
 			}
 		}
-		s.dbClient.PersistBlobSidecars(blobs)
+		return s.dbClient.PersistBlobSidecars(blobs)
 	}
+	return nil
 }
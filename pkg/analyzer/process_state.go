@@ -1,13 +1,16 @@
 package analyzer
 
 import (
+	"errors"
 	"fmt"
 
+	eth2_client_spec "github.com/attestantio/go-eth2-client/spec"
 	"github.com/attestantio/go-eth2-client/spec/phase0"
 	"github.com/migalabs/goteth/pkg/db"
 	"github.com/migalabs/goteth/pkg/relay"
 	"github.com/migalabs/goteth/pkg/spec"
 	"github.com/migalabs/goteth/pkg/spec/metrics"
+	"github.com/migalabs/goteth/pkg/stream"
 )
 
 var (
@@ -21,6 +24,18 @@ func (s *ChainAnalyzer) ProcessStateTransitionMetrics(epoch phase0.Epoch) {
 	if !s.metrics.Epoch {
 		return
 	}
+	// the warm-up of a backfill chunk only replays the transition before its first epoch,
+	// which fills the rewards of the blocks the first transition reads. Nothing is persisted
+	warmUp := epoch < s.processFrom
+	if warmUp && epoch+1 < s.processFrom {
+		return
+	}
+	if !warmUp && s.progress.allDone(s.stateProgressMetrics(), epoch) {
+		log.Debugf("epoch %d already processed, skipping", epoch)
+		return
+	}
+	epochDone := s.progress.isDone(db.EpochProgress, epoch)
+	rewardsDone := s.progress.isDone(db.RewardsProgress, epoch)
 
 	routineKey := fmt.Sprintf("%s%d", epochProcesserTag, epoch)
 	s.processerBook.Acquire(routineKey) // resgiter we are about to process metrics for epoch
@@ -45,7 +60,7 @@ func (s *ChainAnalyzer) ProcessStateTransitionMetrics(epoch phase0.Epoch) {
 	var err error
 
 	// this state may never be downloaded if it is below initSlot
-	if epoch >= 2 && epoch-2 >= phase0.Epoch(s.initSlot/spec.SlotsPerEpoch) {
+	if epoch >= 2 && epoch-2 >= spec.EpochAtSlot(s.initSlot) {
 		prevState, err = s.downloadCache.StateHistory.Wait(s.ctx, EpochTo[uint64](epoch)-2)
 		if err != nil {
 			s.processerBook.FreePage(routineKey)
@@ -53,7 +68,7 @@ func (s *ChainAnalyzer) ProcessStateTransitionMetrics(epoch phase0.Epoch) {
 			return
 		}
 	}
-	if epoch >= 1 && epoch-1 >= phase0.Epoch(s.initSlot/spec.SlotsPerEpoch) {
+	if epoch >= 1 && epoch-1 >= spec.EpochAtSlot(s.initSlot) {
 		currentState, err = s.downloadCache.StateHistory.Wait(s.ctx, EpochTo[uint64](epoch)-1)
 		if err != nil {
 			s.processerBook.FreePage(routineKey)
@@ -68,6 +83,11 @@ func (s *ChainAnalyzer) ProcessStateTransitionMetrics(epoch phase0.Epoch) {
 		return
 	}
 
+	// attestation duties are filled while the bundle processes the attestations of currentState
+	if s.metrics.Attestations && !currentState.EmptyStateRoot() {
+		currentState.TrackValidatorAttestations()
+	}
+
 	bundle, err := metrics.StateMetricsByForkVersion(nextState, currentState, prevState, s.cli.Api)
 	if err != nil {
 		s.processerBook.FreePage(routineKey)
@@ -76,97 +96,165 @@ func (s *ChainAnalyzer) ProcessStateTransitionMetrics(epoch phase0.Epoch) {
 		s.cancel()
 		return
 	}
+	if warmUp {
+		s.processerBook.FreePage(routineKey)
+		return
+	}
 
 	// If prevState, currentState and nextState are filled, we can process proposer duties, epoch metrics and validator rewards
+	// Epochs already processed in a previous run only process the metrics still missing
 	if !nextState.EmptyStateRoot() && !currentState.EmptyStateRoot() && !prevState.EmptyStateRoot() {
-		s.processEpochDuties(bundle)
-		s.processValLastStatus(bundle)
-		s.processEpochMetrics(bundle)
-		s.processBlockRewards(bundle) // block rewards depend on two previous epochs
-		if s.metrics.ValidatorRewards {
+		var epochErr error
+		if !epochDone {
+			s.processEpochDuties(bundle)
+			s.processValLastStatus(bundle)
+			epochErr = errors.Join(
+				s.processEpochMetrics(bundle),
+				s.processBlockRewards(bundle)) // block rewards depend on two previous epochs
+		}
+		if s.metrics.ValidatorRewards && !rewardsDone {
 			s.processEpochValRewards(bundle)
 		}
-		s.processSlashings(bundle)
-		s.storeDepositsProcessed(bundle) // we store deposits processed from electra + in the database
-		s.storeConsolidationRequests(bundle)
-		s.storeWithdrawalRequests(bundle)
-		s.storeDepositRequests(bundle)
-		s.storeConsoidationsProcessed(bundle)
-		s.processPoolMetrics(bundle.GetMetricsBase().PrevState.Epoch) // Calculated over prev state so we make sure that tables are filled
+		s.processAlerts(bundle)
+		if !epochDone {
+			epochErr = errors.Join(epochErr, s.processSlashings(bundle))
+			if s.metrics.Attestations {
+				epochErr = errors.Join(epochErr, s.processValidatorAttestations(bundle))
+			}
+			if s.metrics.SyncCommittee {
+				epochErr = errors.Join(epochErr, s.processSyncCommitteeDuties(bundle))
+			}
+			epochErr = errors.Join(
+				epochErr,
+				s.storeDepositsProcessed(bundle), // we store deposits processed from electra + in the database
+				s.storeConsolidationRequests(bundle),
+				s.storeWithdrawalRequests(bundle),
+				s.storeDepositRequests(bundle),
+				s.storeConsoidationsProcessed(bundle))
+			s.processPoolMetrics(bundle.GetMetricsBase().PrevState.Epoch) // Calculated over prev state so we make sure that tables are filled
+			if epochErr == nil {
+				s.progress.markDone(db.EpochProgress, epoch)
+			}
+		}
 	}
 
 	s.processerBook.FreePage(routineKey)
 
 }
 
-func (s *ChainAnalyzer) processSlashings(bundle metrics.StateMetrics) {
+func (s *ChainAnalyzer) processSlashings(bundle metrics.StateMetrics) error {
 	slashings := bundle.GetMetricsBase().NextState.Slashings
 	if len(slashings) == 0 {
-		return
+		return nil
 	}
 	err := s.dbClient.PersistSlashings(slashings)
 	if err != nil {
 		log.Errorf("error persisting slashings: %s", err.Error())
 	}
+	return err
+}
+
+func (s *ChainAnalyzer) processValidatorAttestations(bundle metrics.StateMetrics) error {
+	currentState := bundle.GetMetricsBase().CurrentState
+	if currentState.Version == eth2_client_spec.DataVersionPhase0 {
+		return nil // phase0 attestations are pending attestations, inclusions are not tracked
+	}
+	attestations := make([]spec.ValidatorAttestation, 0)
+	for _, attestation := range currentState.GetValidatorAttestations() {
+		if s.isTrackedValidator(currentState, attestation.ValIdx) {
+			attestations = append(attestations, attestation)
+		}
+	}
+	if len(attestations) == 0 {
+		return nil
+	}
+	err := s.dbClient.PersistValidatorAttestations(attestations)
+	if err != nil {
+		log.Errorf("error persisting validator attestations: %s", err.Error())
+	}
+	return err
+}
+
+func (s *ChainAnalyzer) processSyncCommitteeDuties(bundle metrics.StateMetrics) error {
+	nextState := bundle.GetMetricsBase().NextState
+	duties := make([]spec.SyncCommitteeDuty, 0)
+	for _, duty := range nextState.SyncCommitteeDuties {
+		if s.isTrackedValidator(nextState, duty.ValIdx) {
+			duties = append(duties, duty)
+		}
+	}
+	if len(duties) == 0 {
+		return nil
+	}
+	err := s.dbClient.PersistSyncCommitteeDuties(duties)
+	if err != nil {
+		log.Errorf("error persisting sync committee duties: %s", err.Error())
+	}
+	return err
 }
 
 // storeDepositsProcessed stores the deposits processed from electra + in the database
-func (s *ChainAnalyzer) storeDepositsProcessed(bundle metrics.StateMetrics) {
+func (s *ChainAnalyzer) storeDepositsProcessed(bundle metrics.StateMetrics) error {
 	depositsProcessed := bundle.GetMetricsBase().NextState.DepositsProcessed
 	if len(depositsProcessed) == 0 {
-		return
+		return nil
 	}
 	err := s.dbClient.PersistDeposits(depositsProcessed)
 	if err != nil {
 		log.Errorf("error persisting deposits processed: %s", err.Error())
 	}
+	return err
 }
 
-func (s *ChainAnalyzer) storeConsoidationsProcessed(bundle metrics.StateMetrics) {
+func (s *ChainAnalyzer) storeConsoidationsProcessed(bundle metrics.StateMetrics) error {
 	consolidationsProcessed := bundle.GetMetricsBase().NextState.ConsolidationsProcessed
 	if len(consolidationsProcessed) == 0 {
-		return
+		return nil
 	}
 	err := s.dbClient.PersistConsolidationsProcessed(consolidationsProcessed)
 	if err != nil {
 		log.Errorf("error persisting consolidationsProcessed: %s", err.Error())
 	}
+	return err
 }
 
-func (s *ChainAnalyzer) storeWithdrawalRequests(bundle metrics.StateMetrics) {
+func (s *ChainAnalyzer) storeWithdrawalRequests(bundle metrics.StateMetrics) error {
 	withdrawalRequests := bundle.GetMetricsBase().NextState.WithdrawalRequests
 	if len(withdrawalRequests) == 0 {
-		return
+		return nil
 	}
 	err := s.dbClient.PersistWithdrawalRequests(withdrawalRequests)
 	if err != nil {
 		log.Errorf("error persisting withdrawal requests: %s", err.Error())
 	}
+	return err
 }
 
-func (s *ChainAnalyzer) storeConsolidationRequests(bundle metrics.StateMetrics) {
+func (s *ChainAnalyzer) storeConsolidationRequests(bundle metrics.StateMetrics) error {
 	consolidationRequests := bundle.GetMetricsBase().NextState.ConsolidationRequests
 	if len(consolidationRequests) == 0 {
-		return
+		return nil
 	}
 	err := s.dbClient.PersistConsolidationRequests(consolidationRequests)
 	if err != nil {
 		log.Errorf("error persisting consolidation requests: %s", err.Error())
 	}
+	return err
 }
 
-func (s *ChainAnalyzer) storeDepositRequests(bundle metrics.StateMetrics) {
+func (s *ChainAnalyzer) storeDepositRequests(bundle metrics.StateMetrics) error {
 	depositRequests := bundle.GetMetricsBase().NextState.DepositRequests
 	if len(depositRequests) == 0 {
-		return
+		return nil
 	}
 	err := s.dbClient.PersistDepositRequests(depositRequests)
 	if err != nil {
 		log.Errorf("error persisting deposit requests: %s", err.Error())
 	}
+	return err
 }
 
-func (s *ChainAnalyzer) processEpochMetrics(bundle metrics.StateMetrics) {
+func (s *ChainAnalyzer) processEpochMetrics(bundle metrics.StateMetrics) error {
 
 	// we need sameEpoch and nextEpoch
 	metricsBase := bundle.GetMetricsBase()
@@ -177,8 +265,12 @@ func (s *ChainAnalyzer) processEpochMetrics(bundle metrics.StateMetrics) {
 	err := s.dbClient.PersistEpochs([]spec.Epoch{epoch})
 	if err != nil {
 		log.Errorf("error persisting epoch: %s", err.Error())
+		return err
 	}
-
+	s.stream.Publish(stream.EpochEvent, func() ([]db.Row, error) {
+		return db.EpochRows([]spec.Epoch{epoch})
+	})
+	return nil
 }
 
 func (s *ChainAnalyzer) processPoolMetrics(epoch phase0.Epoch) {
@@ -232,6 +324,9 @@ func (s *ChainAnalyzer) processValLastStatus(bundle metrics.StateMetrics) {
 		nextState := bundle.GetMetricsBase().NextState
 		for i, validator := range nextState.Validators {
 			valIdx := phase0.ValidatorIndex(i)
+			if !s.isTrackedValidator(nextState, valIdx) {
+				continue
+			}
 			newVal := spec.ValidatorLastStatus{
 				ValIdx:                valIdx,
 				Epoch:                 nextState.Epoch,
@@ -269,6 +364,9 @@ func (s *ChainAnalyzer) processEpochValRewards(bundle metrics.StateMetrics) {
 	// process each validator
 	for i, validator := range nextState.Validators {
 		valIdx := phase0.ValidatorIndex(i)
+		if !s.isTrackedValidator(nextState, valIdx) {
+			continue
+		}
 
 		// get max reward at given epoch using the formulas
 		maxRewards, err := bundle.GetMaxReward(valIdx)
@@ -293,12 +391,25 @@ func (s *ChainAnalyzer) processEpochValRewards(bundle metrics.StateMetrics) {
 		if err != nil {
 			log.Fatalf("error persisting validator rewards: %s", err.Error())
 		}
+		s.stream.Publish(stream.ValidatorRewardsEvent, func() ([]db.Row, error) {
+			return db.ValidatorRewardsRows(insertValsObj)
+		})
+	}
+
+	epoch := bundle.GetMetricsBase().NextState.Epoch
+	if s.rewardsAggregationEpochs <= 1 {
+		s.progress.markDone(db.RewardsProgress, epoch)
 	}
 
 	if s.rewardsAggregationEpochs > 1 {
 		s.validatorsRewardsAggregationsMu.Lock()
 
-		epoch := bundle.GetMetricsBase().NextState.Epoch
+		// Epochs processed in a previous run are skipped, and their windows were
+		// flushed entirely. Start the window at the next epoch still pending.
+		if len(s.aggregatedEpochsInWindow) == 0 && epoch > s.endEpochAggregation {
+			s.startEpochAggregation = epoch
+			s.endEpochAggregation = epoch + phase0.Epoch(s.rewardsAggregationEpochs-1)
+		}
 
 		// Only aggregate if:
 		//  1. The epoch belongs to the current window (rejects stale epochs
@@ -329,6 +440,11 @@ func (s *ChainAnalyzer) processEpochValRewards(bundle metrics.StateMetrics) {
 						log.Fatalf("error persisting validator rewards aggregation: %s", err.Error())
 					}
 				}
+				windowEpochs := make([]phase0.Epoch, 0, len(s.aggregatedEpochsInWindow))
+				for windowEpoch := range s.aggregatedEpochsInWindow {
+					windowEpochs = append(windowEpochs, windowEpoch)
+				}
+				s.progress.markDone(db.RewardsProgress, windowEpochs...)
 				s.validatorsRewardsAggregations = make(map[phase0.ValidatorIndex]*spec.ValidatorRewardsAggregation)
 				s.startEpochAggregation = s.endEpochAggregation + 1
 				s.endEpochAggregation = s.endEpochAggregation + phase0.Epoch(s.rewardsAggregationEpochs)
@@ -341,11 +457,24 @@ func (s *ChainAnalyzer) processEpochValRewards(bundle metrics.StateMetrics) {
 
 }
 
-func (s *ChainAnalyzer) processBlockRewards(bundle metrics.StateMetrics) {
+// isTrackedValidator returns whether validator level metrics are persisted for the validator,
+// every validator is tracked unless --validators-file is given
+func (s *ChainAnalyzer) isTrackedValidator(state *spec.AgnosticState, valIdx phase0.ValidatorIndex) bool {
+	if s.trackedValidators == nil {
+		return true
+	}
+	pubkey := phase0.BLSPubKey{}
+	if int(valIdx) < len(state.Validators) {
+		pubkey = state.Validators[valIdx].PublicKey
+	}
+	return s.trackedValidators.Contains(valIdx, pubkey)
+}
+
+func (s *ChainAnalyzer) processBlockRewards(bundle metrics.StateMetrics) error {
 
 	blockRewards := make([]db.BlockReward, 0)
 
-	mevBids, err := s.relayCli.GetDeliveredBidsPerSlotRange(bundle.GetMetricsBase().CurrentState.Slot, spec.SlotsPerEpoch)
+	mevBids, err := s.relayCli.GetDeliveredBidsPerSlotRange(bundle.GetMetricsBase().CurrentState.Slot, int(spec.SlotsPerEpoch))
 	if err != nil {
 		log.Errorf("error getting mev bids: %s", err.Error())
 	}
@@ -364,8 +493,7 @@ func (s *ChainAnalyzer) processBlockRewards(bundle metrics.StateMetrics) {
 		blockRewards = append(blockRewards, s.getSingleBlockRewards(*block, mevBids))
 	}
 
-	s.dbClient.PersistBlockRewards(blockRewards)
-
+	return s.dbClient.PersistBlockRewards(blockRewards)
 }
 
 func (s *ChainAnalyzer) getSingleBlockRewards(
@@ -404,13 +532,14 @@ func (s *ChainAnalyzer) getSingleBlockRewards(
 		}
 	}
 	return db.BlockReward{
-		Slot:           slot,
-		CLManualReward: clManualReward,
-		CLApiReward:    clApiReward,
-		RewardFees:     rewardFees,
-		BurntFees:      burntFees,
-		Relays:         relayAddresses,
-		BidCommision:   bidCommision,
-		BuilderPubkeys: builderPubkeys,
+		Slot:               slot,
+		CLManualReward:     clManualReward,
+		CLApiReward:        clApiReward,
+		CLRewardComponents: block.ManualRewardComponents,
+		RewardFees:         rewardFees,
+		BurntFees:          burntFees,
+		Relays:             relayAddresses,
+		BidCommision:       bidCommision,
+		BuilderPubkeys:     builderPubkeys,
 	}
 }
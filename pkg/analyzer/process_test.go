@@ -0,0 +1,585 @@
+package analyzer
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	v1 "github.com/attestantio/go-eth2-client/api/v1"
+	eth2_client_spec "github.com/attestantio/go-eth2-client/spec"
+	"github.com/attestantio/go-eth2-client/spec/altair"
+	"github.com/attestantio/go-eth2-client/spec/bellatrix"
+	"github.com/attestantio/go-eth2-client/spec/deneb"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/holiman/uint256"
+	"github.com/migalabs/goteth/pkg/clientapi"
+	"github.com/migalabs/goteth/pkg/db"
+	"github.com/migalabs/goteth/pkg/relay"
+	"github.com/migalabs/goteth/pkg/spec"
+	"github.com/migalabs/goteth/pkg/testutil/mockbeacon"
+	"github.com/migalabs/goteth/pkg/utils"
+	"github.com/prysmaticlabs/go-bitfield"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	testValidators        = 64
+	testGenesisTime       = uint64(1606824023)
+	testGraffiti          = "goteth-test"
+	testReorgGraffiti     = "goteth-reorg"
+	testSyncParticipation = 64 // sync committee bits set in every block
+)
+
+// testChain is a deterministic Deneb chain where every slot has a block.
+// Its canonical blocks are served by a mock beacon node, used to redownload
+// blocks and check roots, so the chain can be changed to simulate reorgs.
+type testChain struct {
+	*mockbeacon.Server
+	t *testing.T
+}
+
+func newTestChain(t *testing.T) *testChain {
+	server := mockbeacon.New()
+	t.Cleanup(server.Close)
+	return &testChain{Server: server, t: t}
+}
+
+func testBlock(slot phase0.Slot, graffiti string) *deneb.SignedBeaconBlock {
+	syncBits := bitfield.NewBitvector512()
+	for i := 0; i < testSyncParticipation; i++ {
+		syncBits.SetBitAt(uint64(i), true)
+	}
+	block := &deneb.SignedBeaconBlock{
+		Message: &deneb.BeaconBlock{
+			Slot:          slot,
+			ProposerIndex: phase0.ValidatorIndex(uint64(slot) % uint64(testValidators)),
+			StateRoot:     testStateRoot(slot),
+			Body: &deneb.BeaconBlockBody{
+				ETH1Data: &phase0.ETH1Data{
+					BlockHash: make([]byte, 32),
+				},
+				ProposerSlashings: make([]*phase0.ProposerSlashing, 0),
+				AttesterSlashings: make([]*phase0.AttesterSlashing, 0),
+				Attestations:      make([]*phase0.Attestation, 0),
+				Deposits:          make([]*phase0.Deposit, 0),
+				VoluntaryExits:    make([]*phase0.SignedVoluntaryExit, 0),
+				SyncAggregate: &altair.SyncAggregate{
+					SyncCommitteeBits: syncBits,
+				},
+				ExecutionPayload: &deneb.ExecutionPayload{
+					BlockNumber:   uint64(slot),
+					GasLimit:      30_000_000,
+					Timestamp:     testGenesisTime + uint64(slot)*spec.SlotSeconds,
+					ExtraData:     make([]byte, 0),
+					BaseFeePerGas: uint256.NewInt(7),
+					Transactions:  make([]bellatrix.Transaction, 0),
+				},
+			},
+		},
+	}
+	copy(block.Message.Body.Graffiti[:], graffiti)
+	block.Message.Body.ExecutionPayload.BlockHash[0] = byte(slot)
+	block.Message.Body.ExecutionPayload.BlockHash[1] = byte(slot >> 8)
+	block.Message.Body.ExecutionPayload.BlockHash[2] = graffiti[len(graffiti)-1]
+	return block
+}
+
+// testStateRoot is the state root of the block at every slot, the beacon API and the cached states agree on it
+func testStateRoot(slot phase0.Slot) phase0.Root {
+	return phase0.Root{byte(slot), byte(slot >> 8), 0x5a}
+}
+
+func testBalance(epoch phase0.Epoch, valIdx int) phase0.Gwei {
+	return phase0.Gwei(32_000_000_000 + uint64(epoch)*uint64(valIdx+1)*1000)
+}
+
+func (c *testChain) addBlocks(graffiti string, slots ...phase0.Slot) {
+	for _, slot := range slots {
+		addMockBlock(c.t, c.Server, slot, graffiti)
+	}
+}
+
+// addAttestation includes in the block at slot the votes of the given committee positions for attSlot
+func (c *testChain) addAttestation(slot phase0.Slot, attSlot phase0.Slot, positions ...uint64) {
+	aggregationBits := bitfield.NewBitlist(uint64(testValidators) / spec.SlotsPerEpoch)
+	for _, position := range positions {
+		aggregationBits.SetBitAt(position, true)
+	}
+	block := c.block(slot)
+	block.Message.Body.Attestations = append(block.Message.Body.Attestations, &phase0.Attestation{
+		AggregationBits: aggregationBits,
+		Data: &phase0.AttestationData{
+			Slot:   attSlot,
+			Index:  0,
+			Source: &phase0.Checkpoint{},
+			Target: &phase0.Checkpoint{Epoch: spec.EpochAtSlot(attSlot)},
+		},
+		Signature: phase0.BLSSignature{},
+	})
+	// the root of the block changed
+	require.NoError(c.t, c.AddBlock(&eth2_client_spec.VersionedSignedBeaconBlock{
+		Version: eth2_client_spec.DataVersionDeneb,
+		Deneb:   block,
+	}))
+}
+
+func (c *testChain) block(slot phase0.Slot) *deneb.SignedBeaconBlock {
+	block, ok := c.Block(slot)
+	if !ok {
+		return nil
+	}
+	return block.Deneb
+}
+
+func (c *testChain) agnosticBlock(t *testing.T, slot phase0.Slot) *spec.AgnosticBlock {
+	block, err := spec.GetCustomBlock(eth2_client_spec.VersionedSignedBeaconBlock{
+		Version: eth2_client_spec.DataVersionDeneb,
+		Deneb:   c.block(slot),
+	})
+	require.NoError(t, err)
+	return &block
+}
+
+func (c *testChain) blockRoot(t *testing.T, slot phase0.Slot) phase0.Root {
+	root, err := c.block(slot).Message.HashTreeRoot()
+	require.NoError(t, err)
+	return root
+}
+
+// state returns the state at the last slot of the epoch, all validators are
+// active and attested with every flag in the previous epoch
+func (c *testChain) state(t *testing.T, epoch phase0.Epoch) *spec.AgnosticState {
+	lastSlot := spec.ComputeStartSlotAtEpoch(epoch+1) - 1
+
+	validators := make([]*phase0.Validator, testValidators)
+	balances := make([]phase0.Gwei, testValidators)
+	participation := make([]altair.ParticipationFlags, testValidators)
+	for i := range validators {
+		validators[i] = &phase0.Validator{
+			PublicKey:                  phase0.BLSPubKey{byte(i + 1)},
+			WithdrawalCredentials:      append([]byte{0x01}, make([]byte, 31)...),
+			EffectiveBalance:           32_000_000_000,
+			ActivationEligibilityEpoch: 0,
+			ActivationEpoch:            0,
+			ExitEpoch:                  phase0.Epoch(spec.FarFutureEpoch),
+			WithdrawableEpoch:          phase0.Epoch(spec.FarFutureEpoch),
+		}
+		balances[i] = testBalance(epoch, i)
+		participation[i] = 0b111
+	}
+
+	syncCommittee := &altair.SyncCommittee{
+		Pubkeys: make([]phase0.BLSPubKey, spec.SyncCommitteeSize),
+	}
+	for i := range syncCommittee.Pubkeys {
+		syncCommittee.Pubkeys[i] = validators[i%testValidators].PublicKey
+	}
+
+	blockRoots := make([]phase0.Root, spec.SlotsPerHistoricalRoot)
+	for slot := phase0.Slot(0); slot < lastSlot; slot++ {
+		blockRoots[slot%phase0.Slot(spec.SlotsPerHistoricalRoot)] = c.blockRoot(t, slot)
+	}
+
+	duties := spec.EpochDuties{
+		ProposerDuties:   make([]*v1.ProposerDuty, 0),
+		BeaconCommittees: make([]*v1.BeaconCommittee, 0),
+		ValidatorAttSlot: make(map[phase0.ValidatorIndex]phase0.Slot),
+	}
+	for slot := spec.ComputeStartSlotAtEpoch(epoch); slot <= lastSlot; slot++ {
+		duties.ProposerDuties = append(duties.ProposerDuties, &v1.ProposerDuty{
+			Slot:           slot,
+			ValidatorIndex: c.block(slot).Message.ProposerIndex,
+		})
+	}
+	for i := range validators {
+		duties.ValidatorAttSlot[phase0.ValidatorIndex(i)] = spec.ComputeStartSlotAtEpoch(epoch) + phase0.Slot(uint64(i)%spec.SlotsPerEpoch)
+	}
+	// one committee per slot, with the validators attesting at that slot
+	for slot := spec.ComputeStartSlotAtEpoch(epoch); slot <= lastSlot; slot++ {
+		committee := &v1.BeaconCommittee{Slot: slot, Index: 0}
+		for i := int(slot % phase0.Slot(spec.SlotsPerEpoch)); i < testValidators; i += int(spec.SlotsPerEpoch) {
+			committee.Validators = append(committee.Validators, phase0.ValidatorIndex(i))
+		}
+		duties.BeaconCommittees = append(duties.BeaconCommittees, committee)
+	}
+
+	state, err := spec.GetCustomState(eth2_client_spec.VersionedBeaconState{
+		Version: eth2_client_spec.DataVersionDeneb,
+		Deneb: &deneb.BeaconState{
+			GenesisTime:                testGenesisTime,
+			Slot:                       lastSlot,
+			LatestBlockHeader:          &phase0.BeaconBlockHeader{Slot: lastSlot},
+			BlockRoots:                 blockRoots,
+			Validators:                 validators,
+			Balances:                   balances,
+			PreviousEpochParticipation: participation,
+			CurrentJustifiedCheckpoint: &phase0.Checkpoint{},
+			FinalizedCheckpoint:        &phase0.Checkpoint{},
+			CurrentSyncCommittee:       syncCommittee,
+		},
+	}, duties)
+	require.NoError(t, err)
+	state.StateRoot = testStateRoot(lastSlot)
+	return &state
+}
+
+// newTestAnalyzer returns an analyzer persisting into a MemorySink, with the
+// blocks from 0 to lastSlot (included) already in the cache
+func newTestAnalyzer(t *testing.T, chain *testChain, lastSlot phase0.Slot) (*ChainAnalyzer, *db.MemorySink) {
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	utils.CheckPageInterval = time.Millisecond // processed slots and epochs are polled in the routine book
+
+	dbMetrics := db.DBMetrics{
+		Block:            true,
+		Epoch:            true,
+		ValidatorRewards: true,
+	}
+	cli, err := clientapi.NewAPIClient(ctx, chain.URL(), 1, clientapi.WithDBMetrics(dbMetrics))
+	require.NoError(t, err)
+
+	sink := db.NewMemorySink()
+	analyzer := &ChainAnalyzer{
+		ctx:                           ctx,
+		cancel:                        cancel,
+		cli:                           cli,
+		relayCli:                      &relay.RelaysMonitor{},
+		dbClient:                      sink,
+		downloadMode:                  "historical",
+		metrics:                       dbMetrics,
+		downloadCache:                 NewQueue(),
+		validatorsRewardsAggregations: make(map[phase0.ValidatorIndex]*spec.ValidatorRewardsAggregation),
+		aggregatedEpochsInWindow:      make(map[phase0.Epoch]bool),
+		processerBook:                 utils.NewRoutineBook(32, "processer"),
+	}
+
+	for slot := phase0.Slot(0); slot <= lastSlot; slot++ {
+		analyzer.downloadCache.AddNewBlock(chain.agnosticBlock(t, slot))
+	}
+	return analyzer, sink
+}
+
+func (s *ChainAnalyzer) addTestStates(t *testing.T, chain *testChain, epochs ...phase0.Epoch) {
+	for _, epoch := range epochs {
+		require.NoError(t, s.downloadCache.AddNewState(s.ctx, chain.state(t, epoch)))
+	}
+}
+
+func graffitiOf(rows []db.Row) []string {
+	graffitis := make([]string, 0, len(rows))
+	for _, row := range rows {
+		graffitis = append(graffitis, row["f_graffiti"].(string))
+	}
+	return graffitis
+}
+
+func deleteArgs(records []db.DeleteRecord) []uint64 {
+	args := make([]uint64, 0, len(records))
+	for _, record := range records {
+		switch arg := record.Args[0].(type) {
+		case phase0.Slot:
+			args = append(args, uint64(arg))
+		case phase0.Epoch:
+			args = append(args, uint64(arg))
+		}
+	}
+	return args
+}
+
+func TestProcessBlockRows(t *testing.T) {
+	chain := newTestChain(t)
+	chain.addBlocks(testGraffiti, 0, 1, 2)
+	analyzer, sink := newTestAnalyzer(t, chain, 2)
+
+	for slot := phase0.Slot(0); slot <= 2; slot++ {
+		analyzer.ProcessBlock(slot)
+	}
+
+	rows := sink.Rows("t_block_metrics")
+	require.Len(t, rows, 3)
+	for i, row := range rows {
+		assert.Equal(t, uint64(i), row.Uint64("f_slot"))
+		assert.Equal(t, uint64(0), row.Uint64("f_epoch"))
+		assert.Equal(t, uint64(i), row.Uint64("f_proposer_index"))
+		assert.Equal(t, testGraffiti, row["f_graffiti"])
+		assert.Equal(t, true, row["f_proposed"])
+		assert.Equal(t, uint64(testSyncParticipation), row.Uint64("f_sync_bits"))
+		assert.Equal(t, testGenesisTime+uint64(i)*spec.SlotSeconds, row.Uint64("f_timestamp"))
+		assert.Equal(t, uint64(i), row.Uint64("f_el_block_number"))
+	}
+	assert.Equal(t, 0, sink.Count("t_withdrawals"))
+	assert.Empty(t, sink.Deletes())
+
+	lastSlot, err := sink.RetrieveLastSlot()
+	require.NoError(t, err)
+	assert.Equal(t, phase0.Slot(2), lastSlot)
+}
+
+func TestProcessStateTransitionMetricsRows(t *testing.T) {
+	chain := newTestChain(t)
+	for slot := phase0.Slot(0); slot < spec.ComputeStartSlotAtEpoch(4); slot++ {
+		chain.addBlocks(testGraffiti, slot)
+	}
+	analyzer, sink := newTestAnalyzer(t, chain, spec.ComputeStartSlotAtEpoch(4)-1)
+	analyzer.addTestStates(t, chain, 1, 2, 3)
+
+	analyzer.ProcessStateTransitionMetrics(3)
+
+	// epoch metrics are written for the current state (epoch 2)
+	epochs := sink.Rows("t_epoch_metrics_summary")
+	require.Len(t, epochs, 1)
+	assert.Equal(t, uint64(2), epochs[0].Uint64("f_epoch"))
+	assert.Equal(t, uint64(3*spec.SlotsPerEpoch-1), epochs[0].Uint64("f_slot"))
+	assert.Equal(t, uint64(testValidators), epochs[0].Uint64("f_num_vals"))
+	assert.Equal(t, uint64(testValidators), epochs[0].Uint64("f_num_active_vals"))
+	assert.Equal(t, uint64(testValidators*32), epochs[0].Uint64("f_total_effective_balance_eth"))
+	assert.Equal(t, spec.SlotsPerEpoch*uint64(testSyncParticipation), epochs[0].Uint64("f_sync_committee_participation"))
+	assert.Equal(t, uint64(0), epochs[0].Uint64("f_missing_source"))
+	assert.Equal(t, testGenesisTime+2*spec.SlotsPerEpoch*spec.SlotSeconds, epochs[0].Uint64("f_timestamp"))
+
+	// proposer duties are written for the next state (epoch 3)
+	duties := sink.Rows("t_proposer_duties")
+	require.Len(t, duties, int(spec.SlotsPerEpoch))
+	for i, duty := range duties {
+		slot := 3*spec.SlotsPerEpoch + uint64(i)
+		assert.Equal(t, slot, duty.Uint64("f_proposer_slot"))
+		assert.Equal(t, slot%uint64(testValidators), duty.Uint64("f_val_idx"))
+		assert.Equal(t, true, duty["f_proposed"])
+	}
+
+	// validator rewards are the balance difference between epochs 2 and 3
+	rewards := sink.Where("t_validator_rewards_summary", "f_epoch", 3)
+	require.Len(t, rewards, testValidators)
+	for i, reward := range rewards {
+		assert.Equal(t, uint64(i), reward.Uint64("f_val_idx"))
+		assert.Equal(t, int64(testBalance(3, i)-testBalance(2, i)), reward["f_reward"])
+		assert.Equal(t, uint8(spec.ACTIVE_STATUS), reward["f_status"])
+		assert.Equal(t, true, reward["f_in_sync_committee"])
+		assert.Equal(t, uint8(spec.SlotsPerEpoch), reward["f_sync_committee_participations_included"])
+		assert.Equal(t, false, reward["f_missing_source"])
+	}
+
+	// block rewards are written for the blocks of the current state (epoch 2)
+	blockRewards := sink.Rows("t_block_rewards")
+	require.Len(t, blockRewards, int(spec.SlotsPerEpoch))
+	for i, blockReward := range blockRewards {
+		assert.Equal(t, 2*spec.SlotsPerEpoch+uint64(i), blockReward.Uint64("f_slot"))
+	}
+	assert.Empty(t, sink.Deletes())
+}
+
+func TestHandleReorgRewritesBlocks(t *testing.T) {
+	headSlot := phase0.Slot(100)
+	chain := newTestChain(t)
+	for slot := phase0.Slot(0); slot <= headSlot; slot++ {
+		chain.addBlocks(testGraffiti, slot)
+	}
+	analyzer, sink := newTestAnalyzer(t, chain, headSlot)
+	for slot := headSlot - 5; slot <= headSlot; slot++ {
+		analyzer.ProcessBlock(slot)
+	}
+
+	// the canonical chain now has different blocks at 98, 99 and 100
+	chain.addBlocks(testReorgGraffiti, 98, 99, 100)
+	analyzer.HandleReorg(v1.ChainReorgEvent{
+		Slot:  headSlot,
+		Depth: 2,
+	})
+
+	orphans := sink.Rows("t_orphans")
+	require.Len(t, orphans, 3)
+	assert.Equal(t, []string{testGraffiti, testGraffiti, testGraffiti}, graffitiOf(orphans))
+	assert.Equal(t, []uint64{100, 99, 98}, []uint64{orphans[0].Uint64("f_slot"), orphans[1].Uint64("f_slot"), orphans[2].Uint64("f_slot")})
+
+	blockDeletes := sink.DeletesOn("t_block_metrics")
+	assert.Equal(t, []uint64{100, 99, 98}, deleteArgs(blockDeletes))
+	for _, record := range blockDeletes {
+		assert.Equal(t, 1, record.Removed)
+	}
+
+	// every slot is persisted once, the reorged ones with the new block
+	assert.Equal(t, 6, sink.Count("t_block_metrics"))
+	for slot := headSlot - 5; slot <= headSlot; slot++ {
+		rows := sink.Where("t_block_metrics", "f_slot", slot)
+		require.Len(t, rows, 1, "slot %d", slot)
+		expected := testGraffiti
+		if slot >= 98 {
+			expected = testReorgGraffiti
+		}
+		assert.Equal(t, []string{expected}, graffitiOf(rows), "slot %d", slot)
+	}
+
+	cachedBlock, err := analyzer.downloadCache.BlockHistory.Wait(analyzer.ctx, 99)
+	require.NoError(t, err)
+	assert.Equal(t, chain.blockRoot(t, 99), cachedBlock.Root)
+}
+
+func TestAdvanceFinalizedRewritesChangedEpochs(t *testing.T) {
+	lastSlot := 4*spec.SlotsPerEpoch - 1
+	chain := newTestChain(t)
+	for slot := phase0.Slot(0); slot <= phase0.Slot(lastSlot); slot++ {
+		chain.addBlocks(testGraffiti, slot)
+	}
+	analyzer, sink := newTestAnalyzer(t, chain, phase0.Slot(lastSlot))
+	analyzer.addTestStates(t, chain, 0, 1, 2, 3)
+	for slot := phase0.Slot(0); slot <= phase0.Slot(lastSlot); slot++ {
+		analyzer.ProcessBlock(slot)
+	}
+	analyzer.ProcessStateTransitionMetrics(2)
+	analyzer.ProcessStateTransitionMetrics(3)
+	require.Empty(t, sink.Deletes())
+
+	// the finalized chain has a different block at slot 70 (epoch 2)
+	chain.addBlocks(testReorgGraffiti, 70)
+	analyzer.AdvanceFinalized(phase0.Slot(4 * spec.SlotsPerEpoch))
+
+	// the block is rewritten once
+	assert.Equal(t, []uint64{70}, deleteArgs(sink.DeletesOn("t_block_metrics")))
+	assert.Len(t, sink.Where("t_block_metrics", "f_slot", 70), 1)
+	assert.Equal(t, 4*int(spec.SlotsPerEpoch), sink.Count("t_block_metrics"))
+
+	// epoch 2 changed and epoch 3 depends on it, both are rewritten
+	assert.Equal(t, []uint64{1, 2, 2, 3}, deleteArgs(sink.DeletesOn("t_epoch_metrics_summary")))
+	assert.Equal(t, []uint64{2, 3}, deleteArgs(sink.DeletesOn("t_proposer_duties")))
+	epochs := sink.Rows("t_epoch_metrics_summary")
+	require.Len(t, epochs, 2)
+	assert.Equal(t, []uint64{1, 2}, []uint64{epochs[0].Uint64("f_epoch"), epochs[1].Uint64("f_epoch")})
+	for epoch := 2; epoch <= 3; epoch++ {
+		assert.Len(t, sink.Where("t_validator_rewards_summary", "f_epoch", epoch), testValidators, "epoch %d", epoch)
+	}
+	assert.Equal(t, 2*int(spec.SlotsPerEpoch), sink.Count("t_proposer_duties"))
+
+	// finalized epochs are removed from the cache
+	assert.False(t, analyzer.downloadCache.StateHistory.Available(3))
+	assert.False(t, analyzer.downloadCache.BlockHistory.Available(70))
+}
+
+func TestProcessValidatorAttestationsRows(t *testing.T) {
+	chain := newTestChain(t)
+	for slot := phase0.Slot(0); slot < spec.ComputeStartSlotAtEpoch(4); slot++ {
+		chain.addBlocks(testGraffiti, slot)
+	}
+	// duties of epoch 2: validators 0 and 32 attest at slot 64, validators 1 and 33 at slot 65
+	chain.addAttestation(65, 64, 0, 1)
+	chain.addAttestation(70, 64, 0)
+	chain.addAttestation(68, 65, 1)
+	analyzer, sink := newTestAnalyzer(t, chain, spec.ComputeStartSlotAtEpoch(4)-1)
+	analyzer.metrics.Attestations = true
+	analyzer.addTestStates(t, chain, 1, 2, 3)
+
+	analyzer.ProcessStateTransitionMetrics(3)
+
+	attestations := sink.Rows("t_attestations")
+	require.Len(t, attestations, testValidators)
+	for _, attestation := range attestations {
+		assert.Equal(t, uint64(2), attestation.Uint64("f_epoch"))
+		valIdx := attestation.Uint64("f_val_idx")
+		assert.Equal(t, 2*spec.SlotsPerEpoch+valIdx%spec.SlotsPerEpoch, attestation.Uint64("f_att_slot"))
+		assert.Equal(t, valIdx/spec.SlotsPerEpoch, attestation.Uint64("f_committee_position"))
+	}
+
+	byValidator := func(valIdx int) db.Row {
+		rows := sink.Where("t_attestations", "f_val_idx", valIdx)
+		require.Len(t, rows, 1)
+		return rows[0]
+	}
+	first := byValidator(0)
+	assert.Equal(t, true, first["f_included"])
+	assert.Equal(t, uint64(65), first.Uint64("f_inclusion_slot"))
+	assert.Equal(t, chain.blockRoot(t, 65).String(), first["f_inclusion_block_root"])
+	assert.Equal(t, uint64(1), first.Uint64("f_inclusion_delay"))
+	assert.Equal(t, []string{"65:0", "70:0"}, first["f_aggregates"])
+	assert.Equal(t, false, first["f_included_late"])
+
+	late := byValidator(33)
+	assert.Equal(t, uint64(1), late.Uint64("f_committee_position"))
+	assert.Equal(t, uint64(68), late.Uint64("f_inclusion_slot"))
+	assert.Equal(t, uint64(3), late.Uint64("f_inclusion_delay"))
+	assert.Equal(t, true, late["f_included_late"])
+
+	missed := byValidator(1)
+	assert.Equal(t, false, missed["f_included"])
+	assert.Equal(t, "", missed["f_inclusion_block_root"])
+	assert.Empty(t, missed["f_aggregates"])
+
+	// the rows are rewritten with the state
+	require.NoError(t, sink.DeleteStateMetrics(3))
+	assert.Equal(t, []uint64{2, 3}, deleteArgs(sink.DeletesOn("t_attestations")))
+	assert.Equal(t, 0, sink.Count("t_attestations"))
+}
+
+func TestProcessSyncCommitteeDutiesRows(t *testing.T) {
+	chain := newTestChain(t)
+	for slot := phase0.Slot(0); slot < spec.ComputeStartSlotAtEpoch(4); slot++ {
+		chain.addBlocks(testGraffiti, slot)
+	}
+	analyzer, sink := newTestAnalyzer(t, chain, spec.ComputeStartSlotAtEpoch(4)-1)
+	analyzer.metrics.SyncCommittee = true
+	analyzer.addTestStates(t, chain, 1, 2, 3)
+
+	analyzer.ProcessStateTransitionMetrics(3)
+
+	duties := sink.Rows("t_sync_committee_participation")
+	require.Len(t, duties, int(spec.SlotsPerEpoch*spec.SyncCommitteeSize))
+	for _, duty := range duties {
+		assert.Equal(t, uint64(3), duty.Uint64("f_epoch"))
+		slot := duty.Uint64("f_slot")
+		assert.Equal(t, uint64(3), slot/spec.SlotsPerEpoch)
+
+		position := duty.Uint64("f_committee_position")
+		assert.Equal(t, position%uint64(testValidators), duty.Uint64("f_val_idx"))
+		reward := duty["f_reward"].(int64)
+		if position < uint64(testSyncParticipation) {
+			assert.Equal(t, true, duty["f_participated"])
+			assert.Positive(t, reward)
+		} else {
+			assert.Equal(t, false, duty["f_participated"])
+			assert.Negative(t, reward)
+		}
+	}
+
+	// the rows are rewritten with the state
+	require.NoError(t, sink.DeleteStateMetrics(3))
+	assert.Equal(t, []uint64{3}, deleteArgs(sink.DeletesOn("t_sync_committee_participation")))
+	assert.Equal(t, 0, sink.Count("t_sync_committee_participation"))
+}
+
+func TestProcessTrackedValidatorsRows(t *testing.T) {
+	chain := newTestChain(t)
+	for slot := phase0.Slot(0); slot < spec.ComputeStartSlotAtEpoch(4); slot++ {
+		chain.addBlocks(testGraffiti, slot)
+	}
+	analyzer, sink := newTestAnalyzer(t, chain, spec.ComputeStartSlotAtEpoch(4)-1)
+	analyzer.downloadMode = "finalized"
+	analyzer.metrics.SyncCommittee = true
+	analyzer.trackedValidators = &utils.TrackedValidators{
+		Indexes: map[phase0.ValidatorIndex]bool{3: true},
+		Pubkeys: map[phase0.BLSPubKey]bool{{11}: true}, // validator 10
+	}
+	analyzer.addTestStates(t, chain, 1, 2, 3)
+
+	analyzer.ProcessStateTransitionMetrics(3)
+
+	// network level metrics still cover every validator
+	epochs := sink.Rows("t_epoch_metrics_summary")
+	require.Len(t, epochs, 1)
+	assert.Equal(t, uint64(testValidators), epochs[0].Uint64("f_num_vals"))
+	assert.Len(t, sink.Rows("t_proposer_duties"), int(spec.SlotsPerEpoch))
+
+	valIdxs := func(table string) []uint64 {
+		result := make([]uint64, 0)
+		for _, row := range sink.Rows(table) {
+			valIdx := row.Uint64("f_val_idx")
+			if !slices.Contains(result, valIdx) {
+				result = append(result, valIdx)
+			}
+		}
+		return result
+	}
+	assert.ElementsMatch(t, []uint64{3, 10}, valIdxs("t_validator_rewards_summary"))
+	assert.ElementsMatch(t, []uint64{3, 10}, valIdxs("t_validator_last_status"))
+	assert.ElementsMatch(t, []uint64{3, 10}, valIdxs("t_sync_committee_participation"))
+	assert.Len(t, sink.Rows("t_sync_committee_participation"), int(spec.SlotsPerEpoch*2*spec.SyncCommitteeSize)/testValidators)
+}
@@ -0,0 +1,237 @@
+package analyzer
+
+import (
+	"sync"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/migalabs/goteth/pkg/db"
+	"github.com/migalabs/goteth/pkg/spec"
+)
+
+// backfillProgress tracks the epochs fully processed per metric, so a restart
+// only downloads the epochs still missing.
+// Block metrics are done once every slot of the epoch was persisted, state metrics
+// once the epoch transition was persisted (the whole window when rewards are aggregated).
+type backfillProgress struct {
+	mu    sync.Mutex
+	sink  db.Sink
+	done  map[string]map[phase0.Epoch]bool                 // metric -> epochs done
+	slots map[phase0.Epoch]map[string]map[phase0.Slot]bool // epoch -> block metric -> slots persisted
+}
+
+// slotRange is a range of slots to download, both included
+type slotRange struct {
+	init phase0.Slot
+	end  phase0.Slot
+}
+
+func newBackfillProgress(sink db.Sink) *backfillProgress {
+	return &backfillProgress{
+		sink:  sink,
+		done:  make(map[string]map[phase0.Epoch]bool),
+		slots: make(map[phase0.Epoch]map[string]map[phase0.Slot]bool),
+	}
+}
+
+// load reads the progress recorded in the database between both epochs
+func (p *backfillProgress) load(from phase0.Epoch, to phase0.Epoch) error {
+	if p == nil {
+		return nil
+	}
+	progress, err := p.sink.RetrieveProgress(from, to)
+	if err != nil {
+		return err
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, item := range progress {
+		p.setDone(item.Metric, item.Epoch)
+	}
+	return nil
+}
+
+func (p *backfillProgress) setDone(metric string, epoch phase0.Epoch) {
+	if _, ok := p.done[metric]; !ok {
+		p.done[metric] = make(map[phase0.Epoch]bool)
+	}
+	p.done[metric][epoch] = true
+}
+
+// allDone returns whether the epoch is done for every metric given
+func (p *backfillProgress) allDone(metrics []string, epoch phase0.Epoch) bool {
+	if p == nil || len(metrics) == 0 {
+		return false
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, metric := range metrics {
+		if !p.done[metric][epoch] {
+			return false
+		}
+	}
+	return true
+}
+
+func (p *backfillProgress) isDone(metric string, epoch phase0.Epoch) bool {
+	return p.allDone([]string{metric}, epoch)
+}
+
+// markDone records the epochs as done for the metric
+func (p *backfillProgress) markDone(metric string, epochs ...phase0.Epoch) {
+	if p == nil || len(epochs) == 0 {
+		return
+	}
+	progress := make([]db.Progress, 0, len(epochs))
+	for _, epoch := range epochs {
+		progress = append(progress, db.Progress{Metric: metric, Epoch: epoch})
+	}
+	if err := p.sink.PersistProgress(progress); err != nil {
+		log.Errorf("could not record %s progress: %s", metric, err)
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, epoch := range epochs {
+		p.setDone(metric, epoch)
+	}
+}
+
+// assumeDone sets the epoch as done for the metrics without recording it, so it is
+// skipped in this run only (i.e. epochs downloaded to repair their neighbours)
+func (p *backfillProgress) assumeDone(metrics []string, epoch phase0.Epoch) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, metric := range metrics {
+		p.setDone(metric, epoch)
+	}
+}
+
+// slotProcessed records the block metrics persisted for the slot, the epoch is
+// done for a metric once all its slots are
+func (p *backfillProgress) slotProcessed(slot phase0.Slot, metrics ...string) {
+	if p == nil {
+		return
+	}
+	epoch := spec.EpochAtSlot(slot)
+	completed := make([]string, 0)
+
+	p.mu.Lock()
+	for _, metric := range metrics {
+		if _, ok := p.slots[epoch]; !ok {
+			p.slots[epoch] = make(map[string]map[phase0.Slot]bool)
+		}
+		if _, ok := p.slots[epoch][metric]; !ok {
+			p.slots[epoch][metric] = make(map[phase0.Slot]bool)
+		}
+		p.slots[epoch][metric][slot] = true
+		if len(p.slots[epoch][metric]) == int(spec.SlotsPerEpoch) {
+			delete(p.slots[epoch], metric)
+			completed = append(completed, metric)
+		}
+	}
+	if len(p.slots[epoch]) == 0 {
+		delete(p.slots, epoch)
+	}
+	p.mu.Unlock()
+
+	for _, metric := range completed {
+		p.markDone(metric, epoch)
+	}
+}
+
+// reset forgets the progress of an epoch that is about to be rewritten (reorgs),
+// keep lists the metrics that stay done (i.e. rewards already aggregated in a window)
+func (p *backfillProgress) reset(epoch phase0.Epoch, keep ...string) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	kept := make([]string, 0, len(keep))
+	for _, metric := range keep {
+		if p.done[metric][epoch] {
+			kept = append(kept, metric)
+		}
+	}
+	for metric := range p.done {
+		delete(p.done[metric], epoch)
+	}
+	delete(p.slots, epoch)
+	p.mu.Unlock()
+
+	if err := p.sink.DeleteProgress(epoch); err != nil {
+		log.Errorf("could not reset progress of epoch %d: %s", epoch, err)
+	}
+	for _, metric := range kept {
+		p.markDone(metric, epoch)
+	}
+}
+
+// pendingRanges returns the slots to download so every epoch in [first, last] not
+// done for all the metrics is processed. Each range starts 2 epochs before its
+// first pending epoch, as the epoch transition needs the two previous states.
+// The last range finishes at end when it includes the last epoch.
+func (p *backfillProgress) pendingRanges(metrics []string, init phase0.Slot, end phase0.Slot, first phase0.Epoch, last phase0.Epoch) []slotRange {
+	ranges := make([]slotRange, 0)
+	for epoch := first; epoch <= last; epoch++ {
+		if p.allDone(metrics, epoch) {
+			continue
+		}
+		rangeInit := init
+		if epoch >= 2 && spec.ComputeStartSlotAtEpoch(epoch-2) > init {
+			rangeInit = spec.ComputeStartSlotAtEpoch(epoch - 2)
+		}
+		rangeEnd := spec.ComputeStartSlotAtEpoch(epoch+1) - 1
+		if epoch == last || rangeEnd > end {
+			rangeEnd = end
+		}
+
+		if len(ranges) > 0 && rangeInit <= ranges[len(ranges)-1].end+1 {
+			ranges[len(ranges)-1].end = rangeEnd
+			continue
+		}
+		ranges = append(ranges, slotRange{init: rangeInit, end: rangeEnd})
+	}
+	return ranges
+}
+
+// blockProgressMetrics returns the metrics persisted when processing a block
+func (s *ChainAnalyzer) blockProgressMetrics() []string {
+	metrics := make([]string, 0)
+	if !s.metrics.Block {
+		return metrics
+	}
+	metrics = append(metrics, db.BlockProgress)
+	if s.metrics.Transactions {
+		metrics = append(metrics, db.TransactionsProgress)
+	}
+	if s.metrics.BlobSidecars {
+		metrics = append(metrics, db.BlobSidecarsProgress)
+	}
+	return metrics
+}
+
+// stateProgressMetrics returns the metrics persisted when processing an epoch transition
+func (s *ChainAnalyzer) stateProgressMetrics() []string {
+	metrics := make([]string, 0)
+	if !s.metrics.Epoch {
+		return metrics
+	}
+	metrics = append(metrics, db.EpochProgress)
+	if s.metrics.ValidatorRewards {
+		metrics = append(metrics, db.RewardsProgress)
+	}
+	return metrics
+}
+
+// resetProgress forgets the progress of an epoch before rewriting it. Rewards already
+// aggregated are kept, as the flushed window would be aggregated twice otherwise
+func (s *ChainAnalyzer) resetProgress(epoch phase0.Epoch) {
+	if s.rewardsAggregationEpochs > 1 {
+		s.progress.reset(epoch, db.RewardsProgress)
+		return
+	}
+	s.progress.reset(epoch)
+}
@@ -0,0 +1,114 @@
+package analyzer
+
+import (
+	"testing"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/migalabs/goteth/pkg/db"
+	"github.com/migalabs/goteth/pkg/spec"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPendingRanges(t *testing.T) {
+	sink := db.NewMemorySink()
+	metrics := []string{db.BlockProgress, db.EpochProgress}
+	progress := make([]db.Progress, 0)
+	for _, epoch := range []phase0.Epoch{4, 5, 6, 9, 10, 11} {
+		for _, metric := range metrics {
+			progress = append(progress, db.Progress{Metric: metric, Epoch: epoch})
+		}
+	}
+	// epoch 2 is only done for block metrics
+	progress = append(progress, db.Progress{Metric: db.BlockProgress, Epoch: 2})
+	require.NoError(t, sink.PersistProgress(progress))
+
+	tracker := newBackfillProgress(sink)
+	require.NoError(t, tracker.load(2, 12))
+
+	// each range starts two epochs before its first pending epoch, the last one finishes at end
+	end := spec.ComputeStartSlotAtEpoch(13)
+	assert.Equal(t, []slotRange{
+		{init: 0, end: spec.ComputeStartSlotAtEpoch(4) - 1},
+		{init: spec.ComputeStartSlotAtEpoch(5), end: spec.ComputeStartSlotAtEpoch(9) - 1},
+		{init: spec.ComputeStartSlotAtEpoch(10), end: end},
+	}, tracker.pendingRanges(metrics, 0, end, 2, 12))
+
+	// nothing is pending once every epoch is done
+	assert.Empty(t, tracker.pendingRanges(metrics, spec.ComputeStartSlotAtEpoch(2), spec.ComputeStartSlotAtEpoch(7)-1, 4, 6))
+
+	// without progress the whole range is downloaded
+	assert.Equal(t, []slotRange{{init: 0, end: end}}, newBackfillProgress(db.NewMemorySink()).pendingRanges(metrics, 0, end, 2, 12))
+}
+
+func TestProcessRecordsProgress(t *testing.T) {
+	chain := newTestChain(t)
+	for slot := phase0.Slot(0); slot < spec.ComputeStartSlotAtEpoch(4); slot++ {
+		chain.addBlocks(testGraffiti, slot)
+	}
+	analyzer, sink := newTestAnalyzer(t, chain, spec.ComputeStartSlotAtEpoch(4)-1)
+	analyzer.addTestStates(t, chain, 1, 2, 3)
+	analyzer.progress = newBackfillProgress(sink)
+
+	// block metrics are done once every slot of the epoch is persisted
+	for slot := spec.ComputeStartSlotAtEpoch(3); slot < spec.ComputeStartSlotAtEpoch(4)-1; slot++ {
+		analyzer.ProcessBlock(slot)
+	}
+	assert.Empty(t, sink.Where("t_backfill_progress", "f_metric", db.BlockProgress))
+	analyzer.ProcessBlock(spec.ComputeStartSlotAtEpoch(4) - 1)
+	analyzer.ProcessStateTransitionMetrics(3)
+
+	for _, metric := range []string{db.BlockProgress, db.EpochProgress, db.RewardsProgress} {
+		rows := sink.Where("t_backfill_progress", "f_metric", metric)
+		require.Len(t, rows, 1, metric)
+		assert.Equal(t, uint64(3), rows[0].Uint64("f_epoch"))
+	}
+
+	// a restart loads the progress and skips the epoch
+	resumed := newBackfillProgress(sink)
+	require.NoError(t, resumed.load(0, 10))
+	analyzer.progress = resumed
+	analyzer.ProcessBlock(spec.ComputeStartSlotAtEpoch(3))
+	analyzer.ProcessStateTransitionMetrics(3)
+	assert.Equal(t, int(spec.SlotsPerEpoch), sink.Count("t_block_metrics"))
+	assert.Equal(t, 1, sink.Count("t_epoch_metrics_summary"))
+
+	// rewriting the epoch (reorgs) forgets its progress
+	analyzer.resetProgress(3)
+	assert.Equal(t, []uint64{3}, deleteArgs(sink.DeletesOn("t_backfill_progress")))
+	assert.False(t, resumed.isDone(db.EpochProgress, 3))
+	analyzer.ProcessStateTransitionMetrics(3)
+	assert.Equal(t, 2, sink.Count("t_epoch_metrics_summary"))
+	assert.True(t, resumed.isDone(db.EpochProgress, 3))
+}
+
+func TestAggregatedRewardsProgress(t *testing.T) {
+	chain := newTestChain(t)
+	for slot := phase0.Slot(0); slot < spec.ComputeStartSlotAtEpoch(6); slot++ {
+		chain.addBlocks(testGraffiti, slot)
+	}
+	analyzer, sink := newTestAnalyzer(t, chain, spec.ComputeStartSlotAtEpoch(6)-1)
+	analyzer.addTestStates(t, chain, 1, 2, 3, 4, 5)
+	analyzer.progress = newBackfillProgress(sink)
+	analyzer.rewardsAggregationEpochs = 2
+	analyzer.startEpochAggregation = 2
+	analyzer.endEpochAggregation = 3
+
+	// epochs 2 and 3 were aggregated in a previous run, the window restarts at epoch 4
+	analyzer.progress.markDone(db.RewardsProgress, 2, 3)
+	analyzer.ProcessStateTransitionMetrics(4)
+	assert.False(t, analyzer.progress.isDone(db.RewardsProgress, 4), "rewards are recorded when the window is flushed")
+	analyzer.ProcessStateTransitionMetrics(5)
+
+	aggregations := sink.Rows("t_validator_rewards_aggregation")
+	require.NotEmpty(t, aggregations)
+	assert.Equal(t, uint64(4), aggregations[0].Uint64("f_start_epoch"))
+	assert.Equal(t, uint64(5), aggregations[0].Uint64("f_end_epoch"))
+	assert.True(t, analyzer.progress.isDone(db.RewardsProgress, 4))
+	assert.True(t, analyzer.progress.isDone(db.RewardsProgress, 5))
+
+	// a rewritten epoch keeps its rewards, the window is not aggregated again
+	analyzer.resetProgress(4)
+	assert.True(t, analyzer.progress.isDone(db.RewardsProgress, 4))
+	assert.False(t, analyzer.progress.isDone(db.EpochProgress, 4))
+}
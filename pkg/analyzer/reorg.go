@@ -6,12 +6,14 @@ import (
 
 	v1 "github.com/attestantio/go-eth2-client/api/v1"
 	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/migalabs/goteth/pkg/db"
 	"github.com/migalabs/goteth/pkg/spec"
+	"github.com/migalabs/goteth/pkg/stream"
 )
 
 func (s *ChainAnalyzer) AdvanceFinalized(newFinalizedSlot phase0.Slot) {
 
-	finalizedEpoch := newFinalizedSlot / spec.SlotsPerEpoch
+	finalizedEpoch := spec.EpochAtSlot(newFinalizedSlot)
 
 	stateKeys := s.downloadCache.StateHistory.GetKeyList()
 
@@ -48,6 +50,7 @@ func (s *ChainAnalyzer) AdvanceFinalized(newFinalizedSlot phase0.Slot) {
 				log.Warnf("cache block root: %s\nfinalized block root: %s", cacheBlockRoot, finalizedBlockRoot)
 				log.Warnf("block root for block (slot=%d) incorrect, redownload", cacheBlock.Slot)
 
+				s.resetProgress(spec.EpochAtSlot(phase0.Slot(slot)))
 				s.dbClient.DeleteBlockMetrics(phase0.Slot(slot))
 				log.Infof("rewriting metrics for slot %d", slot)
 				s.ProcessBlock(phase0.Slot(slot))
@@ -113,6 +116,7 @@ func (s *ChainAnalyzer) AdvanceFinalized(newFinalizedSlot phase0.Slot) {
 			// to block forever. Re-download any that are missing. (#245)
 			s.ensureDependencyStates(epoch)
 
+			s.resetProgress(phase0.Epoch(epoch))
 			s.dbClient.DeleteStateMetrics(phase0.Epoch(epoch))
 			log.Infof("rewriting metrics for epoch %d (stateRootChanged=%t, blocksChanged=%t, dep=%t)",
 				epoch, stateRootChanged, blocksChanged,
@@ -124,7 +128,7 @@ func (s *ChainAnalyzer) AdvanceFinalized(newFinalizedSlot phase0.Slot) {
 	s.downloadCache.CleanUpTo(newFinalizedSlot)
 
 	if advance {
-		log.Infof("checked states until slot %d, epoch %d", newFinalizedSlot, newFinalizedSlot/spec.SlotsPerEpoch)
+		log.Infof("checked states until slot %d, epoch %d", newFinalizedSlot, spec.EpochAtSlot(newFinalizedSlot))
 	}
 }
 
@@ -141,7 +145,7 @@ func (s *ChainAnalyzer) ensureDependencyStates(epoch uint64) {
 		depEpochs = append(depEpochs, epoch-2)
 	}
 
-	initEpoch := uint64(s.initSlot / spec.SlotsPerEpoch)
+	initEpoch := uint64(spec.EpochAtSlot(s.initSlot))
 	for _, dep := range depEpochs {
 		if dep < initEpoch {
 			continue
@@ -194,7 +198,11 @@ func (s *ChainAnalyzer) HandleReorg(newReorg v1.ChainReorgEvent) {
 		if newBlock.Root != oldBlock.Root { // only rewrite if stateroots are different
 			if block.Proposed { // keep orphans -> if previous block was proposed and roots have changed
 				s.dbClient.PersistOrphans([]spec.AgnosticBlock{oldBlock})
+				s.stream.Publish(stream.OrphanEvent, func() ([]db.Row, error) {
+					return db.OrphanRows([]spec.AgnosticBlock{oldBlock})
+				})
 			}
+			s.resetProgress(spec.EpochAtSlot(i))
 			s.dbClient.DeleteBlockMetrics(i)
 			log.Infof("rewriting metrics for slot %d", i)
 			// write slot metrics
@@ -203,8 +211,8 @@ func (s *ChainAnalyzer) HandleReorg(newReorg v1.ChainReorgEvent) {
 			log.Infof("reorg slot %d: block roots are the same", i)
 		}
 
-		if (i+1)%spec.SlotsPerEpoch == 0 { // then we are at the end of the epoch, rewrite state
-			epoch := phase0.Epoch(i / spec.SlotsPerEpoch)
+		if (i+1)%phase0.Slot(spec.SlotsPerEpoch) == 0 { // then we are at the end of the epoch, rewrite state
+			epoch := spec.EpochAtSlot(i)
 
 			state, err := s.downloadCache.StateHistory.Wait(s.ctx, EpochTo[uint64](epoch)) // first check that it was already in the cache
 			if err != nil {
@@ -221,6 +229,7 @@ func (s *ChainAnalyzer) HandleReorg(newReorg v1.ChainReorgEvent) {
 			}
 
 			if newState.StateRoot != oldState.StateRoot {
+				s.resetProgress(epoch)
 				s.dbClient.DeleteStateMetrics(epoch)
 				log.Infof("rewriting metrics for epoch %d", epoch)
 				// write epoch metrics
@@ -230,4 +239,8 @@ func (s *ChainAnalyzer) HandleReorg(newReorg v1.ChainReorgEvent) {
 		i -= 1
 	}
 
+	// the reorg is published once every slot and epoch has been rewritten
+	s.stream.Publish(stream.ReorgEvent, func() ([]db.Row, error) {
+		return db.ReorgRows([]v1.ChainReorgEvent{newReorg})
+	})
 }
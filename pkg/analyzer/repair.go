@@ -0,0 +1,307 @@
+package analyzer
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/migalabs/goteth/pkg/db"
+	"github.com/migalabs/goteth/pkg/spec"
+	"github.com/pkg/errors"
+)
+
+// Gap is an epoch whose rows are missing or inconsistent in a table
+type Gap struct {
+	Table    string
+	Epoch    phase0.Epoch
+	Rows     uint64
+	Expected uint64 // 0 when the number of rows is unknown, only missing epochs are gaps
+}
+
+// the epoch metrics, whose active validators are the rows expected in the rewards
+var epochsTable = "t_epoch_metrics_summary"
+
+func (g Gap) String() string {
+	if g.Rows == 0 {
+		return fmt.Sprintf("%s epoch %d: no rows", g.Table, g.Epoch)
+	}
+	return fmt.Sprintf("%s epoch %d: %d rows, expected %d", g.Table, g.Epoch, g.Rows, g.Expected)
+}
+
+// repairCheck is a table scanned for gaps, grouped by epoch
+type repairCheck struct {
+	table    string
+	column   string
+	divisor  uint64
+	final    bool
+	expected uint64
+	// rows written by the epoch transition (state metrics) instead of the blocks,
+	// the transition of epoch+offset writes the rows of the epoch
+	transition bool
+	offset     phase0.Epoch
+	// a row per validator active in the epoch of the previous state (epoch-2) at
+	// least, the ones counted in the epoch metrics (f_num_active_vals)
+	activeVals bool
+}
+
+// transitionEpoch returns the epoch transition that writes the rows of the epoch
+func (c repairCheck) transitionEpoch(epoch phase0.Epoch) phase0.Epoch {
+	return epoch + c.offset
+}
+
+// firstDownloaded returns the first epoch downloaded to write the rows of the epoch
+func (c repairCheck) firstDownloaded(epoch phase0.Epoch) phase0.Epoch {
+	if !c.transition {
+		return epoch
+	}
+	// the transition needs the two previous states
+	if c.transitionEpoch(epoch) < 2 {
+		return 0
+	}
+	return c.transitionEpoch(epoch) - 2
+}
+
+// repairChecks returns the tables scanned for the metrics enabled
+func (s *ChainAnalyzer) repairChecks() []repairCheck {
+	checks := make([]repairCheck, 0)
+	if s.metrics.Block {
+		checks = append(checks, repairCheck{
+			table:    "t_block_metrics",
+			column:   "f_slot",
+			divisor:  spec.SlotsPerEpoch,
+			final:    true,
+			expected: spec.SlotsPerEpoch, // missed slots are persisted too
+		})
+	}
+	if s.metrics.Epoch {
+		checks = append(checks, repairCheck{
+			table:      "t_proposer_duties",
+			column:     "f_proposer_slot",
+			divisor:    spec.SlotsPerEpoch,
+			final:      true,
+			expected:   spec.SlotsPerEpoch,
+			transition: true,
+		}, repairCheck{
+			table:      epochsTable,
+			column:     "f_epoch",
+			divisor:    1,
+			final:      true,
+			expected:   1,
+			transition: true,
+			offset:     1, // written with the currentState of the next transition
+		})
+		if s.metrics.ValidatorRewards {
+			checks = append(checks, repairCheck{
+				table:      "t_validator_rewards_summary",
+				column:     "f_epoch",
+				divisor:    1,
+				transition: true,
+				activeVals: s.trackedValidators == nil, // else only the tracked ones are written
+			})
+		}
+	}
+	return checks
+}
+
+// FindGaps scans the tables of the metrics enabled between the init and final slots.
+// Without init slot the scan starts at the first epoch downloaded, as the earlier
+// epochs were never processed. The last epoch in the database may still be processed
+func (s *ChainAnalyzer) FindGaps() ([]Gap, error) {
+	counter, ok := s.dbClient.(db.Counter)
+	if !ok {
+		return nil, errors.New("the storage backend cannot count the rows of the tables")
+	}
+	lastSlot, err := s.dbClient.RetrieveLastSlot()
+	if err != nil {
+		return nil, errors.Wrap(err, "could not retrieve the last slot")
+	}
+	if spec.EpochAtSlot(lastSlot) == 0 {
+		return nil, nil
+	}
+	lastEpoch := spec.EpochAtSlot(lastSlot) - 1
+	if s.finalSlot > 0 && spec.EpochAtSlot(s.finalSlot) < lastEpoch {
+		lastEpoch = spec.EpochAtSlot(s.finalSlot)
+	}
+	firstEpoch := spec.EpochAtSlot(s.initSlot)
+	if lastEpoch < firstEpoch {
+		return nil, nil
+	}
+
+	checks := s.repairChecks()
+	counts := make([]map[uint64]uint64, len(checks))
+	firstDownloaded := lastEpoch + 1
+	for i, check := range checks {
+		counts[i], err = counter.CountRows(db.CountQuery{
+			Table:   check.table,
+			Final:   check.final,
+			Column:  check.column,
+			Divisor: check.divisor,
+			From:    uint64(firstEpoch),
+			To:      uint64(lastEpoch),
+		})
+		if err != nil {
+			return nil, errors.Wrapf(err, "could not count the rows of %s", check.table)
+		}
+		for epoch := range counts[i] {
+			firstDownloaded = min(firstDownloaded, check.firstDownloaded(phase0.Epoch(epoch)))
+		}
+	}
+	scanAll := s.initSlot == 0
+	if scanAll {
+		firstEpoch = max(firstEpoch, firstDownloaded)
+	}
+	var activeVals map[phase0.Epoch]uint64
+	for _, check := range checks {
+		if check.activeVals && activeVals == nil {
+			activeVals, err = s.activeValidators(firstEpoch-min(firstEpoch, 2), lastEpoch)
+			if err != nil {
+				return nil, errors.Wrap(err, "could not read the active validators")
+			}
+		}
+	}
+
+	gaps := make([]Gap, 0)
+	for i, check := range checks {
+		for epoch := firstEpoch; epoch+check.offset <= lastEpoch; epoch++ {
+			if scanAll && check.firstDownloaded(epoch) < firstEpoch {
+				continue
+			}
+			rows := counts[i][uint64(epoch)]
+			expected := check.expected
+			missingRows := check.expected > 0 && rows != check.expected
+			if check.activeVals && epoch >= 2 {
+				// unknown if the epoch metrics are missing too, a gap themselves
+				expected = activeVals[epoch-2]
+				missingRows = rows < expected
+			}
+			if rows == 0 || missingRows {
+				gaps = append(gaps, Gap{
+					Table:    check.table,
+					Epoch:    epoch,
+					Rows:     rows,
+					Expected: expected,
+				})
+			}
+		}
+	}
+	return gaps, nil
+}
+
+// activeValidators returns the active validators of the epochs between from and to, as
+// counted in the epoch metrics. Empty if the storage backend cannot read back its rows
+func (s *ChainAnalyzer) activeValidators(from, to phase0.Epoch) (map[phase0.Epoch]uint64, error) {
+	activeVals := make(map[phase0.Epoch]uint64)
+	reader, ok := s.dbClient.(db.Reader)
+	if !ok {
+		return activeVals, nil
+	}
+	rows, err := reader.SelectRows(db.Query{
+		Table: epochsTable,
+		Final: true,
+		Filters: []db.Filter{
+			{Column: "f_epoch", Op: ">=", Value: uint64(from)},
+			{Column: "f_epoch", Op: "<=", Value: uint64(to)},
+		},
+		Limit: int(to-from) + 1,
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		activeVals[phase0.Epoch(row.Uint64("f_epoch"))] = row.Uint64("f_num_active_vals")
+	}
+	return activeVals, nil
+}
+
+// prepareRepair deletes the rows of the epochs partially written and returns the slots
+// to download to rewrite the gaps. The rest of the epochs downloaded are not processed
+func (s *ChainAnalyzer) prepareRepair(gaps []Gap) []slotRange {
+	checks := make(map[string]repairCheck)
+	for _, check := range s.repairChecks() {
+		checks[check.table] = check
+	}
+
+	blockEpochs := make(map[phase0.Epoch]bool)
+	transitions := make(map[phase0.Epoch]bool)
+	for _, gap := range gaps {
+		check, ok := checks[gap.Table]
+		if !ok {
+			continue
+		}
+		if !check.transition {
+			blockEpochs[gap.Epoch] = true
+			if gap.Rows > 0 {
+				for slot := spec.ComputeStartSlotAtEpoch(gap.Epoch); slot < spec.ComputeStartSlotAtEpoch(gap.Epoch+1); slot++ {
+					s.dbClient.DeleteBlockMetrics(slot)
+				}
+			}
+			continue
+		}
+		epoch := check.transitionEpoch(gap.Epoch)
+		transitions[epoch] = true
+		if gap.Rows > 0 {
+			// the deletes of an epoch reach the rows written by the two next transitions
+			s.dbClient.DeleteStateMetrics(epoch)
+			transitions[epoch+1] = true
+			transitions[epoch+2] = true
+		}
+	}
+	if len(blockEpochs) == 0 && len(transitions) == 0 {
+		return nil
+	}
+
+	epochs := make([]phase0.Epoch, 0, len(blockEpochs)+len(transitions))
+	for epoch := range blockEpochs {
+		epochs = append(epochs, epoch)
+	}
+	for epoch := range transitions {
+		epochs = append(epochs, epoch)
+	}
+	sort.Slice(epochs, func(i, j int) bool { return epochs[i] < epochs[j] })
+	first, last := epochs[0], epochs[len(epochs)-1]
+	init := phase0.Slot(0)
+	if first >= 2 {
+		init = spec.ComputeStartSlotAtEpoch(first - 2)
+	}
+
+	// only the gaps are pending, every other epoch downloaded is skipped
+	blockMetrics := s.blockProgressMetrics()
+	stateMetrics := s.stateProgressMetrics()
+	for epoch := spec.EpochAtSlot(init); epoch <= last; epoch++ {
+		if blockEpochs[epoch] || transitions[epoch] {
+			s.resetProgress(epoch)
+		}
+		if !blockEpochs[epoch] {
+			s.progress.assumeDone(blockMetrics, epoch)
+		}
+		if !transitions[epoch] {
+			s.progress.assumeDone(stateMetrics, epoch)
+		}
+	}
+	s.initSlot = init
+
+	metrics := append(blockMetrics, stateMetrics...)
+	return s.progress.pendingRanges(metrics, init, spec.ComputeStartSlotAtEpoch(last+1)-1, first, last)
+}
+
+// runRepair rewrites the gaps found in the database
+func (s *ChainAnalyzer) runRepair() {
+	defer s.wgMainRoutine.Done()
+
+	gaps, err := s.FindGaps()
+	if err != nil {
+		log.Errorf("could not scan the database: %s", err)
+		return
+	}
+	for _, gap := range gaps {
+		log.Warnf("gap found in %s", gap)
+	}
+	log.Infof("found %d gaps", len(gaps))
+	if len(gaps) == 0 || s.dryRun {
+		return
+	}
+
+	ranges := s.prepareRepair(gaps)
+	log.Infof("repairing %d ranges", len(ranges))
+	s.runRanges(ranges)
+}
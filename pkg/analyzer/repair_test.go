@@ -0,0 +1,157 @@
+package analyzer
+
+import (
+	"testing"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/migalabs/goteth/pkg/db"
+	"github.com/migalabs/goteth/pkg/spec"
+	"github.com/migalabs/goteth/pkg/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRepairGaps(t *testing.T) {
+	chain := newTestChain(t)
+	for slot := phase0.Slot(0); slot < spec.ComputeStartSlotAtEpoch(8); slot++ {
+		chain.addBlocks(testGraffiti, slot)
+	}
+	analyzer, sink := newTestAnalyzer(t, chain, spec.ComputeStartSlotAtEpoch(8)-1)
+	analyzer.addTestStates(t, chain, 1, 2, 3, 4, 5, 6, 7)
+	analyzer.initSlot = spec.ComputeStartSlotAtEpoch(1)
+
+	// a slot of epoch 2 and the transition of epoch 4 are missing, a slot of epoch 5 is written twice
+	for slot := spec.ComputeStartSlotAtEpoch(1); slot < spec.ComputeStartSlotAtEpoch(8); slot++ {
+		if slot != spec.ComputeStartSlotAtEpoch(2)+5 {
+			analyzer.ProcessBlock(slot)
+		}
+	}
+	analyzer.ProcessBlock(spec.ComputeStartSlotAtEpoch(5))
+	for _, epoch := range []phase0.Epoch{3, 5, 6, 7} {
+		analyzer.ProcessStateTransitionMetrics(epoch)
+	}
+
+	// without init slot the scan starts at the first epoch downloaded
+	analyzer.initSlot = 0
+	gaps, err := analyzer.FindGaps()
+	require.NoError(t, err)
+	assert.Equal(t, []Gap{
+		{Table: "t_block_metrics", Epoch: 2, Rows: spec.SlotsPerEpoch - 1, Expected: spec.SlotsPerEpoch},
+		{Table: "t_block_metrics", Epoch: 5, Rows: spec.SlotsPerEpoch + 1, Expected: spec.SlotsPerEpoch},
+		{Table: "t_proposer_duties", Epoch: 4, Rows: 0, Expected: spec.SlotsPerEpoch},
+		{Table: "t_epoch_metrics_summary", Epoch: 3, Rows: 0, Expected: 1},
+		{Table: "t_validator_rewards_summary", Epoch: 4, Rows: 0, Expected: uint64(testValidators)},
+	}, gaps)
+	assert.Equal(t, "t_block_metrics epoch 5: 33 rows, expected 32", gaps[1].String())
+	assert.Equal(t, "t_proposer_duties epoch 4: no rows", gaps[2].String())
+
+	// the epochs partially written are deleted before being rewritten
+	analyzer.progress = newBackfillProgress(sink)
+	ranges := analyzer.prepareRepair(gaps)
+	assert.Equal(t, []slotRange{{init: 0, end: spec.ComputeStartSlotAtEpoch(6) - 1}}, ranges)
+	assert.Len(t, sink.DeletesOn("t_block_metrics"), int(spec.SlotsPerEpoch)*2)
+	assert.Empty(t, sink.DeletesOn("t_proposer_duties"))
+
+	// only the gaps are processed while downloading the ranges
+	for _, pending := range ranges {
+		for slot := pending.init; slot <= pending.end; slot++ {
+			analyzer.ProcessBlock(slot)
+			if slot%phase0.Slot(spec.SlotsPerEpoch) == phase0.Slot(spec.SlotsPerEpoch)-1 {
+				analyzer.ProcessStateTransitionMetrics(spec.EpochAtSlot(slot))
+			}
+		}
+	}
+	assert.Equal(t, int(spec.SlotsPerEpoch)*7, sink.Count("t_block_metrics"))
+	assert.Equal(t, 5, sink.Count("t_epoch_metrics_summary"))
+
+	gaps, err = analyzer.FindGaps()
+	require.NoError(t, err)
+	assert.Empty(t, gaps)
+}
+
+func TestPrepareRepairRewritesTransitions(t *testing.T) {
+	chain := newTestChain(t)
+	chain.addBlocks(testGraffiti, 0)
+	analyzer, sink := newTestAnalyzer(t, chain, 0)
+	analyzer.progress = newBackfillProgress(sink)
+
+	// the state metrics of epoch 5 are deleted, which reaches the rows of the next two transitions
+	ranges := analyzer.prepareRepair([]Gap{
+		{Table: "t_proposer_duties", Epoch: 5, Rows: spec.SlotsPerEpoch * 2, Expected: spec.SlotsPerEpoch},
+	})
+	assert.Equal(t, []slotRange{{init: spec.ComputeStartSlotAtEpoch(3), end: spec.ComputeStartSlotAtEpoch(8) - 1}}, ranges)
+	assert.Equal(t, []uint64{5}, deleteArgs(sink.DeletesOn("t_proposer_duties")))
+	assert.Equal(t, spec.ComputeStartSlotAtEpoch(3), analyzer.initSlot)
+
+	// the blocks and the previous transitions downloaded are skipped
+	assert.True(t, analyzer.progress.allDone(analyzer.blockProgressMetrics(), 5))
+	assert.True(t, analyzer.progress.allDone(analyzer.stateProgressMetrics(), 4))
+	for _, epoch := range []phase0.Epoch{5, 6, 7} {
+		assert.False(t, analyzer.progress.isDone(db.EpochProgress, epoch))
+	}
+	assert.Empty(t, sink.Rows("t_backfill_progress"), "skipped epochs are not recorded")
+}
+
+func TestFindGapsRange(t *testing.T) {
+	chain := newTestChain(t)
+	for slot := phase0.Slot(0); slot < spec.ComputeStartSlotAtEpoch(7); slot++ {
+		chain.addBlocks(testGraffiti, slot)
+	}
+	analyzer, _ := newTestAnalyzer(t, chain, spec.ComputeStartSlotAtEpoch(7)-1)
+	analyzer.metrics = db.DBMetrics{Block: true}
+
+	// an empty database has no gaps
+	gaps, err := analyzer.FindGaps()
+	require.NoError(t, err)
+	assert.Empty(t, gaps)
+
+	// epochs outside the init and final slots are not scanned
+	analyzer.ProcessBlock(spec.ComputeStartSlotAtEpoch(1))
+	analyzer.ProcessBlock(spec.ComputeStartSlotAtEpoch(6))
+	analyzer.initSlot = spec.ComputeStartSlotAtEpoch(2)
+	analyzer.finalSlot = spec.ComputeStartSlotAtEpoch(3)
+	gaps, err = analyzer.FindGaps()
+	require.NoError(t, err)
+	assert.Equal(t, []Gap{
+		{Table: "t_block_metrics", Epoch: 2, Expected: spec.SlotsPerEpoch},
+		{Table: "t_block_metrics", Epoch: 3, Expected: spec.SlotsPerEpoch},
+	}, gaps)
+}
+
+func TestFindGapsMissingRewards(t *testing.T) {
+	chain := newTestChain(t)
+	for slot := phase0.Slot(0); slot < spec.ComputeStartSlotAtEpoch(6); slot++ {
+		chain.addBlocks(testGraffiti, slot)
+	}
+	analyzer, _ := newTestAnalyzer(t, chain, spec.ComputeStartSlotAtEpoch(6)-1)
+	analyzer.addTestStates(t, chain, 1, 2, 3, 4)
+	for slot := spec.ComputeStartSlotAtEpoch(3); slot < spec.ComputeStartSlotAtEpoch(6); slot++ {
+		analyzer.ProcessBlock(slot)
+	}
+	analyzer.ProcessStateTransitionMetrics(3)
+
+	// the rewards of epoch 4 are written for a single validator
+	analyzer.trackedValidators = &utils.TrackedValidators{Indexes: map[phase0.ValidatorIndex]bool{3: true}}
+	analyzer.ProcessStateTransitionMetrics(4)
+	analyzer.trackedValidators = nil
+
+	// the rows are compared with the validators active in epoch 2
+	analyzer.initSlot = spec.ComputeStartSlotAtEpoch(3)
+	gaps, err := analyzer.FindGaps()
+	require.NoError(t, err)
+	rewardGaps := make([]Gap, 0)
+	for _, gap := range gaps {
+		if gap.Table == "t_validator_rewards_summary" {
+			rewardGaps = append(rewardGaps, gap)
+		}
+	}
+	assert.Equal(t, []Gap{{Table: "t_validator_rewards_summary", Epoch: 4, Rows: 1, Expected: uint64(testValidators)}}, rewardGaps)
+
+	// the tracked validators are not compared, only epochs without rows are gaps
+	analyzer.trackedValidators = &utils.TrackedValidators{Indexes: map[phase0.ValidatorIndex]bool{3: true}}
+	gaps, err = analyzer.FindGaps()
+	require.NoError(t, err)
+	for _, gap := range gaps {
+		assert.NotEqual(t, "t_validator_rewards_summary", gap.Table)
+	}
+}
@@ -31,13 +31,13 @@ downloadRoutine:
 			go s.ProcessBlock(downloadSlot)
 
 			// if epoch boundary, download state
-			if (downloadSlot % spec.SlotsPerEpoch) == (spec.SlotsPerEpoch - 1) { // last slot of epoch
+			if (downloadSlot % phase0.Slot(spec.SlotsPerEpoch)) == (phase0.Slot(spec.SlotsPerEpoch) - 1) { // last slot of epoch
 				// new epoch
 				go s.DownloadState(downloadSlot)
-				go s.ProcessStateTransitionMetrics(phase0.Epoch(downloadSlot / spec.SlotsPerEpoch))
+				go s.ProcessStateTransitionMetrics(spec.EpochAtSlot(downloadSlot))
 			}
 		case <-ticker.C: // every certain amount of time check if need to finish
-			if s.stop && len(s.downloadTaskChan) == 0 && s.cli.ActiveReqNum() == 0 && s.processerBook.ActivePages() == 0 {
+			if s.stop && len(s.downloadTaskChan) == 0 && s.cli.ActiveReqNum() == 0 && s.statesClient().ActiveReqNum() == 0 && s.processerBook.ActivePages() == 0 {
 				break downloadRoutine
 			}
 		}
@@ -48,19 +48,20 @@ downloadRoutine:
 func (s *ChainAnalyzer) runHead() {
 	defer s.wgMainRoutine.Done()
 	log.Info("launching head routine")
-	nextSlotDownload := s.fillToHead()
+	nextSlotDownload, lastRangeInit := s.fillToHead()
 
 	// Wait for blocks that may still be in-flight. During historical
 	// processing, CleanUpTo evicts blocks older than 5 epochs, so only
 	// blocks within the last 5 epochs can still be pending. Waiting from
 	// initSlot would deadlock on evicted blocks (#253), and skipping via
 	// Available() would miss in-flight downloads (#248).
+	// Slots before the last range downloaded were skipped as already processed.
 	waitFrom := nextSlotDownload
-	if nextSlotDownload > 5*spec.SlotsPerEpoch {
-		waitFrom = nextSlotDownload - 5*spec.SlotsPerEpoch
+	if nextSlotDownload > 5*phase0.Slot(spec.SlotsPerEpoch) {
+		waitFrom = nextSlotDownload - 5*phase0.Slot(spec.SlotsPerEpoch)
 	}
-	if waitFrom < s.initSlot {
-		waitFrom = s.initSlot
+	if waitFrom < lastRangeInit {
+		waitFrom = lastRangeInit
 	}
 	log.Infof("waiting for remaining historical blocks (%d to %d) to complete...", waitFrom, nextSlotDownload)
 	for slot := waitFrom; slot <= nextSlotDownload; slot++ {
@@ -94,7 +95,7 @@ func (s *ChainAnalyzer) runHead() {
 			// This allows DownloadState to fetch the state by root instead of by slot,
 			// avoiding a race condition in Lighthouse v8.1.0+ where the Head event is
 			// emitted before canonical_head is updated.
-			lastSlotOfEpoch := (event.HeadEvent.Slot/spec.SlotsPerEpoch+1)*spec.SlotsPerEpoch - 1
+			lastSlotOfEpoch := spec.ComputeStartSlotAtEpoch(spec.EpochAtSlot(event.HeadEvent.Slot)+1) - 1
 			if event.HeadEvent.Slot == lastSlotOfEpoch {
 				s.setEpochBoundaryStateRoot(lastSlotOfEpoch, event.HeadEvent.State)
 			}
@@ -109,9 +110,9 @@ func (s *ChainAnalyzer) runHead() {
 			}
 		case newFinalCheckpoint := <-s.eventsObj.FinalizedChan:
 			s.dbClient.PersistFinalized([]v1.FinalizedCheckpointEvent{newFinalCheckpoint})
-			finalizedSlot := phase0.Slot(newFinalCheckpoint.Epoch * spec.SlotsPerEpoch)
+			finalizedSlot := spec.ComputeStartSlotAtEpoch(newFinalCheckpoint.Epoch)
 
-			go s.AdvanceFinalized(finalizedSlot - (2 * spec.SlotsPerEpoch))
+			go s.AdvanceFinalized(finalizedSlot - (2 * phase0.Slot(spec.SlotsPerEpoch)))
 
 		case newReorg := <-s.eventsObj.ReorgChan:
 			s.dbClient.PersistReorgs([]v1.ChainReorgEvent{newReorg})
@@ -134,7 +135,8 @@ func (s *ChainAnalyzer) runHead() {
 	}
 }
 
-func (s *ChainAnalyzer) fillToHead() phase0.Slot {
+// fillToHead returns the head slot and the first slot of the last range downloaded
+func (s *ChainAnalyzer) fillToHead() (phase0.Slot, phase0.Slot) {
 	// ------ fill from last epoch in database to current head -------
 
 	// obtain current finalized
@@ -160,23 +162,71 @@ func (s *ChainAnalyzer) fillToHead() phase0.Slot {
 	// if we did not get a last slot from the database, or we were too close to the head
 	// then start from two epochs before current finalized in the chain
 	if nextSlotDownload == 0 || nextSlotDownload > finalizedBlock.Slot {
-		log.Infof("continue from finalized slot %d, epoch %d", finalizedBlock.Slot, finalizedBlock.Slot/spec.SlotsPerEpoch)
-		nextSlotDownload = finalizedBlock.Slot - (epochsToFinalizedTentative * spec.SlotsPerEpoch) // 2 epochs before
+		log.Infof("continue from finalized slot %d, epoch %d", finalizedBlock.Slot, spec.EpochAtSlot(finalizedBlock.Slot))
+		nextSlotDownload = finalizedBlock.Slot - (epochsToFinalizedTentative * phase0.Slot(spec.SlotsPerEpoch)) // 2 epochs before
 
 	} else {
 		// database detected
-		log.Infof("database detected, continue from slot %d, epoch %d", nextSlotDownload, nextSlotDownload/spec.SlotsPerEpoch)
-		nextSlotDownload = nextSlotDownload - (epochsToFinalizedTentative * spec.SlotsPerEpoch) // 2 epochs before
+		log.Infof("database detected, continue from slot %d, epoch %d", nextSlotDownload, spec.EpochAtSlot(nextSlotDownload))
+		nextSlotDownload = nextSlotDownload - (epochsToFinalizedTentative * phase0.Slot(spec.SlotsPerEpoch)) // 2 epochs before
 	}
-	nextSlotDownload = nextSlotDownload / spec.SlotsPerEpoch * spec.SlotsPerEpoch
-	s.initSlot = nextSlotDownload / spec.SlotsPerEpoch * spec.SlotsPerEpoch
+	nextSlotDownload = spec.ComputeStartSlotAtEpoch(spec.EpochAtSlot(nextSlotDownload))
+	s.initSlot = nextSlotDownload
 	s.startEpochAggregation = phase0.Epoch(spec.EpochAtSlot(s.initSlot) + 2)
 	s.endEpochAggregation = s.startEpochAggregation + phase0.Epoch(s.rewardsAggregationEpochs-1)
 
 	log.Infof("filling to head...")
-	s.wgMainRoutine.Add(1) // add because historical will defer it
-	s.runHistorical(nextSlotDownload, headSlot)
-	return headSlot
+	s.wgMainRoutine.Add(1) // add because backfill will defer it
+	lastRangeInit := s.runBackfill(nextSlotDownload, headSlot, spec.EpochAtSlot(headSlot))
+	return headSlot, lastRangeInit
+}
+
+// runBackfill downloads the slots between init and end, skipping the epochs up to
+// lastEpoch already processed for every metric in a previous run.
+// It returns the first slot of the last range downloaded
+func (s *ChainAnalyzer) runBackfill(init phase0.Slot, end phase0.Slot, lastEpoch phase0.Epoch) phase0.Slot {
+	defer s.wgMainRoutine.Done()
+
+	// the first epoch transition processed needs the two previous states
+	firstEpoch := spec.EpochAtSlot(init) + 2
+	err := s.progress.load(firstEpoch, lastEpoch)
+	if err != nil {
+		log.Errorf("could not retrieve backfill progress, processing every epoch: %s", err)
+	}
+
+	metrics := append(s.blockProgressMetrics(), s.stateProgressMetrics()...)
+	ranges := s.progress.pendingRanges(metrics, init, end, firstEpoch, lastEpoch)
+	if len(ranges) != 1 || ranges[0].init != init || ranges[0].end != end {
+		log.Infof("resuming backfill: %d ranges pending between slots %d and %d", len(ranges), init, end)
+	}
+
+	lastRangeInit, ok := s.runRanges(ranges)
+	if !ok {
+		lastRangeInit = end + 1
+	}
+	return lastRangeInit
+}
+
+// runRanges downloads the ranges one after the other, it returns the first slot of
+// the last range downloaded, if any
+func (s *ChainAnalyzer) runRanges(ranges []slotRange) (phase0.Slot, bool) {
+	lastRangeInit := phase0.Slot(0)
+	downloaded := false
+	for _, pending := range ranges {
+		if s.stop {
+			break
+		}
+		if s.backfillChunks > 1 {
+			s.runChunks(pending)
+		} else {
+			s.rangeInitSlot = pending.init
+			s.wgMainRoutine.Add(1) // add because historical will defer it
+			s.runHistorical(pending.init, pending.end)
+		}
+		lastRangeInit = pending.init
+		downloaded = true
+	}
+	return lastRangeInit, downloaded
 }
 
 func (s *ChainAnalyzer) runHistorical(init phase0.Slot, end phase0.Slot) {
@@ -196,7 +246,7 @@ func (s *ChainAnalyzer) runHistorical(init phase0.Slot, end phase0.Slot) {
 			<-limitTicker.C // if rate limit, wait for ticker
 			continue
 		}
-		if i%spec.SlotsPerEpoch == 0 { // every time a new epoch is crossed
+		if i%phase0.Slot(spec.SlotsPerEpoch) == 0 { // every time a new epoch is crossed
 			finalizedSlot, err := s.cli.RequestFinalizedBeaconBlock()
 
 			if err != nil {
@@ -205,11 +255,11 @@ func (s *ChainAnalyzer) runHistorical(init phase0.Slot, end phase0.Slot) {
 
 			if i >= finalizedSlot.Slot {
 				// keep 2 epochs before finalized, needed to calculate epoch metrics
-				s.AdvanceFinalized(finalizedSlot.Slot - spec.SlotsPerEpoch*5) // includes check and clean
-			} else if i > (5 * spec.SlotsPerEpoch) {
+				s.AdvanceFinalized(finalizedSlot.Slot - phase0.Slot(spec.SlotsPerEpoch)*5) // includes check and clean
+			} else if i > (5 * phase0.Slot(spec.SlotsPerEpoch)) {
 				// keep 5 epochs before current downloading slot, need 3 at least for epoch metrics
 				// magic number, 2 extra if processer takes long
-				cleanUpToSlot := i - (5 * spec.SlotsPerEpoch)
+				cleanUpToSlot := i - (5 * phase0.Slot(spec.SlotsPerEpoch))
 				s.downloadCache.CleanUpTo(cleanUpToSlot) // only clean, no check, keep
 			}
 		}
@@ -3,10 +3,15 @@ package clientapi
 import (
 	"context"
 	"fmt"
+	nethttp "net/http"
+	"path/filepath"
 	"time"
 
+	"github.com/attestantio/go-eth2-client/api"
 	"github.com/attestantio/go-eth2-client/http"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
 	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
 	"github.com/migalabs/goteth/pkg/db"
 	prom_metrics "github.com/migalabs/goteth/pkg/metrics"
 	"github.com/migalabs/goteth/pkg/utils"
@@ -25,53 +30,222 @@ var (
 type APIClientOption func(*APIClient) error
 
 type APIClient struct {
-	ctx            context.Context
-	Api            *http.Service     // Beacon Node
-	ELApi          *ethclient.Client // Execution Node
-	Metrics        db.DBMetrics
-	maxRetries     int
-	statesBook     *utils.RoutineBook // Book to track what is being downloaded through the CL API: states
-	blocksBook     *utils.RoutineBook // Book to track what is being downloaded through the CL API: blocks
-	txBook         *utils.RoutineBook // Book to track what is being downloaded through the EL API: transactions
-	receiptMetrics *receiptMetrics
+	ctx             context.Context
+	Api             *http.Service     // First beacon node, the head events are subscribed on it
+	bnNodes         *beaconNodes      // Beacon nodes the requests are balanced across
+	ELApi           *ethclient.Client // First execution node
+	elNodes         []*executionNode  // Execution nodes the requests fail over across
+	Metrics         db.DBMetrics
+	maxRetries      int
+	statesBook      *utils.RoutineBook // Book to track what is being downloaded through the CL API: states
+	blocksBook      *utils.RoutineBook // Book to track what is being downloaded through the CL API: blocks
+	txBook          *utils.RoutineBook // Book to track what is being downloaded through the EL API: transactions
+	receiptMetrics  *receiptMetrics
+	downloadMetrics *downloadMetrics
+	httpCli         *nethttp.Client // Beacon Node requests that are not covered by go-eth2-client
+	elEndpoint      string
+	recordDir       string // Directory where the responses are recorded, empty if disabled
+	replayDir       string // Directory the responses are replayed from (--bn-endpoint file://<dir>)
+	maxHeadDistance phase0.Slot
+	caps            Capabilities // supported by every beacon node
+	capOverrides    string       // capabilities set by the user, see WithCapabilities
+	diskCache       *DiskCache   // States and blocks kept on disk, nil if disabled
 }
 
 func NewAPIClient(ctx context.Context, bnEndpoint string, maxRequestRetries int, options ...APIClientOption) (*APIClient, error) {
 	log.Debugf("generating http client at %s", bnEndpoint)
 
 	apiService := &APIClient{
-		ctx:            ctx,
-		statesBook:     utils.NewRoutineBook(1, "api-cli-states"),
-		blocksBook:     utils.NewRoutineBook(1, "api-cli-blocks"),
-		txBook:         utils.NewRoutineBook(maxParallelConns, "api-cli-tx"),
-		receiptMetrics: newReceiptMetrics(),
+		ctx:             ctx,
+		maxRetries:      maxRequestRetries,
+		maxHeadDistance: DefaultMaxHeadDistance,
+		statesBook:      utils.NewRoutineBook(1, "api-cli-states"),
+		blocksBook:      utils.NewRoutineBook(1, "api-cli-blocks"),
+		txBook:          utils.NewRoutineBook(maxParallelConns, "api-cli-tx"),
+		receiptMetrics:  newReceiptMetrics(),
+		downloadMetrics: newDownloadMetrics(),
+		httpCli:         nethttp.DefaultClient,
+	}
+	for _, o := range options {
+		err := o(apiService)
+		if err != nil {
+			log.Warn(err.Error()) // these are optional, show error and continue
+		}
 	}
 
-	bnCli, err := http.New(
-		ctx,
-		http.WithAddress(bnEndpoint),
+	bnAddress := bnEndpoint
+	bnParams := []http.Parameter{
 		http.WithLogLevel(zerolog.WarnLevel),
 		http.WithTimeout(QueryTimeout),
-	)
-	if err != nil {
-		return &APIClient{}, err
 	}
+	if dir, ok := replayDir(bnEndpoint); ok {
+		log.Infof("replaying recorded responses from %s", dir)
+		transport, err := newReplayTransport(filepath.Join(dir, beaconRecordDir), false)
+		if err != nil {
+			return &APIClient{}, err
+		}
+		apiService.replayDir = dir
+		apiService.httpCli = &nethttp.Client{Transport: transport}
+		bnAddress = replayBeaconAddress
+		bnParams = append(bnParams, http.WithHTTPClient(apiService.httpCli))
+	} else if apiService.recordDir != "" {
+		log.Infof("recording beacon node responses into %s", apiService.recordDir)
+		transport, err := newRecordTransport(filepath.Join(apiService.recordDir, beaconRecordDir), false, newHTTPTransport())
+		if err != nil {
+			return &APIClient{}, err
+		}
+		apiService.httpCli = &nethttp.Client{Transport: transport}
+		bnParams = append(bnParams, http.WithHTTPClient(apiService.httpCli))
+	}
+
+	// several beacon nodes can be given, separated by commas
+	bnEndpoints := SplitEndpoints(bnAddress)
+	if len(bnEndpoints) == 0 {
+		return &APIClient{}, fmt.Errorf("no beacon node endpoint given")
+	}
+	if len(bnEndpoints) > 1 {
+		// the nodes down at start are retried by the health checks
+		bnParams = append(bnParams, http.WithAllowDelayedStart(true))
+	}
+	apiService.bnNodes = &beaconNodes{
+		nodes:           make([]*beaconNode, 0, len(bnEndpoints)),
+		maxHeadDistance: apiService.maxHeadDistance,
+	}
+	nodesCaps := make([]Capabilities, 0, len(bnEndpoints))
+	for _, endpoint := range bnEndpoints {
+		hc, caps, err := apiService.connectBeaconNode(endpoint, bnParams)
+		if err != nil {
+			return &APIClient{}, err
+		}
+		apiService.bnNodes.nodes = append(apiService.bnNodes.nodes, &beaconNode{
+			address: endpoint,
+			api:     hc,
+			caps:    caps,
+			healthy: true,
+		})
+		nodesCaps = append(nodesCaps, caps)
+	}
+	apiService.Api = apiService.bnNodes.nodes[0].api
+	apiService.caps = mergeCapabilities(nodesCaps)
+	if err := apiService.caps.applyOverrides(apiService.capOverrides); err != nil {
+		log.Warn(err.Error())
+	}
+	if len(bnEndpoints) > 1 {
+		log.Infof("balancing the requests across %d beacon nodes", len(bnEndpoints))
+		apiService.bnNodes.checkHealth(ctx)
+		go apiService.bnNodes.monitor(ctx)
+	}
+
+	if apiService.elEndpoint != "" {
+		err := apiService.connectELEndpoint()
+		if err != nil {
+			log.Warn(err.Error())
+		}
+	}
+
+	return apiService, nil
+}
 
+// connectBeaconNode opens the client of the beacon node, detects its implementation and
+// probes its capabilities. Nodes that do not serve SSZ are reopened requesting JSON
+func (s *APIClient) connectBeaconNode(endpoint string, bnParams []http.Parameter) (*http.Service, Capabilities, error) {
+	bnCli, err := http.New(s.ctx, append(bnParams, http.WithAddress(endpoint))...)
+	if err != nil {
+		return nil, Capabilities{}, fmt.Errorf("could not connect to beacon node %s: %w", endpoint, err)
+	}
 	hc, ok := bnCli.(*http.Service)
 	if !ok {
 		log.Error("gernerating the http api client")
 	}
 
-	apiService.Api = hc
-	apiService.maxRetries = maxRequestRetries
-	for _, o := range options {
-		err := o(apiService)
+	version := ""
+	resp, err := hc.NodeVersion(s.ctx, &api.NodeVersionOpts{})
+	if err != nil {
+		log.Warnf("could not detect the implementation of beacon node %s: %s", endpoint, err)
+	} else {
+		version = resp.Data
+	}
+	caps := DetectCapabilities(version)
+	if s.replayDir == "" {
+		// the recordings only hold the requests of the analysis
+		s.probeCapabilities(hc, &caps)
+	}
+	if err := caps.applyOverrides(s.capOverrides); err != nil {
+		log.Warn(err.Error())
+	}
+	log.Infof("beacon node %s: %s %s", endpoint, caps.Version, caps)
+
+	if !caps.SSZ {
+		bnCli, err = http.New(s.ctx, append(bnParams, http.WithAddress(endpoint), http.WithEnforceJSON(true))...)
 		if err != nil {
-			log.Warn(err.Error()) // these are optional, show error and continue
+			return nil, Capabilities{}, fmt.Errorf("could not connect to beacon node %s: %w", endpoint, err)
 		}
+		hc = bnCli.(*http.Service)
 	}
+	return hc, caps, nil
+}
 
-	return apiService, nil
+// newHTTPTransport returns the same transport go-eth2-client uses by default
+func newHTTPTransport() *nethttp.Transport {
+	transport := nethttp.DefaultTransport.(*nethttp.Transport).Clone()
+	transport.MaxIdleConns = 64
+	transport.MaxConnsPerHost = 64
+	transport.MaxIdleConnsPerHost = 64
+	transport.IdleConnTimeout = 600 * time.Second
+	return transport
+}
+
+// connectELEndpoint opens a connection to each execution node of the comma separated
+// endpoints. The nodes that cannot be dialed are skipped
+func (s *APIClient) connectELEndpoint() error {
+	if s.replayDir != "" {
+		transport, err := newReplayTransport(filepath.Join(s.replayDir, executionRecordDir), true)
+		if err != nil {
+			return err
+		}
+		rpcCli, err := rpc.DialOptions(s.ctx, replayExecutionAddress, rpc.WithHTTPClient(&nethttp.Client{Transport: transport}))
+		if err != nil {
+			return err
+		}
+		s.elNodes = []*executionNode{newExecutionNode(replayExecutionAddress, rpcCli)}
+		s.ELApi = s.elNodes[0].api
+		return nil
+	}
+
+	var recordCli *nethttp.Client // shared by the nodes, the recordings do not depend on the host
+	for _, endpoint := range SplitEndpoints(s.elEndpoint) {
+		var (
+			rpcCli *rpc.Client
+			err    error
+		)
+		switch {
+		case s.recordDir != "" && isHTTPEndpoint(endpoint):
+			if recordCli == nil {
+				log.Infof("recording execution node responses into %s", s.recordDir)
+				transport, err := newRecordTransport(filepath.Join(s.recordDir, executionRecordDir), true, newHTTPTransport())
+				if err != nil {
+					return err
+				}
+				recordCli = &nethttp.Client{Transport: transport}
+			}
+			rpcCli, err = rpc.DialOptions(s.ctx, endpoint, rpc.WithHTTPClient(recordCli))
+		default:
+			if s.recordDir != "" {
+				log.Warnf("only http execution endpoints can be recorded, %s responses will not be saved", endpoint)
+			}
+			rpcCli, err = rpc.DialContext(s.ctx, endpoint)
+		}
+		if err != nil {
+			log.Warnf("could not connect to execution node %s: %s", endpoint, err)
+			continue
+		}
+		s.elNodes = append(s.elNodes, newExecutionNode(endpoint, rpcCli))
+	}
+	if len(s.elNodes) == 0 {
+		return fmt.Errorf("could not connect to any execution node of %s", s.elEndpoint)
+	}
+	s.ELApi = s.elNodes[0].api
+	return nil
 }
 
 func WithELEndpoint(url string) APIClientOption {
@@ -79,11 +253,61 @@ func WithELEndpoint(url string) APIClientOption {
 		if url == "" {
 			return fmt.Errorf("empty execution address, skipping. Beware transactions data might not be complete")
 		}
-		client, err := ethclient.DialContext(s.ctx, url)
-		if err != nil {
+		s.elEndpoint = url // the connection is opened once all the options are applied
+		return nil
+	}
+}
+
+// WithRecordDir saves every response received from the beacon and execution
+// nodes into dir, so the run can be replayed with --bn-endpoint file://<dir>
+func WithRecordDir(dir string) APIClientOption {
+	return func(s *APIClient) error {
+		s.recordDir = dir
+		return nil
+	}
+}
+
+// WithMaxParallelStates sets the number of states downloaded at the same time,
+// one by default. Must be given before WithPromMetrics
+func WithMaxParallelStates(n int) APIClientOption {
+	return func(s *APIClient) error {
+		if n < 1 {
+			return fmt.Errorf("invalid number of parallel states %d, downloading one at a time", n)
+		}
+		s.statesBook = utils.NewRoutineBook(n, "api-cli-states")
+		return nil
+	}
+}
+
+// WithMaxHeadDistance sets the slots a beacon node head can be behind the highest
+// head of the nodes before the requests stop being sent to it
+func WithMaxHeadDistance(slots int) APIClientOption {
+	return func(s *APIClient) error {
+		if slots < 0 {
+			return fmt.Errorf("invalid max head distance %d, using %d slots", slots, DefaultMaxHeadDistance)
+		}
+		s.maxHeadDistance = phase0.Slot(slots)
+		return nil
+	}
+}
+
+// WithCapabilities overrides the capabilities detected from the beacon node versions,
+// given as a comma separated list of name=bool (i.e. "state-by-root=false,ssz=false")
+func WithCapabilities(overrides string) APIClientOption {
+	return func(s *APIClient) error {
+		if err := (&Capabilities{}).applyOverrides(overrides); err != nil {
 			return err
 		}
-		s.ELApi = client
+		s.capOverrides = overrides
+		return nil
+	}
+}
+
+// WithDiskCache reads the states and blocks from the disk cache before requesting
+// them, and stores the ones downloaded. A nil cache disables it
+func WithDiskCache(cache *DiskCache) APIClientOption {
+	return func(s *APIClient) error {
+		s.diskCache = cache
 		return nil
 	}
 }
@@ -104,11 +328,19 @@ func WithPromMetrics(metrics *prom_metrics.PrometheusMetrics) APIClientOption {
 		if receiptModule := s.receiptMetrics.getPrometheusMetrics(); receiptModule != nil {
 			metrics.AddMeticsModule(receiptModule)
 		}
+		if downloadModule := s.downloadMetrics.getPrometheusMetrics(); downloadModule != nil {
+			metrics.AddMeticsModule(downloadModule)
+		}
 
 		return nil
 	}
 }
 
+// Capabilities returns the capabilities supported by every beacon node
+func (s *APIClient) Capabilities() Capabilities {
+	return s.caps
+}
+
 func (s APIClient) ActiveReqNum() int {
 
 	return s.blocksBook.ActivePages() + s.statesBook.ActivePages() + s.txBook.ActivePages()
@@ -4,6 +4,7 @@ import (
 	"fmt"
 
 	"github.com/attestantio/go-eth2-client/api"
+	"github.com/attestantio/go-eth2-client/http"
 	"github.com/attestantio/go-eth2-client/spec/deneb"
 	"github.com/attestantio/go-eth2-client/spec/phase0"
 	local_spec "github.com/migalabs/goteth/pkg/spec"
@@ -14,7 +15,7 @@ func (s *APIClient) RequestBlobSidecars(slot phase0.Slot) ([]*local_spec.Agnosti
 
 	agnosticBlobs := make([]*local_spec.AgnosticBlobSidecar, 0)
 
-	blobsResp, err := s.Api.BlobSidecars(s.ctx, &api.BlobSidecarsOpts{
+	blobsResp, err := requestBeacon(s, (*http.Service).BlobSidecars, &api.BlobSidecarsOpts{
 		Block: fmt.Sprintf("%d", slot),
 	})
 
@@ -42,7 +43,7 @@ func (s *APIClient) RequestBlobSidecars(slot phase0.Slot) ([]*local_spec.Agnosti
 // requestKZGCommitmentFromSignedBlock fetches a block from /eth/v2/beacon/blocks/
 // to match the KZG Commitments to the blobs given by the new blobs endpoint.
 func (s *APIClient) requestKZGCommitmentFromSignedBlock(slot phase0.Slot) ([]deneb.KZGCommitment, error) {
-	resp, err := s.Api.SignedBeaconBlock(s.ctx, &api.SignedBeaconBlockOpts{
+	resp, err := requestBeacon(s, (*http.Service).SignedBeaconBlock, &api.SignedBeaconBlockOpts{
 		Block: fmt.Sprintf("%d", slot),
 	})
 
@@ -56,11 +57,15 @@ func (s *APIClient) requestKZGCommitmentFromSignedBlock(slot phase0.Slot) ([]den
 	return resp.Data.BlobKZGCommitments()
 }
 
-// RequestFuluBlobs uses the new endpoint /eth/v1/beacon/blobs/{block_id}
+// RequestFuluBlobs uses the new endpoint /eth/v1/beacon/blobs/{block_id}, or the blob
+// sidecars if the beacon nodes do not serve it
 func (s *APIClient) RequestFuluBlobs(slot phase0.Slot) ([]*local_spec.AgnosticBlobSidecar, error) {
+	if !s.caps.BlobsEndpoint {
+		return s.RequestBlobSidecars(slot)
+	}
 	blobs := make([]*local_spec.AgnosticBlobSidecar, 0)
 
-	resp, err := s.Api.Blobs(s.ctx, &api.BlobsOpts{
+	resp, err := requestBeacon(s, (*http.Service).Blobs, &api.BlobsOpts{
 		Block: fmt.Sprintf("%d", slot),
 	})
 
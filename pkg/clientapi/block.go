@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/attestantio/go-eth2-client/api"
+	"github.com/attestantio/go-eth2-client/http"
 	"github.com/attestantio/go-eth2-client/spec"
 	"github.com/attestantio/go-eth2-client/spec/altair"
 	"github.com/attestantio/go-eth2-client/spec/bellatrix"
@@ -14,6 +15,7 @@ import (
 	"github.com/attestantio/go-eth2-client/spec/phase0"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
 	local_spec "github.com/migalabs/goteth/pkg/spec"
 	"github.com/migalabs/goteth/pkg/utils"
 	bitfield "github.com/prysmaticlabs/go-bitfield"
@@ -31,19 +33,32 @@ func (s *APIClient) RequestBeaconBlock(slot phase0.Slot) (*local_spec.AgnosticBl
 	log.Debugf("downloading block at slot %d", slot)
 
 	startTime := time.Now()
+
+	// the block is looked up in the disk cache by its root, only requested if a block
+	// at the slot is cached. Zero if unknown
+	var cacheRoot phase0.Root
+	if s.diskCache.holds(blockObject, slot) {
+		if root, err := requestBeacon(s, (*http.Service).BeaconBlockRoot, &api.BeaconBlockRootOpts{
+			Block: fmt.Sprintf("%d", slot),
+		}); err == nil && root.Data != nil {
+			cacheRoot = *root.Data
+		}
+	}
+
 	err := errors.New("first attempt")
-	var newBlock *api.Response[*spec.VersionedSignedBeaconBlock]
+	var (
+		newBlock   *spec.VersionedSignedBeaconBlock
+		cacheEntry []byte
+	)
 
 	attempts := 0
 	for err != nil && attempts < s.maxRetries {
 
-		newBlock, err = s.Api.SignedBeaconBlock(s.ctx, &api.SignedBeaconBlockOpts{
-			Block: fmt.Sprintf("%d", slot),
-		})
+		newBlock, cacheEntry, err = s.requestSignedBeaconBlock(slot, fmt.Sprintf("%d", slot), cacheRoot)
 		if err != nil {
 			if response404(err.Error()) {
-				if attempts < s.maxRetries-1 {
-					// Retry on 404: with Lighthouse v8.1.0+ the Head SSE event can
+				if s.caps.HeadBeforeCanonical && attempts < s.maxRetries-1 {
+					// Retry on 404: with Lighthouse the Head SSE event can
 					// fire before the block is queryable via the API.
 					log.Debugf("block at slot %d not found, retrying (attempt %d)", slot, attempts+1)
 					time.Sleep(500 * time.Millisecond)
@@ -67,12 +82,13 @@ func (s *APIClient) RequestBeaconBlock(slot phase0.Slot) (*local_spec.AgnosticBl
 		// close the channel (to tell other routines to stop processing and end)
 		return &local_spec.AgnosticBlock{}, fmt.Errorf("unable to retrieve Beacon Block at slot %d: %s", slot, err.Error())
 	}
-	customBlock, err := local_spec.GetCustomBlock(*newBlock.Data)
+	customBlock, err := local_spec.GetCustomBlock(*newBlock)
 
 	if err != nil {
 		// close the channel (to tell other routines to stop processing and end)
 		return &local_spec.AgnosticBlock{}, fmt.Errorf("unable to parse Beacon Block at slot %d: %s", slot, err.Error())
 	}
+	s.diskCache.put(blockObject, slot, customBlock.Root, cacheEntry) // by the root of the block downloaded
 
 	// fill in block size on custom block using RequestBlockByHash
 	// shows error inside function if ELApi is not defined
@@ -107,18 +123,18 @@ func (s *APIClient) RequestBeaconBlock(slot phase0.Slot) (*local_spec.AgnosticBl
 
 func (s *APIClient) RequestFinalizedBeaconBlock() (*local_spec.AgnosticBlock, error) {
 
-	finalityCheckpoint, _ := s.Api.Finality(s.ctx, &api.FinalityOpts{
+	finalityCheckpoint, _ := requestBeacon(s, (*http.Service).Finality, &api.FinalityOpts{
 		State: "head",
 	})
 
-	finalizedSlot := finalityCheckpoint.Data.Finalized.Epoch * local_spec.SlotsPerEpoch
+	finalizedSlot := local_spec.ComputeStartSlotAtEpoch(finalityCheckpoint.Data.Finalized.Epoch)
 
-	return s.RequestBeaconBlock(phase0.Slot(finalizedSlot))
+	return s.RequestBeaconBlock(finalizedSlot)
 }
 
 func (s *APIClient) RequestBlockRoot(slot phase0.Slot) phase0.Root {
 
-	root, err := s.Api.BeaconBlockRoot(s.ctx, &api.BeaconBlockRootOpts{
+	root, err := requestBeacon(s, (*http.Service).BeaconBlockRoot, &api.BeaconBlockRootOpts{
 		Block: fmt.Sprintf("%d", slot),
 	})
 	if err != nil {
@@ -137,9 +153,9 @@ func (s *APIClient) RequestBlockRoot(slot phase0.Slot) phase0.Root {
 }
 
 func (s *APIClient) CreateMissingBlock(slot phase0.Slot) *local_spec.AgnosticBlock {
-	duties, err := s.Api.ProposerDuties(s.ctx, &api.ProposerDutiesOpts{
+	duties, err := requestBeacon(s, (*http.Service).ProposerDuties, &api.ProposerDutiesOpts{
 		Indices: []phase0.ValidatorIndex{},
-		Epoch:   phase0.Epoch(slot / 32),
+		Epoch:   local_spec.EpochAtSlot(slot),
 	})
 	proposerValIdx := phase0.ValidatorIndex(0)
 	if err != nil {
@@ -193,7 +209,7 @@ func (s *APIClient) CreateMissingBlock(slot phase0.Slot) *local_spec.AgnosticBlo
 // RequestBlockByHash retrieves block from the execution client for the given hash
 func (s *APIClient) RequestExecutionBlockByHash(hash common.Hash) (*types.Block, error) {
 
-	if s.ELApi == nil {
+	if len(s.elNodes) == 0 {
 		return nil, nil
 	}
 	emptyHash := common.Hash{}
@@ -206,7 +222,11 @@ func (s *APIClient) RequestExecutionBlockByHash(hash common.Hash) (*types.Block,
 	s.txBook.Acquire(routineKey)
 	defer s.txBook.FreePage(routineKey)
 
-	block, err := s.ELApi.BlockByHash(s.ctx, hash)
+	var block *types.Block
+	err := s.requestExecution("eth_getBlockByHash", func(el *ethclient.Client) (err error) {
+		block, err = el.BlockByHash(s.ctx, hash)
+		return err
+	})
 	if err != nil {
 		return nil, fmt.Errorf("unable to retrieve block by hash %s: %s", hash.String(), err.Error())
 	}
@@ -215,7 +235,7 @@ func (s *APIClient) RequestExecutionBlockByHash(hash common.Hash) (*types.Block,
 
 func (s *APIClient) RequestCurrentHead() phase0.Slot {
 
-	head, err := s.Api.BeaconBlockHeader(s.ctx, &api.BeaconBlockHeaderOpts{
+	head, err := requestBeacon(s, (*http.Service).BeaconBlockHeader, &api.BeaconBlockHeaderOpts{
 		Block: "head",
 	})
 	if err != nil {
@@ -0,0 +1,192 @@
+package clientapi
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	nethttp "net/http"
+	"strconv"
+	"strings"
+
+	"github.com/attestantio/go-eth2-client/api"
+	"github.com/attestantio/go-eth2-client/http"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+)
+
+// BeaconClient is the implementation of a beacon node, as reported by /eth/v1/node/version
+type BeaconClient string
+
+const (
+	Lighthouse    BeaconClient = "lighthouse"
+	Prysm         BeaconClient = "prysm"
+	Teku          BeaconClient = "teku"
+	Nimbus        BeaconClient = "nimbus"
+	Lodestar      BeaconClient = "lodestar"
+	Grandine      BeaconClient = "grandine"
+	UnknownClient BeaconClient = "unknown"
+)
+
+// Capabilities are the behaviours of the beacon API that differ across implementations
+type Capabilities struct {
+	Client  BeaconClient
+	Version string // as reported by /eth/v1/node/version
+
+	// StateByRoot is true if the states of any slot kept by the node can be requested
+	// by state root, not only the recent ones
+	StateByRoot bool
+	// BlobsEndpoint is true if /eth/v1/beacon/blobs/{block_id} is served, the blobs are
+	// read from /eth/v1/beacon/blob_sidecars/{block_id} otherwise
+	BlobsEndpoint bool
+	// SSZ is true if blocks and states are requested as SSZ, JSON is enforced otherwise
+	SSZ bool
+	// HeadBeforeCanonical is true if the head event can be emitted before its block and
+	// state are served by slot, the missing blocks are retried then
+	HeadBeforeCanonical bool
+}
+
+// clientCapabilities are the capabilities of each implementation, UnknownClient is
+// used for the rest: the standard API, retrying the missing blocks
+var clientCapabilities = map[BeaconClient]Capabilities{
+	Lighthouse:    {StateByRoot: true, BlobsEndpoint: true, SSZ: true, HeadBeforeCanonical: true},
+	Prysm:         {StateByRoot: false, BlobsEndpoint: true, SSZ: true},
+	Teku:          {StateByRoot: true, BlobsEndpoint: true, SSZ: true},
+	Nimbus:        {StateByRoot: false, BlobsEndpoint: true, SSZ: true},
+	Lodestar:      {StateByRoot: true, BlobsEndpoint: true, SSZ: true},
+	Grandine:      {StateByRoot: true, BlobsEndpoint: true, SSZ: true},
+	UnknownClient: {StateByRoot: false, BlobsEndpoint: true, SSZ: true, HeadBeforeCanonical: true},
+}
+
+// stateByRootProbeDepth is how far behind the head is the state requested by root when
+// probing the node, older than the recent states every node serves by root
+var stateByRootProbeDepth = phase0.Slot(1024)
+
+// DetectCapabilities returns the capabilities of the beacon node with the given version,
+// i.e. "Lighthouse/v8.1.0-4fdc5e8/x86_64-linux"
+func DetectCapabilities(version string) Capabilities {
+	name, _, _ := strings.Cut(version, "/")
+	client := BeaconClient(strings.ToLower(strings.TrimSpace(name)))
+	if _, ok := clientCapabilities[client]; !ok {
+		client = UnknownClient
+	}
+	caps := clientCapabilities[client]
+	caps.Client = client
+	caps.Version = version
+	return caps
+}
+
+// probeCapabilities checks with a few requests the capabilities detected from the version
+// of the node. The ones the node does not answer clearly are kept as detected
+func (s *APIClient) probeCapabilities(hc *http.Service, caps *Capabilities) {
+	if status, contentType, err := s.probe(hc, "/eth/v2/beacon/blocks/head"); err == nil && status == nethttp.StatusOK {
+		caps.SSZ = contentType == sszContentType
+	}
+
+	if status, _, err := s.probe(hc, "/eth/v1/beacon/blobs/head"); err == nil {
+		switch status {
+		case nethttp.StatusOK:
+			caps.BlobsEndpoint = true
+		case nethttp.StatusNotFound, nethttp.StatusMethodNotAllowed, nethttp.StatusNotImplemented:
+			caps.BlobsEndpoint = false
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(s.ctx, QueryTimeout)
+	defer cancel()
+	syncing, err := hc.NodeSyncing(ctx, &api.NodeSyncingOpts{})
+	if err != nil || syncing.Data.HeadSlot < stateByRootProbeDepth {
+		return
+	}
+	root, err := hc.BeaconStateRoot(ctx, &api.BeaconStateRootOpts{
+		State: fmt.Sprintf("%d", syncing.Data.HeadSlot-stateByRootProbeDepth),
+	})
+	if err != nil {
+		return
+	}
+	if status, _, err := s.probe(hc, fmt.Sprintf("/eth/v1/beacon/states/%#x/finality_checkpoints", *root.Data)); err == nil {
+		switch status {
+		case nethttp.StatusOK:
+			caps.StateByRoot = true
+		case nethttp.StatusNotFound, nethttp.StatusBadRequest:
+			caps.StateByRoot = false
+		}
+	}
+}
+
+// probe requests the endpoint of the node, accepting SSZ, and returns the status and
+// the content type answered
+func (s *APIClient) probe(hc *http.Service, endpoint string) (int, string, error) {
+	ctx, cancel := context.WithTimeout(s.ctx, QueryTimeout)
+	defer cancel()
+	req, err := nethttp.NewRequestWithContext(ctx, nethttp.MethodGet, hc.Address()+endpoint, nil)
+	if err != nil {
+		return 0, "", err
+	}
+	req.Header.Set("Accept", sszAccept)
+	resp, err := s.httpCli.Do(req)
+	if err != nil {
+		log.Debugf("could not probe %s of %s: %s", endpoint, hc.Address(), err)
+		return 0, "", err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+	contentType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	log.Debugf("probed %s of %s: %d %s", endpoint, hc.Address(), resp.StatusCode, contentType)
+	return resp.StatusCode, contentType, nil
+}
+
+// mergeCapabilities returns the capabilities every node supports, the requests can be
+// sent to any of them
+func mergeCapabilities(caps []Capabilities) Capabilities {
+	if len(caps) == 0 {
+		return DetectCapabilities("")
+	}
+	merged := caps[0]
+	for _, other := range caps[1:] {
+		if other.Client != merged.Client {
+			merged.Client = UnknownClient
+			merged.Version = ""
+		}
+		merged.StateByRoot = merged.StateByRoot && other.StateByRoot
+		merged.BlobsEndpoint = merged.BlobsEndpoint && other.BlobsEndpoint
+		merged.SSZ = merged.SSZ && other.SSZ
+		merged.HeadBeforeCanonical = merged.HeadBeforeCanonical || other.HeadBeforeCanonical
+	}
+	return merged
+}
+
+// applyOverrides sets the capabilities given as a comma separated list of name=bool,
+// i.e. "state-by-root=false,ssz=false"
+func (c *Capabilities) applyOverrides(overrides string) error {
+	for _, item := range strings.Split(overrides, ",") {
+		if item = strings.TrimSpace(item); item == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(item, "=")
+		if !ok {
+			return fmt.Errorf("invalid capability %s, expected name=true|false", item)
+		}
+		enabled, err := strconv.ParseBool(strings.TrimSpace(value))
+		if err != nil {
+			return fmt.Errorf("invalid value of capability %s: %w", name, err)
+		}
+		switch strings.TrimSpace(name) {
+		case "state-by-root":
+			c.StateByRoot = enabled
+		case "blobs-endpoint":
+			c.BlobsEndpoint = enabled
+		case "ssz":
+			c.SSZ = enabled
+		case "head-before-canonical":
+			c.HeadBeforeCanonical = enabled
+		default:
+			return fmt.Errorf("unknown capability %s", name)
+		}
+	}
+	return nil
+}
+
+func (c Capabilities) String() string {
+	return fmt.Sprintf("%s (state-by-root=%t, blobs-endpoint=%t, ssz=%t, head-before-canonical=%t)",
+		c.Client, c.StateByRoot, c.BlobsEndpoint, c.SSZ, c.HeadBeforeCanonical)
+}
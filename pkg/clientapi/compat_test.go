@@ -0,0 +1,182 @@
+package clientapi
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetectCapabilities(t *testing.T) {
+	tests := []struct {
+		version string
+		want    Capabilities
+	}{
+		{"Lighthouse/v8.1.0-4fdc5e8/x86_64-linux",
+			Capabilities{Client: Lighthouse, StateByRoot: true, BlobsEndpoint: true, SSZ: true, HeadBeforeCanonical: true}},
+		{"Lighthouse/v7.0.1-e42406d/x86_64-linux",
+			Capabilities{Client: Lighthouse, StateByRoot: true, BlobsEndpoint: true, SSZ: true, HeadBeforeCanonical: true}},
+		{"Prysm/v6.0.4 (linux amd64)",
+			Capabilities{Client: Prysm, BlobsEndpoint: true, SSZ: true}},
+		{"teku/v25.4.1/linux-x86_64/-eclipseadoptium-openjdk64bitservervm-java-21",
+			Capabilities{Client: Teku, StateByRoot: true, BlobsEndpoint: true, SSZ: true}},
+		{"Nimbus/v25.4.1-77cfa7-stateofus",
+			Capabilities{Client: Nimbus, BlobsEndpoint: true, SSZ: true}},
+		{"Lodestar/v1.29.0/1a2b3c4",
+			Capabilities{Client: Lodestar, StateByRoot: true, BlobsEndpoint: true, SSZ: true}},
+		{"Grandine/1.1.0-29cb5fc/x86_64-linux",
+			Capabilities{Client: Grandine, StateByRoot: true, BlobsEndpoint: true, SSZ: true}},
+		{"mockbeacon/v0.1.0",
+			Capabilities{Client: UnknownClient, BlobsEndpoint: true, SSZ: true, HeadBeforeCanonical: true}},
+		{"",
+			Capabilities{Client: UnknownClient, BlobsEndpoint: true, SSZ: true, HeadBeforeCanonical: true}},
+	}
+	for _, test := range tests {
+		t.Run(test.version, func(t *testing.T) {
+			test.want.Version = test.version
+			assert.Equal(t, test.want, DetectCapabilities(test.version))
+		})
+	}
+}
+
+func TestCapabilitiesOverrides(t *testing.T) {
+	caps := DetectCapabilities("Prysm/v6.0.4 (linux amd64)")
+	require.NoError(t, caps.applyOverrides(" state-by-root=true, ssz=false,"))
+	assert.True(t, caps.StateByRoot)
+	assert.False(t, caps.SSZ)
+	assert.True(t, caps.BlobsEndpoint)
+
+	assert.ErrorContains(t, caps.applyOverrides("ssz"), "expected name=true|false")
+	assert.ErrorContains(t, caps.applyOverrides("ssz=maybe"), "invalid value")
+	assert.ErrorContains(t, caps.applyOverrides("events=false"), "unknown capability")
+}
+
+func TestMergeCapabilities(t *testing.T) {
+	lighthouse := DetectCapabilities("Lighthouse/v8.1.0-4fdc5e8/x86_64-linux")
+	nimbus := DetectCapabilities("Nimbus/v25.4.1-77cfa7-stateofus")
+
+	merged := mergeCapabilities([]Capabilities{lighthouse, lighthouse})
+	assert.Equal(t, lighthouse, merged)
+
+	// only what every node supports is used
+	merged = mergeCapabilities([]Capabilities{lighthouse, nimbus})
+	assert.Equal(t, UnknownClient, merged.Client)
+	assert.False(t, merged.StateByRoot)
+	assert.True(t, merged.BlobsEndpoint)
+	assert.True(t, merged.HeadBeforeCanonical)
+}
+
+// TestRecordedClients replays the version answered by each client, recorded under
+// testdata/compat/<client>
+func TestRecordedClients(t *testing.T) {
+	tests := map[string]Capabilities{
+		"lighthouse":    {Client: Lighthouse, StateByRoot: true, BlobsEndpoint: true, SSZ: true, HeadBeforeCanonical: true},
+		"lighthouse-v7": {Client: Lighthouse, StateByRoot: true, BlobsEndpoint: true, SSZ: true, HeadBeforeCanonical: true},
+		"prysm":         {Client: Prysm, BlobsEndpoint: true, SSZ: true},
+		"teku":          {Client: Teku, StateByRoot: true, BlobsEndpoint: true, SSZ: true},
+		"nimbus":        {Client: Nimbus, BlobsEndpoint: true, SSZ: true},
+		"lodestar":      {Client: Lodestar, StateByRoot: true, BlobsEndpoint: true, SSZ: true},
+		"grandine":      {Client: Grandine, StateByRoot: true, BlobsEndpoint: true, SSZ: true},
+	}
+	for name, want := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			cli, err := NewAPIClient(ctx, ReplayScheme+"://"+filepath.Join("testdata", "compat", name), 1)
+			require.NoError(t, err)
+
+			caps := cli.Capabilities()
+			assert.NotEmpty(t, caps.Version)
+			caps.Version = ""
+			assert.Equal(t, want, caps)
+		})
+	}
+
+	// the overrides are applied on top of the detected capabilities
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	cli, err := NewAPIClient(ctx, ReplayScheme+"://"+filepath.Join("testdata", "compat", "nimbus"), 1,
+		WithCapabilities("state-by-root=true"))
+	require.NoError(t, err)
+	assert.True(t, cli.Capabilities().StateByRoot)
+}
+
+func TestBlobsEndpointFallback(t *testing.T) {
+	var blobsHits, sidecarsHits atomic.Int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/eth/v1/node/syncing":
+			fmt.Fprint(w, `{"data":{"head_slot":"10","sync_distance":"0","is_syncing":false,"is_optimistic":false}}`)
+		case "/eth/v1/node/version":
+			fmt.Fprint(w, `{"data":{"version":"Homebrew/v0.0.1"}}`)
+		case "/eth/v1/beacon/blobs/10":
+			blobsHits.Add(1)
+			w.WriteHeader(http.StatusNotFound)
+		case "/eth/v1/beacon/blob_sidecars/10":
+			sidecarsHits.Add(1)
+			fmt.Fprint(w, `{"data":[]}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	cli, err := NewAPIClient(ctx, server.URL, 1)
+	require.NoError(t, err)
+	require.Equal(t, UnknownClient, cli.Capabilities().Client)
+	require.False(t, cli.Capabilities().BlobsEndpoint)
+
+	// the node does not serve the blobs endpoint, the sidecars are read instead
+	blobs, err := cli.RequestFuluBlobs(10)
+	require.NoError(t, err)
+	assert.Empty(t, blobs)
+	assert.Equal(t, int64(0), blobsHits.Load())
+	assert.Equal(t, int64(1), sidecarsHits.Load())
+}
+
+func TestProbeCapabilities(t *testing.T) {
+	stateRoot := phase0.Root{1}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/eth/v1/node/syncing":
+			fmt.Fprint(w, `{"data":{"head_slot":"2000","sync_distance":"0","is_syncing":false,"is_optimistic":false}}`)
+		case "/eth/v1/node/version":
+			fmt.Fprint(w, `{"data":{"version":"Prysm/v6.0.4 (linux amd64)"}}`)
+		case "/eth/v2/beacon/blocks/head":
+			// SSZ is not served
+			fmt.Fprint(w, `{"version":"phase0","data":{}}`)
+		case "/eth/v1/beacon/states/976/root":
+			fmt.Fprintf(w, `{"data":{"root":"%#x"}}`, stateRoot)
+		case fmt.Sprintf("/eth/v1/beacon/states/%#x/finality_checkpoints", stateRoot):
+			fmt.Fprint(w, `{"data":{}}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	cli, err := NewAPIClient(ctx, server.URL, 1)
+	require.NoError(t, err)
+
+	// the answers of the node take precedence over its version
+	assert.Equal(t, Capabilities{Client: Prysm, Version: "Prysm/v6.0.4 (linux amd64)", StateByRoot: true},
+		cli.Capabilities())
+
+	// and the overrides over the answers
+	cli, err = NewAPIClient(ctx, server.URL, 1, WithCapabilities("ssz=true"))
+	require.NoError(t, err)
+	assert.True(t, cli.Capabilities().SSZ)
+}
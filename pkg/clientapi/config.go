@@ -0,0 +1,45 @@
+package clientapi
+
+import (
+	"fmt"
+
+	"github.com/attestantio/go-eth2-client/api"
+	"github.com/attestantio/go-eth2-client/http"
+	"github.com/migalabs/goteth/pkg/spec"
+)
+
+// RequestChainSpec returns the config and preset values of the network (/eth/v1/config/spec)
+func (s *APIClient) RequestChainSpec() (map[string]any, error) {
+	resp, err := requestBeacon(s, (*http.Service).Spec, &api.SpecOpts{})
+	if err != nil {
+		return nil, fmt.Errorf("unable to retrieve chain spec: %w", err)
+	}
+	return resp.Data, nil
+}
+
+// LoadChainSpec applies the spec of the beacon node, overwritten by the values of the file if any
+func (s *APIClient) LoadChainSpec(chainSpecFile string) error {
+	nodeValues, err := s.RequestChainSpec()
+	if err != nil {
+		return err
+	}
+	values := make(map[string]any, len(nodeValues))
+	for key, value := range nodeValues {
+		values[key] = value
+	}
+	if chainSpecFile != "" {
+		fileValues, err := spec.ReadChainSpecFile(chainSpecFile)
+		if err != nil {
+			return err
+		}
+		for key, value := range fileValues {
+			values[key] = value
+		}
+	}
+	err = spec.ApplyChainSpec(values)
+	if err != nil {
+		return err
+	}
+	log.Infof("chain spec %s: %d slots per epoch, %d seconds per slot", spec.ConfigName, spec.SlotsPerEpoch, spec.SlotSeconds)
+	return nil
+}
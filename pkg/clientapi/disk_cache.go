@@ -0,0 +1,265 @@
+package clientapi
+
+import (
+	"container/list"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/attestantio/go-eth2-client/spec"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/golang/snappy"
+)
+
+// The states and blocks downloaded as SSZ can be kept on disk (--disk-cache-dir), so
+// the ones missing from the chain cache (finalization checks, dependency states, reorgs,
+// repairs) are read back instead of downloaded again.
+// They are content addressed: each one is stored under <dir>/states or <dir>/blocks in
+// a file named after its slot and root, holding its fork (one byte) and its snappy
+// compressed SSZ. The root of a slot is only requested to the beacon node when the cache
+// holds an object at that slot, so a cold cache costs no extra request.
+// Once the files exceed the size of the cache, the least recently used are removed.
+
+var (
+	stateObject = "state"
+	blockObject = "block"
+	cacheExt    = ".ssz_snappy"
+)
+
+// DiskCache is a size limited cache of SSZ states and blocks on disk, shared by the
+// API clients of the analyzer
+type DiskCache struct {
+	dir     string
+	maxSize int64
+
+	mu      sync.Mutex
+	size    int64
+	entries map[string]*list.Element // by path
+	lru     *list.List               // of *diskCacheEntry, the most recently used first
+	slots   map[slotKey]int          // entries at each slot, several after a reorg
+}
+
+type diskCacheEntry struct {
+	path string
+	size int64
+	key  slotKey
+}
+
+type slotKey struct {
+	object string
+	slot   phase0.Slot
+}
+
+// NewDiskCache opens the cache at dir, keeping the files already there up to maxSize bytes
+func NewDiskCache(dir string, maxSize int64) (*DiskCache, error) {
+	if maxSize <= 0 {
+		return nil, fmt.Errorf("invalid disk cache size %d", maxSize)
+	}
+	c := &DiskCache{
+		dir:     dir,
+		maxSize: maxSize,
+		entries: make(map[string]*list.Element),
+		lru:     list.New(),
+		slots:   make(map[slotKey]int),
+	}
+
+	type cachedFile struct {
+		diskCacheEntry
+		modTime time.Time
+	}
+	files := make([]cachedFile, 0)
+	for _, object := range []string{stateObject, blockObject} {
+		objectDir := filepath.Join(dir, object+"s")
+		if err := os.MkdirAll(objectDir, 0o755); err != nil {
+			return nil, fmt.Errorf("could not create disk cache directory: %w", err)
+		}
+		// left by a write interrupted
+		if tmpPaths, err := filepath.Glob(filepath.Join(objectDir, "*.tmp")); err == nil {
+			for _, path := range tmpPaths {
+				os.Remove(path)
+			}
+		}
+		paths, err := filepath.Glob(filepath.Join(objectDir, "*"+cacheExt))
+		if err != nil {
+			return nil, err
+		}
+		for _, path := range paths {
+			slot, err := strconv.ParseUint(strings.SplitN(filepath.Base(path), "_", 2)[0], 10, 64)
+			if err != nil {
+				// not named after its slot
+				os.Remove(path)
+				continue
+			}
+			info, err := os.Stat(path)
+			if err != nil {
+				continue
+			}
+			entry := diskCacheEntry{path, info.Size(), slotKey{object, phase0.Slot(slot)}}
+			files = append(files, cachedFile{entry, info.ModTime()})
+		}
+	}
+
+	// the access time is kept as the modification time of the files
+	sort.Slice(files, func(i, j int) bool { return files[i].modTime.After(files[j].modTime) })
+	for _, file := range files {
+		entry := file.diskCacheEntry
+		c.entries[entry.path] = c.lru.PushBack(&entry)
+		c.slots[entry.key]++
+		c.size += entry.size
+	}
+	c.evict()
+	log.Infof("disk cache at %s: %d files, %d MiB of %d MiB", dir, c.lru.Len(), c.size>>20, c.maxSize>>20)
+
+	return c, nil
+}
+
+func (c *DiskCache) path(object string, slot phase0.Slot, root phase0.Root) string {
+	return filepath.Join(c.dir, object+"s", fmt.Sprintf("%d_%#x%s", slot, root, cacheExt))
+}
+
+// holds returns whether an object at the slot is cached, whatever its root, so its root
+// is only requested when a hit is likely
+func (c *DiskCache) holds(object string, slot phase0.Slot) bool {
+	if c == nil {
+		return false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.slots[slotKey{object, slot}] > 0
+}
+
+// encode returns the content of the cache file of an SSZ object, nil without cache
+func (c *DiskCache) encode(version spec.DataVersion, data []byte) []byte {
+	if c == nil {
+		return nil
+	}
+	// copied so the buffer of the worst case compression is not kept until stored
+	compressed := snappy.Encode(nil, data)
+	entry := make([]byte, 1+len(compressed))
+	entry[0] = byte(version)
+	copy(entry[1:], compressed)
+	return entry
+}
+
+// get returns the fork and the SSZ of the object at the slot with the root, if cached
+func (c *DiskCache) get(object string, slot phase0.Slot, root phase0.Root) (spec.DataVersion, []byte, bool) {
+	if c == nil || root == (phase0.Root{}) {
+		return 0, nil, false
+	}
+	path := c.path(object, slot, root)
+	c.mu.Lock()
+	element, ok := c.entries[path]
+	if ok {
+		c.lru.MoveToFront(element)
+	}
+	c.mu.Unlock()
+	if !ok {
+		diskCacheRequests.WithLabelValues(object, "miss").Inc()
+		return 0, nil, false
+	}
+
+	entry, err := os.ReadFile(path)
+	var data []byte
+	if err == nil && len(entry) > 0 {
+		data, err = snappy.Decode(nil, entry[1:])
+	}
+	if errors.Is(err, fs.ErrNotExist) {
+		// evicted meanwhile
+		diskCacheRequests.WithLabelValues(object, "miss").Inc()
+		return 0, nil, false
+	}
+	if err != nil || len(entry) == 0 {
+		log.Warnf("removing unreadable %s %#x from the disk cache: %v", object, root, err)
+		c.remove(path)
+		diskCacheRequests.WithLabelValues(object, "miss").Inc()
+		return 0, nil, false
+	}
+
+	now := time.Now()
+	os.Chtimes(path, now, now)
+	diskCacheRequests.WithLabelValues(object, "hit").Inc()
+	return spec.DataVersion(entry[0]), data, true
+}
+
+// put stores the entry returned by encode as the object at the slot with the root
+func (c *DiskCache) put(object string, slot phase0.Slot, root phase0.Root, entry []byte) {
+	if c == nil || entry == nil || root == (phase0.Root{}) {
+		return
+	}
+	path := c.path(object, slot, root)
+	if int64(len(entry)) > c.maxSize {
+		return
+	}
+
+	// written to a temporary file first, so a file is never read half written
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err == nil {
+		_, err = tmp.Write(entry)
+		if closeErr := tmp.Close(); err == nil {
+			err = closeErr
+		}
+		if err == nil {
+			err = os.Rename(tmp.Name(), path)
+		}
+		if err != nil {
+			os.Remove(tmp.Name())
+		}
+	}
+	if err != nil {
+		log.Warnf("could not write %s %#x to the disk cache: %s", object, root, err)
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if element, ok := c.entries[path]; ok {
+		c.drop(element)
+	}
+	key := slotKey{object, slot}
+	c.entries[path] = c.lru.PushFront(&diskCacheEntry{path, int64(len(entry)), key})
+	c.slots[key]++
+	c.size += int64(len(entry))
+	c.evict()
+}
+
+func (c *DiskCache) remove(path string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if element, ok := c.entries[path]; ok {
+		c.drop(element)
+	}
+	os.Remove(path)
+	diskCacheSize.Set(float64(c.size))
+}
+
+// drop forgets the entry, the file is not removed. Must be called holding the lock
+func (c *DiskCache) drop(element *list.Element) {
+	entry := element.Value.(*diskCacheEntry)
+	c.lru.Remove(element)
+	delete(c.entries, entry.path)
+	if c.slots[entry.key]--; c.slots[entry.key] <= 0 {
+		delete(c.slots, entry.key)
+	}
+	c.size -= entry.size
+}
+
+// evict removes the least recently used files until the cache fits its size.
+// Must be called holding the lock
+func (c *DiskCache) evict() {
+	for c.size > c.maxSize {
+		element := c.lru.Back()
+		entry := element.Value.(*diskCacheEntry)
+		if err := os.Remove(entry.path); err != nil && !os.IsNotExist(err) {
+			log.Warnf("could not remove %s from the disk cache: %s", entry.path, err)
+		}
+		c.drop(element)
+	}
+	diskCacheSize.Set(float64(c.size))
+}
@@ -0,0 +1,138 @@
+package clientapi
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/attestantio/go-eth2-client/spec"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/migalabs/goteth/pkg/testutil/mockbeacon"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiskCacheEviction(t *testing.T) {
+	dir := t.TempDir()
+	data := make([]byte, 1000)
+	entry := (&DiskCache{}).encode(spec.DataVersionDeneb, data)
+	assert.Equal(t, len(entry), cap(entry), "no room for the worst case compression kept")
+	cache, err := NewDiskCache(dir, int64(len(entry))*3)
+	require.NoError(t, err)
+
+	for i := byte(1); i <= 3; i++ {
+		cache.put(stateObject, phase0.Slot(i), phase0.Root{i}, entry)
+	}
+	version, cached, ok := cache.get(stateObject, phase0.Slot(1), phase0.Root{1})
+	require.True(t, ok)
+	assert.Equal(t, spec.DataVersionDeneb, version)
+	assert.Equal(t, data, cached)
+
+	// the least recently used state is removed to fit the next one
+	cache.put(blockObject, phase0.Slot(4), phase0.Root{4}, entry)
+	_, _, ok = cache.get(stateObject, phase0.Slot(2), phase0.Root{2})
+	assert.False(t, ok)
+	assert.NoFileExists(t, cache.path(stateObject, phase0.Slot(2), phase0.Root{2}))
+	for _, root := range []phase0.Root{{1}, {3}} {
+		_, _, ok = cache.get(stateObject, phase0.Slot(root[0]), root)
+		assert.True(t, ok)
+	}
+	_, _, ok = cache.get(blockObject, phase0.Slot(4), phase0.Root{4})
+	assert.True(t, ok)
+	_, _, ok = cache.get(stateObject, phase0.Slot(4), phase0.Root{4})
+	assert.False(t, ok, "states and blocks are apart")
+
+	// an entry bigger than the cache is not kept
+	cache.put(stateObject, phase0.Slot(5), phase0.Root{5}, cache.encode(spec.DataVersionDeneb, make([]byte, 1<<20)))
+	_, _, ok = cache.get(stateObject, phase0.Slot(5), phase0.Root{5})
+	assert.False(t, ok)
+	assert.Equal(t, int64(len(entry))*3, cache.size)
+}
+
+func TestDiskCacheReopen(t *testing.T) {
+	dir := t.TempDir()
+	entry := (&DiskCache{}).encode(spec.DataVersionElectra, []byte("state"))
+	cache, err := NewDiskCache(dir, 1<<20)
+	require.NoError(t, err)
+	for i := byte(1); i <= 3; i++ {
+		cache.put(stateObject, phase0.Slot(i), phase0.Root{i}, entry)
+		// the last access is kept as the modification time
+		accessTime := time.Now().Add(time.Duration(i) * time.Minute)
+		require.NoError(t, os.Chtimes(cache.path(stateObject, phase0.Slot(i), phase0.Root{i}), accessTime, accessTime))
+	}
+	require.NoError(t, os.WriteFile(cache.path(stateObject, phase0.Slot(9), phase0.Root{9})+".123.tmp", entry, 0o644))
+	corrupted := cache.path(blockObject, phase0.Slot(9), phase0.Root{9})
+	require.NoError(t, os.WriteFile(corrupted, []byte{byte(spec.DataVersionElectra), 0xff}, 0o644))
+	accessTime := time.Now().Add(time.Hour)
+	require.NoError(t, os.Chtimes(corrupted, accessTime, accessTime))
+
+	// reopened smaller, the oldest state is removed along with the interrupted writes
+	reopened, err := NewDiskCache(dir, int64(len(entry))*2+2)
+	require.NoError(t, err)
+	assert.Equal(t, 3, reopened.lru.Len())
+	_, _, ok := reopened.get(stateObject, phase0.Slot(1), phase0.Root{1})
+	assert.False(t, ok)
+	version, data, ok := reopened.get(stateObject, phase0.Slot(3), phase0.Root{3})
+	require.True(t, ok)
+	assert.Equal(t, spec.DataVersionElectra, version)
+	assert.Equal(t, []byte("state"), data)
+	tmpFiles, err := filepath.Glob(filepath.Join(dir, "states", "*.tmp"))
+	require.NoError(t, err)
+	assert.Empty(t, tmpFiles)
+
+	// the states are indexed by slot again
+	assert.True(t, reopened.holds(stateObject, 3))
+	assert.False(t, reopened.holds(stateObject, 1))
+	assert.False(t, reopened.holds(blockObject, 3))
+
+	// an unreadable file is removed
+	_, _, ok = reopened.get(blockObject, phase0.Slot(9), phase0.Root{9})
+	assert.False(t, ok)
+	assert.NoFileExists(t, reopened.path(blockObject, phase0.Slot(9), phase0.Root{9}))
+	assert.Equal(t, 2, reopened.lru.Len())
+}
+
+func TestDiskCacheRequests(t *testing.T) {
+	node := newSSZNode(t)
+	cache, err := NewDiskCache(t.TempDir(), 1<<30)
+	require.NoError(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	cli, err := NewAPIClient(ctx, node.URL(), 1, WithDiskCache(cache))
+	require.NoError(t, err)
+	served := downloads(node)
+	stateRoots := node.Requests(mockbeacon.RouteStateRoot)
+
+	// nothing cached at the slot, the roots are not requested before downloading
+	block, err := cli.RequestBeaconBlock(10)
+	require.NoError(t, err)
+	assert.FileExists(t, cache.path(blockObject, 10, block.Root))
+	state, err := cli.RequestBeaconState(10)
+	require.NoError(t, err)
+	assert.Equal(t, phase0.Root{31: 1}, state.StateRoot)
+	assert.FileExists(t, cache.path(stateObject, 10, state.StateRoot))
+	require.Equal(t, served+2, downloads(node))
+	assert.Equal(t, 0, node.Requests(mockbeacon.RouteBlockRoot))
+	assert.Equal(t, stateRoots+2, node.Requests(mockbeacon.RouteStateRoot), "only after the downloads, for the block and the state")
+
+	// read back from the disk cache
+	cachedBlock, err := cli.RequestBeaconBlock(10)
+	require.NoError(t, err)
+	assert.Equal(t, block.Root, cachedBlock.Root)
+	assert.Equal(t, block.ProposerIndex, cachedBlock.ProposerIndex)
+	cachedState, err := cli.RequestBeaconState(10)
+	require.NoError(t, err)
+	assert.Equal(t, state.Balances, cachedState.Balances)
+	assert.Equal(t, served+2, downloads(node))
+	assert.Equal(t, 1, node.Requests(mockbeacon.RouteBlockRoot))
+
+	// a slot with another root (i.e. after a reorg) is downloaded again
+	node.SetStateRoot(10, phase0.Root{31: 2})
+	reorged, err := cli.RequestBeaconState(10)
+	require.NoError(t, err)
+	assert.Equal(t, phase0.Root{31: 2}, reorged.StateRoot)
+	assert.Equal(t, served+3, downloads(node))
+	assert.FileExists(t, cache.path(stateObject, 10, phase0.Root{31: 2}))
+}
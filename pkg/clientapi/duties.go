@@ -4,13 +4,14 @@ import (
 	"fmt"
 
 	"github.com/attestantio/go-eth2-client/api"
+	"github.com/attestantio/go-eth2-client/http"
 	"github.com/attestantio/go-eth2-client/spec/phase0"
 	"github.com/migalabs/goteth/pkg/spec"
 )
 
 func (s *APIClient) NewEpochData(slot phase0.Slot) spec.EpochDuties {
 
-	epochCommittees, err := s.Api.BeaconCommittees(s.ctx, &api.BeaconCommitteesOpts{
+	epochCommittees, err := requestBeacon(s, (*http.Service).BeaconCommittees, &api.BeaconCommitteesOpts{
 		State: fmt.Sprintf("%d", slot),
 	})
 
@@ -37,8 +38,8 @@ func (s *APIClient) NewEpochData(slot phase0.Slot) spec.EpochDuties {
 		}
 	}
 
-	proposerDuties, err := s.Api.ProposerDuties(s.ctx, &api.ProposerDutiesOpts{
-		Epoch: phase0.Epoch(slot / spec.SlotsPerEpoch),
+	proposerDuties, err := requestBeacon(s, (*http.Service).ProposerDuties, &api.ProposerDutiesOpts{
+		Epoch: spec.EpochAtSlot(slot),
 	})
 
 	if err != nil {
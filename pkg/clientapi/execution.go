@@ -0,0 +1,79 @@
+package clientapi
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/migalabs/goteth/pkg/utils"
+)
+
+const (
+	// Circuit breaker: after this many consecutive failures, skip the
+	// execution node for a cooldown period and ask the next one instead.
+	elFailureThreshold = 3
+	elCooldown         = 2 * time.Minute
+)
+
+// executionNode is one of the execution nodes the requests fail over across
+type executionNode struct {
+	address string
+	api     *ethclient.Client
+	breaker *utils.CircuitBreaker
+}
+
+func newExecutionNode(address string, rpcCli *rpc.Client) *executionNode {
+	return &executionNode{
+		address: address,
+		api:     ethclient.NewClient(rpcCli),
+		breaker: utils.NewCircuitBreaker(elFailureThreshold, elCooldown),
+	}
+}
+
+// requestExecution runs the request against the execution nodes in order until one
+// of them answers. With several nodes, the nodes whose circuit breaker is open are skipped
+func (s *APIClient) requestExecution(method string, f func(el *ethclient.Client) error) error {
+	err := errors.New("every execution node circuit breaker is open")
+	for _, node := range s.elNodes {
+		failover := len(s.elNodes) > 1
+		if failover && node.breaker.IsOpen() {
+			continue
+		}
+
+		startTime := time.Now()
+		err = f(node.api)
+		s.receiptMetrics.recordRequest(redactEndpoint(node.address), method, err, time.Since(startTime))
+		if failover && node.breaker.RecordResult(isExecutionFailure(err)) {
+			log.Warnf("circuit breaker open for execution node %s, skipping for %s", redactEndpoint(node.address), elCooldown)
+		}
+		if err == nil || s.ctx.Err() != nil {
+			return err
+		}
+		if failover {
+			log.Debugf("%s failed on execution node %s: %s", method, redactEndpoint(node.address), err)
+		}
+	}
+	return err
+}
+
+// isExecutionFailure returns true if the error counts towards the circuit breaker of
+// the node: missing data may only mean the node is a few blocks behind, and an error
+// answered by the node (i.e. -32601 method not found) means it is up
+func isExecutionFailure(err error) bool {
+	var rpcErr rpc.Error
+	return err != nil && !errors.Is(err, ethereum.NotFound) && !errors.Is(err, context.Canceled) && !errors.As(err, &rpcErr)
+}
+
+// redactEndpoint returns the host of the endpoint, without the credentials or API keys
+// some providers include in the URL
+func redactEndpoint(endpoint string) string {
+	u, err := url.Parse(endpoint)
+	if err != nil || u.Host == "" {
+		return endpoint
+	}
+	return u.Host
+}
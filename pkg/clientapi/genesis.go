@@ -1,9 +1,17 @@
 package clientapi
 
-import "time"
+import (
+	"time"
+
+	"github.com/attestantio/go-eth2-client/http"
+)
 
 func (s APIClient) RequestGenesis() time.Time {
-	genesis, err := s.Api.GenesisTime(s.ctx)
+	var genesis time.Time
+	err := s.request(func(bn *http.Service) (err error) {
+		genesis, err = bn.GenesisTime(s.ctx)
+		return err
+	})
 	if err != nil {
 		log.Panicf("could not get genesis time: %s", err)
 	}
@@ -0,0 +1,248 @@
+package clientapi
+
+import (
+	"context"
+	"errors"
+	nethttp "net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/attestantio/go-eth2-client/api"
+	apiv1 "github.com/attestantio/go-eth2-client/api/v1"
+	"github.com/attestantio/go-eth2-client/http"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+)
+
+var (
+	HealthCheckInterval    = 12 * time.Second
+	DefaultMaxHeadDistance = phase0.Slot(4)
+)
+
+// beaconNode is one of the beacon nodes the requests are spread across
+type beaconNode struct {
+	address string
+	api     *http.Service
+	caps    Capabilities
+
+	mu       sync.RWMutex
+	healthy  bool
+	headSlot phase0.Slot
+}
+
+func (n *beaconNode) isHealthy() bool {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	return n.healthy
+}
+
+func (n *beaconNode) setHealthy(healthy bool) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.healthy = healthy
+}
+
+// beaconNodes balances the requests across the beacon nodes in turns, and fails over
+// to the next node when a node does not answer
+type beaconNodes struct {
+	nodes           []*beaconNode
+	next            atomic.Uint64
+	maxHeadDistance phase0.Slot
+}
+
+// order returns the nodes in the order a request tries them: the healthy nodes first,
+// starting from the node in turn, then the unhealthy ones as a last resort
+func (b *beaconNodes) order() []*beaconNode {
+	if len(b.nodes) == 1 {
+		return b.nodes
+	}
+	first := int(b.next.Add(1)-1) % len(b.nodes)
+	healthy := make([]*beaconNode, 0, len(b.nodes))
+	unhealthy := make([]*beaconNode, 0)
+	for i := range b.nodes {
+		node := b.nodes[(first+i)%len(b.nodes)]
+		if node.isHealthy() {
+			healthy = append(healthy, node)
+		} else {
+			unhealthy = append(unhealthy, node)
+		}
+	}
+	return append(healthy, unhealthy...)
+}
+
+// checkHealth updates the health of every node: a node is healthy when it is not
+// syncing and its head is at most maxHeadDistance slots behind the highest head
+func (b *beaconNodes) checkHealth(ctx context.Context) {
+	states := make([]*api.Response[*apiv1.SyncState], len(b.nodes))
+	errs := make([]error, len(b.nodes))
+	var wg sync.WaitGroup
+	for i, node := range b.nodes {
+		wg.Add(1)
+		go func(i int, node *beaconNode) {
+			defer wg.Done()
+			states[i], errs[i] = node.api.NodeSyncing(ctx, &api.NodeSyncingOpts{})
+		}(i, node)
+	}
+	wg.Wait()
+
+	highestHead := phase0.Slot(0)
+	for i := range b.nodes {
+		if errs[i] == nil && !states[i].Data.IsSyncing {
+			highestHead = max(highestHead, states[i].Data.HeadSlot)
+		}
+	}
+
+	for i, node := range b.nodes {
+		healthy := false
+		switch {
+		case errs[i] != nil:
+			log.Debugf("beacon node %s did not answer the health check: %s", node.address, errs[i])
+		case states[i].Data.IsSyncing:
+			log.Debugf("beacon node %s is syncing, %d slots behind", node.address, states[i].Data.SyncDistance)
+		case highestHead-states[i].Data.HeadSlot > b.maxHeadDistance:
+			log.Debugf("beacon node %s head is %d slots behind", node.address, highestHead-states[i].Data.HeadSlot)
+		default:
+			healthy = true
+		}
+
+		wasHealthy := node.isHealthy()
+		node.mu.Lock()
+		node.healthy = healthy
+		if errs[i] == nil {
+			node.headSlot = states[i].Data.HeadSlot
+		}
+		node.mu.Unlock()
+
+		if wasHealthy && !healthy {
+			log.Warnf("beacon node %s is unhealthy, requests are sent to the other nodes", node.address)
+		}
+		if !wasHealthy && healthy {
+			log.Infof("beacon node %s is healthy", node.address)
+		}
+	}
+}
+
+// monitor checks the health of the nodes periodically until the context is done
+func (b *beaconNodes) monitor(ctx context.Context) {
+	ticker := time.NewTicker(HealthCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			b.checkHealth(ctx)
+		}
+	}
+}
+
+// request runs the request against the beacon nodes until one of them answers.
+// Errors returned by the node itself, such as a bad request, are not retried. A missing
+// object (404) is asked to the next node, as the node might be behind the others: it
+// is only missing once every node answered 404
+func (s *APIClient) request(f func(bn *http.Service) error) error {
+	var err error
+	for _, node := range s.bnNodes.order() {
+		err = f(node.api)
+		if err == nil || s.ctx.Err() != nil {
+			return err
+		}
+		if isNotFound(err) {
+			if len(s.bnNodes.nodes) > 1 {
+				log.Debugf("beacon node %s answered not found, trying the next node: %s", node.address, err)
+			}
+			continue
+		}
+		if !isNodeFailure(err) {
+			return err
+		}
+		if len(s.bnNodes.nodes) > 1 {
+			log.Warnf("beacon node %s failed, trying the next node: %s", node.address, err)
+			node.setHealthy(false) // until the next health check
+		}
+	}
+	return err
+}
+
+// requestBeacon calls the method of the beacon node API on the nodes, see request
+func requestBeacon[O, T any](s *APIClient, method func(*http.Service, context.Context, O) (T, error), opts O) (T, error) {
+	var result T
+	err := s.request(func(bn *http.Service) (err error) {
+		result, err = method(bn, s.ctx, opts)
+		return err
+	})
+	return result, err
+}
+
+// isNodeFailure returns true if the error comes from a node that could not answer:
+// timeouts, connection errors and server errors
+func isNodeFailure(err error) bool {
+	var apiErr *api.Error
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode >= nethttp.StatusInternalServerError
+	}
+	return true
+}
+
+// isNotFound returns true if the node answered that the object does not exist
+func isNotFound(err error) bool {
+	var apiErr *api.Error
+	return errors.As(err, &apiErr) && apiErr.StatusCode == nethttp.StatusNotFound
+}
+
+// Events subscribes to the events of the topics on a healthy beacon node. When the
+// node becomes unhealthy, the events are subscribed on another node, checked with the
+// health checks, until the context is done
+func (s *APIClient) Events(ctx context.Context, opts *api.EventsOpts) error {
+	node := s.bnNodes.order()[0]
+	if len(s.bnNodes.nodes) == 1 {
+		return node.api.Events(ctx, opts)
+	}
+	nodeCtx, cancel := context.WithCancel(ctx)
+	if err := node.api.Events(nodeCtx, opts); err != nil {
+		cancel()
+		return err
+	}
+
+	go func() {
+		ticker := time.NewTicker(HealthCheckInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				cancel()
+				return
+			case <-ticker.C:
+			}
+			if node.isHealthy() {
+				continue
+			}
+			next := s.bnNodes.order()[0]
+			if next == node || !next.isHealthy() {
+				continue
+			}
+			nextCtx, nextCancel := context.WithCancel(ctx)
+			if err := next.api.Events(nextCtx, opts); err != nil {
+				nextCancel()
+				log.Errorf("could not subscribe to %v events on beacon node %s: %s", opts.Topics, next.address, err)
+				continue
+			}
+			log.Warnf("beacon node %s is unhealthy, %v events are received from %s", node.address, opts.Topics, next.address)
+			cancel()
+			node, cancel = next, nextCancel
+		}
+	}()
+	return nil
+}
+
+// SplitEndpoints returns the endpoints of a comma separated list
+func SplitEndpoints(endpoints string) []string {
+	result := make([]string, 0)
+	for _, endpoint := range strings.Split(endpoints, ",") {
+		if endpoint = strings.TrimSpace(endpoint); endpoint != "" {
+			result = append(result, endpoint)
+		}
+	}
+	return result
+}
@@ -0,0 +1,114 @@
+package clientapi
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/migalabs/goteth/pkg/testutil/mockbeacon"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestNode starts a beacon node with its head at the given slot and the state
+// root of the first slots set
+func newTestNode(t *testing.T, headSlot phase0.Slot) *mockbeacon.Server {
+	node := mockbeacon.New()
+	t.Cleanup(node.Close)
+	node.SetHead(headSlot)
+	for slot := phase0.Slot(0); slot < 4; slot++ {
+		node.SetStateRoot(slot, phase0.Root{31: 1})
+	}
+	return node
+}
+
+func newTestNodesClient(t *testing.T, nodes ...*mockbeacon.Server) *APIClient {
+	endpoints := make([]string, 0, len(nodes))
+	for _, node := range nodes {
+		endpoints = append(endpoints, node.URL())
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	cli, err := NewAPIClient(ctx, strings.Join(endpoints, ","), 1)
+	require.NoError(t, err)
+	return cli
+}
+
+func TestRequestFailover(t *testing.T) {
+	failing := newTestNode(t, 100)
+	failing.SetFailure(mockbeacon.RouteStateRoot, http.StatusInternalServerError)
+	working := newTestNode(t, 100)
+	cli := newTestNodesClient(t, failing, working)
+	require.Len(t, cli.bnNodes.nodes, 2)
+
+	for i := 0; i < 4; i++ {
+		root, err := cli.RequestStateRoot(phase0.Slot(i))
+		require.NoError(t, err)
+		assert.Equal(t, phase0.Root{31: 1}, root)
+	}
+	// the failing node is skipped until the next health check
+	assert.Equal(t, 1, failing.Requests(mockbeacon.RouteStateRoot))
+	assert.Equal(t, 4, working.Requests(mockbeacon.RouteStateRoot))
+	assert.False(t, cli.bnNodes.nodes[0].isHealthy())
+
+	cli.bnNodes.checkHealth(context.Background())
+	assert.True(t, cli.bnNodes.nodes[0].isHealthy())
+
+	// errors answered by the node are not retried on the others
+	failing.SetFailure(mockbeacon.RouteStateRoot, http.StatusBadRequest)
+	working.SetFailure(mockbeacon.RouteStateRoot, http.StatusBadRequest)
+	cli.bnNodes.next.Store(0)
+	_, err := cli.RequestStateRoot(1)
+	require.Error(t, err)
+	assert.Equal(t, 2, failing.Requests(mockbeacon.RouteStateRoot))
+	assert.Equal(t, 4, working.Requests(mockbeacon.RouteStateRoot))
+
+	// but a node behind the others answers not found, the next node is asked
+	failing.SetFailure(mockbeacon.RouteStateRoot, http.StatusNotFound)
+	working.SetFailure(mockbeacon.RouteStateRoot, http.StatusOK)
+	cli.bnNodes.next.Store(0)
+	root, err := cli.RequestStateRoot(1)
+	require.NoError(t, err)
+	assert.Equal(t, phase0.Root{31: 1}, root)
+	assert.Equal(t, 3, failing.Requests(mockbeacon.RouteStateRoot))
+	assert.Equal(t, 5, working.Requests(mockbeacon.RouteStateRoot))
+	assert.True(t, cli.bnNodes.nodes[0].isHealthy(), "not found is not a node failure")
+
+	// missing once every node answered not found
+	working.SetFailure(mockbeacon.RouteStateRoot, http.StatusNotFound)
+	_, err = cli.RequestStateRoot(1)
+	require.Error(t, err)
+	assert.True(t, response404(err.Error()))
+	assert.Equal(t, 10, failing.Requests(mockbeacon.RouteStateRoot)+working.Requests(mockbeacon.RouteStateRoot), "asked to both nodes")
+}
+
+func TestCheckHealth(t *testing.T) {
+	head := newTestNode(t, 100)
+	behind := newTestNode(t, 90)
+	syncing := newTestNode(t, 100)
+	syncing.SetSyncing(true)
+	cli := newTestNodesClient(t, behind, syncing, head)
+
+	assert.False(t, cli.bnNodes.nodes[0].isHealthy(), "head too far from the other nodes")
+	assert.False(t, cli.bnNodes.nodes[1].isHealthy(), "syncing")
+	assert.True(t, cli.bnNodes.nodes[2].isHealthy())
+
+	// the healthy nodes are tried first, in turns
+	for i := 0; i < 3; i++ {
+		assert.Equal(t, head.URL(), cli.bnNodes.order()[0].address)
+	}
+	assert.Len(t, cli.bnNodes.order(), 3, "unhealthy nodes are a last resort")
+
+	// a node catching up within the max head distance is healthy again
+	behind.SetHead(97)
+	cli.bnNodes.checkHealth(context.Background())
+	assert.True(t, cli.bnNodes.nodes[0].isHealthy())
+	assert.Equal(t, phase0.Slot(97), cli.bnNodes.nodes[0].headSlot)
+
+	// a node that stops answering is unhealthy
+	head.Close()
+	cli.bnNodes.checkHealth(context.Background())
+	assert.False(t, cli.bnNodes.nodes[2].isHealthy())
+}
@@ -3,6 +3,7 @@ package clientapi
 import (
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/migalabs/goteth/pkg/metrics"
 	"github.com/migalabs/goteth/pkg/utils"
@@ -16,8 +17,9 @@ const (
 )
 
 var (
-	registerReceiptMetricsOnce sync.Once
-	receiptFailureReasons      = []string{"not_found", "not_canonical", "deadline_exceeded", "context_cancelled", "other", "unknown"}
+	registerReceiptMetricsOnce  sync.Once
+	registerDownloadMetricsOnce sync.Once
+	receiptFailureReasons       = []string{"not_found", "not_canonical", "deadline_exceeded", "context_cancelled", "other", "unknown"}
 
 	receiptRequestFailures = prometheus.NewCounterVec(
 		prometheus.CounterOpts{
@@ -49,6 +51,78 @@ var (
 		},
 		[]string{"reason"},
 	)
+
+	executionRequests = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: strings.ToLower(utils.CliName),
+			Subsystem: clientAPIMetricsName,
+			Name:      "execution_requests_total",
+			Help:      "Total number of requests to each execution node, grouped by method and result.",
+		},
+		[]string{"endpoint", "method", "result"},
+	)
+
+	executionRequestDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: strings.ToLower(utils.CliName),
+			Subsystem: clientAPIMetricsName,
+			Name:      "execution_request_duration_seconds",
+			Help:      "Latency of the requests to each execution node, grouped by method.",
+			Buckets:   []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30},
+		},
+		[]string{"endpoint", "method"},
+	)
+
+	beaconDownloadDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: strings.ToLower(utils.CliName),
+			Subsystem: clientAPIMetricsName,
+			Name:      "beacon_download_duration_seconds",
+			Help:      "Time to download the states and blocks from the beacon nodes, grouped by object, fork and encoding (ssz or json).",
+			Buckets:   []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 20, 40, 80},
+		},
+		[]string{"object", "fork", "encoding"},
+	)
+
+	beaconDecodeDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: strings.ToLower(utils.CliName),
+			Subsystem: clientAPIMetricsName,
+			Name:      "beacon_decode_duration_seconds",
+			Help:      "Time to decode the states and blocks downloaded from the beacon nodes, grouped by object, fork and encoding (ssz or json).",
+			Buckets:   []float64{0.001, 0.005, 0.01, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5},
+		},
+		[]string{"object", "fork", "encoding"},
+	)
+
+	beaconDownloadBytes = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: strings.ToLower(utils.CliName),
+			Subsystem: clientAPIMetricsName,
+			Name:      "beacon_download_bytes_total",
+			Help:      "Total size of the states and blocks downloaded from the beacon nodes, grouped by object, fork and encoding (ssz or json).",
+		},
+		[]string{"object", "fork", "encoding"},
+	)
+
+	diskCacheRequests = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: strings.ToLower(utils.CliName),
+			Subsystem: clientAPIMetricsName,
+			Name:      "disk_cache_requests_total",
+			Help:      "Total number of states and blocks looked up in the disk cache, grouped by object and result (hit or miss).",
+		},
+		[]string{"object", "result"},
+	)
+
+	diskCacheSize = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Namespace: strings.ToLower(utils.CliName),
+			Subsystem: clientAPIMetricsName,
+			Name:      "disk_cache_size_bytes",
+			Help:      "Size of the states and blocks kept in the disk cache.",
+		},
+	)
 )
 
 type receiptMetrics struct {
@@ -62,6 +136,20 @@ func newReceiptMetrics() *receiptMetrics {
 	}
 }
 
+// recordRequest records the result and latency of a request to an execution node
+func (m *receiptMetrics) recordRequest(endpoint string, method string, err error, duration time.Duration) {
+	if m == nil {
+		return
+	}
+	result := "success"
+	if err != nil {
+		result = "error"
+	}
+
+	executionRequests.WithLabelValues(endpoint, method, result).Inc()
+	executionRequestDuration.WithLabelValues(endpoint, method).Observe(duration.Seconds())
+}
+
 func (m *receiptMetrics) recordFailure(reason string, attempts int) {
 	if m == nil {
 		return
@@ -108,6 +196,8 @@ func (m *receiptMetrics) getPrometheusMetrics() *metrics.MetricsModule {
 			prometheus.MustRegister(receiptRequestFailures)
 			prometheus.MustRegister(receiptRequestFailureAttempts)
 			prometheus.MustRegister(receiptRequestFailureTotals)
+			prometheus.MustRegister(executionRequests)
+			prometheus.MustRegister(executionRequestDuration)
 			for _, reason := range receiptFailureReasons {
 				receiptRequestFailures.WithLabelValues(reason).Add(0)
 				_, _ = receiptRequestFailureAttempts.GetMetricWithLabelValues(reason)
@@ -138,3 +228,83 @@ func (m *receiptMetrics) getPrometheusMetrics() *metrics.MetricsModule {
 
 	return mod
 }
+
+// downloadMetrics records the states and blocks downloaded from the beacon nodes
+type downloadMetrics struct {
+	mu    sync.Mutex
+	bytes map[string]int64 // by object
+}
+
+func newDownloadMetrics() *downloadMetrics {
+	return &downloadMetrics{
+		bytes: make(map[string]int64),
+	}
+}
+
+// recordDownload records the size, download and decoding time of a state or block
+func (m *downloadMetrics) recordDownload(object string, fork string, encoding string, size int, download time.Duration, decode time.Duration) {
+	if m == nil {
+		return
+	}
+	beaconDownloadDuration.WithLabelValues(object, fork, encoding).Observe(download.Seconds())
+	beaconDecodeDuration.WithLabelValues(object, fork, encoding).Observe(decode.Seconds())
+	beaconDownloadBytes.WithLabelValues(object, fork, encoding).Add(float64(size))
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.bytes[object] += int64(size)
+}
+
+func (m *downloadMetrics) snapshot() map[string]int64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	out := make(map[string]int64, len(m.bytes))
+	for object, size := range m.bytes {
+		out[object] = size
+	}
+	return out
+}
+
+func (m *downloadMetrics) getPrometheusMetrics() *metrics.MetricsModule {
+	if m == nil {
+		return nil
+	}
+
+	mod := metrics.NewMetricsModule(
+		clientAPIMetricsName,
+		clientAPIMetricsDetails,
+	)
+
+	initFn := func() error {
+		registerDownloadMetricsOnce.Do(func() {
+			prometheus.MustRegister(beaconDownloadDuration)
+			prometheus.MustRegister(beaconDecodeDuration)
+			prometheus.MustRegister(beaconDownloadBytes)
+			prometheus.MustRegister(diskCacheRequests)
+			prometheus.MustRegister(diskCacheSize)
+		})
+		return nil
+	}
+
+	updateFn := func() (interface{}, error) {
+		return m.snapshot(), nil
+	}
+
+	indvMetrics, err := metrics.NewIndvMetrics(
+		"beacon_downloads",
+		initFn,
+		updateFn,
+	)
+	if err != nil {
+		log.Error(errors.Wrap(err, "unable to init beacon_downloads metrics"))
+		return nil
+	}
+
+	if err := mod.AddIndvMetric(indvMetrics); err != nil {
+		log.Error(errors.Wrap(err, "unable to register beacon download metrics module"))
+		return nil
+	}
+
+	return mod
+}
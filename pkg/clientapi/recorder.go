@@ -0,0 +1,316 @@
+package clientapi
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Every response received from the beacon and execution nodes can be saved
+// into a directory (--record-dir) and served back later with
+// --bn-endpoint file://<dir>, so a run can be replayed without the nodes.
+// Each request is stored under <dir>/beacon or <dir>/execution as two files
+// named after the request key: <key>.json (request and response headers) and
+// <key>.body (the raw response body, SSZ for states and blocks).
+// If the same request is answered differently during the recording (i.e. head),
+// the last response is the one replayed.
+
+var (
+	ReplayScheme           = "file"
+	replayBeaconAddress    = "http://replay.beacon"    // address of the beacon node when replaying
+	replayExecutionAddress = "http://replay.execution" // address of the execution node when replaying
+	beaconRecordDir        = "beacon"
+	executionRecordDir     = "execution"
+	recordMetaExt          = ".json"
+	recordBodyExt          = ".body"
+)
+
+type recordedResponse struct {
+	Method     string            `json:"method"`
+	URL        string            `json:"url"`
+	Request    json.RawMessage   `json:"request,omitempty"`     // JSON-RPC request without ids
+	RequestIDs []json.RawMessage `json:"request_ids,omitempty"` // JSON-RPC ids used during the recording
+	StatusCode int               `json:"status_code"`
+	Header     http.Header       `json:"header"`
+}
+
+// replayDir returns the directory of a file://<dir> endpoint
+func replayDir(endpoint string) (string, bool) {
+	parsedUrl, err := url.Parse(endpoint)
+	if err != nil || parsedUrl.Scheme != ReplayScheme {
+		return "", false
+	}
+	return parsedUrl.Host + parsedUrl.Path, true
+}
+
+// recordRequest identifies a request regardless of when it was done.
+// JSON-RPC requests are identified by their method and params, as the ids
+// change from one run to another.
+type recordRequest struct {
+	key     string
+	request json.RawMessage
+	ids     []json.RawMessage
+}
+
+func newRecordRequest(req *http.Request, jsonRPC bool) (recordRequest, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return recordRequest{}, err
+		}
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	result := recordRequest{}
+	if jsonRPC && len(body) > 0 {
+		var err error
+		result.request, result.ids, err = stripJSONRPCIds(body)
+		if err != nil {
+			return recordRequest{}, err
+		}
+		body = result.request
+	}
+
+	hash := sha256.New()
+	fmt.Fprintf(hash, "%s\n%s\n%s\n", req.Method, req.URL.RequestURI(), req.Header.Get("Accept"))
+	hash.Write(body)
+	result.key = hex.EncodeToString(hash.Sum(nil))
+	return result, nil
+}
+
+// stripJSONRPCIds removes the id of a single or batch JSON-RPC message
+func stripJSONRPCIds(body []byte) (json.RawMessage, []json.RawMessage, error) {
+	batch := bytes.HasPrefix(bytes.TrimSpace(body), []byte("["))
+
+	var msgs []map[string]json.RawMessage
+	if batch {
+		if err := json.Unmarshal(body, &msgs); err != nil {
+			return nil, nil, err
+		}
+	} else {
+		msg := make(map[string]json.RawMessage)
+		if err := json.Unmarshal(body, &msg); err != nil {
+			return nil, nil, err
+		}
+		msgs = append(msgs, msg)
+	}
+
+	ids := make([]json.RawMessage, len(msgs))
+	for i, msg := range msgs {
+		ids[i] = msg["id"]
+		delete(msg, "id")
+	}
+
+	var stripped []byte
+	var err error
+	if batch {
+		stripped, err = json.Marshal(msgs)
+	} else {
+		stripped, err = json.Marshal(msgs[0])
+	}
+	return stripped, ids, err
+}
+
+// replaceJSONRPCIds sets the ids of the current request in a recorded response
+func replaceJSONRPCIds(body []byte, recordedIds []json.RawMessage, ids []json.RawMessage) ([]byte, error) {
+	newIds := make(map[string]json.RawMessage, len(recordedIds))
+	for i, id := range recordedIds {
+		if i < len(ids) {
+			newIds[string(id)] = ids[i]
+		}
+	}
+	replace := func(msg map[string]json.RawMessage) {
+		if id, ok := newIds[string(msg["id"])]; ok {
+			msg["id"] = id
+		}
+	}
+
+	if bytes.HasPrefix(bytes.TrimSpace(body), []byte("[")) {
+		var msgs []map[string]json.RawMessage
+		if err := json.Unmarshal(body, &msgs); err != nil {
+			return nil, err
+		}
+		for _, msg := range msgs {
+			replace(msg)
+		}
+		return json.Marshal(msgs)
+	}
+	msg := make(map[string]json.RawMessage)
+	if err := json.Unmarshal(body, &msg); err != nil {
+		return nil, err
+	}
+	replace(msg)
+	return json.Marshal(msg)
+}
+
+// recordTransport saves every response received through it into dir
+type recordTransport struct {
+	dir     string
+	jsonRPC bool
+	next    http.RoundTripper
+}
+
+func newRecordTransport(dir string, jsonRPC bool, next http.RoundTripper) (*recordTransport, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("unable to create record directory %s: %w", dir, err)
+	}
+	return &recordTransport{
+		dir:     dir,
+		jsonRPC: jsonRPC,
+		next:    next,
+	}, nil
+}
+
+func (t *recordTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Header.Get("Accept") == "text/event-stream" {
+		return t.next.RoundTrip(req) // event streams are not recorded
+	}
+
+	recReq, err := newRecordRequest(req, t.jsonRPC)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		return nil, err // connection errors are not recorded
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	err = t.save(recReq, req, resp, body)
+	if err != nil {
+		log.Warnf("could not record response of %s: %s", req.URL.Path, err)
+	}
+	return resp, nil
+}
+
+func (t *recordTransport) save(recReq recordRequest, req *http.Request, resp *http.Response, body []byte) error {
+	meta, err := json.MarshalIndent(recordedResponse{
+		Method:     req.Method,
+		URL:        req.URL.RequestURI(),
+		Request:    recReq.request,
+		RequestIDs: recReq.ids,
+		StatusCode: resp.StatusCode,
+		Header:     resp.Header,
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	// the body goes first, a record is only replayed once its metadata exists
+	err = writeFileAtomic(filepath.Join(t.dir, recReq.key+recordBodyExt), body)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(filepath.Join(t.dir, recReq.key+recordMetaExt), meta)
+}
+
+func writeFileAtomic(path string, data []byte) error {
+	tmpFile, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	_, err = tmpFile.Write(data)
+	if closeErr := tmpFile.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmpFile.Name())
+		return err
+	}
+	return os.Rename(tmpFile.Name(), path)
+}
+
+// replayTransport answers the requests with the responses saved in dir.
+// Requests that were not recorded get a 503, so they are not confused with a
+// recorded 404 (i.e. a missed block).
+type replayTransport struct {
+	dir     string
+	jsonRPC bool
+}
+
+func newReplayTransport(dir string, jsonRPC bool) (*replayTransport, error) {
+	if _, err := os.Stat(dir); err != nil {
+		return nil, fmt.Errorf("unable to open replay directory: %w", err)
+	}
+	return &replayTransport{
+		dir:     dir,
+		jsonRPC: jsonRPC,
+	}, nil
+}
+
+func (t *replayTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	recReq, err := newRecordRequest(req, t.jsonRPC)
+	if err != nil {
+		return nil, err
+	}
+
+	metaBytes, err := os.ReadFile(filepath.Join(t.dir, recReq.key+recordMetaExt))
+	if os.IsNotExist(err) {
+		log.Warnf("request %s %s was not recorded", req.Method, req.URL.RequestURI())
+		return newReplayResponse(req, http.StatusServiceUnavailable, http.Header{},
+			[]byte(fmt.Sprintf("request %s was not recorded", req.URL.RequestURI()))), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var meta recordedResponse
+	if err := json.Unmarshal(metaBytes, &meta); err != nil {
+		return nil, fmt.Errorf("unable to parse record %s: %w", recReq.key, err)
+	}
+
+	body, err := os.ReadFile(filepath.Join(t.dir, recReq.key+recordBodyExt))
+	if err != nil {
+		return nil, err
+	}
+	if t.jsonRPC && len(meta.RequestIDs) > 0 && meta.StatusCode == http.StatusOK {
+		body, err = replaceJSONRPCIds(body, meta.RequestIDs, recReq.ids)
+		if err != nil {
+			return nil, fmt.Errorf("unable to replay record %s: %w", recReq.key, err)
+		}
+	}
+
+	return newReplayResponse(req, meta.StatusCode, meta.Header, body), nil
+}
+
+func newReplayResponse(req *http.Request, statusCode int, header http.Header, body []byte) *http.Response {
+	header = header.Clone()
+	if header == nil {
+		header = http.Header{}
+	}
+	header.Del("Content-Encoding") // the body was stored already decoded
+	header.Set("Content-Length", fmt.Sprintf("%d", len(body)))
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", statusCode, http.StatusText(statusCode)),
+		StatusCode:    statusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}
+}
+
+// isHTTPEndpoint is true for the endpoints whose requests can be recorded
+func isHTTPEndpoint(endpoint string) bool {
+	return strings.HasPrefix(endpoint, "http://") || strings.HasPrefix(endpoint, "https://")
+}
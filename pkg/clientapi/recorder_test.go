@@ -0,0 +1,132 @@
+package clientapi
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeRecorderJSON(w http.ResponseWriter, data any) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"execution_optimistic": false,
+		"finalized":            true,
+		"data":                 data,
+	})
+}
+
+// recorderBeacon serves the beacon API used by the test, with a 404 for slot 11
+func recorderBeacon(t *testing.T) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /eth/v1/node/syncing", func(w http.ResponseWriter, r *http.Request) {
+		writeRecorderJSON(w, map[string]any{
+			"head_slot":     "10",
+			"sync_distance": "0",
+			"is_syncing":    false,
+			"is_optimistic": false,
+			"el_offline":    false,
+		})
+	})
+	mux.HandleFunc("GET /eth/v1/node/version", func(w http.ResponseWriter, r *http.Request) {
+		writeRecorderJSON(w, map[string]any{"version": "goteth-test/v0.0.0"})
+	})
+	mux.HandleFunc("GET /eth/v1/beacon/states/{state_id}/root", func(w http.ResponseWriter, r *http.Request) {
+		if r.PathValue("state_id") == "11" {
+			http.Error(w, `{"code":404,"message":"state not found"}`, http.StatusNotFound)
+			return
+		}
+		writeRecorderJSON(w, map[string]any{"root": fmt.Sprintf("%#x", phase0.Root{0x01, 0x02})})
+	})
+	mux.HandleFunc("GET /eth/v1/beacon/rewards/blocks/{block_id}", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"execution_optimistic":false,"finalized":true,"data":{"proposer_index":"7","total":"100","attestations":"90","sync_aggregate":"10","proposer_slashings":"0","attester_slashings":"0"}}`))
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+// recorderExecution answers eth_chainId, any other method is not supported
+func recorderExecution(t *testing.T) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ID     json.RawMessage `json:"id"`
+			Method string          `json:"method"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		w.Header().Set("Content-Type", "application/json")
+		if req.Method != "eth_chainId" {
+			fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%s,"error":{"code":-32601,"message":"method not found"}}`, req.ID)
+			return
+		}
+		fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%s,"result":"0x1"}`, req.ID)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestRecordAndReplay(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	dir := t.TempDir()
+
+	beacon := recorderBeacon(t)
+	execution := recorderExecution(t)
+	recordCli, err := NewAPIClient(ctx, beacon.URL, 1,
+		WithELEndpoint(execution.URL),
+		WithRecordDir(dir))
+	require.NoError(t, err)
+
+	root, err := recordCli.RequestStateRoot(10)
+	require.NoError(t, err)
+	_, err = recordCli.RequestStateRoot(11)
+	require.Error(t, err)
+	rewards, err := recordCli.RequestBlockRewards(10)
+	require.NoError(t, err)
+	chainID, err := recordCli.ELApi.ChainID(ctx)
+	require.NoError(t, err)
+
+	states, err := filepath.Glob(filepath.Join(dir, beaconRecordDir, "*"+recordBodyExt))
+	require.NoError(t, err)
+	assert.NotEmpty(t, states)
+	_, err = os.Stat(filepath.Join(dir, executionRecordDir))
+	require.NoError(t, err)
+
+	// the nodes are gone, everything has to come from the recording
+	beacon.Close()
+	execution.Close()
+
+	replayCli, err := NewAPIClient(ctx, ReplayScheme+"://"+dir, 1,
+		WithELEndpoint(execution.URL))
+	require.NoError(t, err)
+
+	replayedRoot, err := replayCli.RequestStateRoot(10)
+	require.NoError(t, err)
+	assert.Equal(t, root, replayedRoot)
+
+	_, err = replayCli.RequestStateRoot(11)
+	require.ErrorContains(t, err, "404")
+
+	_, err = replayCli.RequestStateRoot(12)
+	require.ErrorContains(t, err, "503")
+
+	replayedRewards, err := replayCli.RequestBlockRewards(10)
+	require.NoError(t, err)
+	assert.Equal(t, rewards, replayedRewards)
+
+	// an unrecorded call moves the JSON-RPC id, the recorded response must follow it
+	_, err = replayCli.ELApi.BlockNumber(ctx)
+	require.Error(t, err)
+	replayedChainID, err := replayCli.ELApi.ChainID(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, chainID, replayedChainID)
+}
@@ -6,22 +6,40 @@ import (
 	"io"
 	"net/http"
 
+	"github.com/attestantio/go-eth2-client/api"
+	eth2http "github.com/attestantio/go-eth2-client/http"
 	"github.com/attestantio/go-eth2-client/spec/phase0"
 	"github.com/migalabs/goteth/pkg/spec"
 )
 
 func (s *APIClient) RequestBlockRewards(slot phase0.Slot) (spec.BlockRewards, error) {
 
-	uri := s.Api.Address() + "/eth/v1/beacon/rewards/blocks/" + fmt.Sprintf("%d", slot)
-	resp, err := http.Get(uri)
+	endpoint := "/eth/v1/beacon/rewards/blocks/" + fmt.Sprintf("%d", slot)
+	var (
+		resp *http.Response
+		body []byte
+	)
+	err := s.request(func(bn *eth2http.Service) error {
+		var err error
+		resp, err = s.httpCli.Get(bn.Address() + endpoint)
+		if err != nil {
+			return fmt.Errorf("block rewards request failed for slot %d: %w", slot, err)
+		}
+		defer resp.Body.Close()
+
+		body, err = io.ReadAll(resp.Body)
+		if err != nil {
+			return fmt.Errorf("block rewards read body failed for slot %d: %w", slot, err)
+		}
+		if resp.StatusCode >= http.StatusInternalServerError {
+			// the next node is tried
+			return fmt.Errorf("block rewards API returned status %d for slot %d: %w", resp.StatusCode, slot,
+				&api.Error{Method: http.MethodGet, Endpoint: endpoint, StatusCode: resp.StatusCode, Data: body})
+		}
+		return nil
+	})
 	if err != nil {
-		return spec.BlockRewards{}, fmt.Errorf("block rewards request failed for slot %d: %w", slot, err)
-	}
-	defer resp.Body.Close()
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return spec.BlockRewards{}, fmt.Errorf("block rewards read body failed for slot %d: %w", slot, err)
+		return spec.BlockRewards{}, err
 	}
 
 	if resp.StatusCode != http.StatusOK {
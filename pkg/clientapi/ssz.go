@@ -0,0 +1,275 @@
+package clientapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	nethttp "net/http"
+	"sync"
+	"time"
+
+	"github.com/attestantio/go-eth2-client/api"
+	"github.com/attestantio/go-eth2-client/http"
+	"github.com/attestantio/go-eth2-client/spec"
+	"github.com/attestantio/go-eth2-client/spec/altair"
+	"github.com/attestantio/go-eth2-client/spec/bellatrix"
+	"github.com/attestantio/go-eth2-client/spec/capella"
+	"github.com/attestantio/go-eth2-client/spec/deneb"
+	"github.com/attestantio/go-eth2-client/spec/electra"
+	"github.com/attestantio/go-eth2-client/spec/fulu"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+)
+
+// States and blocks are downloaded outside go-eth2-client, which reads every response
+// into a growing buffer: the body is streamed into a buffer sized from its Content-Length
+// and reused across downloads, then decoded for its fork, as SSZ or as JSON if the node
+// answered JSON.
+// The decoded objects copy the bytes they keep, so the buffer can be reused right away.
+
+var (
+	// sszAccept is the Accept header of go-eth2-client, the recordings are replayed by both
+	sszAccept       = "application/octet-stream;q=1,application/json;q=0.9"
+	sszContentType  = "application/octet-stream"
+	jsonContentType = "application/json"
+
+	// one pool per object, so the state buffers are not handed to the blocks
+	stateBuffers = sync.Pool{New: func() any { return new(bytes.Buffer) }}
+	blockBuffers = sync.Pool{New: func() any { return new(bytes.Buffer) }}
+)
+
+// beaconObject is implemented by every fork specific block and state
+type beaconObject interface {
+	UnmarshalSSZ(buf []byte) error
+	UnmarshalJSON(input []byte) error
+}
+
+// requestBeaconState reads the state at the slot with the root from the disk cache, or downloads it.
+// It also returns the disk cache entry of the state downloaded as SSZ, to be stored
+// once its root is confirmed
+func (s *APIClient) requestBeaconState(slot phase0.Slot, stateID string, root phase0.Root) (*spec.VersionedBeaconState, []byte, error) {
+	var state *spec.VersionedBeaconState
+	newObject := func(version spec.DataVersion) beaconObject {
+		var obj beaconObject
+		state, obj = newState(version)
+		return obj
+	}
+
+	if version, data, ok := s.diskCache.get(stateObject, slot, root); ok {
+		err := decodeSSZ(stateObject, version, data, newObject)
+		if err == nil {
+			log.Debugf("state %s read from the disk cache", stateID)
+			return state, nil, nil
+		}
+		log.Warnf("could not decode state %#x from the disk cache, downloading it: %s", root, err)
+	}
+
+	entry, err := s.download(stateObject, &stateBuffers, "/eth/v2/debug/beacon/states/"+stateID, newObject)
+	if err != nil {
+		return nil, nil, err
+	}
+	return state, entry, nil
+}
+
+// requestSignedBeaconBlock reads the block at the slot with the root from the disk cache, or
+// downloads it. It also returns the disk cache entry of the block downloaded as SSZ
+func (s *APIClient) requestSignedBeaconBlock(slot phase0.Slot, blockID string, root phase0.Root) (*spec.VersionedSignedBeaconBlock, []byte, error) {
+	var block *spec.VersionedSignedBeaconBlock
+	newObject := func(version spec.DataVersion) beaconObject {
+		var obj beaconObject
+		block, obj = newBlock(version)
+		return obj
+	}
+
+	if version, data, ok := s.diskCache.get(blockObject, slot, root); ok {
+		err := decodeSSZ(blockObject, version, data, newObject)
+		if err == nil {
+			log.Debugf("block %s read from the disk cache", blockID)
+			return block, nil, nil
+		}
+		log.Warnf("could not decode block %#x from the disk cache, downloading it: %s", root, err)
+	}
+
+	entry, err := s.download(blockObject, &blockBuffers, "/eth/v2/beacon/blocks/"+blockID, newObject)
+	if err != nil {
+		return nil, nil, err
+	}
+	return block, entry, nil
+}
+
+// decodeSSZ decodes the SSZ of the object into the fork specific object of newObject
+func decodeSSZ(object string, version spec.DataVersion, data []byte, newObject func(version spec.DataVersion) beaconObject) error {
+	obj := newObject(version)
+	if obj == nil {
+		return fmt.Errorf("unsupported %s version %s", object, version)
+	}
+	return obj.UnmarshalSSZ(data)
+}
+
+// download requests the object at the endpoint from the beacon nodes, as SSZ unless
+// they do not serve it, and decodes it into the fork specific object of newObject.
+// A node answering JSON is decoded from the same response. It returns the disk cache
+// entry of the object downloaded as SSZ, nil without disk cache or answered as JSON.
+// Errors answered by the node are returned as *api.Error, as go-eth2-client does
+func (s *APIClient) download(
+	object string,
+	buffers *sync.Pool,
+	endpoint string,
+	newObject func(version spec.DataVersion) beaconObject) ([]byte, error) {
+
+	buf := buffers.Get().(*bytes.Buffer)
+	defer func() {
+		buf.Reset()
+		buffers.Put(buf)
+	}()
+
+	accept := sszAccept
+	if !s.caps.SSZ {
+		accept = jsonContentType // as go-eth2-client enforcing JSON
+	}
+	var (
+		isSSZ         bool
+		versionHeader string
+	)
+	startTime := time.Now()
+	err := s.request(func(bn *http.Service) error {
+		buf.Reset()
+
+		ctx, cancel := context.WithTimeout(s.ctx, QueryTimeout)
+		defer cancel()
+		req, err := nethttp.NewRequestWithContext(ctx, nethttp.MethodGet, bn.Address()+endpoint, nil)
+		if err != nil {
+			return fmt.Errorf("could not create %s request: %w", object, err)
+		}
+		req.Header.Set("Accept", accept)
+
+		resp, err := s.httpCli.Do(req)
+		if err != nil {
+			return fmt.Errorf("%s request failed: %w", object, err)
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != nethttp.StatusOK {
+			data, _ := io.ReadAll(resp.Body)
+			return &api.Error{Method: nethttp.MethodGet, Endpoint: endpoint, StatusCode: resp.StatusCode, Data: data}
+		}
+		contentType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+		isSSZ = contentType == sszContentType
+		versionHeader = resp.Header.Get("Eth-Consensus-Version")
+
+		if resp.ContentLength > 0 {
+			// room for the whole body, and for the read that finds its end
+			buf.Grow(int(resp.ContentLength) + bytes.MinRead)
+		}
+		if _, err := buf.ReadFrom(resp.Body); err != nil {
+			return fmt.Errorf("could not read %s: %w", object, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	downloadTime := time.Since(startTime)
+
+	startTime = time.Now()
+	data := buf.Bytes()
+	encoding := "ssz"
+	if !isSSZ {
+		encoding = "json"
+		var resp struct {
+			Version string          `json:"version"`
+			Data    json.RawMessage `json:"data"`
+		}
+		if err := json.Unmarshal(data, &resp); err != nil {
+			return nil, fmt.Errorf("could not parse %s: %w", object, err)
+		}
+		if versionHeader == "" {
+			versionHeader = resp.Version
+		}
+		data = resp.Data
+	}
+	version, err := spec.DataVersionFromString(versionHeader)
+	if err != nil {
+		return nil, fmt.Errorf("could not read the fork of the %s: %w", object, err)
+	}
+	obj := newObject(version)
+	if obj == nil {
+		return nil, fmt.Errorf("unsupported %s version %s", object, version)
+	}
+	if isSSZ {
+		err = obj.UnmarshalSSZ(data)
+	} else {
+		err = obj.UnmarshalJSON(data)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not decode %s %s: %w", version, object, err)
+	}
+	decodeTime := time.Since(startTime)
+	s.downloadMetrics.recordDownload(object, version.String(), encoding, buf.Len(), downloadTime, decodeTime)
+	log.Debugf("%s %s: %d bytes of %s downloaded in %s, decoded in %s", version, object, buf.Len(), encoding, downloadTime, decodeTime)
+
+	if !isSSZ {
+		return nil, nil
+	}
+	return s.diskCache.encode(version, data), nil
+}
+
+// newBlock returns an empty versioned block and the fork specific block to decode into
+func newBlock(version spec.DataVersion) (*spec.VersionedSignedBeaconBlock, beaconObject) {
+	block := &spec.VersionedSignedBeaconBlock{Version: version}
+	switch version {
+	case spec.DataVersionPhase0:
+		block.Phase0 = &phase0.SignedBeaconBlock{}
+		return block, block.Phase0
+	case spec.DataVersionAltair:
+		block.Altair = &altair.SignedBeaconBlock{}
+		return block, block.Altair
+	case spec.DataVersionBellatrix:
+		block.Bellatrix = &bellatrix.SignedBeaconBlock{}
+		return block, block.Bellatrix
+	case spec.DataVersionCapella:
+		block.Capella = &capella.SignedBeaconBlock{}
+		return block, block.Capella
+	case spec.DataVersionDeneb:
+		block.Deneb = &deneb.SignedBeaconBlock{}
+		return block, block.Deneb
+	case spec.DataVersionElectra:
+		block.Electra = &electra.SignedBeaconBlock{}
+		return block, block.Electra
+	case spec.DataVersionFulu:
+		block.Fulu = &electra.SignedBeaconBlock{}
+		return block, block.Fulu
+	}
+	return block, nil
+}
+
+// newState returns an empty versioned state and the fork specific state to decode into
+func newState(version spec.DataVersion) (*spec.VersionedBeaconState, beaconObject) {
+	state := &spec.VersionedBeaconState{Version: version}
+	switch version {
+	case spec.DataVersionPhase0:
+		state.Phase0 = &phase0.BeaconState{}
+		return state, state.Phase0
+	case spec.DataVersionAltair:
+		state.Altair = &altair.BeaconState{}
+		return state, state.Altair
+	case spec.DataVersionBellatrix:
+		state.Bellatrix = &bellatrix.BeaconState{}
+		return state, state.Bellatrix
+	case spec.DataVersionCapella:
+		state.Capella = &capella.BeaconState{}
+		return state, state.Capella
+	case spec.DataVersionDeneb:
+		state.Deneb = &deneb.BeaconState{}
+		return state, state.Deneb
+	case spec.DataVersionElectra:
+		state.Electra = &electra.BeaconState{}
+		return state, state.Electra
+	case spec.DataVersionFulu:
+		state.Fulu = &fulu.BeaconState{}
+		return state, state.Fulu
+	}
+	return state, nil
+}
@@ -0,0 +1,118 @@
+package clientapi
+
+import (
+	"context"
+	"testing"
+
+	"github.com/attestantio/go-eth2-client/spec"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/migalabs/goteth/pkg/testutil/mockbeacon"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestPhase0State(slot phase0.Slot) *phase0.BeaconState {
+	return &phase0.BeaconState{
+		Slot:                        slot,
+		Fork:                        &phase0.Fork{},
+		LatestBlockHeader:           &phase0.BeaconBlockHeader{},
+		BlockRoots:                  make([]phase0.Root, 8192),
+		StateRoots:                  make([]phase0.Root, 8192),
+		ETH1Data:                    &phase0.ETH1Data{BlockHash: make([]byte, 32)},
+		Validators:                  []*phase0.Validator{{PublicKey: phase0.BLSPubKey{1}, WithdrawalCredentials: make([]byte, 32), EffectiveBalance: 32_000_000_000}},
+		Balances:                    []phase0.Gwei{32_000_000_001},
+		RANDAOMixes:                 make([]phase0.Root, 65536),
+		Slashings:                   make([]phase0.Gwei, 8192),
+		JustificationBits:           []byte{0},
+		PreviousJustifiedCheckpoint: &phase0.Checkpoint{},
+		CurrentJustifiedCheckpoint:  &phase0.Checkpoint{},
+		FinalizedCheckpoint:         &phase0.Checkpoint{},
+	}
+}
+
+// newSSZNode starts a beacon node serving the phase0 state and block at slot 10
+func newSSZNode(t *testing.T) *mockbeacon.Server {
+	node := mockbeacon.New()
+	t.Cleanup(node.Close)
+	block := &phase0.SignedBeaconBlock{
+		Message: &phase0.BeaconBlock{Slot: 10, ProposerIndex: 7, Body: &phase0.BeaconBlockBody{
+			ETH1Data:          &phase0.ETH1Data{BlockHash: make([]byte, 32)},
+			ProposerSlashings: []*phase0.ProposerSlashing{},
+			AttesterSlashings: []*phase0.AttesterSlashing{},
+			Attestations:      []*phase0.Attestation{},
+			Deposits:          []*phase0.Deposit{},
+			VoluntaryExits:    []*phase0.SignedVoluntaryExit{},
+		}},
+	}
+	require.NoError(t, node.AddBlock(&spec.VersionedSignedBeaconBlock{Version: spec.DataVersionPhase0, Phase0: block}))
+	require.NoError(t, node.AddState(&spec.VersionedBeaconState{Version: spec.DataVersionPhase0, Phase0: newTestPhase0State(10)}))
+	node.SetStateRoot(10, phase0.Root{31: 1})
+	return node
+}
+
+// downloads returns the states and blocks served by the node, the capabilities probe included
+func downloads(node *mockbeacon.Server) int {
+	return node.Requests(mockbeacon.RouteBlock) + node.Requests(mockbeacon.RouteState)
+}
+
+func TestSSZDownloads(t *testing.T) {
+	server := newSSZNode(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	cli, err := NewAPIClient(ctx, server.URL(), 1)
+	require.NoError(t, err)
+	require.True(t, cli.Capabilities().SSZ)
+
+	stateBytes := testutil.ToFloat64(beaconDownloadBytes.WithLabelValues("state", "phase0", "ssz"))
+	state, _, err := cli.requestBeaconState(10, "10", phase0.Root{})
+	require.NoError(t, err)
+	require.Equal(t, spec.DataVersionPhase0, state.Version)
+	assert.Equal(t, phase0.Slot(10), state.Phase0.Slot)
+	assert.Equal(t, []phase0.Gwei{32_000_000_001}, state.Phase0.Balances)
+	assert.Equal(t, phase0.BLSPubKey{1}, state.Phase0.Validators[0].PublicKey)
+
+	size := newTestPhase0State(10).SizeSSZ()
+	assert.Equal(t, stateBytes+float64(size), testutil.ToFloat64(beaconDownloadBytes.WithLabelValues("state", "phase0", "ssz")))
+
+	// the pooled buffer is reused, the decoded state keeps its own copy
+	again, _, err := cli.requestBeaconState(10, "10", phase0.Root{})
+	require.NoError(t, err)
+	assert.Equal(t, state.Phase0.Validators, again.Phase0.Validators)
+
+	block, _, err := cli.requestSignedBeaconBlock(10, "10", phase0.Root{})
+	require.NoError(t, err)
+	assert.Equal(t, phase0.ValidatorIndex(7), block.Phase0.Message.ProposerIndex)
+
+	// a missing block keeps the error of go-eth2-client, so it is detected as missing
+	_, _, err = cli.requestSignedBeaconBlock(11, "11", phase0.Root{})
+	require.Error(t, err)
+	assert.True(t, response404(err.Error()))
+}
+
+func TestSSZFallbackToJSON(t *testing.T) {
+	server := newSSZNode(t)
+	server.SetJSONOnly(true)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	cli, err := NewAPIClient(ctx, server.URL(), 1)
+	require.NoError(t, err)
+	served := downloads(server)
+
+	// the node does not serve SSZ, the JSON answered is decoded instead
+	stateBytes := testutil.ToFloat64(beaconDownloadBytes.WithLabelValues("state", "phase0", "json"))
+	state, entry, err := cli.requestBeaconState(10, "10", phase0.Root{})
+	require.NoError(t, err)
+	assert.Equal(t, phase0.Slot(10), state.Phase0.Slot)
+	assert.Equal(t, []phase0.Gwei{32_000_000_001}, state.Phase0.Balances)
+	assert.Equal(t, phase0.BLSPubKey{1}, state.Phase0.Validators[0].PublicKey)
+	assert.Nil(t, entry)
+	assert.Greater(t, testutil.ToFloat64(beaconDownloadBytes.WithLabelValues("state", "phase0", "json")), stateBytes)
+
+	block, _, err := cli.requestSignedBeaconBlock(10, "10", phase0.Root{})
+	require.NoError(t, err)
+	assert.Equal(t, phase0.ValidatorIndex(7), block.Phase0.Message.ProposerIndex)
+
+	// downloaded once each
+	assert.Equal(t, served+2, downloads(server))
+}
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/attestantio/go-eth2-client/api"
+	"github.com/attestantio/go-eth2-client/http"
 	"github.com/attestantio/go-eth2-client/spec"
 	"github.com/attestantio/go-eth2-client/spec/phase0"
 	local_spec "github.com/migalabs/goteth/pkg/spec"
@@ -36,15 +37,24 @@ func (s *APIClient) requestBeaconStateWithID(slot phase0.Slot, stateID string, k
 
 	startTime := time.Now()
 
+	// the state is looked up in the disk cache by its root, only requested if a state
+	// at the slot is cached
+	var zeroRoot phase0.Root
+	cacheRoot := knownRoot
+	if cacheRoot == zeroRoot && s.diskCache.holds(stateObject, slot) {
+		cacheRoot, _ = s.RequestStateRoot(slot) // zero if unknown, the state is downloaded
+	}
+
 	err := errors.New("first attempt")
-	var newState *api.Response[*spec.VersionedBeaconState]
+	var (
+		newState   *spec.VersionedBeaconState
+		cacheEntry []byte
+	)
 
 	attempts := 0
 	for err != nil && attempts < s.maxRetries {
 
-		newState, err = s.Api.BeaconState(s.ctx, &api.BeaconStateOpts{
-			State: stateID,
-		})
+		newState, cacheEntry, err = s.requestBeaconState(slot, stateID, cacheRoot)
 
 		if errors.Is(err, context.DeadlineExceeded) {
 			ticker := time.NewTicker(utils.RoutineFlushTimeout)
@@ -61,12 +71,11 @@ func (s *APIClient) requestBeaconStateWithID(slot phase0.Slot, stateID string, k
 	}
 
 	log.Infof("state at slot %d downloaded in %f seconds (id=%s)", slot, time.Since(startTime).Seconds(), stateID)
-	resultState, err := local_spec.GetCustomState(*newState.Data, s.NewEpochData(slot))
+	resultState, err := local_spec.GetCustomState(*newState, s.NewEpochData(slot))
 	if err != nil {
 		return nil, fmt.Errorf("unable to open beacon state, closing requester routine. %s", err.Error())
 	}
 
-	var zeroRoot phase0.Root
 	if knownRoot != zeroRoot {
 		resultState.StateRoot = knownRoot
 	} else {
@@ -76,13 +85,18 @@ func (s *APIClient) requestBeaconStateWithID(slot phase0.Slot, stateID string, k
 		}
 		resultState.StateRoot = stateRoot
 	}
+	// a state downloaded by slot is cached under the root requested after it, unless the
+	// slot was found with another root before (reorged meanwhile)
+	if cacheRoot == zeroRoot || resultState.StateRoot == cacheRoot {
+		s.diskCache.put(stateObject, slot, resultState.StateRoot, cacheEntry)
+	}
 
 	return &resultState, nil
 }
 
 func (s *APIClient) RequestStateRoot(slot phase0.Slot) (phase0.Root, error) {
 
-	root, err := s.Api.BeaconStateRoot(s.ctx, &api.BeaconStateRootOpts{
+	root, err := requestBeacon(s, (*http.Service).BeaconStateRoot, &api.BeaconStateRootOpts{
 		State: fmt.Sprintf("%d", slot),
 	})
 	if err != nil {
@@ -97,7 +111,7 @@ func (s *APIClient) RequestStateRoot(slot phase0.Slot) (phase0.Root, error) {
 // Usually, it is the slot before the finalized one
 func (s *APIClient) GetFinalizedEndSlotStateRoot() (phase0.Slot, phase0.Root, error) {
 
-	currentFinalized, err := s.Api.Finality(s.ctx, &api.FinalityOpts{
+	currentFinalized, err := requestBeacon(s, (*http.Service).Finality, &api.FinalityOpts{
 		State: "head",
 	})
 
@@ -105,7 +119,7 @@ func (s *APIClient) GetFinalizedEndSlotStateRoot() (phase0.Slot, phase0.Root, er
 		return 0, phase0.Root{}, fmt.Errorf("could not determine the current finalized checkpoint: %w", err)
 	}
 
-	finalizedSlot := phase0.Slot(currentFinalized.Data.Finalized.Epoch*local_spec.SlotsPerEpoch - 1)
+	finalizedSlot := local_spec.ComputeStartSlotAtEpoch(currentFinalized.Data.Finalized.Epoch) - 1
 
 	root, err := s.RequestStateRoot(finalizedSlot)
 	if err != nil {
@@ -1,6 +1,7 @@
 package clientapi
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"strings"
@@ -8,15 +9,22 @@ import (
 
 	"github.com/attestantio/go-eth2-client/spec/bellatrix"
 	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
 	"github.com/ethereum/go-ethereum/rpc"
 	"github.com/migalabs/goteth/pkg/spec"
 	"github.com/migalabs/goteth/pkg/utils"
 )
 
+var (
+	// receipts requested per batch when the nodes refuse eth_getBlockReceipts
+	receiptsBatchSize = 100
+)
+
 func (client *APIClient) GetBlockReceipts(block spec.AgnosticBlock) ([]*types.Receipt, error) {
-	if client.ELApi == nil {
+	if len(client.elNodes) == 0 {
 		return nil, errors.New("execution endpoint not configured")
 	}
 
@@ -26,7 +34,6 @@ func (client *APIClient) GetBlockReceipts(block spec.AgnosticBlock) ([]*types.Re
 	}
 
 	blockHash := common.BytesToHash(block.ExecutionPayload.BlockHash[:])
-	blockNumber := rpc.BlockNumber(block.ExecutionPayload.BlockNumber)
 	routineKey := fmt.Sprintf("receipts=%s:%d", blockHash.Hex(), block.ExecutionPayload.BlockNumber)
 	client.txBook.Acquire(routineKey)
 	defer client.txBook.FreePage(routineKey)
@@ -36,7 +43,7 @@ func (client *APIClient) GetBlockReceipts(block spec.AgnosticBlock) ([]*types.Re
 	)
 
 	for attempt := 1; attempt <= maxAttempts; attempt++ {
-		receipts, err = client.requestBlockReceipts(blockHash, blockNumber)
+		receipts, err = client.requestBlockReceipts(block)
 		if err == nil {
 			return receipts, nil
 		}
@@ -74,26 +81,30 @@ func (s *APIClient) GetTransactionReceipt(iTx bellatrix.Transaction,
 		return nil, err
 	}
 
+	if len(s.elNodes) == 0 {
+		log.Warn("EL endpoint not provided. The gas price read from the CL may not be the effective gas price.")
+		return nil, nil
+	}
+
 	var receipt *types.Receipt
 	err := errors.New("first attempt")
+	attempts := 0
+	for err != nil && attempts < s.maxRetries {
+		err = s.requestExecution("eth_getTransactionReceipt", func(el *ethclient.Client) (err error) {
+			receipt, err = el.TransactionReceipt(s.ctx, parsedTx.Hash())
+			return err
+		})
 
-	if s.ELApi == nil {
-		log.Warn("EL endpoint not provided. The gas price read from the CL may not be the effective gas price.")
-		attempts := 0
-		for err != nil && attempts < s.maxRetries {
-			receipt, err = s.ELApi.TransactionReceipt(s.ctx, parsedTx.Hash())
-
-			if err != nil {
-				ticker := time.NewTicker(utils.RoutineFlushTimeout)
-				log.Warnf("retrying transaction request: %s", parsedTx.Hash().String())
-				<-ticker.C
-			}
-			attempts += 1
-
-		}
 		if err != nil {
-			log.Errorf("could not retrieve the receipt for tx %s: %s", parsedTx.Hash().String(), err)
+			ticker := time.NewTicker(utils.RoutineFlushTimeout)
+			log.Warnf("retrying transaction request: %s", parsedTx.Hash().String())
+			<-ticker.C
 		}
+		attempts += 1
+
+	}
+	if err != nil {
+		log.Errorf("could not retrieve the receipt for tx %s: %s", parsedTx.Hash().String(), err)
 	}
 
 	return receipt, nil
@@ -127,7 +138,71 @@ func classifyReceiptError(err error) string {
 	}
 }
 
-func (client *APIClient) requestBlockReceipts(blockHash common.Hash, blockNumber rpc.BlockNumber) ([]*types.Receipt, error) {
+// requestBlockReceipts requests the receipts of the block with eth_getBlockReceipts,
+// falling back to eth_getTransactionReceipt per transaction, sent in batches, when the
+// nodes refuse it (method not supported, response too large...)
+func (client *APIClient) requestBlockReceipts(block spec.AgnosticBlock) ([]*types.Receipt, error) {
+	blockHash := common.BytesToHash(block.ExecutionPayload.BlockHash[:])
+	blockNumber := rpc.BlockNumber(block.ExecutionPayload.BlockNumber)
+
+	var receipts []*types.Receipt
+	err := client.requestExecution("eth_getBlockReceipts", func(el *ethclient.Client) (err error) {
+		receipts, err = blockReceipts(client.ctx, el, blockHash, blockNumber)
+		return err
+	})
+	var rpcErr rpc.Error
+	if err == nil || !errors.As(err, &rpcErr) {
+		return receipts, err
+	}
+
+	log.Warnf("block %d receipts could not be requested at once (%s), requesting them per transaction", blockNumber, err)
+	txHashes := make([]common.Hash, 0, len(block.ExecutionPayload.Transactions))
+	for _, tx := range block.ExecutionPayload.Transactions {
+		var parsedTx = &types.Transaction{}
+		if err := parsedTx.UnmarshalBinary(tx); err != nil {
+			return nil, fmt.Errorf("unable to unmarshal transaction of block %d: %w", blockNumber, err)
+		}
+		txHashes = append(txHashes, parsedTx.Hash())
+	}
+
+	// the receipts are requested in batches, each one a single request to the node
+	receipts = make([]*types.Receipt, 0, len(txHashes))
+	for start := 0; start < len(txHashes); start += receiptsBatchSize {
+		batchHashes := txHashes[start:min(start+receiptsBatchSize, len(txHashes))]
+		batch := make([]rpc.BatchElem, len(batchHashes))
+		batchReceipts := make([]*types.Receipt, len(batchHashes))
+		for i, txHash := range batchHashes {
+			batch[i] = rpc.BatchElem{
+				Method: "eth_getTransactionReceipt",
+				Args:   []any{txHash},
+				Result: &batchReceipts[i],
+			}
+		}
+		err := client.requestExecution("eth_getTransactionReceipt", func(el *ethclient.Client) error {
+			if err := el.Client().BatchCallContext(client.ctx, batch); err != nil {
+				return err
+			}
+			for i, elem := range batch {
+				if elem.Error != nil {
+					return fmt.Errorf("unable to retrieve the receipt of tx %s: %w", batchHashes[i].String(), elem.Error)
+				}
+				if batchReceipts[i] == nil {
+					return fmt.Errorf("unable to retrieve the receipt of tx %s: %w", batchHashes[i].String(), ethereum.NotFound)
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		receipts = append(receipts, batchReceipts...)
+	}
+	return receipts, nil
+}
+
+// blockReceipts requests the receipts of the block to the node by hash, and by number
+// if the hash is not known
+func blockReceipts(ctx context.Context, el *ethclient.Client, blockHash common.Hash, blockNumber rpc.BlockNumber) ([]*types.Receipt, error) {
 	var selector rpc.BlockNumberOrHash
 	if blockHash != (common.Hash{}) {
 		selector = rpc.BlockNumberOrHashWithHash(blockHash, false)
@@ -135,10 +210,10 @@ func (client *APIClient) requestBlockReceipts(blockHash common.Hash, blockNumber
 		selector = rpc.BlockNumberOrHashWithNumber(blockNumber)
 	}
 
-	receipts, err := client.ELApi.BlockReceipts(client.ctx, selector)
+	receipts, err := el.BlockReceipts(ctx, selector)
 	if err != nil && blockHash != (common.Hash{}) {
 		log.Debugf("receipt request by hash failed (%s), retrying via block number %d", blockHash.Hex(), blockNumber)
-		return client.ELApi.BlockReceipts(client.ctx, rpc.BlockNumberOrHashWithNumber(blockNumber))
+		return el.BlockReceipts(ctx, rpc.BlockNumberOrHashWithNumber(blockNumber))
 	}
 	return receipts, err
 }
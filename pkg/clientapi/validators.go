@@ -0,0 +1,34 @@
+package clientapi
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/attestantio/go-eth2-client/api"
+	"github.com/attestantio/go-eth2-client/http"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+)
+
+// RequestValidators returns the validators at the given state (slot, root, "head", "finalized"...),
+// the position in the slice is the validator index
+func (s *APIClient) RequestValidators(stateID string) ([]*phase0.Validator, error) {
+	startTime := time.Now()
+
+	resp, err := requestBeacon(s, (*http.Service).Validators, &api.ValidatorsOpts{
+		State: stateID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to retrieve validators at state %s: %w", stateID, err)
+	}
+
+	validators := make([]*phase0.Validator, len(resp.Data))
+	for valIdx, validator := range resp.Data {
+		if int(valIdx) >= len(validators) {
+			return nil, fmt.Errorf("validator index %d out of range at state %s", valIdx, stateID)
+		}
+		validators[valIdx] = validator.Validator
+	}
+
+	log.Infof("%d validators at state %s downloaded in %f seconds", len(validators), stateID, time.Since(startTime).Seconds())
+	return validators, nil
+}
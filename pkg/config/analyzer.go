@@ -20,6 +20,19 @@ type AnalyzerConfig struct {
 	PrometheusPort           int         `json:"prometheus-port"`
 	MaxRequestRetries        int         `json:"max-request-retries"`
 	BeaconContractAddress    string      `json:"beacon-contract-address"`
+	RecordDir                string      `json:"record-dir"`
+	ValidatorsFile           string      `json:"validators-file"`
+	ChainSpecFile            string      `json:"chain-spec-file"`
+	StreamPort               int         `json:"stream-port"`
+	PublishUrl               string      `json:"publish-url"`
+	AlertsFile               string      `json:"alerts-file"`
+	DryRun                   bool        `json:"dry-run"`
+	BackfillChunks           int         `json:"backfill-chunks"`
+	StateEndpoints           string      `json:"state-endpoints"`
+	MaxHeadDistance          int         `json:"max-head-distance"`
+	BnCapabilities           string      `json:"bn-capabilities"`
+	DiskCacheDir             string      `json:"disk-cache-dir"`
+	DiskCacheSize            int         `json:"disk-cache-size"`
 }
 
 // TODO: read from config-file
@@ -40,6 +53,19 @@ func NewAnalyzerConfig() *AnalyzerConfig {
 		PrometheusPort:           DefaultPrometheusPort,
 		MaxRequestRetries:        DefaultMaxRequestRetries,
 		BeaconContractAddress:    DefaultBeaconContractAddress,
+		RecordDir:                DefaultRecordDir,
+		ValidatorsFile:           DefaultValidatorsFile,
+		ChainSpecFile:            DefaultChainSpecFile,
+		StreamPort:               DefaultStreamPort,
+		PublishUrl:               DefaultPublishUrl,
+		AlertsFile:               DefaultAlertsFile,
+		DryRun:                   DefaultDryRun,
+		BackfillChunks:           DefaultBackfillChunks,
+		StateEndpoints:           DefaultStateEndpoints,
+		MaxHeadDistance:          DefaultMaxHeadDistance,
+		BnCapabilities:           DefaultBnCapabilities,
+		DiskCacheDir:             DefaultDiskCacheDir,
+		DiskCacheSize:            DefaultDiskCacheSize,
 	}
 }
 
@@ -101,4 +127,55 @@ func (c *AnalyzerConfig) Apply(ctx *cli.Context) {
 	if ctx.IsSet("beacon-contract-address") {
 		c.BeaconContractAddress = ctx.String("beacon-contract-address")
 	}
+	// record dir
+	if ctx.IsSet("record-dir") {
+		c.RecordDir = ctx.String("record-dir")
+	}
+	// validators file
+	if ctx.IsSet("validators-file") {
+		c.ValidatorsFile = ctx.String("validators-file")
+	}
+	// chain spec file
+	if ctx.IsSet("chain-spec-file") {
+		c.ChainSpecFile = ctx.String("chain-spec-file")
+	}
+	// stream port
+	if ctx.IsSet("stream-port") {
+		c.StreamPort = ctx.Int("stream-port")
+	}
+	// publish url
+	if ctx.IsSet("publish-url") {
+		c.PublishUrl = ctx.String("publish-url")
+	}
+	// alerts file
+	if ctx.IsSet("alerts-file") {
+		c.AlertsFile = ctx.String("alerts-file")
+	}
+	// dry run
+	if ctx.IsSet("dry-run") {
+		c.DryRun = ctx.Bool("dry-run")
+	}
+	// backfill chunks
+	if ctx.IsSet("backfill-chunks") {
+		c.BackfillChunks = ctx.Int("backfill-chunks")
+	}
+	// state endpoints
+	if ctx.IsSet("state-endpoints") {
+		c.StateEndpoints = ctx.String("state-endpoints")
+	}
+	// max head distance
+	if ctx.IsSet("max-head-distance") {
+		c.MaxHeadDistance = ctx.Int("max-head-distance")
+	}
+	// beacon node capabilities
+	if ctx.IsSet("bn-capabilities") {
+		c.BnCapabilities = ctx.String("bn-capabilities")
+	}
+	// disk cache
+	if ctx.IsSet("disk-cache-dir") {
+		c.DiskCacheDir = ctx.String("disk-cache-dir")
+	}
+	if ctx.IsSet("disk-cache-size") {
+		c.DiskCacheSize = ctx.Int("disk-cache-size")
+	}
 }
@@ -16,4 +16,19 @@ var (
 	DefaultValidatorWindowEpochs    int    = 100
 	DefaultMaxRequestRetries        int    = 3
 	DefaultBeaconContractAddress    string = "mainnet"
+	DefaultRecordDir                string = ""
+	DefaultValidatorsFile           string = ""
+	DefaultChainSpecFile            string = ""
+	DefaultLabelsState              string = "finalized"
+	DefaultServePort                int    = 8080
+	DefaultStreamPort               int    = 0
+	DefaultPublishUrl               string = ""
+	DefaultAlertsFile               string = ""
+	DefaultDryRun                   bool   = false
+	DefaultBackfillChunks           int    = 1
+	DefaultStateEndpoints           string = ""
+	DefaultMaxHeadDistance          int    = 4
+	DefaultBnCapabilities           string = ""
+	DefaultDiskCacheDir             string = ""
+	DefaultDiskCacheSize            int    = 10240 // MiB
 )
@@ -0,0 +1,48 @@
+package config
+
+import (
+	cli "github.com/urfave/cli/v2"
+)
+
+type LabelsConfig struct {
+	LogLevel          string `json:"log-level"`
+	DBUrl             string `json:"db-url"`
+	BnEndpoint        string `json:"bn-endpoint"`
+	MaxRequestRetries int    `json:"max-request-retries"`
+	State             string `json:"state"`
+}
+
+func NewLabelsConfig() *LabelsConfig {
+	// Return Default values for the ethereum configuration
+	return &LabelsConfig{
+		LogLevel:          DefaultLogLevel,
+		DBUrl:             DefaultDBUrl,
+		BnEndpoint:        DefaultBnEndpoint,
+		MaxRequestRetries: DefaultMaxRequestRetries,
+		State:             DefaultLabelsState,
+	}
+}
+
+func (c *LabelsConfig) Apply(ctx *cli.Context) {
+	// apply to the existing Default configuration the set flags
+	// log level
+	if ctx.IsSet("log-level") {
+		c.LogLevel = ctx.String("log-level")
+	}
+	// db url
+	if ctx.IsSet("db-url") {
+		c.DBUrl = ctx.String("db-url")
+	}
+	// cl url
+	if ctx.IsSet("bn-endpoint") {
+		c.BnEndpoint = ctx.String("bn-endpoint")
+	}
+	// max request retries
+	if ctx.IsSet("max-request-retries") {
+		c.MaxRequestRetries = ctx.Int("max-request-retries")
+	}
+	// state to read the validators from
+	if ctx.IsSet("state") {
+		c.State = ctx.String("state")
+	}
+}
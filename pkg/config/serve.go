@@ -0,0 +1,54 @@
+package config
+
+import (
+	cli "github.com/urfave/cli/v2"
+)
+
+type ServeConfig struct {
+	LogLevel          string `json:"log-level"`
+	BnEndpoint        string `json:"bn-endpoint"`
+	MaxRequestRetries int    `json:"max-request-retries"`
+	DBUrl             string `json:"db-url"`
+	Port              int    `json:"port"`
+	ChainSpecFile     string `json:"chain-spec-file"`
+}
+
+func NewServeConfig() *ServeConfig {
+	// Return Default values for the query API configuration
+	return &ServeConfig{
+		LogLevel:          DefaultLogLevel,
+		BnEndpoint:        DefaultBnEndpoint,
+		MaxRequestRetries: DefaultMaxRequestRetries,
+		DBUrl:             DefaultDBUrl,
+		Port:              DefaultServePort,
+		ChainSpecFile:     DefaultChainSpecFile,
+	}
+}
+
+func (c *ServeConfig) Apply(ctx *cli.Context) {
+	// apply to the existing Default configuration the set flags
+	// log level
+	if ctx.IsSet("log-level") {
+		c.LogLevel = ctx.String("log-level")
+	}
+	// beacon node the chain spec is requested from
+	if ctx.IsSet("bn-endpoint") {
+		c.BnEndpoint = ctx.String("bn-endpoint")
+	}
+	// max request retries
+	if ctx.IsSet("max-request-retries") {
+		c.MaxRequestRetries = ctx.Int("max-request-retries")
+	}
+	// db url
+	if ctx.IsSet("db-url") {
+		c.DBUrl = ctx.String("db-url")
+	}
+	// port of the query API
+	if ctx.IsSet("port") {
+		c.Port = ctx.Int("port")
+	}
+	// spec of the indexed network
+	if ctx.IsSet("chain-spec-file") {
+		c.ChainSpecFile = ctx.String("chain-spec-file")
+	}
+}
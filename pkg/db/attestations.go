@@ -0,0 +1,97 @@
+package db
+
+import (
+	"github.com/ClickHouse/ch-go/proto"
+	"github.com/migalabs/goteth/pkg/spec"
+)
+
+var (
+	attestationsTable       = "t_attestations"
+	insertAttestationsQuery = `
+	INSERT INTO %s (
+		f_val_idx,
+		f_epoch,
+		f_att_slot,
+		f_committee_index,
+		f_committee_position,
+		f_included,
+		f_inclusion_slot,
+		f_inclusion_block_root,
+		f_inclusion_delay,
+		f_aggregates,
+		f_included_late)
+		VALUES`
+
+	deleteAttestationsQuery = `
+		DELETE FROM %s
+		WHERE f_epoch = $1;
+`
+)
+
+func attestationsInput(attestations []spec.ValidatorAttestation) proto.Input {
+	// one object per column
+	var (
+		f_val_idx              proto.ColUInt64
+		f_epoch                proto.ColUInt64
+		f_att_slot             proto.ColUInt64
+		f_committee_index      proto.ColUInt64
+		f_committee_position   proto.ColUInt64
+		f_included             proto.ColBool
+		f_inclusion_slot       proto.ColUInt64
+		f_inclusion_block_root proto.ColStr
+		f_inclusion_delay      proto.ColUInt64
+		f_aggregates           = new(proto.ColStr).Array()
+		f_included_late        proto.ColBool
+	)
+
+	for _, attestation := range attestations {
+		inclusionBlockRoot := ""
+		if attestation.Included {
+			inclusionBlockRoot = attestation.InclusionBlockRoot.String()
+		}
+
+		f_val_idx.Append(uint64(attestation.ValIdx))
+		f_epoch.Append(uint64(attestation.Epoch))
+		f_att_slot.Append(uint64(attestation.AttSlot))
+		f_committee_index.Append(uint64(attestation.CommitteeIndex))
+		f_committee_position.Append(attestation.CommitteePosition)
+		f_included.Append(attestation.Included)
+		f_inclusion_slot.Append(uint64(attestation.InclusionSlot))
+		f_inclusion_block_root.Append(inclusionBlockRoot)
+		f_inclusion_delay.Append(attestation.InclusionDelay)
+		f_aggregates.Append(attestation.Aggregates)
+		f_included_late.Append(attestation.IncludedLate)
+	}
+
+	return proto.Input{
+		{Name: "f_val_idx", Data: f_val_idx},
+		{Name: "f_epoch", Data: f_epoch},
+		{Name: "f_att_slot", Data: f_att_slot},
+		{Name: "f_committee_index", Data: f_committee_index},
+		{Name: "f_committee_position", Data: f_committee_position},
+		{Name: "f_included", Data: f_included},
+		{Name: "f_inclusion_slot", Data: f_inclusion_slot},
+		{Name: "f_inclusion_block_root", Data: f_inclusion_block_root},
+		{Name: "f_inclusion_delay", Data: f_inclusion_delay},
+		{Name: "f_aggregates", Data: f_aggregates},
+		{Name: "f_included_late", Data: f_included_late},
+	}
+}
+
+func (p *DBService) PersistValidatorAttestations(data []spec.ValidatorAttestation) error {
+	persistObj := PersistableObject[spec.ValidatorAttestation]{
+		input: attestationsInput,
+		table: attestationsTable,
+		query: insertAttestationsQuery,
+	}
+
+	for _, item := range data {
+		persistObj.Append(item)
+	}
+
+	err := p.Persist(persistObj.ExportPersist())
+	if err != nil {
+		log.Errorf("error persisting validator attestations: %s", err.Error())
+	}
+	return err
+}
@@ -17,6 +17,7 @@ var (
 		f_timestamp,
 		f_epoch, 
 		f_slot,
+		f_block_root,
 		f_graffiti,
 		f_proposer_index,
 		f_proposed,
@@ -60,6 +61,7 @@ func blocksInput(blocks []spec.AgnosticBlock) proto.Input {
 		f_timestamp                  proto.ColUInt64
 		f_epoch                      proto.ColUInt64
 		f_slot                       proto.ColUInt64
+		f_block_root                 proto.ColStr
 		f_graffiti                   proto.ColStr
 		f_proposer_index             proto.ColUInt64
 		f_proposed                   proto.ColBool
@@ -87,8 +89,14 @@ func blocksInput(blocks []spec.AgnosticBlock) proto.Input {
 	)
 	for _, block := range blocks {
 		f_timestamp.Append(uint64(block.ExecutionPayload.Timestamp))
-		f_epoch.Append(uint64(block.Slot / spec.SlotsPerEpoch))
+		f_epoch.Append(uint64(spec.EpochAtSlot(block.Slot)))
 		f_slot.Append(uint64(block.Slot))
+		// a missed slot has no block
+		if block.Proposed {
+			f_block_root.Append(block.Root.String())
+		} else {
+			f_block_root.Append("")
+		}
 
 		graffiti := strings.ToValidUTF8(string(block.Graffiti[:]), "?")
 		graffiti = strings.ReplaceAll(graffiti, "\u0000", "")
@@ -144,6 +152,7 @@ func blocksInput(blocks []spec.AgnosticBlock) proto.Input {
 		{Name: "f_timestamp", Data: f_timestamp},
 		{Name: "f_epoch", Data: f_epoch},
 		{Name: "f_slot", Data: f_slot},
+		{Name: "f_block_root", Data: f_block_root},
 		{Name: "f_graffiti", Data: f_graffiti},
 		{Name: "f_proposer_index", Data: f_proposer_index},
 		{Name: "f_proposed", Data: f_proposed},
@@ -171,40 +180,39 @@ func blocksInput(blocks []spec.AgnosticBlock) proto.Input {
 	}
 }
 
-func (s *DBService) DeleteBlockMetrics(slot phase0.Slot) error {
-
-	err := s.Delete(DeletableObject{
-		query: deleteBlockQuery,
-		table: blocksTable,
-		args:  []any{slot},
-	})
-	if err != nil {
-		return err
+// blockMetricsDeletes returns the rows that were written when processing the block at the given slot
+func blockMetricsDeletes(slot phase0.Slot) []DeletableObject {
+	return []DeletableObject{
+		{
+			query: deleteBlockQuery,
+			table: blocksTable,
+			args:  []any{slot},
+		},
+		{
+			query: deleteTransactionsQuery,
+			table: transactionsTable,
+			args:  []any{slot},
+		},
+		{
+			query: deleteWithdrawalsQuery,
+			table: withdrawalsTable,
+			args:  []any{slot},
+		},
+		{
+			query: deleteBlobsQuery,
+			table: blobsTable,
+			args:  []any{slot},
+		},
 	}
+}
 
-	err = s.Delete(DeletableObject{
-		query: deleteTransactionsQuery,
-		table: transactionsTable,
-		args:  []any{slot},
-	})
-	if err != nil {
-		return err
-	}
-	err = s.Delete(DeletableObject{
-		query: deleteWithdrawalsQuery,
-		table: withdrawalsTable,
-		args:  []any{slot},
-	})
-	if err != nil {
-		return err
-	}
-	err = s.Delete(DeletableObject{
-		query: deleteBlobsQuery,
-		table: blobsTable,
-		args:  []any{slot},
-	})
-	if err != nil {
-		return err
+func (s *DBService) DeleteBlockMetrics(slot phase0.Slot) error {
+
+	for _, deleteObj := range blockMetricsDeletes(slot) {
+		err := s.Delete(deleteObj)
+		if err != nil {
+			return err
+		}
 	}
 	return nil
 }
@@ -3,6 +3,7 @@ package db
 import (
 	"github.com/ClickHouse/ch-go/proto"
 	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/migalabs/goteth/pkg/spec"
 )
 
 var (
@@ -14,6 +15,10 @@ var (
 		f_burnt_fees,
 		f_cl_manual_reward,
 		f_cl_api_reward,
+		f_cl_attestations_reward,
+		f_cl_sync_aggregate_reward,
+		f_cl_proposer_slashings_reward,
+		f_cl_attester_slashings_reward,
 		f_relays,
 		f_builder_pubkey,
 		f_bid_commission)
@@ -23,14 +28,18 @@ var (
 func blockRewardsInput(blocks []BlockReward) proto.Input {
 	// one object per column
 	var (
-		f_slot             proto.ColUInt64
-		f_reward_fees      proto.ColUInt64
-		f_burnt_fees       proto.ColUInt64
-		f_cl_manual_reward proto.ColUInt64
-		f_cl_api_reward    proto.ColUInt64
-		f_relays           = new(proto.ColStr).Array()
-		f_builder_pubkey   proto.ColStr
-		f_bid_commission   proto.ColUInt64
+		f_slot                         proto.ColUInt64
+		f_reward_fees                  proto.ColUInt64
+		f_burnt_fees                   proto.ColUInt64
+		f_cl_manual_reward             proto.ColUInt64
+		f_cl_api_reward                proto.ColUInt64
+		f_cl_attestations_reward       proto.ColUInt64
+		f_cl_sync_aggregate_reward     proto.ColUInt64
+		f_cl_proposer_slashings_reward proto.ColUInt64
+		f_cl_attester_slashings_reward proto.ColUInt64
+		f_relays                       = new(proto.ColStr).Array()
+		f_builder_pubkey               proto.ColStr
+		f_bid_commission               proto.ColUInt64
 	)
 
 	for _, blockReward := range blocks {
@@ -45,6 +54,10 @@ func blockRewardsInput(blocks []BlockReward) proto.Input {
 		f_burnt_fees.Append(blockReward.BurntFees)
 		f_cl_manual_reward.Append(uint64(blockReward.CLManualReward))
 		f_cl_api_reward.Append(uint64(blockReward.CLApiReward))
+		f_cl_attestations_reward.Append(uint64(blockReward.CLRewardComponents.Attestations))
+		f_cl_sync_aggregate_reward.Append(uint64(blockReward.CLRewardComponents.SyncAggregate))
+		f_cl_proposer_slashings_reward.Append(uint64(blockReward.CLRewardComponents.ProposerSlashings))
+		f_cl_attester_slashings_reward.Append(uint64(blockReward.CLRewardComponents.AttesterSlashings))
 		f_relays.Append(blockReward.Relays)
 		f_builder_pubkey.Append(builder_pubkey)
 		f_bid_commission.Append(blockReward.BidCommision)
@@ -57,6 +70,10 @@ func blockRewardsInput(blocks []BlockReward) proto.Input {
 		{Name: "f_burnt_fees", Data: f_burnt_fees},
 		{Name: "f_cl_manual_reward", Data: f_cl_manual_reward},
 		{Name: "f_cl_api_reward", Data: f_cl_api_reward},
+		{Name: "f_cl_attestations_reward", Data: f_cl_attestations_reward},
+		{Name: "f_cl_sync_aggregate_reward", Data: f_cl_sync_aggregate_reward},
+		{Name: "f_cl_proposer_slashings_reward", Data: f_cl_proposer_slashings_reward},
+		{Name: "f_cl_attester_slashings_reward", Data: f_cl_attester_slashings_reward},
 		{Name: "f_relays", Data: f_relays},
 		{Name: "f_builder_pubkey", Data: f_builder_pubkey},
 		{Name: "f_bid_commission", Data: f_bid_commission},
@@ -85,9 +102,11 @@ type BlockReward struct {
 	Slot           phase0.Slot
 	CLManualReward phase0.Gwei // Gwei
 	CLApiReward    phase0.Gwei // Gwei
-	RewardFees     uint64      // Gwei
-	BurntFees      uint64      // Gwei
-	Relays         []string
-	BuilderPubkeys []string
-	BidCommision   uint64
+	// CLManualReward split by component
+	CLRewardComponents spec.BlockRewardComponents
+	RewardFees         uint64 // Gwei
+	BurntFees          uint64 // Gwei
+	Relays             []string
+	BuilderPubkeys     []string
+	BidCommision       uint64
 }
@@ -0,0 +1,220 @@
+package db
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ClickHouse/ch-go/proto"
+	"github.com/migalabs/goteth/pkg/utils"
+	"github.com/nats-io/nats.go"
+	"github.com/pkg/errors"
+)
+
+var (
+	NatsScheme = "nats"
+
+	DefaultBusPrefix = "goteth"
+	defaultNatsPort  = "4222"
+
+	natsDialTimeout   = 10 * time.Second
+	natsReconnectWait = time.Second
+	natsFlushTimeout  = 30 * time.Second
+)
+
+// columns used as key of the messages, in order of preference
+var busKeyColumns = []string{"f_val_idx", "f_slot", "f_proposer_slot", "f_epoch"}
+
+// tables whose rows are keyed by another column than the preferred one
+var busTableKeys = map[string]string{
+	epochsTable: "f_epoch", // f_slot is the first slot of the epoch
+}
+
+// busDelete is the message published when rows are deleted (reorgs and reprocessing):
+// every row of the table whose column (divided by divisor, if any) matches the value
+type busDelete struct {
+	Table   string `json:"table"`
+	Column  string `json:"column"`
+	Divisor uint64 `json:"divisor,omitempty"`
+	Op      string `json:"op"`
+	Value   any    `json:"value"`
+}
+
+// busBackend publishes every row as a JSON message to <prefix>.<table>.<key>,
+// and the deletes to <prefix>.deletes.<table>.
+// The messages are buffered by the nats client, which reconnects on its own and sends
+// the ones published meanwhile once reconnected: a publish does not wait for the server
+type busBackend struct {
+	prefix string
+	conn   *nats.Conn
+
+	mu        sync.Mutex
+	maxValues map[string]map[string]uint64
+}
+
+// NewBusSink returns a Sink publishing to a NATS server, url: nats://[user:pass@]host:4222?prefix=goteth
+func NewBusSink(busUrl string) (Sink, error) {
+	backend, err := newBusBackend(busUrl)
+	if err != nil {
+		return nil, err
+	}
+	return newRowSink(backend), nil
+}
+
+func newBusBackend(busUrl string) (*busBackend, error) {
+	parsedUrl, err := url.Parse(busUrl)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to parse nats url")
+	}
+	if parsedUrl.Scheme != NatsScheme {
+		return nil, errors.Errorf("unsupported message bus url scheme: %s", parsedUrl.Scheme)
+	}
+	if parsedUrl.Hostname() == "" {
+		return nil, errors.Errorf("no host given in nats url %s", busUrl)
+	}
+	port := parsedUrl.Port()
+	if port == "" {
+		port = defaultNatsPort
+	}
+
+	backend := &busBackend{
+		prefix:    DefaultBusPrefix,
+		maxValues: make(map[string]map[string]uint64),
+	}
+	if prefix := parsedUrl.Query().Get("prefix"); prefix != "" {
+		if strings.ContainsAny(prefix, " \t*>") {
+			return nil, errors.Errorf("invalid subject prefix %s", prefix)
+		}
+		backend.prefix = prefix
+	}
+
+	// the credentials are read by the client: user and password, or a user alone as token
+	serverUrl := url.URL{Scheme: NatsScheme, User: parsedUrl.User, Host: fmt.Sprintf("%s:%s", parsedUrl.Hostname(), port)}
+	backend.conn, err = nats.Connect(serverUrl.String(),
+		nats.Name(utils.CliName),
+		nats.Timeout(natsDialTimeout),
+		nats.MaxReconnects(-1),
+		nats.ReconnectWait(natsReconnectWait),
+		nats.DisconnectErrHandler(func(_ *nats.Conn, err error) {
+			if err != nil {
+				log.Warnf("nats connection lost, reconnecting: %s", err)
+			}
+		}),
+		nats.ReconnectHandler(func(conn *nats.Conn) {
+			log.Infof("reconnected to nats server %s", conn.ConnectedAddr())
+		}),
+		nats.ErrorHandler(func(_ *nats.Conn, _ *nats.Subscription, err error) {
+			log.Errorf("nats error: %s", err)
+		}),
+	)
+	if err != nil {
+		// fail early if the server is not reachable
+		return nil, errors.Wrap(err, "unable to connect to nats server")
+	}
+	log.Infof("publishing rows to nats server %s, subjects %s.<table>.<key>", serverUrl.Redacted(), backend.prefix)
+	return backend, nil
+}
+
+func (b *busBackend) subject(table string, row Row) string {
+	if column, ok := busTableKeys[table]; ok {
+		return fmt.Sprintf("%s.%s.%d", b.prefix, table, row.Uint64(column))
+	}
+	for _, column := range busKeyColumns {
+		if _, ok := row[column]; ok {
+			return fmt.Sprintf("%s.%s.%d", b.prefix, table, row.Uint64(column))
+		}
+	}
+	return fmt.Sprintf("%s.%s", b.prefix, table)
+}
+
+func (b *busBackend) insert(table string, input proto.Input) error {
+	rows, err := tableRows(input)
+	if err != nil {
+		return err
+	}
+	return b.insertRows(table, rows)
+}
+
+func (b *busBackend) insertRows(table string, rows []Row) error {
+	for _, row := range rows {
+		payload, err := json.Marshal(row)
+		if err != nil {
+			return errors.Wrapf(err, "unable to encode %s row", table)
+		}
+		if err := b.conn.Publish(b.subject(table, row), payload); err != nil {
+			return errors.Wrapf(err, "unable to publish %s row", table)
+		}
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.trackMaxValues(table, rows)
+	return nil
+}
+
+// trackMaxValues keeps the last slot and epoch published, so the analyzer can resume
+// when the bus is used instead of a database (only for the lifetime of the process).
+// Must be called holding the lock
+func (b *busBackend) trackMaxValues(table string, rows []Row) {
+	if _, ok := b.maxValues[table]; !ok {
+		b.maxValues[table] = make(map[string]uint64)
+	}
+	for _, row := range rows {
+		for _, column := range fileTrackedColumns {
+			if _, ok := row[column]; !ok {
+				continue
+			}
+			if value := row.Uint64(column); value > b.maxValues[table][column] {
+				b.maxValues[table][column] = value
+			}
+		}
+	}
+}
+
+func (b *busBackend) delete(obj DeletableObject) error {
+	filter, err := obj.rowFilter()
+	if err != nil {
+		return err
+	}
+	message := busDelete{
+		Table:  obj.Table(),
+		Column: filter.column,
+		Op:     filter.op,
+		Value:  filter.value,
+	}
+	if filter.divisor > 1 {
+		message.Divisor = filter.divisor
+	}
+	if filter.text != nil {
+		message.Value = *filter.text
+	}
+	payload, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+
+	subject := fmt.Sprintf("%s.deletes.%s", b.prefix, obj.Table())
+	if err := b.conn.Publish(subject, payload); err != nil {
+		return errors.Wrapf(err, "unable to publish delete from %s", obj.Table())
+	}
+	return nil
+}
+
+func (b *busBackend) maxValue(table string, column string) (uint64, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.maxValues[table][column], nil
+}
+
+// close waits until the server received the messages published, then disconnects
+func (b *busBackend) close() error {
+	defer b.conn.Close()
+	if b.conn.IsClosed() {
+		return nil
+	}
+	return b.conn.FlushTimeout(natsFlushTimeout)
+}
@@ -0,0 +1,124 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/migalabs/goteth/pkg/spec"
+	"github.com/migalabs/goteth/pkg/testutil/mocknats"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newMockNats(t *testing.T) *mocknats.Server {
+	server, err := mocknats.New()
+	require.NoError(t, err)
+	t.Cleanup(server.Close)
+	return server
+}
+
+// waitMessages waits until the server received n messages on the subject, the client
+// does not wait for the server when publishing
+func waitMessages(t *testing.T, server *mocknats.Server, subject string, n int) []mocknats.Message {
+	require.Eventually(t, func() bool {
+		return len(server.MessagesOn(subject)) >= n
+	}, 10*time.Second, 10*time.Millisecond, "%d messages on %s", n, subject)
+	return server.MessagesOn(subject)
+}
+
+func TestBusSink(t *testing.T) {
+	server := newMockNats(t)
+	sink, err := NewSink(context.Background(), server.Url()+"?prefix=test")
+	require.NoError(t, err)
+	defer sink.Finish()
+
+	require.NoError(t, sink.PersistEpochs([]spec.Epoch{{Epoch: 10, Slot: 320}, {Epoch: 11, Slot: 352}}))
+	require.NoError(t, sink.PersistValidatorRewards([]spec.ValidatorRewards{
+		{ValidatorIndex: 7, Epoch: 10, Reward: 1500},
+		{ValidatorIndex: 8, Epoch: 10, Reward: -300},
+	}))
+	require.NoError(t, sink.DeleteStateMetrics(11))
+
+	// one message per row, keyed by validator, else slot or epoch
+	epochs := waitMessages(t, server, "test.t_epoch_metrics_summary.11", 1)
+	require.Len(t, epochs, 1)
+	var epoch map[string]any
+	require.NoError(t, json.Unmarshal(epochs[0].Data, &epoch))
+	assert.EqualValues(t, 352, epoch["f_slot"])
+
+	rewards := waitMessages(t, server, "test.t_validator_rewards_summary.8", 1)
+	require.Len(t, rewards, 1)
+	var reward map[string]any
+	require.NoError(t, json.Unmarshal(rewards[0].Data, &reward))
+	assert.EqualValues(t, -300, reward["f_reward"])
+
+	deletes := waitMessages(t, server, "test.deletes.t_epoch_metrics_summary", 1)
+	require.NotEmpty(t, deletes)
+	var deleteMsg busDelete
+	require.NoError(t, json.Unmarshal(deletes[0].Data, &deleteMsg))
+	assert.Equal(t, epochsTable, deleteMsg.Table)
+	assert.Equal(t, "f_epoch", deleteMsg.Column)
+	assert.Equal(t, "=", deleteMsg.Op)
+
+	for _, msg := range server.Messages() {
+		assert.True(t, strings.HasPrefix(msg.Subject, "test."), msg.Subject)
+	}
+
+	// the last epoch is known for the lifetime of the process
+	lastEpoch, err := sink.RetrieveLastEpoch()
+	require.NoError(t, err)
+	assert.Equal(t, phase0.Epoch(11), lastEpoch)
+}
+
+func TestAttachPublisher(t *testing.T) {
+	server := newMockNats(t)
+	server.RequireToken("secret")
+
+	sink := NewMemorySink()
+	assert.Error(t, AttachPublisher(sink, server.Url()), "the token is required")
+	require.NoError(t, AttachPublisher(sink, strings.Replace(server.Url(), "nats://", "nats://secret@", 1)))
+
+	rewards := []spec.ValidatorRewards{{ValidatorIndex: 7, Epoch: 10}}
+	require.NoError(t, sink.PersistValidatorRewards(rewards))
+	assert.Equal(t, 1, sink.Count(valRewardsTable))
+	waitMessages(t, server, "goteth.t_validator_rewards_summary.7", 1)
+
+	// the client reconnects on its own when the connection was lost
+	server.DropConnections()
+	require.Eventually(t, func() bool { return server.Connects() == 2 }, 10*time.Second, 10*time.Millisecond)
+	require.NoError(t, sink.PersistValidatorRewards(rewards))
+	require.NoError(t, sink.PersistValidatorRewards(rewards))
+	assert.Equal(t, 3, sink.Count(valRewardsTable))
+	waitMessages(t, server, "goteth.t_validator_rewards_summary.7", 3)
+
+	// failing to publish does not fail the write to the database
+	server.SetMaxPayload(16)
+	server.DropConnections()
+	require.Eventually(t, func() bool { return server.Connects() == 3 }, 10*time.Second, 10*time.Millisecond)
+	require.NoError(t, sink.PersistValidatorRewards(rewards))
+	assert.Equal(t, 4, sink.Count(valRewardsTable))
+
+	// closing publishes the queued writes first
+	sink.Finish()
+	assert.Len(t, server.MessagesOn("goteth.t_validator_rewards_summary.7"), 3)
+}
+
+func TestBusPublisherQueueFull(t *testing.T) {
+	server := newMockNats(t)
+	backend, err := newBusBackend(server.Url())
+	require.NoError(t, err)
+	publisher := &busPublisher{backend: backend, queue: make(chan busWrite, 1), done: make(chan struct{})}
+
+	// the worker is not running: the second write does not fit and is dropped
+	rewards := []spec.ValidatorRewards{{ValidatorIndex: 7, Epoch: 10}}
+	require.NoError(t, publisher.insert(valRewardsTable, rewardsInput(rewards)))
+	assert.Error(t, publisher.insert(valRewardsTable, rewardsInput(rewards)))
+
+	go publisher.run()
+	require.NoError(t, publisher.close())
+	assert.Len(t, server.MessagesOn("goteth.t_validator_rewards_summary.7"), 1)
+}
@@ -0,0 +1,181 @@
+package db
+
+import (
+	"reflect"
+	"regexp"
+	"strconv"
+
+	"github.com/ClickHouse/ch-go/proto"
+	"github.com/pkg/errors"
+)
+
+// The proto.Input builders of each table describe every column of the table
+// with the same names as the migrations. Backends other than ClickHouse read
+// them back row by row using the helpers below.
+
+// inputColumns returns the column names of the input, in insertion order
+func inputColumns(input proto.Input) []string {
+	columns := make([]string, 0, len(input))
+	for _, column := range input {
+		columns = append(columns, column.Name)
+	}
+	return columns
+}
+
+// inputRows transposes the column builders of the input into rows
+func inputRows(input proto.Input) ([][]any, error) {
+	if len(input) == 0 {
+		return nil, nil
+	}
+	rows := make([][]any, input[0].Data.Rows())
+	for i := range rows {
+		rows[i] = make([]any, len(input))
+	}
+
+	for j, column := range input {
+		if column.Data.Rows() != len(rows) {
+			return nil, errors.Errorf("column %s has %d rows, expected %d", column.Name, column.Data.Rows(), len(rows))
+		}
+		for i := range rows {
+			value, err := columnValue(column.Data, i)
+			if err != nil {
+				return nil, errors.Wrap(err, column.Name)
+			}
+			rows[i][j] = value
+		}
+	}
+	return rows, nil
+}
+
+func columnValue(col proto.ColInput, i int) (any, error) {
+	switch c := col.(type) {
+	case proto.ColBool:
+		return c.Row(i), nil
+	case proto.ColFloat32:
+		return c.Row(i), nil
+	case proto.ColInt64:
+		return c.Row(i), nil
+	case proto.ColStr:
+		return c.Row(i), nil
+	case proto.ColUInt8:
+		return c.Row(i), nil
+	case proto.ColUInt16:
+		return c.Row(i), nil
+	case proto.ColUInt32:
+		return c.Row(i), nil
+	case proto.ColUInt64:
+		return c.Row(i), nil
+	case *proto.ColArr[string]:
+		return c.Row(i), nil
+	default:
+		return nil, errors.Errorf("unsupported column type %T", col)
+	}
+}
+
+// toUint64 converts the numeric values stored in rows and delete arguments
+// (slots, epochs, indexes...) so they can be compared
+func toUint64(value any) (uint64, bool) {
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return v.Uint(), true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if v.Int() < 0 {
+			return 0, false
+		}
+		return uint64(v.Int()), true
+	default:
+		return 0, false
+	}
+}
+
+// deleteCondition is the WHERE clause of a delete query in typed form, so that backends
+// without a SQL engine can apply the same deletes over the rows they keep: the rows
+// whose column (divided by divisor, if set) compares through op with the argument
+type deleteCondition struct {
+	column  string
+	divisor uint64 // f_proposer_slot/<slots per epoch>
+	op      string // =, <, <=, > or >=
+}
+
+// deleteWhereRegex matches the WHERE clause of the delete queries, i.e.
+// "WHERE f_epoch <= $1" or "WHERE f_proposer_slot/32 = $1"
+var deleteWhereRegex = regexp.MustCompile(`(?is)\bWHERE\s+(\w+)(?:\s*/\s*(\d+))?\s*(<=|>=|=|<|>)\s*\$1\s*;?\s*$`)
+
+// parseDeleteCondition reads the condition of a delete query, so the query stays the
+// only definition of what each delete removes
+func parseDeleteCondition(query string) (deleteCondition, bool) {
+	match := deleteWhereRegex.FindStringSubmatch(query)
+	if match == nil {
+		return deleteCondition{}, false
+	}
+	where := deleteCondition{column: match[1], op: match[3]}
+	if match[2] != "" {
+		where.divisor, _ = strconv.ParseUint(match[2], 10, 64)
+	}
+	return where, true
+}
+
+// rowFilter is the condition of a DeletableObject with its argument
+type rowFilter struct {
+	column  string
+	divisor uint64
+	op      string
+	value   uint64
+	text    *string // set when the argument is a string (f_pool_name), only = is supported
+}
+
+func (d DeletableObject) rowFilter() (rowFilter, error) {
+	where, ok := parseDeleteCondition(d.Query())
+	if !ok || len(d.args) != 1 {
+		return rowFilter{}, errors.Errorf("unsupported delete condition for table %s", d.table)
+	}
+	if text, ok := d.args[0].(string); ok {
+		if where.op != "=" || where.divisor > 1 {
+			return rowFilter{}, errors.Errorf("unsupported delete condition for table %s", d.table)
+		}
+		return rowFilter{column: where.column, op: where.op, text: &text}, nil
+	}
+	value, ok := toUint64(d.args[0])
+	if !ok {
+		return rowFilter{}, errors.Errorf("unsupported delete argument %v for table %s", d.args[0], d.table)
+	}
+
+	filter := rowFilter{
+		column:  where.column,
+		divisor: max(where.divisor, 1),
+		op:      where.op,
+		value:   value,
+	}
+	return filter, nil
+}
+
+// matches returns whether the row must be deleted, columns gives the position of each column in the row
+func (f rowFilter) matches(columns []string, row []any) bool {
+	for i, column := range columns {
+		if column != f.column {
+			continue
+		}
+		if f.text != nil {
+			return row[i] == *f.text
+		}
+		value, ok := toUint64(row[i])
+		if !ok {
+			return false
+		}
+		value = value / f.divisor
+		switch f.op {
+		case "=":
+			return value == f.value
+		case "<":
+			return value < f.value
+		case "<=":
+			return value <= f.value
+		case ">":
+			return value > f.value
+		case ">=":
+			return value >= f.value
+		}
+	}
+	return false
+}
@@ -0,0 +1,37 @@
+package db
+
+import (
+	"testing"
+
+	"github.com/migalabs/goteth/pkg/spec"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseDeleteCondition(t *testing.T) {
+	tests := map[string]deleteCondition{
+		deleteBlockQuery:                                 {column: "f_slot", op: "="},
+		deleteValidatorStatus:                            {column: "f_epoch", op: "<"},
+		deleteValidatorRewardsUntilEpochQuery:            {column: "f_epoch", op: "<="},
+		deleteValidatorRewardsAggregationUntilEpochQuery: {column: "f_start_epoch", op: "<="},
+		deleteEth2PubkeysQuery:                           {column: "f_pool_name", op: "="},
+	}
+	for query, want := range tests {
+		where, ok := parseDeleteCondition(query)
+		require.True(t, ok, query)
+		assert.Equal(t, want, where)
+	}
+
+	where, ok := parseDeleteCondition(stateMetricsDeletes(10)[2].Query())
+	require.True(t, ok)
+	assert.Equal(t, deleteCondition{column: "f_proposer_slot", divisor: spec.SlotsPerEpoch, op: "="}, where)
+
+	// the deletes of every table can be applied by the backends without SQL
+	for _, obj := range append(stateMetricsDeletes(10), blockMetricsDeletes(10)...) {
+		_, err := obj.rowFilter()
+		assert.NoError(t, err, obj.Table())
+	}
+
+	_, ok = parseDeleteCondition("DELETE FROM t WHERE f_epoch IN ($1)")
+	assert.False(t, ok)
+}
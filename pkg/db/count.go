@@ -0,0 +1,154 @@
+package db
+
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// CountQuery counts the rows of a table grouped by Column/Divisor (i.e. epochs of
+// a slot column), only the groups between From and To are counted
+type CountQuery struct {
+	Table   string
+	Final   bool // deduplicate the rows of the ReplacingMergeTree tables
+	Column  string
+	Divisor uint64
+	From    uint64
+	To      uint64
+}
+
+// Counter is implemented by the Sinks able to count the rows of the tables
+type Counter interface {
+	CountRows(query CountQuery) (map[uint64]uint64, error)
+}
+
+var (
+	_ Counter = &DBService{}
+	_ Counter = &rowSink{}
+)
+
+// CanCountRows returns an error if the sink cannot count the rows of its tables (the
+// Parquet files and the message bus), so the modes relying on it are refused at startup
+func CanCountRows(sink Sink) error {
+	if s, ok := sink.(interface{ countsRows() bool }); ok && !s.countsRows() {
+		return errors.New("the storage backend cannot count the rows of its tables, use ClickHouse, PostgreSQL or memory")
+	}
+	return nil
+}
+
+func (s *rowSink) countsRows() bool {
+	_, ok := s.backend.(countsBackend)
+	return ok
+}
+
+// countsBackend is implemented by the row backends able to count the rows of the tables
+type countsBackend interface {
+	countRows(query CountQuery) (map[uint64]uint64, error)
+}
+
+func (q CountQuery) validate() error {
+	if !slices.Contains(monitoredTables, q.Table) {
+		return errors.Errorf("unknown table %s", q.Table)
+	}
+	if !columnNameRegex.MatchString(q.Column) {
+		return errors.Errorf("invalid column %s", q.Column)
+	}
+	if q.Divisor == 0 || q.To < q.From {
+		return errors.Errorf("invalid divisor %d or range %d-%d", q.Divisor, q.From, q.To)
+	}
+	return nil
+}
+
+// sql returns the count query, final is only supported by ClickHouse. The group is the
+// integer division of the column, intDiv in ClickHouse and / on BIGINT in PostgreSQL
+func (q CountQuery) sql(final bool) (string, []any) {
+	table := q.Table
+	group := fmt.Sprintf("%s / %d", q.Column, q.Divisor)
+	if final {
+		group = fmt.Sprintf("intDiv(%s, %d)", q.Column, q.Divisor)
+		if q.Final {
+			table += " FINAL"
+		}
+	}
+	sqlQuery := fmt.Sprintf(
+		`SELECT %s AS f_group, count(*) AS f_count FROM %s WHERE %s >= $1 AND %s <= $2 GROUP BY f_group`,
+		group, table, q.Column, q.Column)
+	return sqlQuery, []any{q.From * q.Divisor, q.To*q.Divisor + q.Divisor - 1}
+}
+
+// CountRows returns the number of rows of each group of the query
+func (p *DBService) CountRows(query CountQuery) (map[uint64]uint64, error) {
+	if err := query.validate(); err != nil {
+		return nil, err
+	}
+	sqlQuery, args := query.sql(true)
+
+	startTime := time.Now()
+	p.highMu.Lock()
+	defer p.highMu.Unlock()
+
+	rows, err := p.highLevelClient.Query(p.ctx, sqlQuery, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := make(map[uint64]uint64)
+	for rows.Next() {
+		var group, count uint64
+		if err := rows.Scan(&group, &count); err != nil {
+			return nil, err
+		}
+		counts[group] = count
+	}
+	log.Debugf("counted %d groups in %f seconds, query: %s", len(counts), time.Since(startTime).Seconds(), sqlQuery)
+	return counts, rows.Err()
+}
+
+// CountRows returns the number of rows of each group of the query
+func (s *rowSink) CountRows(query CountQuery) (map[uint64]uint64, error) {
+	if err := query.validate(); err != nil {
+		return nil, err
+	}
+	counter, ok := s.backend.(countsBackend)
+	if !ok {
+		return nil, errors.New("the storage backend cannot count the rows of the tables")
+	}
+	return counter.countRows(query)
+}
+
+func (b *memoryBackend) countRows(query CountQuery) (map[uint64]uint64, error) {
+	counts := make(map[uint64]uint64)
+	for _, row := range b.rows(query.Table) {
+		value, ok := toUint64(row[query.Column])
+		if !ok {
+			continue
+		}
+		group := value / query.Divisor
+		if group >= query.From && group <= query.To {
+			counts[group]++
+		}
+	}
+	return counts, nil
+}
+
+func (b *postgresBackend) countRows(query CountQuery) (map[uint64]uint64, error) {
+	sqlQuery, args := query.sql(false)
+	rows, err := b.pool.Query(b.ctx, sqlQuery, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := make(map[uint64]uint64)
+	for rows.Next() {
+		var group, count int64
+		if err := rows.Scan(&group, &count); err != nil {
+			return nil, err
+		}
+		counts[uint64(group)] = uint64(count)
+	}
+	return counts, rows.Err()
+}
@@ -43,7 +43,8 @@ var (
 		f_deposit_requests_num,
 		f_withdrawal_requests_num,
 		f_consolidations_processed_num,
-		f_consolidations_processed_amount	
+		f_consolidations_processed_amount,
+		f_finalized_epoch
 		)
 		VALUES`
 
@@ -94,6 +95,7 @@ func epochsInput(epochs []spec.Epoch) proto.Input {
 		f_withdrawal_requests_num          proto.ColUInt64
 		f_consolidations_processed_num     proto.ColUInt64
 		f_consolidations_processed_amount  proto.ColUInt64
+		f_finalized_epoch                  proto.ColUInt64
 	)
 
 	for _, epoch := range epochs {
@@ -129,6 +131,7 @@ func epochsInput(epochs []spec.Epoch) proto.Input {
 		f_withdrawal_requests_num.Append(uint64(epoch.WithdrawalRequestsNum))
 		f_consolidations_processed_num.Append(epoch.ConsolidationsProcessedNum)
 		f_consolidations_processed_amount.Append(uint64(epoch.ConsolidationsProcessedAmount))
+		f_finalized_epoch.Append(uint64(epoch.FinalizedEpoch))
 	}
 
 	return proto.Input{
@@ -164,6 +167,7 @@ func epochsInput(epochs []spec.Epoch) proto.Input {
 		{Name: "f_withdrawal_requests_num", Data: f_withdrawal_requests_num},
 		{Name: "f_consolidations_processed_num", Data: f_consolidations_processed_num},
 		{Name: "f_consolidations_processed_amount", Data: f_consolidations_processed_amount},
+		{Name: "f_finalized_epoch", Data: f_finalized_epoch},
 	}
 }
 
@@ -202,64 +206,74 @@ func (p *DBService) RetrieveLastEpoch() (phase0.Epoch, error) {
 
 }
 
-// delete metrics that use the state at epoch x
-func (s *DBService) DeleteStateMetrics(epoch phase0.Epoch) error {
-	var err error
+// stateMetricsDeletes returns the rows that were written using the state at the given epoch
+func stateMetricsDeletes(epoch phase0.Epoch) []DeletableObject {
+	return []DeletableObject{
+		// epochs are written at currentState using current state and nextState
+		{
+			query: deleteEpochsQuery,
+			table: epochsTable,
+			args:  []any{epoch - 1},
+		}, // when deleteState -> nextState
+		{
+			query: deleteEpochsQuery,
+			table: epochsTable,
+			args:  []any{epoch},
+		}, // when deleteState -> currentState
 
-	// epochs are written at currentState using current state and nextState
-	err = s.Delete(DeletableObject{
-		query: deleteEpochsQuery,
-		table: epochsTable,
-		args:  []any{epoch - 1},
-	}) // when deleteState -> nextState
+		// proposer duties are writter using nextState
+		{
+			query: fmt.Sprintf(deleteProposerDutiesQuery, spec.SlotsPerEpoch),
+			table: proposerDutiesTable,
+			args:  []any{epoch},
+		},
 
-	if err != nil {
-		return err
-	}
-	err = s.Delete(DeletableObject{
-		query: deleteEpochsQuery,
-		table: epochsTable,
-		args:  []any{epoch},
-	}) // when deleteState -> currentState
-	if err != nil {
-		return err
-	}
+		// valRewards are written at nextState using prevState, currentState and nextState
+		{
+			query: deleteValidatorRewardsInEpochQuery,
+			table: valRewardsTable,
+			args:  []any{epoch + 2},
+		}, // when deleteState -> prevState
+		{
+			query: deleteValidatorRewardsInEpochQuery,
+			table: valRewardsTable,
+			args:  []any{epoch + 1},
+		}, // when deleteState -> currentState
+		{
+			query: deleteValidatorRewardsInEpochQuery,
+			table: valRewardsTable,
+			args:  []any{epoch},
+		}, // when deleteState -> nextState
 
-	// proposer duties are writter using nextState
-	err = s.Delete(DeletableObject{
-		query: deleteProposerDutiesQuery,
-		table: proposerDutiesTable,
-		args:  []any{epoch},
-	})
-	if err != nil {
-		return err
-	}
+		// attestations are written at nextState for the duties of currentState
+		{
+			query: deleteAttestationsQuery,
+			table: attestationsTable,
+			args:  []any{epoch - 1},
+		}, // when deleteState -> nextState
+		{
+			query: deleteAttestationsQuery,
+			table: attestationsTable,
+			args:  []any{epoch},
+		}, // when deleteState -> currentState
 
-	// valRewards are written at nextState using prevState, currentState and nextState
-	err = s.Delete(DeletableObject{
-		query: deleteValidatorRewardsInEpochQuery,
-		table: valRewardsTable,
-		args:  []any{epoch + 2},
-	}) // when deleteState -> prevState
-	if err != nil {
-		return err
-	}
-	err = s.Delete(DeletableObject{
-		query: deleteValidatorRewardsInEpochQuery,
-		table: valRewardsTable,
-		args:  []any{epoch + 1},
-	}) // when deleteState -> currentState
-	if err != nil {
-		return err
+		// sync committee duties are written using nextState
+		{
+			query: deleteSyncCommitteeQuery,
+			table: syncCommitteeTable,
+			args:  []any{epoch},
+		},
 	}
-	err = s.Delete(DeletableObject{
-		query: deleteValidatorRewardsInEpochQuery,
-		table: valRewardsTable,
-		args:  []any{epoch},
-	}) // when deleteState -> nextState
-	if err != nil {
-		return err
+}
+
+// delete metrics that use the state at epoch x
+func (s *DBService) DeleteStateMetrics(epoch phase0.Epoch) error {
+
+	for _, deleteObj := range stateMetricsDeletes(epoch) {
+		err := s.Delete(deleteObj)
+		if err != nil {
+			return err
+		}
 	}
 	return nil
-
 }
@@ -1,6 +1,9 @@
 package db
 
 import (
+	"fmt"
+	"strings"
+
 	"github.com/ClickHouse/ch-go/proto"
 	"github.com/migalabs/goteth/pkg/spec"
 )
@@ -23,6 +26,11 @@ var (
 			f_signature,
 			f_amount)
 		VALUES`
+
+	selectDepositSendersQuery = `
+		SELECT f_validator_pubkey, f_sender
+		FROM %s
+		ORDER BY f_deposit_index;`
 )
 
 func eth1DepositsInput(eth1Deposits []spec.ETH1Deposit) proto.Input {
@@ -93,3 +101,33 @@ func (p *DBService) PersistETH1Deposits(data []spec.ETH1Deposit) error {
 	}
 	return err
 }
+
+// RetrieveDepositSenders returns the address that sent the first deposit of each
+// validator public key, both in lowercase hex
+func (p *DBService) RetrieveDepositSenders() (map[string]string, error) {
+	var dest []struct {
+		F_validator_pubkey string `ch:"f_validator_pubkey"`
+		F_sender           string `ch:"f_sender"`
+	}
+
+	err := p.highSelect(
+		fmt.Sprintf(selectDepositSendersQuery, eth1DepositsTable),
+		&dest)
+	if err != nil {
+		return nil, err
+	}
+
+	senders := make(map[string]string, len(dest))
+	for _, row := range dest {
+		addDepositSender(senders, row.F_validator_pubkey, row.F_sender)
+	}
+	return senders, nil
+}
+
+// addDepositSender keeps the first sender of each public key, top ups from other addresses are ignored
+func addDepositSender(senders map[string]string, pubkey string, sender string) {
+	pubkey = strings.ToLower(pubkey)
+	if _, ok := senders[pubkey]; !ok {
+		senders[pubkey] = strings.ToLower(sender)
+	}
+}
@@ -0,0 +1,75 @@
+package db
+
+import (
+	"github.com/ClickHouse/ch-go/proto"
+	"github.com/migalabs/goteth/pkg/spec"
+)
+
+var (
+	eth2PubkeysTable       = "t_eth2_pubkeys"
+	insertEth2PubkeysQuery = `
+	INSERT INTO %s (
+		f_val_idx,
+		f_public_key,
+		f_pool_name,
+		f_pool)
+		VALUES`
+
+	deleteEth2PubkeysQuery = `
+		DELETE FROM %s
+		WHERE f_pool_name = $1;
+`
+)
+
+func eth2PubkeysInput(labels []spec.ValidatorLabel) proto.Input {
+	// one object per column
+	var (
+		f_val_idx    proto.ColUInt64
+		f_public_key proto.ColStr
+		f_pool_name  proto.ColStr
+		f_pool       proto.ColStr
+	)
+
+	for _, label := range labels {
+		f_val_idx.Append(uint64(label.ValIdx))
+		f_public_key.Append(label.PublicKey.String())
+		f_pool_name.Append(label.PoolName)
+		f_pool.Append(label.Pool)
+	}
+
+	return proto.Input{
+		{Name: "f_val_idx", Data: f_val_idx},
+		{Name: "f_public_key", Data: f_public_key},
+		{Name: "f_pool_name", Data: f_pool_name},
+		{Name: "f_pool", Data: f_pool},
+	}
+}
+
+func (p *DBService) PersistValidatorLabels(data []spec.ValidatorLabel) error {
+	persistObj := PersistableObject[spec.ValidatorLabel]{
+		input: eth2PubkeysInput,
+		table: eth2PubkeysTable,
+		query: insertEth2PubkeysQuery,
+	}
+
+	for _, item := range data {
+		persistObj.Append(item)
+	}
+
+	err := p.Persist(persistObj.ExportPersist())
+	if err != nil {
+		log.Errorf("error persisting validator labels: %s", err.Error())
+	}
+	return err
+}
+
+// DeleteValidatorLabels removes every validator labelled with the given pool name
+func (p *DBService) DeleteValidatorLabels(poolName string) error {
+	deleteObj := DeletableObject{
+		query: deleteEth2PubkeysQuery,
+		table: eth2PubkeysTable,
+		args:  []any{poolName},
+	}
+
+	return p.Delete(deleteObj)
+}
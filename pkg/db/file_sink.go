@@ -0,0 +1,507 @@
+package db
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/ClickHouse/ch-go/proto"
+	"github.com/migalabs/goteth/pkg/spec"
+	"github.com/parquet-go/parquet-go"
+	"github.com/pkg/errors"
+)
+
+var (
+	FileScheme = "file"
+
+	DefaultPartitionEpochs uint64 = 225 // one day of epochs per file
+	// rows are kept in memory until their epoch is this far from the newest one,
+	// so the deletes done on reorgs and reprocessing are applied before writing them
+	fileFlushEpochMargin uint64 = 8
+
+	errFileAppendOnly = errors.New("the file backend is append-only, rows already written cannot be deleted")
+)
+
+// columns used to place each row into an epoch partition, in order of preference.
+// Slots are divided by the slots per epoch of the chain spec when the row is written,
+// as the spec is only known once loaded from the beacon node
+var partitionColumns = []struct {
+	name  string
+	slots bool
+}{
+	{"f_epoch", false},
+	{"f_slot", true},
+	{"f_proposer_slot", true},
+	{"f_start_epoch", false},
+	{"f_epoch_processed", false},
+}
+
+// columns whose max value is tracked once rows are written to disk (see rowBackend.maxValue)
+var fileTrackedColumns = []string{"f_slot", "f_epoch", "f_genesis_time"}
+
+// fileBackend writes every table into Parquet files under
+// <dir>/<table>/<table>_<first_epoch>_<last_epoch>.parquet,
+// one file per table every partitionEpochs epochs
+type fileBackend struct {
+	dir             string
+	partitionEpochs uint64
+
+	mu         sync.Mutex
+	tables     map[string]*fileTable
+	headEpoch  uint64                       // newest epoch received
+	writtenMax map[string]map[string]uint64 // table -> max of the tracked columns in the files of previous runs
+}
+
+type fileTable struct {
+	name            string
+	columns         []string
+	schema          *parquet.Schema
+	leafColumns     []int // input column of each parquet leaf
+	partitionColumn int   // -1 if the table has no epoch or slot column
+	partitionSlots  bool  // the partition column is a slot
+
+	pending         map[uint64][][]any // epoch -> rows not written yet
+	maxWritten      map[string]uint64
+	written         bool
+	maxWrittenEpoch uint64
+
+	writer          *parquet.Writer
+	writerFile      *os.File
+	writerPartition uint64
+}
+
+// NewFileSink returns a Sink writing Parquet files, url: file:///path/to/dir?partition_epochs=225
+func NewFileSink(dbUrl string) (Sink, error) {
+	parsedUrl, err := url.Parse(dbUrl)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to parse file url")
+	}
+	if parsedUrl.Path == "" {
+		return nil, errors.Errorf("no directory given in file url %s", dbUrl)
+	}
+
+	backend := &fileBackend{
+		dir:             parsedUrl.Path,
+		partitionEpochs: DefaultPartitionEpochs,
+		tables:          make(map[string]*fileTable),
+	}
+	if value := parsedUrl.Query().Get("partition_epochs"); value != "" {
+		backend.partitionEpochs, err = strconv.ParseUint(value, 10, 64)
+		if err != nil || backend.partitionEpochs == 0 {
+			return nil, errors.Errorf("invalid partition_epochs: %s", value)
+		}
+	}
+
+	err = os.MkdirAll(backend.dir, 0755)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to create output directory")
+	}
+	backend.writtenMax, err = readWrittenMax(backend.dir)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to read the parquet files already written")
+	}
+	log.Infof("writing parquet files to %s, %d epochs per file", backend.dir, backend.partitionEpochs)
+
+	return newRowSink(backend), nil
+}
+
+func parquetNode(col proto.ColInput) (parquet.Node, error) {
+	switch col.(type) {
+	case proto.ColBool:
+		return parquet.Leaf(parquet.BooleanType), nil
+	case proto.ColFloat32:
+		return parquet.Leaf(parquet.FloatType), nil
+	case proto.ColInt64:
+		return parquet.Int(64), nil
+	case proto.ColStr:
+		return parquet.String(), nil
+	case proto.ColUInt8:
+		return parquet.Uint(8), nil
+	case proto.ColUInt16:
+		return parquet.Uint(16), nil
+	case proto.ColUInt32:
+		return parquet.Uint(32), nil
+	case proto.ColUInt64:
+		return parquet.Uint(64), nil
+	case *proto.ColArr[string]:
+		return parquet.Repeated(parquet.String()), nil
+	default:
+		return nil, errors.Errorf("unsupported column type %T", col)
+	}
+}
+
+func newFileTable(name string, input proto.Input) (*fileTable, error) {
+	group := parquet.Group{}
+	for _, column := range input {
+		node, err := parquetNode(column.Data)
+		if err != nil {
+			return nil, errors.Wrap(err, column.Name)
+		}
+		group[column.Name] = node
+	}
+
+	table := &fileTable{
+		name:            name,
+		columns:         inputColumns(input),
+		schema:          parquet.NewSchema(name, group),
+		leafColumns:     make([]int, len(input)),
+		partitionColumn: -1,
+		pending:         make(map[uint64][][]any),
+		maxWritten:      make(map[string]uint64),
+	}
+	for i, column := range table.columns {
+		leaf, _ := table.schema.Lookup(column)
+		table.leafColumns[leaf.ColumnIndex] = i
+	}
+
+partitionLoop:
+	for _, partitionColumn := range partitionColumns {
+		for i, column := range table.columns {
+			if column == partitionColumn.name {
+				table.partitionColumn = i
+				table.partitionSlots = partitionColumn.slots
+				break partitionLoop
+			}
+		}
+	}
+	return table, nil
+}
+
+func (t *fileTable) rowEpoch(row []any, fallback uint64) uint64 {
+	if t.partitionColumn < 0 {
+		return fallback
+	}
+	value, ok := toUint64(row[t.partitionColumn])
+	if !ok {
+		return fallback
+	}
+	return value / t.partitionDivisor()
+}
+
+// partitionDivisor returns the divisor of the partition column that gives its epoch
+func (t *fileTable) partitionDivisor() uint64 {
+	if t.partitionSlots {
+		return spec.SlotsPerEpoch
+	}
+	return 1
+}
+
+// parquetRow orders the values by leaf column, as expected by the writer
+func (t *fileTable) parquetRow(row []any) parquet.Row {
+	parquetRow := make(parquet.Row, 0, len(row))
+	for leafIdx, column := range t.leafColumns {
+		switch value := row[column].(type) {
+		case []string:
+			if len(value) == 0 {
+				parquetRow = append(parquetRow, parquet.Value{}.Level(0, 0, leafIdx))
+			}
+			for i, item := range value {
+				repetitionLevel := 1
+				if i == 0 {
+					repetitionLevel = 0
+				}
+				parquetRow = append(parquetRow, parquet.ValueOf(item).Level(repetitionLevel, 1, leafIdx))
+			}
+		default:
+			parquetRow = append(parquetRow, parquet.ValueOf(value).Level(0, 0, leafIdx))
+		}
+	}
+	return parquetRow
+}
+
+func (b *fileBackend) insert(table string, input proto.Input) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	fTable, ok := b.tables[table]
+	if !ok {
+		var err error
+		fTable, err = newFileTable(table, input)
+		if err != nil {
+			return err
+		}
+		for column, value := range b.writtenMax[table] {
+			fTable.maxWritten[column] = value
+		}
+		b.tables[table] = fTable
+	}
+
+	rows, err := inputRows(input)
+	if err != nil {
+		return err
+	}
+	for _, row := range rows {
+		epoch := fTable.rowEpoch(row, b.headEpoch)
+		fTable.pending[epoch] = append(fTable.pending[epoch], row)
+		if epoch > b.headEpoch {
+			b.headEpoch = epoch
+		}
+	}
+
+	if b.headEpoch < fileFlushEpochMargin {
+		return nil
+	}
+	return b.flushUntil(b.headEpoch - fileFlushEpochMargin)
+}
+
+// flushUntil writes the pending rows of all tables up to the given epoch (included)
+func (b *fileBackend) flushUntil(lastEpoch uint64) error {
+	for _, fTable := range b.tables {
+		epochs := make([]uint64, 0)
+		for epoch := range fTable.pending {
+			if epoch <= lastEpoch {
+				epochs = append(epochs, epoch)
+			}
+		}
+		sort.Slice(epochs, func(i, j int) bool { return epochs[i] < epochs[j] })
+
+		for _, epoch := range epochs {
+			err := b.writeEpoch(fTable, epoch)
+			if err != nil {
+				return errors.Wrapf(err, "could not write %s at epoch %d", fTable.name, epoch)
+			}
+		}
+	}
+	return nil
+}
+
+func (b *fileBackend) writeEpoch(fTable *fileTable, epoch uint64) error {
+	rows := fTable.pending[epoch]
+	delete(fTable.pending, epoch)
+	if len(rows) == 0 {
+		return nil
+	}
+
+	partition := epoch / b.partitionEpochs
+	if fTable.writer != nil && fTable.writerPartition != partition {
+		err := fTable.closeWriter()
+		if err != nil {
+			return err
+		}
+	}
+	if fTable.writer == nil {
+		err := b.openWriter(fTable, partition)
+		if err != nil {
+			return err
+		}
+	}
+
+	parquetRows := make([]parquet.Row, 0, len(rows))
+	for _, row := range rows {
+		parquetRows = append(parquetRows, fTable.parquetRow(row))
+		for _, column := range fileTrackedColumns {
+			for i, name := range fTable.columns {
+				if name != column {
+					continue
+				}
+				if value, ok := toUint64(row[i]); ok && value > fTable.maxWritten[column] {
+					fTable.maxWritten[column] = value
+				}
+			}
+		}
+	}
+	_, err := fTable.writer.WriteRows(parquetRows)
+	if err != nil {
+		return err
+	}
+	fTable.written = true
+	fTable.maxWrittenEpoch = max(fTable.maxWrittenEpoch, epoch)
+	// one row group per epoch keeps the memory used by the writer bounded
+	return fTable.writer.Flush()
+}
+
+func (b *fileBackend) openWriter(fTable *fileTable, partition uint64) error {
+	tableDir := filepath.Join(b.dir, fTable.name)
+	err := os.MkdirAll(tableDir, 0755)
+	if err != nil {
+		return err
+	}
+
+	firstEpoch := partition * b.partitionEpochs
+	lastEpoch := firstEpoch + b.partitionEpochs - 1
+	fileName := fmt.Sprintf("%s_%d_%d", fTable.name, firstEpoch, lastEpoch)
+	filePath := filepath.Join(tableDir, fileName+".parquet")
+	// rows arriving after the partition was closed (or a previous run) go to a new file
+	for i := 1; ; i++ {
+		if _, err := os.Stat(filePath); os.IsNotExist(err) {
+			break
+		}
+		filePath = filepath.Join(tableDir, fmt.Sprintf("%s_%d.parquet", fileName, i))
+	}
+
+	file, err := os.Create(filePath)
+	if err != nil {
+		return err
+	}
+	log.Debugf("writing %s", filePath)
+
+	fTable.writerFile = file
+	fTable.writerPartition = partition
+	fTable.writer = parquet.NewWriter(file, fTable.schema, parquet.Compression(&parquet.Snappy))
+	return nil
+}
+
+func (t *fileTable) closeWriter() error {
+	if t.writer == nil {
+		return nil
+	}
+	err := t.writer.Close()
+	if err != nil {
+		return err
+	}
+	err = t.writerFile.Close()
+	t.writer = nil
+	t.writerFile = nil
+	return err
+}
+
+// readWrittenMax returns the max value of the tracked columns in the files already
+// written under dir, by table, so a restart resumes after the rows of the previous runs
+func readWrittenMax(dir string) (map[string]map[string]uint64, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*", "*.parquet"))
+	if err != nil {
+		return nil, err
+	}
+	writtenMax := make(map[string]map[string]uint64)
+	for _, path := range paths {
+		table := filepath.Base(filepath.Dir(path))
+		if _, ok := writtenMax[table]; !ok {
+			writtenMax[table] = make(map[string]uint64)
+		}
+		err := readFileMax(path, writtenMax[table])
+		if err != nil {
+			// i.e. a file left without footer by a run that did not finish
+			log.Warnf("skipping unreadable parquet file %s: %s", path, err)
+		}
+	}
+	return writtenMax, nil
+}
+
+// readFileMax updates maxValues with the max of the tracked columns in the file, read
+// from the statistics of its row groups
+func readFileMax(path string, maxValues map[string]uint64) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	stat, err := file.Stat()
+	if err != nil {
+		return err
+	}
+	pFile, err := parquet.OpenFile(file, stat.Size())
+	if err != nil {
+		return err
+	}
+
+	for _, column := range fileTrackedColumns {
+		leaf, ok := pFile.Schema().Lookup(column)
+		if !ok {
+			continue
+		}
+		for _, rowGroup := range pFile.RowGroups() {
+			chunk, ok := rowGroup.ColumnChunks()[leaf.ColumnIndex].(*parquet.FileColumnChunk)
+			if !ok {
+				return errors.Errorf("unexpected column chunk of %s", column)
+			}
+			_, maxValue, ok := chunk.Bounds()
+			if !ok {
+				return errors.Errorf("no statistics for %s", column)
+			}
+			if value := maxValue.Uint64(); value > maxValues[column] {
+				maxValues[column] = value
+			}
+		}
+	}
+	return nil
+}
+
+// reachesWritten returns whether rows matching the filter may be in the files already written
+func (t *fileTable) reachesWritten(filter rowFilter) bool {
+	if !t.written {
+		return false
+	}
+	if filter.op != "=" || filter.text != nil || t.partitionColumn < 0 || t.columns[t.partitionColumn] != filter.column {
+		return true
+	}
+	return filter.value*filter.divisor/t.partitionDivisor() <= t.maxWrittenEpoch
+}
+
+// delete removes the matching rows that were not written yet, files already written
+// are never modified. Deleting the rows of a slot or epoch already written (a reorg
+// deeper than fileFlushEpochMargin) fails, as rewriting them would duplicate them.
+// Deleting a range (pruning the older rows) only applies to the rows not written yet
+func (b *fileBackend) delete(obj DeletableObject) error {
+	filter, err := obj.rowFilter()
+	if err != nil {
+		return err
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	fTable, ok := b.tables[obj.Table()]
+	if !ok {
+		return nil
+	}
+	for epoch, rows := range fTable.pending {
+		kept := rows[:0]
+		for _, row := range rows {
+			if !filter.matches(fTable.columns, row) {
+				kept = append(kept, row)
+			}
+		}
+		fTable.pending[epoch] = kept
+	}
+	if filter.op == "=" && fTable.reachesWritten(filter) {
+		return errors.Wrapf(errFileAppendOnly, "%s %s %v", obj.Table(), filter.column, obj.Args()[0])
+	}
+	return nil
+}
+
+// maxValue returns the max value of the column in the files written, also by previous
+// runs for the tracked columns, and in the rows not written yet
+func (b *fileBackend) maxValue(table string, column string) (uint64, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	fTable, ok := b.tables[table]
+	if !ok {
+		return b.writtenMax[table][column], nil
+	}
+	maxValue := fTable.maxWritten[column]
+	for i, name := range fTable.columns {
+		if name != column {
+			continue
+		}
+		for _, rows := range fTable.pending {
+			for _, row := range rows {
+				if value, ok := toUint64(row[i]); ok && value > maxValue {
+					maxValue = value
+				}
+			}
+		}
+	}
+	return maxValue, nil
+}
+
+func (b *fileBackend) close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	err := b.flushUntil(b.headEpoch)
+	if err != nil {
+		return err
+	}
+	for _, fTable := range b.tables {
+		err := fTable.closeWriter()
+		if err != nil {
+			return errors.Wrapf(err, "could not close %s", fTable.name)
+		}
+	}
+	return nil
+}
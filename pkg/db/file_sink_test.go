@@ -0,0 +1,148 @@
+package db
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/migalabs/goteth/pkg/spec"
+	"github.com/parquet-go/parquet-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func readParquetColumn(t *testing.T, path string, column string) []uint64 {
+	file, err := os.Open(path)
+	require.NoError(t, err)
+	defer file.Close()
+	stat, err := file.Stat()
+	require.NoError(t, err)
+
+	pFile, err := parquet.OpenFile(file, stat.Size())
+	require.NoError(t, err)
+	leaf, ok := pFile.Schema().Lookup(column)
+	require.True(t, ok)
+
+	values := make([]uint64, 0)
+	reader := parquet.NewReader(pFile)
+	rows := make([]parquet.Row, 16)
+	for {
+		n, err := reader.ReadRows(rows)
+		for _, row := range rows[:n] {
+			for _, value := range row {
+				if value.Column() == leaf.ColumnIndex {
+					values = append(values, value.Uint64())
+				}
+			}
+		}
+		if err != nil {
+			break
+		}
+	}
+	return values
+}
+
+func TestFileSinkPartitions(t *testing.T) {
+	dir := t.TempDir()
+	sink, err := NewSink(context.Background(), "file://"+dir+"?partition_epochs=4")
+	require.NoError(t, err)
+
+	for epoch := phase0.Epoch(0); epoch < 12; epoch++ {
+		require.NoError(t, sink.PersistEpochs([]spec.Epoch{{Epoch: epoch, Slot: spec.ComputeStartSlotAtEpoch(epoch)}}))
+		require.NoError(t, sink.PersistBlockRewards([]BlockReward{{
+			Slot:   spec.ComputeStartSlotAtEpoch(epoch),
+			Relays: []string{"relay-a", "relay-b"},
+		}}))
+	}
+	// epochs 10 and 11 are still in memory, so the delete is applied before writing them
+	require.NoError(t, sink.DeleteStateMetrics(11))
+
+	lastEpoch, err := sink.RetrieveLastEpoch()
+	require.NoError(t, err)
+	assert.Equal(t, phase0.Epoch(9), lastEpoch)
+
+	sink.Finish()
+
+	files, err := filepath.Glob(filepath.Join(dir, epochsTable, "*.parquet"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		filepath.Join(dir, epochsTable, epochsTable+"_0_3.parquet"),
+		filepath.Join(dir, epochsTable, epochsTable+"_4_7.parquet"),
+		filepath.Join(dir, epochsTable, epochsTable+"_8_11.parquet"),
+	}, files)
+
+	assert.Equal(t, []uint64{0, 1, 2, 3}, readParquetColumn(t, files[0], "f_epoch"))
+	assert.Equal(t, []uint64{8, 9}, readParquetColumn(t, files[2], "f_epoch"))
+
+	slots := readParquetColumn(t, filepath.Join(dir, blockRewardsTable, blockRewardsTable+"_4_7.parquet"), "f_slot")
+	assert.Equal(t, []uint64{128, 160, 192, 224}, slots)
+}
+
+func TestFileSinkAppendOnly(t *testing.T) {
+	sink, err := NewSink(context.Background(), "file://"+t.TempDir())
+	require.NoError(t, err)
+	defer sink.Finish()
+
+	for epoch := phase0.Epoch(0); epoch < 12; epoch++ {
+		require.NoError(t, sink.PersistEpochs([]spec.Epoch{{Epoch: epoch, Slot: spec.ComputeStartSlotAtEpoch(epoch)}}))
+	}
+	// epochs up to 3 are already written
+	assert.NoError(t, sink.DeleteBlockMetrics(spec.ComputeStartSlotAtEpoch(4)))
+	assert.ErrorIs(t, sink.DeleteStateMetrics(3), errFileAppendOnly)
+	assert.NoError(t, sink.DeleteStateMetrics(6))
+
+	// the gaps could not be repaired, refused before starting
+	assert.Error(t, CanCountRows(sink))
+	_, err = sink.(*rowSink).CountRows(CountQuery{Table: epochsTable, Column: "f_epoch", Divisor: 1, To: 11})
+	assert.Error(t, err)
+}
+
+func TestFileSinkResume(t *testing.T) {
+	dir := t.TempDir()
+	sink, err := NewSink(context.Background(), "file://"+dir+"?partition_epochs=4")
+	require.NoError(t, err)
+	require.NoError(t, sink.InitGenesis(time.Unix(1606824023, 0)))
+	for epoch := phase0.Epoch(0); epoch < 6; epoch++ {
+		require.NoError(t, sink.PersistEpochs([]spec.Epoch{{Epoch: epoch, Slot: spec.ComputeStartSlotAtEpoch(epoch)}}))
+	}
+	sink.Finish()
+
+	// the last epoch is read back from the files of the previous run
+	sink, err = NewSink(context.Background(), "file://"+dir+"?partition_epochs=4")
+	require.NoError(t, err)
+	defer sink.Finish()
+	lastEpoch, err := sink.RetrieveLastEpoch()
+	require.NoError(t, err)
+	assert.Equal(t, phase0.Epoch(5), lastEpoch)
+	lastSlot, err := sink.RetrieveLastSlot()
+	require.NoError(t, err)
+	assert.Equal(t, phase0.Slot(0), lastSlot)
+
+	// the genesis is kept, not written again
+	require.NoError(t, sink.InitGenesis(time.Unix(1606824023, 0)))
+	genesisFiles, err := filepath.Glob(filepath.Join(dir, genesisTable, "*.parquet"))
+	require.NoError(t, err)
+	assert.Len(t, genesisFiles, 1)
+
+	require.NoError(t, sink.PersistEpochs([]spec.Epoch{{Epoch: 6, Slot: spec.ComputeStartSlotAtEpoch(6)}}))
+	lastEpoch, err = sink.RetrieveLastEpoch()
+	require.NoError(t, err)
+	assert.Equal(t, phase0.Epoch(6), lastEpoch)
+}
+
+func TestFileSinkChainSpecPartitions(t *testing.T) {
+	slotsPerEpoch := spec.SlotsPerEpoch
+	spec.SlotsPerEpoch = 16 // applied from the beacon node after the package is loaded
+	defer func() { spec.SlotsPerEpoch = slotsPerEpoch }()
+
+	dir := t.TempDir()
+	sink, err := NewSink(context.Background(), "file://"+dir+"?partition_epochs=4")
+	require.NoError(t, err)
+	require.NoError(t, sink.PersistBlockRewards([]BlockReward{{Slot: 64}}))
+	sink.Finish()
+
+	assert.FileExists(t, filepath.Join(dir, blockRewardsTable, blockRewardsTable+"_4_7.parquet"))
+}
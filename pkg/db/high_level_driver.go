@@ -98,6 +98,7 @@ func (p *DBService) Delete(obj DeletableObject) error {
 
 	if err == nil {
 		log.Infof("query: %s finished in %f seconds", obj.Query(), time.Since(startTime).Seconds())
+		mirrorDelete(p.mirror, obj)
 	}
 
 	return err
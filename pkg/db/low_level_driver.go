@@ -76,6 +76,8 @@ func (p *DBService) Persist(
 		p.metricsMu.Lock()
 		p.monitorMetrics[table].addNewPersist(rows, elapsedTime)
 		p.metricsMu.Unlock()
+
+		mirrorInsert(p.mirror, table, input)
 	}
 
 	return err
@@ -0,0 +1,228 @@
+package db
+
+import (
+	"reflect"
+	"sort"
+	"sync"
+
+	"github.com/ClickHouse/ch-go/proto"
+)
+
+var (
+	MemoryScheme = "memory"
+)
+
+// Row is a single row of a table, indexed by column name
+type Row map[string]any
+
+// Uint64 returns the value of a numeric column, 0 if the column does not exist
+func (r Row) Uint64(column string) uint64 {
+	value, _ := toUint64(r[column])
+	return value
+}
+
+// Int64 returns the value of a signed numeric column (i.e. rewards), 0 if the column does not exist
+func (r Row) Int64(column string) int64 {
+	v := reflect.ValueOf(r[column])
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return int64(v.Uint())
+	default:
+		return 0
+	}
+}
+
+// DeleteRecord is a delete executed against the MemorySink
+type DeleteRecord struct {
+	Table   string
+	Query   string
+	Args    []any
+	Removed int // number of rows removed by the delete
+}
+
+// MemorySink keeps every row written by the analyzer in memory.
+// Rows are appended as they are inserted (there is no deduplication like in
+// the ClickHouse ReplacingMergeTree), so tests can check that reprocessed
+// slots and epochs are deleted before being written again.
+type MemorySink struct {
+	*rowSink
+	backend *memoryBackend
+}
+
+type memoryBackend struct {
+	mu      sync.RWMutex
+	tables  map[string]*memoryTable
+	deletes []DeleteRecord
+}
+
+type memoryTable struct {
+	columns []string
+	rows    [][]any
+}
+
+// NewMemorySink returns an empty in-memory Sink, url: memory://
+func NewMemorySink() *MemorySink {
+	backend := &memoryBackend{
+		tables: make(map[string]*memoryTable),
+	}
+	return &MemorySink{
+		rowSink: newRowSink(backend),
+		backend: backend,
+	}
+}
+
+func (b *memoryBackend) insert(table string, input proto.Input) error {
+	rows, err := inputRows(input)
+	if err != nil {
+		return err
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	mTable, ok := b.tables[table]
+	if !ok {
+		mTable = &memoryTable{columns: inputColumns(input)}
+		b.tables[table] = mTable
+	}
+	mTable.rows = append(mTable.rows, rows...)
+	return nil
+}
+
+func (b *memoryBackend) delete(obj DeletableObject) error {
+	filter, err := obj.rowFilter()
+	if err != nil {
+		return err
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	removed := 0
+	if mTable, ok := b.tables[obj.Table()]; ok {
+		kept := make([][]any, 0, len(mTable.rows))
+		for _, row := range mTable.rows {
+			if filter.matches(mTable.columns, row) {
+				removed++
+				continue
+			}
+			kept = append(kept, row)
+		}
+		mTable.rows = kept
+	}
+	b.deletes = append(b.deletes, DeleteRecord{
+		Table:   obj.Table(),
+		Query:   obj.Query(),
+		Args:    obj.Args(),
+		Removed: removed,
+	})
+	return nil
+}
+
+func (b *memoryBackend) maxValue(table string, column string) (uint64, error) {
+	maxValue := uint64(0)
+	for _, row := range b.rows(table) {
+		if value := row.Uint64(column); value > maxValue {
+			maxValue = value
+		}
+	}
+	return maxValue, nil
+}
+
+func (b *memoryBackend) depositSenders() (map[string]string, error) {
+	deposits := b.rows(eth1DepositsTable)
+	sort.SliceStable(deposits, func(i, j int) bool {
+		return deposits[i].Uint64("f_deposit_index") < deposits[j].Uint64("f_deposit_index")
+	})
+
+	senders := make(map[string]string, len(deposits))
+	for _, deposit := range deposits {
+		pubkey, _ := deposit["f_validator_pubkey"].(string)
+		sender, _ := deposit["f_sender"].(string)
+		addDepositSender(senders, pubkey, sender)
+	}
+	return senders, nil
+}
+
+func (b *memoryBackend) close() error {
+	return nil
+}
+
+func (b *memoryBackend) rows(table string) []Row {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	mTable, ok := b.tables[table]
+	if !ok {
+		return nil
+	}
+	rows := make([]Row, 0, len(mTable.rows))
+	for _, values := range mTable.rows {
+		row := make(Row, len(values))
+		for i, column := range mTable.columns {
+			row[column] = values[i]
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+// Rows returns the rows of the table, in insertion order
+func (s *MemorySink) Rows(table string) []Row {
+	return s.backend.rows(table)
+}
+
+// Count returns the number of rows in the table
+func (s *MemorySink) Count(table string) int {
+	return len(s.backend.rows(table))
+}
+
+// Where returns the rows of the table whose column equals the given value.
+// Numeric values are compared regardless of their type (slots, epochs, indexes...)
+func (s *MemorySink) Where(table string, column string, value any) []Row {
+	numValue, numeric := toUint64(value)
+
+	result := make([]Row, 0)
+	for _, row := range s.backend.rows(table) {
+		if rowValue, ok := toUint64(row[column]); ok && numeric {
+			if rowValue == numValue {
+				result = append(result, row)
+			}
+			continue
+		}
+		if reflect.DeepEqual(row[column], value) {
+			result = append(result, row)
+		}
+	}
+	return result
+}
+
+// Deletes returns the deletes executed so far, in order
+func (s *MemorySink) Deletes() []DeleteRecord {
+	s.backend.mu.RLock()
+	defer s.backend.mu.RUnlock()
+
+	return append([]DeleteRecord(nil), s.backend.deletes...)
+}
+
+// DeletesOn returns the deletes executed on the given table
+func (s *MemorySink) DeletesOn(table string) []DeleteRecord {
+	result := make([]DeleteRecord, 0)
+	for _, record := range s.Deletes() {
+		if record.Table == table {
+			result = append(result, record)
+		}
+	}
+	return result
+}
+
+// Reset removes all the rows and deletes recorded so far
+func (s *MemorySink) Reset() {
+	s.backend.mu.Lock()
+	defer s.backend.mu.Unlock()
+
+	s.backend.tables = make(map[string]*memoryTable)
+	s.backend.deletes = nil
+}
@@ -12,6 +12,8 @@ type DBMetrics struct {
 	APIRewards       bool
 	Transactions     bool
 	BlobSidecars     bool
+	Attestations     bool
+	SyncCommittee    bool
 }
 
 func NewMetrics(input string) (DBMetrics, error) {
@@ -34,6 +36,14 @@ func NewMetrics(input string) (DBMetrics, error) {
 		case "transactions":
 			dbMetrics.Transactions = true
 			dbMetrics.Block = true
+		case "attestations":
+			dbMetrics.Attestations = true
+			dbMetrics.Epoch = true
+			dbMetrics.Block = true
+		case "sync_committee":
+			dbMetrics.SyncCommittee = true
+			dbMetrics.Epoch = true
+			dbMetrics.Block = true
 		case "blob_sidecars":
 			dbMetrics.Block = true
 			dbMetrics.BlobSidecars = true
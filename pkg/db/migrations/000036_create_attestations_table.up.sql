@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS t_attestations
+(
+    f_val_idx UInt64,
+    f_epoch UInt64,
+    f_att_slot UInt64,
+    f_committee_index UInt64,
+    f_committee_position UInt64,
+    f_included Bool,
+    f_inclusion_slot UInt64,
+    f_inclusion_block_root TEXT,
+    f_inclusion_delay UInt64,
+    f_aggregates Array(TEXT),
+    f_included_late Bool
+)
+ENGINE = ReplacingMergeTree()
+ORDER BY (f_epoch, f_val_idx);
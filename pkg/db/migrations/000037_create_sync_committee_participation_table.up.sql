@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS t_sync_committee_participation
+(
+    f_slot UInt64,
+    f_epoch UInt64,
+    f_val_idx UInt64,
+    f_committee_position UInt64,
+    f_participated Bool,
+    f_reward Int64
+)
+ENGINE = ReplacingMergeTree()
+ORDER BY (f_slot, f_committee_position);
@@ -0,0 +1,4 @@
+ALTER TABLE t_block_rewards DROP COLUMN f_cl_attestations_reward;
+ALTER TABLE t_block_rewards DROP COLUMN f_cl_sync_aggregate_reward;
+ALTER TABLE t_block_rewards DROP COLUMN f_cl_proposer_slashings_reward;
+ALTER TABLE t_block_rewards DROP COLUMN f_cl_attester_slashings_reward;
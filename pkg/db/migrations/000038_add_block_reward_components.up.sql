@@ -0,0 +1,12 @@
+-- Add the consensus block reward split by component to t_block_rewards
+ALTER TABLE t_block_rewards
+ADD COLUMN f_cl_attestations_reward UInt64 DEFAULT 0 AFTER f_cl_api_reward;
+
+ALTER TABLE t_block_rewards
+ADD COLUMN f_cl_sync_aggregate_reward UInt64 DEFAULT 0 AFTER f_cl_attestations_reward;
+
+ALTER TABLE t_block_rewards
+ADD COLUMN f_cl_proposer_slashings_reward UInt64 DEFAULT 0 AFTER f_cl_sync_aggregate_reward;
+
+ALTER TABLE t_block_rewards
+ADD COLUMN f_cl_attester_slashings_reward UInt64 DEFAULT 0 AFTER f_cl_proposer_slashings_reward;
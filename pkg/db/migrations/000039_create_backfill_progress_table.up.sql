@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS t_backfill_progress
+(
+    f_metric LowCardinality(String),
+    f_epoch UInt64,
+    f_timestamp DateTime DEFAULT now()
+)
+ENGINE = ReplacingMergeTree()
+ORDER BY (f_metric, f_epoch);
@@ -0,0 +1,9 @@
+-- Data needed to serve the rewards endpoints of the Beacon API
+ALTER TABLE t_block_metrics
+ADD COLUMN f_block_root TEXT DEFAULT '' AFTER f_slot;
+
+ALTER TABLE t_epoch_metrics_summary
+ADD COLUMN f_finalized_epoch UInt64 DEFAULT 0;
+
+ALTER TABLE t_validator_rewards_summary
+ADD COLUMN f_inactivity_penalty UInt64 DEFAULT 0 AFTER f_missing_head;
@@ -0,0 +1,27 @@
+DROP TABLE IF EXISTS t_block_metrics;
+DROP TABLE IF EXISTS t_orphans;
+DROP TABLE IF EXISTS t_epoch_metrics_summary;
+DROP TABLE IF EXISTS t_proposer_duties;
+DROP TABLE IF EXISTS t_transactions;
+DROP TABLE IF EXISTS t_validator_last_status;
+DROP TABLE IF EXISTS t_validator_rewards_summary;
+DROP TABLE IF EXISTS t_validator_rewards_aggregation;
+DROP TABLE IF EXISTS t_withdrawals;
+DROP TABLE IF EXISTS t_reorgs;
+DROP TABLE IF EXISTS t_finalized_checkpoint;
+DROP TABLE IF EXISTS t_genesis;
+DROP TABLE IF EXISTS t_head_events;
+DROP TABLE IF EXISTS t_blob_sidecars;
+DROP TABLE IF EXISTS t_blob_sidecars_events;
+DROP TABLE IF EXISTS t_block_rewards;
+DROP TABLE IF EXISTS t_slashings;
+DROP TABLE IF EXISTS t_bls_to_execution_changes;
+DROP TABLE IF EXISTS t_deposits;
+DROP TABLE IF EXISTS t_eth1_deposits;
+DROP TABLE IF EXISTS t_consolidation_requests;
+DROP TABLE IF EXISTS t_consolidations_processed;
+DROP TABLE IF EXISTS t_withdrawal_requests;
+DROP TABLE IF EXISTS t_deposit_requests;
+DROP TABLE IF EXISTS t_pool_summary;
+DROP TABLE IF EXISTS t_eth2_pubkeys;
+DROP TABLE IF EXISTS t_status;
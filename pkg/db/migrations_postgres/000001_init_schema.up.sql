@@ -0,0 +1,366 @@
+-- PostgreSQL schema, same tables and columns as the ClickHouse migrations.
+-- The primary keys match the ORDER BY of each ClickHouse table, rows are upserted on them.
+-- Epochs that can be FAR_FUTURE_EPOCH and wei amounts do not fit in BIGINT and use NUMERIC.
+
+CREATE TABLE IF NOT EXISTS t_block_metrics(
+	f_timestamp BIGINT,
+	f_epoch BIGINT,
+	f_slot BIGINT,
+	f_graffiti TEXT,
+	f_proposer_index BIGINT,
+	f_proposed BOOLEAN,
+	f_attestations BIGINT,
+	f_deposits BIGINT,
+	f_consolidation_requests_num BIGINT,
+	f_deposit_requests_num BIGINT,
+	f_withdrawal_requests_num BIGINT,
+	f_proposer_slashings BIGINT,
+	f_attester_slashings BIGINT,
+	f_voluntary_exits BIGINT,
+	f_sync_bits BIGINT,
+	f_el_fee_recp TEXT,
+	f_el_gas_limit BIGINT,
+	f_el_gas_used BIGINT,
+	f_el_base_fee_per_gas NUMERIC(20),
+	f_el_block_hash TEXT,
+	f_el_transactions BIGINT,
+	f_el_block_number BIGINT,
+	f_ssz_size_bytes REAL,
+	f_snappy_size_bytes REAL,
+	f_compression_time_ms REAL,
+	f_decompression_time_ms REAL,
+	f_payload_size_bytes BIGINT,
+	PRIMARY KEY (f_slot));
+
+CREATE TABLE IF NOT EXISTS t_orphans(
+	f_timestamp BIGINT,
+	f_epoch BIGINT,
+	f_slot BIGINT,
+	f_graffiti TEXT,
+	f_proposer_index BIGINT,
+	f_proposed BOOLEAN,
+	f_attestations BIGINT,
+	f_deposits BIGINT,
+	f_proposer_slashings BIGINT,
+	f_attester_slashings BIGINT,
+	f_voluntary_exits BIGINT,
+	f_sync_bits BIGINT,
+	f_el_fee_recp TEXT,
+	f_el_gas_limit BIGINT,
+	f_el_gas_used BIGINT,
+	f_el_base_fee_per_gas NUMERIC(20),
+	f_el_block_hash TEXT,
+	f_el_transactions BIGINT,
+	f_el_block_number BIGINT,
+	f_ssz_size_bytes REAL,
+	f_snappy_size_bytes REAL,
+	f_compression_time_ms REAL,
+	f_decompression_time_ms REAL,
+	f_payload_size_bytes BIGINT,
+	PRIMARY KEY (f_slot));
+
+CREATE TABLE IF NOT EXISTS t_epoch_metrics_summary(
+	f_epoch BIGINT,
+	f_slot BIGINT,
+	f_num_att BIGINT,
+	f_num_att_vals BIGINT,
+	f_num_vals BIGINT,
+	f_num_compounding_vals BIGINT,
+	f_total_balance_eth REAL,
+	f_att_effective_balance_eth BIGINT,
+	f_source_att_effective_balance_eth BIGINT,
+	f_target_att_effective_balance_eth BIGINT,
+	f_head_att_effective_balance_eth BIGINT,
+	f_total_effective_balance_eth BIGINT,
+	f_missing_source BIGINT,
+	f_missing_target BIGINT,
+	f_missing_head BIGINT,
+	f_timestamp BIGINT,
+	f_num_slashed_vals BIGINT,
+	f_num_active_vals BIGINT,
+	f_num_exited_vals BIGINT,
+	f_num_in_activation_vals BIGINT,
+	f_sync_committee_participation BIGINT,
+	f_deposits_num BIGINT,
+	f_total_deposits_amount BIGINT,
+	f_withdrawals_num BIGINT,
+	f_total_withdrawals_amount BIGINT,
+	f_new_proposer_slashings BIGINT,
+	f_new_attester_slashings BIGINT,
+	f_consolidation_requests_num BIGINT,
+	f_deposit_requests_num BIGINT,
+	f_withdrawal_requests_num BIGINT,
+	f_consolidations_processed_num BIGINT,
+	f_consolidations_processed_amount BIGINT,
+	PRIMARY KEY (f_epoch));
+
+CREATE TABLE IF NOT EXISTS t_proposer_duties(
+	f_val_idx BIGINT,
+	f_proposer_slot BIGINT,
+	f_proposed BOOLEAN,
+	PRIMARY KEY (f_proposer_slot, f_val_idx));
+
+CREATE TABLE IF NOT EXISTS t_transactions(
+	f_tx_idx BIGINT,
+	f_tx_type BIGINT,
+	f_chain_id BIGINT,
+	f_data TEXT,
+	f_gas BIGINT,
+	f_gas_price NUMERIC(20),
+	f_gas_tip_cap NUMERIC(20),
+	f_gas_fee_cap NUMERIC(20),
+	f_value TEXT,
+	f_nonce BIGINT,
+	f_to TEXT,
+	f_hash TEXT,
+	f_size BIGINT,
+	f_slot BIGINT,
+	f_el_block_number BIGINT,
+	f_timestamp BIGINT,
+	f_from TEXT,
+	f_contract_address TEXT,
+	f_blob_gas_used BIGINT,
+	f_blob_gas_price NUMERIC(20),
+	f_blob_gas_limit BIGINT,
+	f_blob_gas_fee_cap NUMERIC(20),
+	PRIMARY KEY (f_slot, f_el_block_number, f_hash));
+
+CREATE TABLE IF NOT EXISTS t_validator_last_status(
+	f_val_idx BIGINT,
+	f_epoch BIGINT,
+	f_balance_eth REAL,
+	f_effective_balance BIGINT,
+	f_status SMALLINT,
+	f_slashed BOOLEAN,
+	f_activation_epoch NUMERIC(20),
+	f_withdrawal_epoch NUMERIC(20),
+	f_exit_epoch NUMERIC(20),
+	f_public_key TEXT,
+	f_withdrawal_prefix SMALLINT,
+	f_withdrawal_credentials TEXT,
+	PRIMARY KEY (f_val_idx));
+
+CREATE TABLE IF NOT EXISTS t_validator_rewards_summary(
+	f_val_idx BIGINT,
+	f_epoch BIGINT,
+	f_balance_eth REAL,
+	f_effective_balance BIGINT,
+	f_withdrawal_prefix SMALLINT,
+	f_reward BIGINT,
+	f_max_reward BIGINT,
+	f_max_att_reward BIGINT,
+	f_max_sync_reward BIGINT,
+	f_att_slot BIGINT,
+	f_attestation_included BOOLEAN,
+	f_base_reward BIGINT,
+	f_in_sync_committee BOOLEAN,
+	f_sync_committee_participations_included SMALLINT,
+	f_missing_source BOOLEAN,
+	f_missing_target BOOLEAN,
+	f_missing_head BOOLEAN,
+	f_status SMALLINT,
+	f_block_api_reward BIGINT,
+	f_block_experimental_reward BIGINT,
+	f_inclusion_delay SMALLINT,
+	PRIMARY KEY (f_epoch, f_val_idx));
+
+CREATE TABLE IF NOT EXISTS t_validator_rewards_aggregation(
+	f_val_idx BIGINT,
+	f_start_epoch BIGINT,
+	f_end_epoch BIGINT,
+	f_reward BIGINT,
+	f_max_reward BIGINT,
+	f_max_att_reward BIGINT,
+	f_max_sync_reward BIGINT,
+	f_base_reward BIGINT,
+	f_in_sync_committee_count INTEGER,
+	f_sync_committee_participations_included INTEGER,
+	f_attestations_included INTEGER,
+	f_missing_source_count INTEGER,
+	f_missing_target_count INTEGER,
+	f_missing_head_count INTEGER,
+	f_block_api_reward BIGINT,
+	f_block_experimental_reward BIGINT,
+	f_inclusion_delay_sum BIGINT,
+	PRIMARY KEY (f_start_epoch, f_val_idx));
+
+CREATE TABLE IF NOT EXISTS t_withdrawals(
+	f_slot BIGINT,
+	f_index BIGINT,
+	f_val_idx BIGINT,
+	f_address TEXT,
+	f_amount BIGINT,
+	PRIMARY KEY (f_index));
+
+CREATE TABLE IF NOT EXISTS t_reorgs(
+	f_slot BIGINT,
+	f_depth BIGINT,
+	f_old_head_block_root TEXT,
+	f_new_head_block_root TEXT,
+	f_old_head_state_root TEXT,
+	f_new_head_state_root TEXT,
+	PRIMARY KEY (f_slot));
+
+CREATE TABLE IF NOT EXISTS t_finalized_checkpoint(
+	f_block_root TEXT,
+	f_state_root TEXT,
+	f_epoch BIGINT,
+	PRIMARY KEY (f_epoch));
+
+CREATE TABLE IF NOT EXISTS t_genesis(
+	f_genesis_time BIGINT,
+	PRIMARY KEY (f_genesis_time));
+
+CREATE TABLE IF NOT EXISTS t_head_events(
+	f_slot BIGINT,
+	f_block TEXT,
+	f_state TEXT,
+	f_epoch_transition BOOLEAN,
+	f_current_duty_dependent_root TEXT,
+	f_previous_duty_dependent_root TEXT,
+	f_arrival_timestamp BIGINT,
+	PRIMARY KEY (f_block));
+
+CREATE TABLE IF NOT EXISTS t_blob_sidecars(
+	f_blob_hash TEXT,
+	f_tx_hash TEXT,
+	f_slot BIGINT,
+	f_index SMALLINT,
+	f_kzg_commitment TEXT,
+	f_kzg_proof TEXT,
+	f_ending_0s BIGINT,
+	PRIMARY KEY (f_slot, f_index));
+
+CREATE TABLE IF NOT EXISTS t_blob_sidecars_events(
+	f_arrival_timestamp_ms BIGINT,
+	f_blob_hash TEXT,
+	f_slot BIGINT,
+	f_block_root TEXT,
+	f_index SMALLINT,
+	f_kzg_commitment TEXT,
+	PRIMARY KEY (f_arrival_timestamp_ms, f_blob_hash, f_slot));
+
+CREATE TABLE IF NOT EXISTS t_block_rewards(
+	f_slot BIGINT,
+	f_reward_fees NUMERIC(20),
+	f_burnt_fees NUMERIC(20),
+	f_cl_manual_reward BIGINT,
+	f_cl_api_reward BIGINT,
+	f_relays TEXT[],
+	f_builder_pubkey TEXT,
+	f_bid_commission NUMERIC(20),
+	PRIMARY KEY (f_slot));
+
+CREATE TABLE IF NOT EXISTS t_slashings(
+	f_slashed_validator_index BIGINT,
+	f_slashed_by_validator_index BIGINT,
+	f_slashing_reason TEXT,
+	f_slot BIGINT,
+	f_epoch BIGINT,
+	f_valid BOOLEAN,
+	PRIMARY KEY (f_slot, f_slashed_validator_index));
+
+CREATE TABLE IF NOT EXISTS t_bls_to_execution_changes(
+	f_slot BIGINT,
+	f_epoch BIGINT,
+	f_validator_index BIGINT,
+	f_from_bls_pubkey TEXT,
+	f_to_execution_address TEXT,
+	PRIMARY KEY (f_slot, f_epoch, f_validator_index));
+
+CREATE TABLE IF NOT EXISTS t_deposits(
+	f_slot BIGINT,
+	f_epoch_processed BIGINT,
+	f_public_key TEXT,
+	f_withdrawal_credentials TEXT,
+	f_amount BIGINT,
+	f_signature TEXT,
+	f_index SMALLINT,
+	PRIMARY KEY (f_slot, f_epoch_processed, f_index));
+
+CREATE TABLE IF NOT EXISTS t_eth1_deposits(
+	f_block_number BIGINT,
+	f_block_hash TEXT,
+	f_tx_hash TEXT,
+	f_log_index BIGINT,
+	f_sender TEXT,
+	f_recipient TEXT,
+	f_gas_used BIGINT,
+	f_gas_price NUMERIC(20),
+	f_deposit_index BIGINT,
+	f_validator_pubkey TEXT,
+	f_withdrawal_credentials TEXT,
+	f_signature TEXT,
+	f_amount BIGINT,
+	PRIMARY KEY (f_block_number, f_deposit_index));
+
+CREATE TABLE IF NOT EXISTS t_consolidation_requests(
+	f_slot BIGINT,
+	f_index BIGINT,
+	f_source_address TEXT,
+	f_source_pubkey TEXT,
+	f_target_pubkey TEXT,
+	f_result SMALLINT,
+	PRIMARY KEY (f_slot, f_index));
+
+CREATE TABLE IF NOT EXISTS t_consolidations_processed(
+	f_epoch BIGINT,
+	f_index BIGINT,
+	f_source_index BIGINT,
+	f_target_index BIGINT,
+	f_consolidated_amount BIGINT,
+	f_valid BOOLEAN,
+	PRIMARY KEY (f_epoch, f_index));
+
+CREATE TABLE IF NOT EXISTS t_withdrawal_requests(
+	f_slot BIGINT,
+	f_index BIGINT,
+	f_source_address TEXT,
+	f_validator_pubkey TEXT,
+	f_amount BIGINT,
+	f_result SMALLINT,
+	PRIMARY KEY (f_slot, f_index));
+
+CREATE TABLE IF NOT EXISTS t_deposit_requests(
+	f_slot BIGINT,
+	f_pubkey TEXT,
+	f_withdrawal_credentials TEXT,
+	f_amount BIGINT,
+	f_signature TEXT,
+	f_index BIGINT,
+	PRIMARY KEY (f_slot, f_index));
+
+CREATE TABLE IF NOT EXISTS t_pool_summary(
+	f_pool_name TEXT,
+	f_epoch BIGINT,
+	aggregated_rewards BIGINT,
+	aggregated_max_rewards BIGINT,
+	aggregated_effective_balance BIGINT DEFAULT 0,
+	count_sync_committee BIGINT,
+	count_sync_committee_participations_included BIGINT,
+	count_missing_source BIGINT,
+	count_missing_target BIGINT,
+	count_missing_head BIGINT,
+	count_expected_attestations BIGINT,
+	count_attestations_included BIGINT,
+	proposed_blocks_performance BIGINT,
+	missed_blocks_performance BIGINT,
+	number_active_vals BIGINT,
+	number_compounding_vals BIGINT,
+	avg_inclusion_delay REAL,
+	PRIMARY KEY (f_epoch, f_pool_name));
+
+CREATE TABLE IF NOT EXISTS t_eth2_pubkeys(
+	f_val_idx BIGINT PRIMARY KEY,
+	f_public_key TEXT,
+	f_pool_name TEXT,
+	f_pool TEXT);
+
+CREATE TABLE IF NOT EXISTS t_status(
+	f_id BIGINT PRIMARY KEY,
+	f_status TEXT);
+
+INSERT INTO t_status VALUES(0, 'in_activation_queue');
+INSERT INTO t_status VALUES(1, 'active');
+INSERT INTO t_status VALUES(2, 'exited');
+INSERT INTO t_status VALUES(3, 'slashed');
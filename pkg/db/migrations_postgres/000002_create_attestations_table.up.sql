@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS t_attestations(
+	f_val_idx BIGINT,
+	f_epoch BIGINT,
+	f_att_slot BIGINT,
+	f_committee_index BIGINT,
+	f_committee_position BIGINT,
+	f_included BOOLEAN,
+	f_inclusion_slot BIGINT,
+	f_inclusion_block_root TEXT,
+	f_inclusion_delay BIGINT,
+	f_aggregates TEXT[],
+	f_included_late BOOLEAN,
+	PRIMARY KEY (f_epoch, f_val_idx));
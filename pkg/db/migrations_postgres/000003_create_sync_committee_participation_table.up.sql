@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS t_sync_committee_participation(
+	f_slot BIGINT,
+	f_epoch BIGINT,
+	f_val_idx BIGINT,
+	f_committee_position BIGINT,
+	f_participated BOOLEAN,
+	f_reward BIGINT,
+	PRIMARY KEY (f_slot, f_committee_position));
@@ -0,0 +1,5 @@
+ALTER TABLE t_block_rewards
+	DROP COLUMN IF EXISTS f_cl_attestations_reward,
+	DROP COLUMN IF EXISTS f_cl_sync_aggregate_reward,
+	DROP COLUMN IF EXISTS f_cl_proposer_slashings_reward,
+	DROP COLUMN IF EXISTS f_cl_attester_slashings_reward;
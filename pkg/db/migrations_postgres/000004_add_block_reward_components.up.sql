@@ -0,0 +1,5 @@
+ALTER TABLE t_block_rewards
+	ADD COLUMN IF NOT EXISTS f_cl_attestations_reward BIGINT DEFAULT 0,
+	ADD COLUMN IF NOT EXISTS f_cl_sync_aggregate_reward BIGINT DEFAULT 0,
+	ADD COLUMN IF NOT EXISTS f_cl_proposer_slashings_reward BIGINT DEFAULT 0,
+	ADD COLUMN IF NOT EXISTS f_cl_attester_slashings_reward BIGINT DEFAULT 0;
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS t_backfill_progress;
\ No newline at end of file
@@ -0,0 +1,5 @@
+CREATE TABLE IF NOT EXISTS t_backfill_progress(
+	f_metric TEXT,
+	f_epoch BIGINT,
+	f_timestamp TIMESTAMP DEFAULT now(),
+	PRIMARY KEY (f_metric, f_epoch));
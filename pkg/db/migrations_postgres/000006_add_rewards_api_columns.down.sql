@@ -0,0 +1,3 @@
+ALTER TABLE t_block_metrics DROP COLUMN IF EXISTS f_block_root;
+ALTER TABLE t_epoch_metrics_summary DROP COLUMN IF EXISTS f_finalized_epoch;
+ALTER TABLE t_validator_rewards_summary DROP COLUMN IF EXISTS f_inactivity_penalty;
@@ -0,0 +1,8 @@
+ALTER TABLE t_block_metrics
+	ADD COLUMN IF NOT EXISTS f_block_root TEXT DEFAULT '';
+
+ALTER TABLE t_epoch_metrics_summary
+	ADD COLUMN IF NOT EXISTS f_finalized_epoch BIGINT DEFAULT 0;
+
+ALTER TABLE t_validator_rewards_summary
+	ADD COLUMN IF NOT EXISTS f_inactivity_penalty BIGINT DEFAULT 0;
@@ -0,0 +1,154 @@
+package db
+
+import (
+	"sync"
+
+	"github.com/ClickHouse/ch-go/proto"
+	"github.com/pkg/errors"
+)
+
+var (
+	// writes waiting to be published, one per insert or delete
+	publishQueueSize = 1024
+)
+
+// mirroredSink is implemented by the Sinks able to copy their writes into a second backend
+type mirroredSink interface {
+	setMirror(backend rowBackend)
+}
+
+var (
+	_ mirroredSink = &DBService{}
+	_ mirroredSink = &rowSink{}
+)
+
+// AttachPublisher publishes every row the Sink writes, and every delete, to the
+// message bus of the url (see NewBusSink). The database remains the source of
+// truth: failing to publish is logged but does not fail the write.
+// The rows are published in the background, see busPublisher
+func AttachPublisher(sink Sink, publishUrl string) error {
+	mirrored, ok := sink.(mirroredSink)
+	if !ok {
+		return errors.New("the storage backend cannot publish its rows")
+	}
+	backend, err := newBusBackend(publishUrl)
+	if err != nil {
+		return err
+	}
+	mirrored.setMirror(newBusPublisher(backend, publishQueueSize))
+	return nil
+}
+
+// busWrite is an insert (rows) or a delete (obj) waiting to be published
+type busWrite struct {
+	table string
+	rows  []Row
+	obj   *DeletableObject
+}
+
+// busPublisher publishes the writes of the database from a bounded queue, so a slow or
+// unreachable server never delays them. While the queue is full the writes are dropped
+type busPublisher struct {
+	backend *busBackend
+	queue   chan busWrite
+	done    chan struct{}
+
+	closeOnce sync.Once
+	closeErr  error
+}
+
+func newBusPublisher(backend *busBackend, queueSize int) *busPublisher {
+	p := &busPublisher{
+		backend: backend,
+		queue:   make(chan busWrite, queueSize),
+		done:    make(chan struct{}),
+	}
+	go p.run()
+	return p
+}
+
+func (p *busPublisher) run() {
+	defer close(p.done)
+	for write := range p.queue {
+		if write.obj != nil {
+			if err := p.backend.delete(*write.obj); err != nil {
+				log.Errorf("error publishing delete from %s: %s", write.table, err.Error())
+			}
+			continue
+		}
+		if err := p.backend.insertRows(write.table, write.rows); err != nil {
+			log.Errorf("error publishing %s: %s", write.table, err.Error())
+		}
+	}
+}
+
+func (p *busPublisher) enqueue(write busWrite) error {
+	select {
+	case p.queue <- write:
+		return nil
+	default:
+		return errors.Errorf("publish queue full (%d writes)", cap(p.queue))
+	}
+}
+
+// insert reads the rows right away, the columns of the input are not kept
+func (p *busPublisher) insert(table string, input proto.Input) error {
+	rows, err := tableRows(input)
+	if err != nil {
+		return err
+	}
+	return p.enqueue(busWrite{table: table, rows: rows})
+}
+
+func (p *busPublisher) delete(obj DeletableObject) error {
+	return p.enqueue(busWrite{table: obj.Table(), obj: &obj})
+}
+
+func (p *busPublisher) maxValue(table string, column string) (uint64, error) {
+	return p.backend.maxValue(table, column)
+}
+
+// close publishes the writes still queued, then closes the connection
+func (p *busPublisher) close() error {
+	p.closeOnce.Do(func() {
+		close(p.queue)
+		<-p.done
+		p.closeErr = p.backend.close()
+	})
+	return p.closeErr
+}
+
+func (p *DBService) setMirror(backend rowBackend) {
+	p.mirror = backend
+}
+
+func (s *rowSink) setMirror(backend rowBackend) {
+	s.mirror = backend
+}
+
+func mirrorInsert(mirror rowBackend, table string, input proto.Input) {
+	if mirror == nil {
+		return
+	}
+	if err := mirror.insert(table, input); err != nil {
+		log.Errorf("error publishing %s: %s", table, err.Error())
+	}
+}
+
+func mirrorDelete(mirror rowBackend, obj DeletableObject) {
+	if mirror == nil {
+		return
+	}
+	if err := mirror.delete(obj); err != nil {
+		log.Errorf("error publishing delete from %s: %s", obj.Table(), err.Error())
+	}
+}
+
+func closeMirror(mirror rowBackend) {
+	if mirror == nil {
+		return
+	}
+	if err := mirror.close(); err != nil {
+		log.Errorf("error closing publisher: %s", err.Error())
+	}
+}
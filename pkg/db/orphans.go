@@ -70,7 +70,7 @@ func orphansInput(blocks []spec.AgnosticBlock) proto.Input {
 
 	for _, block := range blocks {
 		f_timestamp.Append(uint64(block.ExecutionPayload.Timestamp))
-		f_epoch.Append(uint64(block.Slot / spec.SlotsPerEpoch))
+		f_epoch.Append(uint64(spec.EpochAtSlot(block.Slot)))
 		f_slot.Append(uint64(block.Slot))
 
 		graffiti := strings.ToValidUTF8(string(block.Graffiti[:]), "?")
@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/migalabs/goteth/pkg/spec"
 )
 
 var (
@@ -34,14 +35,14 @@ var (
 				ON t_validator_rewards_summary.f_val_idx = t_eth2_pubkeys.f_val_idx
 			LEFT JOIN t_proposer_duties final
 				ON t_validator_rewards_summary.f_val_idx = t_proposer_duties.f_val_idx 
-				AND t_validator_rewards_summary.f_epoch = toUInt64(t_proposer_duties.f_proposer_slot/32)
+				AND t_validator_rewards_summary.f_epoch = toUInt64(t_proposer_duties.f_proposer_slot/%d)
 			WHERE f_epoch = $1 AND f_status = 1 AND f_pool_name != ''
 			GROUP BY t_eth2_pubkeys.f_pool_name, f_epoch`
 )
 
 func (p *DBService) InsertPoolSummary(epoch phase0.Epoch) error {
 
-	query := fmt.Sprintf(insertPoolSummary, poolsTables)
+	query := fmt.Sprintf(insertPoolSummary, poolsTables, spec.SlotsPerEpoch)
 	var err error
 	startTime := time.Now()
 
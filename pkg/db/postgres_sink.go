@@ -0,0 +1,290 @@
+package db
+
+import (
+	"context"
+	"embed"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/ClickHouse/ch-go/proto"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/golang-migrate/migrate/v4"
+	_ "github.com/golang-migrate/migrate/v4/database/pgx/v5"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/migalabs/goteth/pkg/spec"
+	"github.com/pkg/errors"
+)
+
+// the migrations are embedded, so the binary runs from any directory
+//
+//go:embed migrations_postgres/*.sql
+var postgresMigrations embed.FS
+
+var (
+	PostgresScheme    = "postgres"
+	PostgresqlScheme  = "postgresql"
+	postgresMigrateDB = "pgx5"           // scheme of the golang-migrate pgx driver
+	postgresCopyOrder = "tmp_copy_order" // column of the temporary tables numbering the rows copied
+
+	selectPrimaryKeyQuery = `
+	SELECT a.attname
+	FROM pg_index i
+	JOIN pg_attribute a
+		ON a.attrelid = i.indrelid AND a.attnum = ANY(i.indkey)
+	WHERE i.indrelid = $1::text::regclass AND i.indisprimary;`
+
+	selectMaxValueQuery = `
+	SELECT COALESCE(MAX(%s), 0)
+	FROM %s;`
+
+	insertPoolSummaryPostgres = `
+		INSERT INTO %s
+			SELECT
+				t_eth2_pubkeys.f_pool_name, f_epoch,
+				SUM(CASE WHEN (f_reward <= f_max_reward) THEN f_reward ELSE 0 END) as aggregated_rewards,
+				SUM(CASE WHEN (f_reward <= f_max_reward) THEN f_max_reward ELSE 0 END) as aggregated_max_rewards,
+				SUM(f_effective_balance) as aggregated_effective_balance,
+				COUNT(CASE WHEN f_in_sync_committee = TRUE THEN 1 ELSE null END) as count_sync_committee,
+				SUM(f_sync_committee_participations_included) as count_sync_committee_participations_included,
+				COUNT(CASE WHEN f_missing_source = TRUE THEN 1 ELSE null END) as count_missing_source,
+				COUNT(CASE WHEN f_missing_target = TRUE THEN 1 ELSE null END) as count_missing_target,
+				COUNT(CASE WHEN f_missing_head = TRUE THEN 1 ELSE null END) as count_missing_head,
+				COUNT(*) as count_expected_attestations,
+				SUM(CASE WHEN f_attestation_included = TRUE THEN 1 ELSE 0 END) as count_included_attestations,
+				SUM(CASE WHEN t_proposer_duties.f_proposed = TRUE THEN 1 ELSE 0 END) as proposed_blocks_performance,
+				SUM(CASE WHEN t_proposer_duties.f_proposed = FALSE and t_validator_rewards_summary.f_val_idx = t_proposer_duties.f_val_idx THEN 1 ELSE 0 END) as missed_blocks_performance,
+				COUNT(DISTINCT t_validator_rewards_summary.f_val_idx) as number_active_vals,
+				COUNT(CASE WHEN f_withdrawal_prefix = 2 THEN 1 ELSE null END) as number_compounding_vals,
+				AVG(f_inclusion_delay) as avg_inclusion_delay
+			FROM t_validator_rewards_summary
+			LEFT JOIN t_eth2_pubkeys
+				ON t_validator_rewards_summary.f_val_idx = t_eth2_pubkeys.f_val_idx
+			LEFT JOIN t_proposer_duties
+				ON t_validator_rewards_summary.f_val_idx = t_proposer_duties.f_val_idx
+				AND t_validator_rewards_summary.f_epoch = t_proposer_duties.f_proposer_slot/%d
+			WHERE f_epoch = $1 AND f_status = 1 AND t_eth2_pubkeys.f_pool_name != ''
+			GROUP BY t_eth2_pubkeys.f_pool_name, f_epoch
+		ON CONFLICT (f_epoch, f_pool_name) DO UPDATE SET %s;`
+)
+
+// postgresBackend persists the tables into PostgreSQL (or TimescaleDB).
+// Rows are loaded with COPY into a temporary table and then upserted on the
+// primary key, which gives the same result as the ClickHouse ReplacingMergeTree
+// when a slot or epoch is processed again.
+type postgresBackend struct {
+	ctx           context.Context
+	connectionUrl string
+	pool          *pgxpool.Pool
+
+	primaryKeys   map[string][]string // table -> primary key columns
+	primaryKeysMu sync.Mutex
+}
+
+// NewPostgresSink returns a Sink persisting into PostgreSQL, migrations are applied on connection
+func NewPostgresSink(ctx context.Context, dbUrl string) (Sink, error) {
+	backend := &postgresBackend{
+		ctx:           ctx,
+		connectionUrl: dbUrl,
+		primaryKeys:   make(map[string][]string),
+	}
+
+	err := backend.makeMigrations()
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to apply postgres migrations")
+	}
+
+	backend.pool, err = pgxpool.New(ctx, dbUrl)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to connect to postgres")
+	}
+	err = backend.pool.Ping(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to connect to postgres")
+	}
+	log.Infof("postgres client initialized")
+
+	return newRowSink(backend), nil
+}
+
+func (b *postgresBackend) makeMigrations() error {
+	_, rest, found := strings.Cut(b.connectionUrl, "://")
+	if !found {
+		return errors.Errorf("invalid postgres url")
+	}
+
+	source, err := iofs.New(postgresMigrations, "migrations_postgres")
+	if err != nil {
+		return err
+	}
+	m, err := migrate.NewWithSourceInstance("iofs", source, postgresMigrateDB+"://"+rest)
+	if err != nil {
+		return err
+	}
+	log.Infof("applying database migrations...")
+	if err := m.Up(); err != nil && err != migrate.ErrNoChange {
+		return err
+	}
+	connErr, dbErr := m.Close()
+	if connErr != nil {
+		return connErr
+	}
+	return dbErr
+}
+
+func (b *postgresBackend) primaryKey(table string) ([]string, error) {
+	b.primaryKeysMu.Lock()
+	defer b.primaryKeysMu.Unlock()
+
+	if keys, ok := b.primaryKeys[table]; ok {
+		return keys, nil
+	}
+
+	rows, err := b.pool.Query(b.ctx, selectPrimaryKeyQuery, table)
+	if err != nil {
+		return nil, err
+	}
+	keys, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return nil, err
+	}
+	if len(keys) == 0 {
+		return nil, errors.Errorf("table %s has no primary key", table)
+	}
+	b.primaryKeys[table] = keys
+	return keys, nil
+}
+
+// upsertQuery moves the rows from the temporary table into the final one,
+// replacing the rows that already exist. When the same key is copied twice,
+// the row copied last wins, as in the ClickHouse ReplacingMergeTree
+func upsertQuery(table string, tmpTable string, columns []string, keys []string) string {
+	isKey := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		isKey[key] = true
+	}
+	updates := make([]string, 0, len(columns))
+	for _, column := range columns {
+		if !isKey[column] {
+			updates = append(updates, fmt.Sprintf("%s = EXCLUDED.%s", column, column))
+		}
+	}
+	onConflict := "DO NOTHING"
+	if len(updates) > 0 {
+		onConflict = "DO UPDATE SET " + strings.Join(updates, ", ")
+	}
+
+	return fmt.Sprintf(
+		`INSERT INTO %s (%s) SELECT DISTINCT ON (%s) %s FROM %s ORDER BY %s, %s DESC ON CONFLICT (%s) %s;`,
+		table,
+		strings.Join(columns, ", "),
+		strings.Join(keys, ", "),
+		strings.Join(columns, ", "),
+		tmpTable,
+		strings.Join(keys, ", "),
+		postgresCopyOrder,
+		strings.Join(keys, ", "),
+		onConflict)
+}
+
+func (b *postgresBackend) insert(table string, input proto.Input) error {
+	rows, err := inputRows(input)
+	if err != nil {
+		return err
+	}
+	columns := inputColumns(input)
+	keys, err := b.primaryKey(table)
+	if err != nil {
+		return err
+	}
+
+	tx, err := b.pool.Begin(b.ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(b.ctx)
+
+	tmpTable := "tmp_" + table
+	_, err = tx.Exec(b.ctx, fmt.Sprintf(`CREATE TEMP TABLE %s (LIKE %s INCLUDING DEFAULTS, %s BIGSERIAL) ON COMMIT DROP;`, tmpTable, table, postgresCopyOrder))
+	if err != nil {
+		return err
+	}
+	_, err = tx.CopyFrom(b.ctx, pgx.Identifier{tmpTable}, columns, pgx.CopyFromRows(rows))
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(b.ctx, upsertQuery(table, tmpTable, columns, keys))
+	if err != nil {
+		return err
+	}
+	return tx.Commit(b.ctx)
+}
+
+func (b *postgresBackend) delete(obj DeletableObject) error {
+	_, err := b.pool.Exec(b.ctx, obj.Query(), obj.Args()...)
+	return err
+}
+
+func (b *postgresBackend) maxValue(table string, column string) (uint64, error) {
+	var value int64
+	err := b.pool.QueryRow(b.ctx, fmt.Sprintf(selectMaxValueQuery, column, table)).Scan(&value)
+	if err != nil {
+		return 0, err
+	}
+	return uint64(value), nil
+}
+
+func (b *postgresBackend) insertPoolSummary(epoch phase0.Epoch) error {
+	columns := []string{
+		"aggregated_rewards",
+		"aggregated_max_rewards",
+		"aggregated_effective_balance",
+		"count_sync_committee",
+		"count_sync_committee_participations_included",
+		"count_missing_source",
+		"count_missing_target",
+		"count_missing_head",
+		"count_expected_attestations",
+		"count_attestations_included",
+		"proposed_blocks_performance",
+		"missed_blocks_performance",
+		"number_active_vals",
+		"number_compounding_vals",
+		"avg_inclusion_delay",
+	}
+	updates := make([]string, 0, len(columns))
+	for _, column := range columns {
+		updates = append(updates, fmt.Sprintf("%s = EXCLUDED.%s", column, column))
+	}
+
+	_, err := b.pool.Exec(b.ctx,
+		fmt.Sprintf(insertPoolSummaryPostgres, poolsTables, spec.SlotsPerEpoch, strings.Join(updates, ", ")),
+		uint64(epoch))
+	return err
+}
+
+func (b *postgresBackend) depositSenders() (map[string]string, error) {
+	rows, err := b.pool.Query(b.ctx, fmt.Sprintf(selectDepositSendersQuery, eth1DepositsTable))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	senders := make(map[string]string)
+	for rows.Next() {
+		var pubkey, sender string
+		err = rows.Scan(&pubkey, &sender)
+		if err != nil {
+			return nil, err
+		}
+		addDepositSender(senders, pubkey, sender)
+	}
+	return senders, rows.Err()
+}
+
+func (b *postgresBackend) close() error {
+	b.pool.Close()
+	return nil
+}
@@ -0,0 +1,45 @@
+package db
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPostgresMigrationsEmbedded(t *testing.T) {
+	source, err := iofs.New(postgresMigrations, "migrations_postgres")
+	require.NoError(t, err)
+	defer source.Close()
+
+	version, err := source.First()
+	require.NoError(t, err)
+	count := 1
+	for {
+		version, err = source.Next(version)
+		if err != nil {
+			break
+		}
+		count++
+	}
+	assert.Equal(t, 6, count)
+}
+
+func TestPostgresUpsertQuery(t *testing.T) {
+	query := upsertQuery("t_epoch_metrics_summary", "tmp_t_epoch_metrics_summary", []string{"f_epoch", "f_slot"}, []string{"f_epoch"})
+	assert.Equal(t,
+		"INSERT INTO t_epoch_metrics_summary (f_epoch, f_slot) SELECT DISTINCT ON (f_epoch) f_epoch, f_slot "+
+			"FROM tmp_t_epoch_metrics_summary ORDER BY f_epoch, tmp_copy_order DESC "+
+			"ON CONFLICT (f_epoch) DO UPDATE SET f_slot = EXCLUDED.f_slot;",
+		query)
+}
+
+func TestPostgresNumericValue(t *testing.T) {
+	fee, _ := new(big.Int).SetString("18446744073709551615", 10)
+	assert.Equal(t, uint64(18446744073709551615), postgresValue(pgtype.Numeric{Int: fee, Valid: true}))
+	assert.Equal(t, uint64(12_000), postgresValue(pgtype.Numeric{Int: big.NewInt(12), Exp: 3, Valid: true}))
+	assert.Equal(t, "text", postgresValue("text"))
+}
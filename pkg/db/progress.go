@@ -0,0 +1,141 @@
+package db
+
+import (
+	"github.com/ClickHouse/ch-go/proto"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+)
+
+var (
+	progressTable       = "t_backfill_progress"
+	insertProgressQuery = `
+	INSERT INTO %s (
+		f_metric,
+		f_epoch)
+		VALUES`
+	deleteProgressQuery = `
+		DELETE FROM %s
+		WHERE f_epoch = $1;
+`
+)
+
+// Metrics whose progress is recorded, an epoch is done for a metric once every
+// table of the metric has been written for the whole epoch
+const (
+	BlockProgress        = "block"
+	TransactionsProgress = "transactions"
+	BlobSidecarsProgress = "blob_sidecars"
+	EpochProgress        = "epoch"
+	RewardsProgress      = "rewards"
+)
+
+// Progress records that an epoch has been fully processed for a metric
+type Progress struct {
+	Metric string
+	Epoch  phase0.Epoch
+}
+
+func progressInput(progress []Progress) proto.Input {
+	// one object per column
+	var (
+		f_metric proto.ColStr
+		f_epoch  proto.ColUInt64
+	)
+
+	for _, item := range progress {
+		f_metric.Append(item.Metric)
+		f_epoch.Append(uint64(item.Epoch))
+	}
+
+	return proto.Input{
+		{Name: "f_metric", Data: f_metric},
+		{Name: "f_epoch", Data: f_epoch},
+	}
+}
+
+func (p *DBService) PersistProgress(data []Progress) error {
+	persistObj := PersistableObject[Progress]{
+		input: progressInput,
+		table: progressTable,
+		query: insertProgressQuery,
+	}
+
+	for _, item := range data {
+		persistObj.Append(item)
+	}
+
+	err := p.Persist(persistObj.ExportPersist())
+	if err != nil {
+		log.Errorf("error persisting backfill progress: %s", err.Error())
+	}
+	return err
+}
+
+func (p *DBService) RetrieveProgress(from phase0.Epoch, to phase0.Epoch) ([]Progress, error) {
+	return retrieveProgress(p, from, to)
+}
+
+// DeleteProgress forgets the progress of an epoch, so it is processed again if the
+// analyzer stops before the epoch is rewritten (i.e. after a reorg)
+func (p *DBService) DeleteProgress(epoch phase0.Epoch) error {
+	deleteObj := DeletableObject{
+		query: deleteProgressQuery,
+		table: progressTable,
+		args:  []any{epoch},
+	}
+
+	err := p.Delete(deleteObj)
+	if err != nil {
+		log.Errorf("error deleting backfill progress: %s", err.Error())
+	}
+	return err
+}
+
+func (s *rowSink) PersistProgress(data []Progress) error {
+	return s.persist(progressTable, progressInput(data))
+}
+
+func (s *rowSink) DeleteProgress(epoch phase0.Epoch) error {
+	return s.deleteAll([]DeletableObject{{
+		query: deleteProgressQuery,
+		table: progressTable,
+		args:  []any{epoch},
+	}})
+}
+
+// RetrieveProgress returns no progress for the backends that cannot read back the
+// tables (Parquet files, message bus), so they always start from the init slot
+func (s *rowSink) RetrieveProgress(from phase0.Epoch, to phase0.Epoch) ([]Progress, error) {
+	if _, ok := s.backend.(rowsBackend); !ok {
+		return nil, nil
+	}
+	return retrieveProgress(s, from, to)
+}
+
+func retrieveProgress(reader Reader, from phase0.Epoch, to phase0.Epoch) ([]Progress, error) {
+	if to < from {
+		return nil, nil
+	}
+	rows, err := reader.SelectRows(Query{
+		Table: progressTable,
+		Final: true,
+		Filters: []Filter{
+			{Column: "f_epoch", Op: ">=", Value: uint64(from)},
+			{Column: "f_epoch", Op: "<=", Value: uint64(to)},
+		},
+		OrderBy: []string{"f_epoch"},
+		Limit:   int(to-from+1) * 8, // more than the metrics tracked per epoch
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	progress := make([]Progress, 0, len(rows))
+	for _, row := range rows {
+		metric, _ := row["f_metric"].(string)
+		progress = append(progress, Progress{
+			Metric: metric,
+			Epoch:  phase0.Epoch(row.Uint64("f_epoch")),
+		})
+	}
+	return progress, nil
+}
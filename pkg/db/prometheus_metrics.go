@@ -3,6 +3,7 @@ package db
 import (
 	"strings"
 
+	"github.com/attestantio/go-eth2-client/spec/phase0"
 	"github.com/migalabs/goteth/pkg/metrics"
 	"github.com/migalabs/goteth/pkg/utils"
 	"github.com/prometheus/client_golang/prometheus"
@@ -72,56 +73,71 @@ var (
 	)
 )
 
-func (r *DBService) initMonitorMetrics() {
+// tables whose inserts are monitored
+var monitoredTables = []string{
+	blobsTable,
+	blobEventsTable,
+	blockRewardsTable,
+	blocksTable,
+	epochsTable,
+	finalizedTable,
+	genesisTable,
+	headEventsTable,
+	orphansTable,
+	poolsTables,
+	proposerDutiesTable,
+	reorgsTable,
+	transactionsTable,
+	valLastStatusTable,
+	valRewardsTable,
+	valRewardsAggregationTable,
+	withdrawalsTable,
+	slashingsTable,
+	blsToExecutionChangeTable,
+	depositsTable,
+	eth1DepositsTable,
+	consolidationRequestsTable,
+	consolidationsProcessedTable,
+	withdrawalRequestsTable,
+	depositRequestsTable,
+	attestationsTable,
+	syncCommitteeTable,
+	progressTable,
+}
 
-	tablesArr := []string{
-		blobsTable,
-		blobEventsTable,
-		blockRewardsTable,
-		blocksTable,
-		epochsTable,
-		finalizedTable,
-		genesisTable,
-		headEventsTable,
-		orphansTable,
-		poolsTables,
-		proposerDutiesTable,
-		reorgsTable,
-		transactionsTable,
-		valLastStatusTable,
-		valRewardsTable,
-		valRewardsAggregationTable,
-		withdrawalsTable,
-		slashingsTable,
-		blsToExecutionChangeTable,
-		depositsTable,
-		eth1DepositsTable,
-		consolidationRequestsTable,
-		consolidationsProcessedTable,
-		withdrawalRequestsTable,
-		depositRequestsTable,
-	}
+func (r *DBService) initMonitorMetrics() {
 
-	for _, tableName := range tablesArr {
+	for _, tableName := range monitoredTables {
 		r.monitorMetrics[tableName] = &DBMonitorMetrics{}
 	}
 
 }
 
+// monitoredSink is implemented by every backend that exports the database prometheus metrics
+type monitoredSink interface {
+	getMonitorMetrics() map[string]DBMonitorMetrics
+	RetrieveLastSlot() (phase0.Slot, error)
+	RetrieveLastEpoch() (phase0.Epoch, error)
+}
+
 func (r *DBService) GetPrometheusMetrics() *metrics.MetricsModule {
+	return sinkPrometheusMetrics(r)
+}
+
+func sinkPrometheusMetrics(r monitoredSink) *metrics.MetricsModule {
 	metricsMod := metrics.NewMetricsModule(
 		modName,
 		"metrics about the database",
 	)
 	// compose all the metrics
-	metricsMod.AddIndvMetric(r.getPersistMetrics())
+	metricsMod.AddIndvMetric(getPersistMetrics(r))
 
-	metricsMod.AddIndvMetric(r.lastProcessedSlotMetric())
-	metricsMod.AddIndvMetric(r.lastProcessedEpochMetric())
+	metricsMod.AddIndvMetric(lastProcessedSlotMetric(r))
+	metricsMod.AddIndvMetric(lastProcessedEpochMetric(r))
 	return metricsMod
 }
 
-func (r *DBService) getPersistMetrics() *metrics.IndvMetrics {
+func getPersistMetrics(r monitoredSink) *metrics.IndvMetrics {
 	initFn := func() error {
 		prometheus.MustRegister(RowsPersisted)
 		prometheus.MustRegister(TimePersisted)
@@ -161,7 +177,7 @@ func (r *DBService) getPersistMetrics() *metrics.IndvMetrics {
 	return persistingMetrics
 }
 
-func (r *DBService) lastProcessedEpochMetric() *metrics.IndvMetrics {
+func lastProcessedEpochMetric(r monitoredSink) *metrics.IndvMetrics {
 	initFn := func() error {
 		prometheus.MustRegister(LastProcessedEpoch)
 		return nil
@@ -185,7 +201,7 @@ func (r *DBService) lastProcessedEpochMetric() *metrics.IndvMetrics {
 	return lastEpoch
 }
 
-func (r *DBService) lastProcessedSlotMetric() *metrics.IndvMetrics {
+func lastProcessedSlotMetric(r monitoredSink) *metrics.IndvMetrics {
 	initFn := func() error {
 		prometheus.MustRegister(LastProcessedSlot)
 		return nil
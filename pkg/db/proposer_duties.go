@@ -16,9 +16,10 @@ var (
 	`
 	// if there is a confilct the line already exists
 
+	// formatted with the slots per epoch first
 	deleteProposerDutiesQuery = `
-	DELETE FROM %s
-	WHERE f_proposer_slot/32 = $1;
+	DELETE FROM %%s
+	WHERE f_proposer_slot/%d = $1;
 `
 )
 
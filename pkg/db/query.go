@@ -0,0 +1,286 @@
+package db
+
+import (
+	"fmt"
+	"math/big"
+	"reflect"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/pkg/errors"
+)
+
+// Filter is a condition on a column of the table, Op is one of =, >=, <= and in
+type Filter struct {
+	Column string
+	Op     string
+	Value  any // uint64 or string, []uint64 for in
+}
+
+// Query selects the rows of a table, it is used by the query API (see pkg/server)
+type Query struct {
+	Table   string
+	Final   bool // deduplicate the rows of the ReplacingMergeTree tables
+	Filters []Filter
+	OrderBy []string
+	Desc    bool
+	Limit   int
+	Offset  int
+}
+
+// Reader is implemented by the Sinks able to read back the tables
+type Reader interface {
+	SelectRows(query Query) ([]Row, error)
+}
+
+var (
+	_ Reader = &DBService{}
+	_ Reader = &rowSink{}
+)
+
+// rowsBackend is implemented by the row backends able to read back the tables
+type rowsBackend interface {
+	selectRows(query Query) ([]Row, error)
+}
+
+var columnNameRegex = regexp.MustCompile(`^\w+$`)
+
+func (q Query) validate() error {
+	if !slices.Contains(monitoredTables, q.Table) {
+		return errors.Errorf("unknown table %s", q.Table)
+	}
+	for _, filter := range q.Filters {
+		if !columnNameRegex.MatchString(filter.Column) {
+			return errors.Errorf("invalid column %s", filter.Column)
+		}
+		switch filter.Op {
+		case "=", ">=", "<=":
+		case "in":
+			if values, ok := filter.Value.([]uint64); !ok || len(values) == 0 {
+				return errors.Errorf("invalid values for column %s", filter.Column)
+			}
+		default:
+			return errors.Errorf("invalid operator %s", filter.Op)
+		}
+	}
+	for _, column := range q.OrderBy {
+		if !columnNameRegex.MatchString(column) {
+			return errors.Errorf("invalid column %s", column)
+		}
+	}
+	if q.Limit <= 0 || q.Offset < 0 {
+		return errors.Errorf("invalid limit %d or offset %d", q.Limit, q.Offset)
+	}
+	return nil
+}
+
+// sql returns the select query with numbered arguments, final is only supported by ClickHouse
+func (q Query) sql(final bool) (string, []any) {
+	var sb strings.Builder
+	sb.WriteString("SELECT * FROM " + q.Table)
+	if final && q.Final {
+		sb.WriteString(" FINAL")
+	}
+
+	args := make([]any, 0, len(q.Filters))
+	for i, filter := range q.Filters {
+		if i == 0 {
+			sb.WriteString(" WHERE ")
+		} else {
+			sb.WriteString(" AND ")
+		}
+		if filter.Op != "in" {
+			args = append(args, filter.Value)
+			fmt.Fprintf(&sb, "%s %s $%d", filter.Column, filter.Op, len(args))
+			continue
+		}
+		// one argument per value, so both dialects bind them the same way
+		placeholders := make([]string, 0)
+		for _, value := range filter.Value.([]uint64) {
+			args = append(args, value)
+			placeholders = append(placeholders, fmt.Sprintf("$%d", len(args)))
+		}
+		fmt.Fprintf(&sb, "%s IN (%s)", filter.Column, strings.Join(placeholders, ", "))
+	}
+
+	if len(q.OrderBy) > 0 {
+		direction := " ASC"
+		if q.Desc {
+			direction = " DESC"
+		}
+		sb.WriteString(" ORDER BY " + strings.Join(q.OrderBy, direction+", ") + direction)
+	}
+	fmt.Fprintf(&sb, " LIMIT %d OFFSET %d", q.Limit, q.Offset)
+	return sb.String(), args
+}
+
+// matches returns whether the row passes every filter of the query
+func (q Query) matches(row Row) bool {
+	for _, filter := range q.Filters {
+		if !filter.matches(row[filter.Column]) {
+			return false
+		}
+	}
+	return true
+}
+
+func (f Filter) matches(value any) bool {
+	if text, ok := f.Value.(string); ok {
+		return value == text
+	}
+	if values, ok := f.Value.([]uint64); ok {
+		rowValue, ok := toUint64(value)
+		return ok && slices.Contains(values, rowValue)
+	}
+	filterValue, ok := toUint64(f.Value)
+	if !ok {
+		return false
+	}
+	rowValue, ok := toUint64(value)
+	if !ok {
+		return false
+	}
+	switch f.Op {
+	case ">=":
+		return rowValue >= filterValue
+	case "<=":
+		return rowValue <= filterValue
+	default:
+		return rowValue == filterValue
+	}
+}
+
+// sortRows orders the rows by the columns of the query, numeric or string
+func (q Query) sortRows(rows []Row) {
+	sort.SliceStable(rows, func(i, j int) bool {
+		for _, column := range q.OrderBy {
+			a, b := rows[i][column], rows[j][column]
+			if reflect.DeepEqual(a, b) {
+				continue
+			}
+			var less bool
+			numA, okA := toUint64(a)
+			numB, okB := toUint64(b)
+			if okA && okB {
+				less = numA < numB
+			} else {
+				less = fmt.Sprint(a) < fmt.Sprint(b)
+			}
+			if q.Desc {
+				return !less
+			}
+			return less
+		}
+		return false
+	})
+}
+
+// SelectRows returns the rows of the table matching the query
+func (p *DBService) SelectRows(query Query) ([]Row, error) {
+	if err := query.validate(); err != nil {
+		return nil, err
+	}
+	sqlQuery, args := query.sql(true)
+
+	startTime := time.Now()
+	p.highMu.Lock()
+	defer p.highMu.Unlock()
+
+	rows, err := p.highLevelClient.Query(p.ctx, sqlQuery, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	columnTypes := rows.ColumnTypes()
+	result := make([]Row, 0)
+	for rows.Next() {
+		values := make([]any, len(columnTypes))
+		for i, columnType := range columnTypes {
+			values[i] = reflect.New(columnType.ScanType()).Interface()
+		}
+		if err := rows.Scan(values...); err != nil {
+			return nil, err
+		}
+		row := make(Row, len(columnTypes))
+		for i, columnType := range columnTypes {
+			row[columnType.Name()] = reflect.ValueOf(values[i]).Elem().Interface()
+		}
+		result = append(result, row)
+	}
+	log.Debugf("retrieved %d rows in %f seconds, query: %s", len(result), time.Since(startTime).Seconds(), sqlQuery)
+	return result, rows.Err()
+}
+
+// SelectRows returns the rows of the table matching the query
+func (s *rowSink) SelectRows(query Query) ([]Row, error) {
+	if err := query.validate(); err != nil {
+		return nil, err
+	}
+	reader, ok := s.backend.(rowsBackend)
+	if !ok {
+		return nil, errors.New("the storage backend cannot read back the tables")
+	}
+	return reader.selectRows(query)
+}
+
+func (b *memoryBackend) selectRows(query Query) ([]Row, error) {
+	result := make([]Row, 0)
+	for _, row := range b.rows(query.Table) {
+		if query.matches(row) {
+			result = append(result, row)
+		}
+	}
+	query.sortRows(result)
+
+	if query.Offset >= len(result) {
+		return []Row{}, nil
+	}
+	result = result[query.Offset:]
+	if len(result) > query.Limit {
+		result = result[:query.Limit]
+	}
+	return result, nil
+}
+
+func (b *postgresBackend) selectRows(query Query) ([]Row, error) {
+	sqlQuery, args := query.sql(false)
+	rows, err := b.pool.Query(b.ctx, sqlQuery, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	fields := rows.FieldDescriptions()
+	result := make([]Row, 0)
+	for rows.Next() {
+		values, err := rows.Values()
+		if err != nil {
+			return nil, err
+		}
+		row := make(Row, len(fields))
+		for i, field := range fields {
+			row[field.Name] = postgresValue(values[i])
+		}
+		result = append(result, row)
+	}
+	return result, rows.Err()
+}
+
+// postgresValue returns the uint64 of the NUMERIC columns (wei amounts, far future
+// epochs), as written, so they are read back like the other numeric columns
+func postgresValue(value any) any {
+	numeric, ok := value.(pgtype.Numeric)
+	if !ok || !numeric.Valid || numeric.NaN || numeric.InfinityModifier != pgtype.Finite || numeric.Exp < 0 {
+		return value
+	}
+	integer := new(big.Int).Mul(numeric.Int, new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(numeric.Exp)), nil))
+	if !integer.IsUint64() {
+		return value
+	}
+	return integer.Uint64()
+}
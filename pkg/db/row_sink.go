@@ -0,0 +1,313 @@
+package db
+
+import (
+	"sync"
+	"time"
+
+	"github.com/ClickHouse/ch-go/proto"
+	api "github.com/attestantio/go-eth2-client/api/v1"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/migalabs/goteth/pkg/metrics"
+	"github.com/migalabs/goteth/pkg/spec"
+	"github.com/pkg/errors"
+)
+
+// rowBackend is implemented by the storage backends that are fed with the
+// column builders of each table (see columns.go) instead of ClickHouse queries
+type rowBackend interface {
+	insert(table string, input proto.Input) error
+	delete(obj DeletableObject) error
+	maxValue(table string, column string) (uint64, error) // 0 if the table is empty
+	close() error
+}
+
+// poolSummaryBackend is implemented by the row backends able to aggregate the pool summaries
+type poolSummaryBackend interface {
+	insertPoolSummary(epoch phase0.Epoch) error
+}
+
+// depositSendersBackend is implemented by the row backends able to read back the deposits
+type depositSendersBackend interface {
+	depositSenders() (map[string]string, error)
+}
+
+// rowSink implements the Sink for any rowBackend, using the same tables,
+// columns and deletes as the ClickHouse DBService
+type rowSink struct {
+	backend rowBackend
+	mirror  rowBackend // receives a copy of every write, see AttachPublisher
+
+	monitorMetrics map[string]*DBMonitorMetrics // map table and metrics
+	metricsMu      sync.RWMutex
+}
+
+var _ Sink = &rowSink{}
+
+func newRowSink(backend rowBackend) *rowSink {
+	s := &rowSink{
+		backend:        backend,
+		monitorMetrics: make(map[string]*DBMonitorMetrics),
+	}
+	for _, tableName := range monitoredTables {
+		s.monitorMetrics[tableName] = &DBMonitorMetrics{}
+	}
+	return s
+}
+
+func (s *rowSink) persist(table string, input proto.Input) error {
+	rows := 0
+	if len(input) > 0 {
+		rows = input[0].Data.Rows()
+	}
+	if rows == 0 {
+		return nil
+	}
+
+	startTime := time.Now()
+	err := s.backend.insert(table, input)
+	elapsedTime := time.Since(startTime)
+	if err != nil {
+		log.Errorf("error persisting %s: %s", table, err.Error())
+		return err
+	}
+	log.Debugf("table %s persisted %d rows in %fs", table, rows, elapsedTime.Seconds())
+
+	s.metricsMu.Lock()
+	if _, ok := s.monitorMetrics[table]; !ok {
+		s.monitorMetrics[table] = &DBMonitorMetrics{}
+	}
+	s.monitorMetrics[table].addNewPersist(rows, elapsedTime)
+	s.metricsMu.Unlock()
+
+	mirrorInsert(s.mirror, table, input)
+	return nil
+}
+
+func (s *rowSink) deleteAll(deleteObjs []DeletableObject) error {
+	for _, deleteObj := range deleteObjs {
+		err := s.backend.delete(deleteObj)
+		if err != nil {
+			log.Errorf("error deleting from %s: %s", deleteObj.Table(), err.Error())
+			return err
+		}
+		mirrorDelete(s.mirror, deleteObj)
+	}
+	return nil
+}
+
+func (s *rowSink) getMonitorMetrics() map[string]DBMonitorMetrics {
+	s.metricsMu.RLock()
+	defer s.metricsMu.RUnlock()
+
+	copyMonitorMetrics := make(map[string]DBMonitorMetrics, len(s.monitorMetrics))
+	for table, metrics := range s.monitorMetrics {
+		copyMonitorMetrics[table] = *metrics
+	}
+	return copyMonitorMetrics
+}
+
+func (s *rowSink) GetPrometheusMetrics() *metrics.MetricsModule {
+	return sinkPrometheusMetrics(s)
+}
+
+func (s *rowSink) Finish() {
+	err := s.backend.close()
+	if err != nil {
+		log.Errorf("error closing storage backend: %s", err.Error())
+	}
+	closeMirror(s.mirror)
+	log.Infof("storage backend closed...")
+}
+
+func (s *rowSink) InitGenesis(apiGenesis time.Time) error {
+	dbGenesis, err := s.backend.maxValue(genesisTable, "f_genesis_time")
+	if err != nil {
+		log.Errorf("could not get genesis from database: %s", err)
+		return err
+	}
+
+	if dbGenesis == 0 { // table is empty, probably first time use
+		return s.persist(genesisTable, genesisInput([]int64{apiGenesis.Unix()}))
+	}
+
+	if uint64(apiGenesis.Unix()) != dbGenesis {
+		log.Errorf("the genesis time in the database does not match the API, is the beacon node in the correct network?")
+	}
+	return nil
+}
+
+func (s *rowSink) RetrieveLastSlot() (phase0.Slot, error) {
+	slot, err := s.backend.maxValue(blocksTable, "f_slot")
+	return phase0.Slot(slot), err
+}
+
+func (s *rowSink) RetrieveLastEpoch() (phase0.Epoch, error) {
+	epoch, err := s.backend.maxValue(epochsTable, "f_epoch")
+	return phase0.Epoch(epoch), err
+}
+
+func (s *rowSink) PersistBlocks(data []spec.AgnosticBlock) error {
+	return s.persist(blocksTable, blocksInput(data))
+}
+
+func (s *rowSink) PersistOrphans(data []spec.AgnosticBlock) error {
+	return s.persist(orphansTable, orphansInput(data))
+}
+
+func (s *rowSink) PersistTransactions(data []spec.AgnosticTransaction) error {
+	return s.persist(transactionsTable, transactionsInput(data))
+}
+
+func (s *rowSink) PersistWithdrawals(data []spec.Withdrawal) error {
+	return s.persist(withdrawalsTable, withdrawalsInput(data))
+}
+
+func (s *rowSink) PersistBLSToExecutionChanges(data []spec.BLSToExecutionChange) error {
+	return s.persist(blsToExecutionChangeTable, blsToExecutionChangeInput(data))
+}
+
+func (s *rowSink) PersistETH1Deposits(data []spec.ETH1Deposit) error {
+	return s.persist(eth1DepositsTable, eth1DepositsInput(data))
+}
+
+func (s *rowSink) PersistBlobSidecars(data []*spec.AgnosticBlobSidecar) error {
+	blobs := make([]spec.AgnosticBlobSidecar, 0, len(data))
+	for _, item := range data {
+		blobs = append(blobs, *item)
+	}
+	return s.persist(blobsTable, blobSidecarsInput(blobs))
+}
+
+func (s *rowSink) DeleteBlockMetrics(slot phase0.Slot) error {
+	return s.deleteAll(blockMetricsDeletes(slot))
+}
+
+func (s *rowSink) PersistEpochs(data []spec.Epoch) error {
+	return s.persist(epochsTable, epochsInput(data))
+}
+
+func (s *rowSink) PersistDuties(data []spec.ProposerDuty) error {
+	return s.persist(proposerDutiesTable, proposerDutiesInput(data))
+}
+
+func (s *rowSink) PersistValidatorRewards(data []spec.ValidatorRewards) error {
+	return s.persist(valRewardsTable, rewardsInput(data))
+}
+
+func (s *rowSink) PersistValidatorRewardsAggregation(data map[phase0.ValidatorIndex]*spec.ValidatorRewardsAggregation) error {
+	aggregations := make([]spec.ValidatorRewardsAggregation, 0, len(data))
+	for _, item := range data {
+		aggregations = append(aggregations, *item)
+	}
+	return s.persist(valRewardsAggregationTable, rewardsAggregationInput(aggregations))
+}
+
+func (s *rowSink) PersistValLastStatus(data []spec.ValidatorLastStatus) error {
+	return s.persist(valLastStatusTable, valStatusInput(data))
+}
+
+func (s *rowSink) PersistBlockRewards(data []BlockReward) error {
+	return s.persist(blockRewardsTable, blockRewardsInput(data))
+}
+
+func (s *rowSink) PersistSlashings(data []spec.AgnosticSlashing) error {
+	return s.persist(slashingsTable, slashingsInput(data))
+}
+
+func (s *rowSink) PersistValidatorAttestations(data []spec.ValidatorAttestation) error {
+	return s.persist(attestationsTable, attestationsInput(data))
+}
+
+func (s *rowSink) PersistSyncCommitteeDuties(data []spec.SyncCommitteeDuty) error {
+	return s.persist(syncCommitteeTable, syncCommitteeInput(data))
+}
+
+func (s *rowSink) PersistDeposits(data []spec.Deposit) error {
+	return s.persist(depositsTable, depositsInput(data))
+}
+
+func (s *rowSink) PersistWithdrawalRequests(data []spec.WithdrawalRequest) error {
+	return s.persist(withdrawalRequestsTable, withdrawalRequestsInput(data))
+}
+
+func (s *rowSink) PersistConsolidationRequests(data []spec.ConsolidationRequest) error {
+	return s.persist(consolidationRequestsTable, consolidationRequestsInput(data))
+}
+
+func (s *rowSink) PersistDepositRequests(data []spec.DepositRequest) error {
+	return s.persist(depositRequestsTable, depositRequestsInput(data))
+}
+
+func (s *rowSink) PersistConsolidationsProcessed(data []spec.ConsolidationProcessed) error {
+	return s.persist(consolidationsProcessedTable, consolidationsProcessedInput(data))
+}
+
+func (s *rowSink) InsertPoolSummary(epoch phase0.Epoch) error {
+	summarizer, ok := s.backend.(poolSummaryBackend)
+	if !ok {
+		log.Debugf("pool summaries not supported by the storage backend, skipping epoch %d", epoch)
+		return nil
+	}
+	startTime := time.Now()
+	err := summarizer.insertPoolSummary(epoch)
+	if err == nil {
+		log.Infof("pool summaries created for epoch %d, %f seconds", epoch, time.Since(startTime).Seconds())
+	}
+	return err
+}
+
+func (s *rowSink) DeleteStateMetrics(epoch phase0.Epoch) error {
+	return s.deleteAll(stateMetricsDeletes(epoch))
+}
+
+func (s *rowSink) DeleteValLastStatus(epoch phase0.Epoch) error {
+	return s.deleteAll([]DeletableObject{{
+		query: deleteValidatorStatus,
+		table: valLastStatusTable,
+		args:  []any{epoch},
+	}})
+}
+
+func (s *rowSink) DeleteValidatorRewardsUntil(epoch phase0.Epoch) error {
+	return s.deleteAll([]DeletableObject{{
+		query: deleteValidatorRewardsUntilEpochQuery,
+		table: valRewardsTable,
+		args:  []any{epoch},
+	}})
+}
+
+func (s *rowSink) PersistHeadEvents(data []HeadEvent) error {
+	return s.persist(headEventsTable, headEventsInput(data))
+}
+
+func (s *rowSink) PersistFinalized(data []api.FinalizedCheckpointEvent) error {
+	return s.persist(finalizedTable, finalizedInput(data))
+}
+
+func (s *rowSink) PersistReorgs(data []api.ChainReorgEvent) error {
+	return s.persist(reorgsTable, reorgsInput(data))
+}
+
+func (s *rowSink) PersistBlobSidecarsEvents(data []spec.BlobSideCarEventWraper) error {
+	return s.persist(blobEventsTable, blobSidecarsEventInput(data))
+}
+
+func (s *rowSink) PersistValidatorLabels(data []spec.ValidatorLabel) error {
+	return s.persist(eth2PubkeysTable, eth2PubkeysInput(data))
+}
+
+func (s *rowSink) DeleteValidatorLabels(poolName string) error {
+	return s.deleteAll([]DeletableObject{{
+		query: deleteEth2PubkeysQuery,
+		table: eth2PubkeysTable,
+		args:  []any{poolName},
+	}})
+}
+
+func (s *rowSink) RetrieveDepositSenders() (map[string]string, error) {
+	reader, ok := s.backend.(depositSendersBackend)
+	if !ok {
+		return nil, errors.New("deposits can not be read from the storage backend")
+	}
+	return reader.depositSenders()
+}
@@ -0,0 +1,48 @@
+package db
+
+import (
+	"github.com/ClickHouse/ch-go/proto"
+	api "github.com/attestantio/go-eth2-client/api/v1"
+
+	"github.com/migalabs/goteth/pkg/spec"
+)
+
+// The functions below return the rows that persisting the data writes, with the
+// column names of the tables, so the data can be published as it is stored (see pkg/stream)
+
+func BlockRows(blocks []spec.AgnosticBlock) ([]Row, error) {
+	return tableRows(blocksInput(blocks))
+}
+
+func OrphanRows(blocks []spec.AgnosticBlock) ([]Row, error) {
+	return tableRows(orphansInput(blocks))
+}
+
+func EpochRows(epochs []spec.Epoch) ([]Row, error) {
+	return tableRows(epochsInput(epochs))
+}
+
+func ValidatorRewardsRows(vals []spec.ValidatorRewards) ([]Row, error) {
+	return tableRows(rewardsInput(vals))
+}
+
+func ReorgRows(reorgs []api.ChainReorgEvent) ([]Row, error) {
+	return tableRows(reorgsInput(reorgs))
+}
+
+func tableRows(input proto.Input) ([]Row, error) {
+	values, err := inputRows(input)
+	if err != nil {
+		return nil, err
+	}
+	columns := inputColumns(input)
+	rows := make([]Row, 0, len(values))
+	for _, rowValues := range values {
+		row := make(Row, len(columns))
+		for i, column := range columns {
+			row[column] = rowValues[i]
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
@@ -36,6 +36,7 @@ type DBService struct {
 	highLevelClient driver.Conn // for side tasks, like Select and Delete
 
 	monitorMetrics map[string]*DBMonitorMetrics // map table and metrics
+	mirror         rowBackend                   // receives a copy of every write, see AttachPublisher
 	lowMu          sync.Mutex
 	highMu         sync.Mutex
 	metricsMu      sync.RWMutex
@@ -86,6 +87,7 @@ func (p *DBService) Finish() {
 
 	p.lowLevelClient.Close()
 	p.highLevelClient.Close()
+	closeMirror(p.mirror)
 	log.Infof("Routines finished...")
 	log.Infof("closing connection to database server...")
 	log.Infof("connection to database server closed...")
@@ -154,7 +156,11 @@ type PersistableObject[
 		spec.ConsolidationRequest |
 		spec.ConsolidationProcessed |
 		spec.WithdrawalRequest |
-		spec.DepositRequest] struct {
+		spec.DepositRequest |
+		spec.ValidatorAttestation |
+		spec.SyncCommitteeDuty |
+		spec.ValidatorLabel |
+		Progress] struct {
 	table string
 	query string
 	data  []T
@@ -0,0 +1,109 @@
+package db
+
+import (
+	"context"
+	"net/url"
+	"time"
+
+	api "github.com/attestantio/go-eth2-client/api/v1"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/migalabs/goteth/pkg/metrics"
+	"github.com/migalabs/goteth/pkg/spec"
+	"github.com/pkg/errors"
+)
+
+var (
+	ClickhouseScheme = "clickhouse"
+)
+
+// Sink is the storage backend the analyzer persists its metrics into.
+// DBService (ClickHouse) is the reference implementation, any other backend
+// has to keep the same table semantics (inserts, deletes on reorgs and
+// re-processing, and the last slot/epoch lookups used to resume).
+type Sink interface {
+	InitGenesis(apiGenesis time.Time) error
+	RetrieveLastSlot() (phase0.Slot, error)
+	RetrieveLastEpoch() (phase0.Epoch, error)
+
+	// block metrics
+	PersistBlocks(data []spec.AgnosticBlock) error
+	PersistOrphans(data []spec.AgnosticBlock) error
+	PersistTransactions(data []spec.AgnosticTransaction) error
+	PersistWithdrawals(data []spec.Withdrawal) error
+	PersistBLSToExecutionChanges(data []spec.BLSToExecutionChange) error
+	PersistETH1Deposits(data []spec.ETH1Deposit) error
+	PersistBlobSidecars(data []*spec.AgnosticBlobSidecar) error
+	DeleteBlockMetrics(slot phase0.Slot) error
+
+	// state metrics
+	PersistEpochs(data []spec.Epoch) error
+	PersistDuties(data []spec.ProposerDuty) error
+	PersistValidatorRewards(data []spec.ValidatorRewards) error
+	PersistValidatorRewardsAggregation(data map[phase0.ValidatorIndex]*spec.ValidatorRewardsAggregation) error
+	PersistValLastStatus(data []spec.ValidatorLastStatus) error
+	PersistBlockRewards(data []BlockReward) error
+	PersistSlashings(data []spec.AgnosticSlashing) error
+	PersistValidatorAttestations(data []spec.ValidatorAttestation) error
+	PersistSyncCommitteeDuties(data []spec.SyncCommitteeDuty) error
+	PersistDeposits(data []spec.Deposit) error
+	PersistWithdrawalRequests(data []spec.WithdrawalRequest) error
+	PersistConsolidationRequests(data []spec.ConsolidationRequest) error
+	PersistDepositRequests(data []spec.DepositRequest) error
+	PersistConsolidationsProcessed(data []spec.ConsolidationProcessed) error
+	InsertPoolSummary(epoch phase0.Epoch) error
+	DeleteStateMetrics(epoch phase0.Epoch) error
+	DeleteValLastStatus(epoch phase0.Epoch) error
+	DeleteValidatorRewardsUntil(epoch phase0.Epoch) error
+
+	// backfill progress, the epochs fully processed per metric
+	PersistProgress(data []Progress) error
+	RetrieveProgress(from phase0.Epoch, to phase0.Epoch) ([]Progress, error)
+	DeleteProgress(epoch phase0.Epoch) error
+
+	// events
+	PersistHeadEvents(data []HeadEvent) error
+	PersistFinalized(data []api.FinalizedCheckpointEvent) error
+	PersistReorgs(data []api.ChainReorgEvent) error
+	PersistBlobSidecarsEvents(data []spec.BlobSideCarEventWraper) error
+
+	// validator labels
+	PersistValidatorLabels(data []spec.ValidatorLabel) error
+	DeleteValidatorLabels(poolName string) error
+	RetrieveDepositSenders() (map[string]string, error)
+
+	GetPrometheusMetrics() *metrics.MetricsModule
+	Finish()
+}
+
+var _ Sink = &DBService{}
+
+// NewSink returns a connected Sink, the backend is selected by the scheme of the given url
+func NewSink(ctx context.Context, dbUrl string) (Sink, error) {
+	parsedUrl, err := url.Parse(dbUrl)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to parse db url")
+	}
+
+	switch parsedUrl.Scheme {
+	case ClickhouseScheme:
+		dbClient, err := New(ctx, dbUrl)
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to generate DB Client")
+		}
+		err = dbClient.Connect()
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to connect DB Client")
+		}
+		return dbClient, nil
+	case FileScheme:
+		return NewFileSink(dbUrl)
+	case PostgresScheme, PostgresqlScheme:
+		return NewPostgresSink(ctx, dbUrl)
+	case MemoryScheme:
+		return NewMemorySink(), nil
+	case NatsScheme:
+		return NewBusSink(dbUrl)
+	default:
+		return nil, errors.Errorf("unsupported db url scheme: %s", parsedUrl.Scheme)
+	}
+}
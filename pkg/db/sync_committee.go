@@ -0,0 +1,72 @@
+package db
+
+import (
+	"github.com/ClickHouse/ch-go/proto"
+	"github.com/migalabs/goteth/pkg/spec"
+)
+
+var (
+	syncCommitteeTable       = "t_sync_committee_participation"
+	insertSyncCommitteeQuery = `
+	INSERT INTO %s (
+		f_slot,
+		f_epoch,
+		f_val_idx,
+		f_committee_position,
+		f_participated,
+		f_reward)
+		VALUES`
+
+	deleteSyncCommitteeQuery = `
+		DELETE FROM %s
+		WHERE f_epoch = $1;
+`
+)
+
+func syncCommitteeInput(duties []spec.SyncCommitteeDuty) proto.Input {
+	// one object per column
+	var (
+		f_slot               proto.ColUInt64
+		f_epoch              proto.ColUInt64
+		f_val_idx            proto.ColUInt64
+		f_committee_position proto.ColUInt64
+		f_participated       proto.ColBool
+		f_reward             proto.ColInt64
+	)
+
+	for _, duty := range duties {
+		f_slot.Append(uint64(duty.Slot))
+		f_epoch.Append(uint64(duty.Epoch))
+		f_val_idx.Append(uint64(duty.ValIdx))
+		f_committee_position.Append(duty.CommitteePosition)
+		f_participated.Append(duty.Participated)
+		f_reward.Append(duty.Reward)
+	}
+
+	return proto.Input{
+		{Name: "f_slot", Data: f_slot},
+		{Name: "f_epoch", Data: f_epoch},
+		{Name: "f_val_idx", Data: f_val_idx},
+		{Name: "f_committee_position", Data: f_committee_position},
+		{Name: "f_participated", Data: f_participated},
+		{Name: "f_reward", Data: f_reward},
+	}
+}
+
+func (p *DBService) PersistSyncCommitteeDuties(data []spec.SyncCommitteeDuty) error {
+	persistObj := PersistableObject[spec.SyncCommitteeDuty]{
+		input: syncCommitteeInput,
+		table: syncCommitteeTable,
+		query: insertSyncCommitteeQuery,
+	}
+
+	for _, item := range data {
+		persistObj.Append(item)
+	}
+
+	err := p.Persist(persistObj.ExportPersist())
+	if err != nil {
+		log.Errorf("error persisting sync committee duties: %s", err.Error())
+	}
+	return err
+}
@@ -28,6 +28,7 @@ var (
 		f_missing_source,
 		f_missing_target,
 		f_missing_head,
+		f_inactivity_penalty,
 		f_status,
 		f_block_api_reward,
 		f_block_experimental_reward,
@@ -64,6 +65,7 @@ func rewardsInput(vals []spec.ValidatorRewards) proto.Input {
 		f_missing_source                         proto.ColBool
 		f_missing_target                         proto.ColBool
 		f_missing_head                           proto.ColBool
+		f_inactivity_penalty                     proto.ColUInt64
 		f_status                                 proto.ColUInt8
 		f_block_api_reward                       proto.ColUInt64
 		f_block_experimental_reward              proto.ColUInt64
@@ -88,6 +90,7 @@ func rewardsInput(vals []spec.ValidatorRewards) proto.Input {
 		f_missing_source.Append(val.MissingSource)
 		f_missing_target.Append(val.MissingTarget)
 		f_missing_head.Append(val.MissingHead)
+		f_inactivity_penalty.Append(uint64(val.InactivityPenalty))
 		f_status.Append(uint8(val.Status))
 		f_block_api_reward.Append(uint64(val.ProposerApiReward))
 		f_block_experimental_reward.Append(uint64(val.ProposerManualReward))
@@ -112,6 +115,7 @@ func rewardsInput(vals []spec.ValidatorRewards) proto.Input {
 		{Name: "f_missing_source", Data: f_missing_source},
 		{Name: "f_missing_target", Data: f_missing_target},
 		{Name: "f_missing_head", Data: f_missing_head},
+		{Name: "f_inactivity_penalty", Data: f_inactivity_penalty},
 		{Name: "f_status", Data: f_status},
 		{Name: "f_block_api_reward", Data: f_block_api_reward},
 		{Name: "f_block_experimental_reward", Data: f_block_experimental_reward},
@@ -10,7 +10,7 @@ import (
 
 func (e *Events) SubscribeToBlobSidecarsEvents() {
 	// subscribe to head event
-	err := e.cli.Api.Events(e.ctx, &eth2api.EventsOpts{
+	err := e.cli.Events(e.ctx, &eth2api.EventsOpts{
 		Topics:  []string{"blob_sidecar"},
 		Handler: e.HandleBlobSidecarEvent,
 	}) // every reorg
@@ -7,7 +7,7 @@ import (
 
 func (e *Events) SubscribeToFinalizedCheckpointEvents() {
 	// subscribe to head event
-	err := e.cli.Api.Events(e.ctx, &eth2api.EventsOpts{
+	err := e.cli.Events(e.ctx, &eth2api.EventsOpts{
 		Topics:  []string{"finalized_checkpoint"},
 		Handler: e.HandleCheckpointEvent,
 	}) // every new checkpoint
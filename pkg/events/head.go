@@ -7,13 +7,12 @@ import (
 
 	eth2api "github.com/attestantio/go-eth2-client/api"
 	apiv1 "github.com/attestantio/go-eth2-client/api/v1"
-	"github.com/attestantio/go-eth2-client/spec/phase0"
 	"github.com/migalabs/goteth/pkg/spec"
 )
 
 func (e Events) SubscribeToHeadEvents() {
 	// subscribe to head event
-	err := e.cli.Api.Events(e.ctx, &eth2api.EventsOpts{
+	err := e.cli.Events(e.ctx, &eth2api.EventsOpts{
 		Topics:  []string{"head"},
 		Handler: e.HandleHeadEvent,
 	}) // every new head
@@ -30,12 +29,12 @@ func (e *Events) HandleHeadEvent(event *apiv1.Event) {
 		return
 	}
 	data := event.Data.(*apiv1.HeadEvent) // cast to head event
-	headEpoch := phase0.Epoch(data.Slot) / spec.SlotsPerEpoch
+	headEpoch := spec.EpochAtSlot(data.Slot)
 
 	log.Infof("New event: slot %d, epoch %d. %d pending slots for new epoch",
 		data.Slot,
-		data.Slot/spec.SlotsPerEpoch,
-		(int(headEpoch+1)*spec.EpochSlots)-int(data.Slot))
+		headEpoch,
+		spec.ComputeStartSlotAtEpoch(headEpoch+1)-data.Slot)
 
 	select { // only notify if we can
 	case e.HeadChan <- db.HeadEvent{
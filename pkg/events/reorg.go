@@ -7,7 +7,7 @@ import (
 
 func (e *Events) SubscribeToReorgsEvents() {
 	// subscribe to head event
-	err := e.cli.Api.Events(e.ctx, &eth2api.EventsOpts{
+	err := e.cli.Events(e.ctx, &eth2api.EventsOpts{
 		Topics:  []string{"chain_reorg"},
 		Handler: e.HandleReorgEvent,
 	}) // every reorg
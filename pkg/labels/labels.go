@@ -0,0 +1,226 @@
+package labels
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/migalabs/goteth/pkg/spec"
+	"github.com/pkg/errors"
+)
+
+// Entry is a label given by the user, the validator is its index or its 0x public key
+type Entry struct {
+	Validator string `json:"validator"`
+	PoolName  string `json:"pool_name"`
+	Pool      string `json:"pool"`
+}
+
+// Rule labels every validator whose withdrawal credentials or deposit sender match
+type Rule struct {
+	Match    string `json:"match"`
+	PoolName string `json:"pool_name"`
+	Pool     string `json:"pool"`
+}
+
+// Rules derive the labels from the chain data:
+//   - withdrawal_credentials match the whole 32 bytes credentials or the execution
+//     address of 0x01 and 0x02 credentials
+//   - deposit_senders match the address that sent the first deposit of the validator (t_eth1_deposits)
+type Rules struct {
+	WithdrawalCredentials []Rule `json:"withdrawal_credentials"`
+	DepositSenders        []Rule `json:"deposit_senders"`
+}
+
+// ReadLabelsFile reads the labels from a JSON file (a list of entries) or from a CSV file
+// with validator,pool_name[,pool] lines. A header line is skipped, so the val_idx,custom_pool
+// files are also valid
+func ReadLabelsFile(labelsFile string) ([]Entry, error) {
+	content, err := os.ReadFile(labelsFile)
+	if err != nil {
+		return nil, err
+	}
+
+	if strings.EqualFold(filepath.Ext(labelsFile), ".json") {
+		entries := make([]Entry, 0)
+		err = json.Unmarshal(content, &entries)
+		if err != nil {
+			return nil, errors.Wrap(err, "could not parse labels file")
+		}
+		return entries, nil
+	}
+
+	reader := csv.NewReader(bytes.NewReader(content))
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	reader.Comment = '#'
+
+	entries := make([]Entry, 0)
+	for line := 1; ; line++ {
+		fields, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, errors.Wrap(err, "could not parse labels file")
+		}
+		if len(fields) < 2 || len(fields) > 3 {
+			return nil, errors.Errorf("line %d: the format of the file is not the expected: validator,pool_name[,pool]", line)
+		}
+		if line == 1 && !isValidatorID(fields[0]) {
+			continue // header
+		}
+		entry := Entry{
+			Validator: strings.TrimSpace(fields[0]),
+			PoolName:  strings.TrimSpace(fields[1]),
+		}
+		if len(fields) == 3 {
+			entry.Pool = strings.TrimSpace(fields[2])
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// ReadRulesFile reads the derivation rules from a JSON file
+func ReadRulesFile(rulesFile string) (Rules, error) {
+	content, err := os.ReadFile(rulesFile)
+	if err != nil {
+		return Rules{}, err
+	}
+	var rules Rules
+	err = json.Unmarshal(content, &rules)
+	if err != nil {
+		return Rules{}, errors.Wrap(err, "could not parse rules file")
+	}
+	for _, rule := range append(rules.WithdrawalCredentials, rules.DepositSenders...) {
+		if rule.PoolName == "" {
+			return Rules{}, errors.Errorf("rule %s has no pool_name", rule.Match)
+		}
+	}
+	return rules, nil
+}
+
+func isValidatorID(id string) bool {
+	id = strings.TrimSpace(id)
+	if strings.HasPrefix(id, "0x") {
+		return true
+	}
+	_, err := strconv.ParseUint(id, 10, 64)
+	return err == nil
+}
+
+// Resolve returns the labels of the entries with both the index and the public key
+// of the validator, entries of unknown validators are skipped
+func Resolve(entries []Entry, validators []*phase0.Validator) ([]spec.ValidatorLabel, error) {
+	indexes := make(map[phase0.BLSPubKey]phase0.ValidatorIndex, len(validators))
+	for i, validator := range validators {
+		indexes[validator.PublicKey] = phase0.ValidatorIndex(i)
+	}
+
+	result := make([]spec.ValidatorLabel, 0, len(entries))
+	for _, entry := range entries {
+		if entry.PoolName == "" {
+			return nil, errors.Errorf("validator %s has no pool_name", entry.Validator)
+		}
+
+		var valIdx phase0.ValidatorIndex
+		if strings.HasPrefix(entry.Validator, "0x") {
+			pubkey, err := parsePubkey(entry.Validator)
+			if err != nil {
+				return nil, err
+			}
+			idx, ok := indexes[pubkey]
+			if !ok {
+				log.Warnf("validator %s not found, skipping", entry.Validator)
+				continue
+			}
+			valIdx = idx
+		} else {
+			idx, err := strconv.ParseUint(entry.Validator, 10, 64)
+			if err != nil {
+				return nil, errors.Wrap(err, "could not parse validator index")
+			}
+			if idx >= uint64(len(validators)) {
+				log.Warnf("validator %d not found, skipping", idx)
+				continue
+			}
+			valIdx = phase0.ValidatorIndex(idx)
+		}
+
+		result = append(result, spec.ValidatorLabel{
+			ValIdx:    valIdx,
+			PublicKey: validators[valIdx].PublicKey,
+			PoolName:  entry.PoolName,
+			Pool:      entry.Pool,
+		})
+	}
+	return result, nil
+}
+
+func parsePubkey(input string) (phase0.BLSPubKey, error) {
+	pubkeyBytes, err := hex.DecodeString(strings.TrimPrefix(input, "0x"))
+	if err != nil || len(pubkeyBytes) != phase0.PublicKeyLength {
+		return phase0.BLSPubKey{}, errors.Errorf("invalid public key: %s", input)
+	}
+	return phase0.BLSPubKey(pubkeyBytes), nil
+}
+
+// Derive returns the labels of the validators matching any rule.
+// Withdrawal credentials rules take precedence over the deposit senders ones,
+// and within each list the first matching rule is used
+func (r Rules) Derive(validators []*phase0.Validator, depositSenders map[string]string) []spec.ValidatorLabel {
+	credentialRules := make(map[string]Rule, len(r.WithdrawalCredentials))
+	for _, rule := range r.WithdrawalCredentials {
+		match := strings.ToLower(rule.Match)
+		if _, ok := credentialRules[match]; !ok {
+			credentialRules[match] = rule
+		}
+	}
+	senderRules := make(map[string]Rule, len(r.DepositSenders))
+	for _, rule := range r.DepositSenders {
+		match := strings.ToLower(rule.Match)
+		if _, ok := senderRules[match]; !ok {
+			senderRules[match] = rule
+		}
+	}
+
+	result := make([]spec.ValidatorLabel, 0)
+	for i, validator := range validators {
+		rule, ok := matchCredentials(credentialRules, validator.WithdrawalCredentials)
+		if !ok {
+			rule, ok = senderRules[depositSenders[validator.PublicKey.String()]]
+		}
+		if !ok {
+			continue
+		}
+		result = append(result, spec.ValidatorLabel{
+			ValIdx:    phase0.ValidatorIndex(i),
+			PublicKey: validator.PublicKey,
+			PoolName:  rule.PoolName,
+			Pool:      rule.Pool,
+		})
+	}
+	return result
+}
+
+func matchCredentials(rules map[string]Rule, credentials []byte) (Rule, bool) {
+	if len(credentials) != 32 {
+		return Rule{}, false
+	}
+	if rule, ok := rules["0x"+hex.EncodeToString(credentials)]; ok {
+		return rule, true
+	}
+	if credentials[0] == spec.Eth1AddressWithdrawalPrefix || credentials[0] == spec.CompoundingWithdrawalPrefix {
+		rule, ok := rules["0x"+hex.EncodeToString(credentials[12:])]
+		return rule, ok
+	}
+	return Rule{}, false
+}
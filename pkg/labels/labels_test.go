@@ -0,0 +1,135 @@
+package labels
+
+import (
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/migalabs/goteth/pkg/db"
+	"github.com/migalabs/goteth/pkg/spec"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	testAddress     = "0x00000000219ab540356cbb839cbe05303d7705fa"
+	testSender      = "0xae2fc483527b8ef99eb5d9b44875f005ba1fae13"
+	testCredentials = append([]byte{0x00}, make([]byte, 31)...)
+)
+
+func writeFile(t *testing.T, name string, content string) string {
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	return path
+}
+
+// testValidators returns validators 0..3: 0 and 1 withdraw to testAddress (0x01 and 0x02),
+// 2 has BLS credentials and 3 withdraws to another address
+func testValidators() []*phase0.Validator {
+	credentials := func(prefix byte, address string) []byte {
+		addressBytes, _ := hex.DecodeString(address[2:])
+		return append(append([]byte{prefix}, make([]byte, 11)...), addressBytes...)
+	}
+
+	return []*phase0.Validator{
+		{PublicKey: phase0.BLSPubKey{1}, WithdrawalCredentials: credentials(spec.Eth1AddressWithdrawalPrefix, testAddress)},
+		{PublicKey: phase0.BLSPubKey{2}, WithdrawalCredentials: credentials(spec.CompoundingWithdrawalPrefix, testAddress)},
+		{PublicKey: phase0.BLSPubKey{3}, WithdrawalCredentials: testCredentials},
+		{PublicKey: phase0.BLSPubKey{4}, WithdrawalCredentials: credentials(spec.Eth1AddressWithdrawalPrefix, testSender)},
+	}
+}
+
+func TestReadLabelsFile(t *testing.T) {
+	csvEntries, err := ReadLabelsFile(writeFile(t, "labels.csv",
+		"val_idx,custom_pool\n# staking pools\n1,pool_a\n"+phase0.BLSPubKey{3}.String()+", pool_b, operator_1\n"))
+	require.NoError(t, err)
+	assert.Equal(t, []Entry{
+		{Validator: "1", PoolName: "pool_a"},
+		{Validator: phase0.BLSPubKey{3}.String(), PoolName: "pool_b", Pool: "operator_1"},
+	}, csvEntries)
+
+	jsonEntries, err := ReadLabelsFile(writeFile(t, "labels.json",
+		`[{"validator": "1", "pool_name": "pool_a"}, {"validator": "`+phase0.BLSPubKey{3}.String()+`", "pool_name": "pool_b", "pool": "operator_1"}]`))
+	require.NoError(t, err)
+	assert.Equal(t, csvEntries, jsonEntries)
+
+	_, err = ReadLabelsFile(writeFile(t, "wrong.csv", "1\n"))
+	assert.Error(t, err)
+}
+
+func TestResolve(t *testing.T) {
+	labels, err := Resolve([]Entry{
+		{Validator: "1", PoolName: "pool_a"},
+		{Validator: phase0.BLSPubKey{3}.String(), PoolName: "pool_b", Pool: "operator_1"},
+		{Validator: "100", PoolName: "pool_a"},                          // unknown index
+		{Validator: phase0.BLSPubKey{100}.String(), PoolName: "pool_a"}, // unknown public key
+	}, testValidators())
+	require.NoError(t, err)
+	assert.Equal(t, []spec.ValidatorLabel{
+		{ValIdx: 1, PublicKey: phase0.BLSPubKey{2}, PoolName: "pool_a"},
+		{ValIdx: 2, PublicKey: phase0.BLSPubKey{3}, PoolName: "pool_b", Pool: "operator_1"},
+	}, labels)
+
+	_, err = Resolve([]Entry{{Validator: "1"}}, testValidators())
+	assert.Error(t, err)
+	_, err = Resolve([]Entry{{Validator: "0x1234", PoolName: "pool_a"}}, testValidators())
+	assert.Error(t, err)
+}
+
+func TestDerive(t *testing.T) {
+	rules, err := ReadRulesFile(writeFile(t, "rules.json", `{
+		"withdrawal_credentials": [
+			{"match": "`+testAddress+`", "pool_name": "pool_a"},
+			{"match": "`+phase0.Root(testCredentials).String()+`", "pool_name": "pool_b", "pool": "bls"}
+		],
+		"deposit_senders": [
+			{"match": "`+testSender+`", "pool_name": "pool_c"},
+			{"match": "`+testAddress+`", "pool_name": "pool_d"}
+		]
+	}`))
+	require.NoError(t, err)
+
+	depositSenders := map[string]string{
+		phase0.BLSPubKey{1}.String(): testAddress, // the withdrawal credentials rule is used
+		phase0.BLSPubKey{4}.String(): testSender,
+	}
+	labels := rules.Derive(testValidators(), depositSenders)
+	assert.Equal(t, []spec.ValidatorLabel{
+		{ValIdx: 0, PublicKey: phase0.BLSPubKey{1}, PoolName: "pool_a"},
+		{ValIdx: 1, PublicKey: phase0.BLSPubKey{2}, PoolName: "pool_a"},
+		{ValIdx: 2, PublicKey: phase0.BLSPubKey{3}, PoolName: "pool_b", Pool: "bls"},
+		{ValIdx: 3, PublicKey: phase0.BLSPubKey{4}, PoolName: "pool_c"},
+	}, labels)
+
+	_, err = ReadRulesFile(writeFile(t, "wrong.json", `{"deposit_senders": [{"match": "`+testSender+`"}]}`))
+	assert.Error(t, err)
+}
+
+func TestLabelsSink(t *testing.T) {
+	sink := db.NewMemorySink()
+	manager := &LabelManager{dbClient: sink}
+
+	require.NoError(t, sink.PersistETH1Deposits([]spec.ETH1Deposit{
+		{DepositIndex: 2, ValidatorPubkey: phase0.BLSPubKey{4}.String(), Sender: "0xAE2Fc483527B8EF99EB5D9B44875F005ba1FaE13"},
+		{DepositIndex: 1, ValidatorPubkey: phase0.BLSPubKey{4}.String(), Sender: testAddress}, // first deposit
+	}))
+	senders, err := sink.RetrieveDepositSenders()
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{phase0.BLSPubKey{4}.String(): testAddress}, senders)
+
+	require.NoError(t, manager.persist([]spec.ValidatorLabel{
+		{ValIdx: 0, PublicKey: phase0.BLSPubKey{1}, PoolName: "pool_a"},
+		{ValIdx: 3, PublicKey: phase0.BLSPubKey{4}, PoolName: "pool_c", Pool: "operator_1"},
+	}))
+	rows := sink.Where("t_eth2_pubkeys", "f_pool_name", "pool_c")
+	require.Len(t, rows, 1)
+	assert.Equal(t, uint64(3), rows[0].Uint64("f_val_idx"))
+	assert.Equal(t, phase0.BLSPubKey{4}.String(), rows[0]["f_public_key"])
+	assert.Equal(t, "operator_1", rows[0]["f_pool"])
+
+	require.NoError(t, manager.Delete("pool_c"))
+	assert.Empty(t, sink.Where("t_eth2_pubkeys", "f_pool_name", "pool_c"))
+	assert.Equal(t, 1, sink.Count("t_eth2_pubkeys"))
+}
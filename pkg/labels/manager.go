@@ -0,0 +1,131 @@
+package labels
+
+import (
+	"context"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/migalabs/goteth/pkg/clientapi"
+	"github.com/migalabs/goteth/pkg/config"
+	"github.com/migalabs/goteth/pkg/db"
+	"github.com/migalabs/goteth/pkg/spec"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	log = logrus.WithField(
+		"module", "labels",
+	)
+)
+
+// LabelManager writes the validator labels into t_eth2_pubkeys, which is the table
+// the pool summaries (t_pool_summary) are aggregated with
+type LabelManager struct {
+	ctx      context.Context
+	dbClient db.Sink              // storage backend where labels are persisted
+	cli      *clientapi.APIClient // beacon node to resolve the validators
+	state    string               // state at which the validators are read
+}
+
+func NewLabelManager(
+	pCtx context.Context,
+	iConfig config.LabelsConfig) (*LabelManager, error) {
+
+	idbClient, err := db.NewSink(pCtx, iConfig.DBUrl)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to init DB Client.")
+	}
+
+	// the beacon node is only needed to read the validators (import and derive)
+	var cli *clientapi.APIClient
+	if iConfig.BnEndpoint != "" {
+		cli, err = clientapi.NewAPIClient(pCtx,
+			iConfig.BnEndpoint, iConfig.MaxRequestRetries)
+		if err != nil {
+			idbClient.Finish()
+			return nil, errors.Wrap(err, "unable to generate API Client.")
+		}
+	}
+
+	return &LabelManager{
+		ctx:      pCtx,
+		dbClient: idbClient,
+		cli:      cli,
+		state:    iConfig.State,
+	}, nil
+}
+
+// Import persists the labels of a CSV or JSON file (see ReadLabelsFile)
+func (m *LabelManager) Import(labelsFile string) error {
+	entries, err := ReadLabelsFile(labelsFile)
+	if err != nil {
+		return err
+	}
+	validators, err := m.requestValidators()
+	if err != nil {
+		return err
+	}
+	labels, err := Resolve(entries, validators)
+	if err != nil {
+		return err
+	}
+	return m.persist(labels)
+}
+
+// Derive persists the labels of the validators matching the rules file (see Rules)
+func (m *LabelManager) Derive(rulesFile string) error {
+	rules, err := ReadRulesFile(rulesFile)
+	if err != nil {
+		return err
+	}
+	validators, err := m.requestValidators()
+	if err != nil {
+		return err
+	}
+
+	depositSenders := make(map[string]string)
+	if len(rules.DepositSenders) > 0 {
+		depositSenders, err = m.dbClient.RetrieveDepositSenders()
+		if err != nil {
+			return errors.Wrap(err, "unable to read the deposits")
+		}
+		log.Infof("read the deposit senders of %d validators", len(depositSenders))
+	}
+
+	return m.persist(rules.Derive(validators, depositSenders))
+}
+
+func (m *LabelManager) requestValidators() ([]*phase0.Validator, error) {
+	if m.cli == nil {
+		return nil, errors.New("a beacon node endpoint is needed to read the validators")
+	}
+	return m.cli.RequestValidators(m.state)
+}
+
+// Delete removes the labels of the given pool
+func (m *LabelManager) Delete(poolName string) error {
+	err := m.dbClient.DeleteValidatorLabels(poolName)
+	if err == nil {
+		log.Infof("deleted the labels of pool %s", poolName)
+	}
+	return err
+}
+
+func (m *LabelManager) persist(labels []spec.ValidatorLabel) error {
+	pools := make(map[string]int)
+	for _, label := range labels {
+		pools[label.PoolName]++
+	}
+	for poolName, count := range pools {
+		log.Infof("labelling %d validators as %s", count, poolName)
+	}
+	if len(labels) == 0 {
+		log.Warn("no validators to label")
+		return nil
+	}
+	return m.dbClient.PersistValidatorLabels(labels)
+}
+
+func (m *LabelManager) Close() {
+	m.dbClient.Finish()
+}
@@ -11,6 +11,7 @@ import (
 	v1 "github.com/attestantio/go-relay-client/api/v1"
 	"github.com/attestantio/go-relay-client/http"
 	"github.com/migalabs/goteth/pkg/spec"
+	"github.com/migalabs/goteth/pkg/utils"
 	"github.com/rs/zerolog"
 	"github.com/sirupsen/logrus"
 )
@@ -36,11 +37,7 @@ type RelayClient struct {
 	ctx     context.Context
 	client  relayclient.Service
 	address string
-
-	// Circuit breaker state
-	mu              sync.Mutex
-	consecutiveFail int
-	openUntil       time.Time
+	breaker *utils.CircuitBreaker
 }
 
 func New(pCtx context.Context,
@@ -61,36 +58,14 @@ func New(pCtx context.Context,
 		ctx:     pCtx,
 		client:  client,
 		address: address,
+		breaker: utils.NewCircuitBreaker(cbFailureThreshold, cbCooldown),
 	}, nil
 }
 
-// isOpen returns true if the circuit breaker is open (relay should be skipped).
-func (r *RelayClient) isOpen() bool {
-	r.mu.Lock()
-	defer r.mu.Unlock()
-	if r.consecutiveFail < cbFailureThreshold {
-		return false
-	}
-	if time.Now().After(r.openUntil) {
-		// Cooldown expired — allow one probe attempt (half-open)
-		r.consecutiveFail = cbFailureThreshold - 1
-		return false
-	}
-	return true
-}
-
 // recordResult updates the circuit breaker after a query.
 func (r *RelayClient) recordResult(failed bool) {
-	r.mu.Lock()
-	defer r.mu.Unlock()
-	if failed {
-		r.consecutiveFail++
-		if r.consecutiveFail >= cbFailureThreshold {
-			r.openUntil = time.Now().Add(cbCooldown)
-			log.Warnf("circuit breaker open for %s, skipping for %s", r.address, cbCooldown)
-		}
-	} else {
-		r.consecutiveFail = 0
+	if r.breaker.RecordResult(failed) {
+		log.Warnf("circuit breaker open for %s, skipping for %s", r.address, r.breaker.Cooldown())
 	}
 }
 
@@ -99,7 +74,7 @@ func (r *RelayClient) recordResult(failed bool) {
 // if error, the map positions will have an empty bid
 func (r *RelayClient) GetDeliveredBidsPerSlotRange(slot phase0.Slot, limit int) ([]*v1.BidTrace, error) {
 
-	if r.isOpen() {
+	if r.breaker.IsOpen() {
 		return nil, fmt.Errorf("circuit breaker open for %s, skipping", r.address)
 	}
 
@@ -206,6 +181,19 @@ func (r *RelayBidsPerSlot) GetBidsAtSlot(slot phase0.Slot) map[string]v1.BidTrac
 
 func getNetworkRelays(genesisTime uint64) []string {
 
+	// the network name of the chain spec, the genesis time is used for specs without
+	// name or with an unknown one
+	switch spec.ConfigName {
+	case "mainnet":
+		return mainnetRelayList
+	case "holesky":
+		return holeskyRelayList
+	case "hoodi":
+		return hoodiRelayList
+	case "sepolia":
+		return sepoliaRelayList
+	}
+
 	switch genesisTime {
 	case spec.MainnetGenesis:
 		return mainnetRelayList
@@ -0,0 +1,112 @@
+package server
+
+// param is a query parameter filtering a column of the table
+type param struct {
+	column string
+	op     string
+	text   bool // string column, else the value is parsed as an uint64
+}
+
+// resource is an endpoint serving the rows of a table.
+// Path values are filters on equality, and every query parameter is optional
+type resource struct {
+	pattern    string
+	table      string
+	final      bool // ReplacingMergeTree table
+	orderBy    []string
+	pathValues map[string]param
+	params     map[string]param
+}
+
+func rangeParams(name string, column string) map[string]param {
+	return map[string]param{
+		"from_" + name: {column: column, op: ">="},
+		"to_" + name:   {column: column, op: "<="},
+	}
+}
+
+func mergeParams(params ...map[string]param) map[string]param {
+	result := make(map[string]param)
+	for _, p := range params {
+		for name, value := range p {
+			result[name] = value
+		}
+	}
+	return result
+}
+
+var resources = []resource{
+	{
+		pattern:    "GET /api/v1/validators/{val_idx}/rewards",
+		table:      "t_validator_rewards_summary",
+		final:      true,
+		orderBy:    []string{"f_epoch"},
+		pathValues: map[string]param{"val_idx": {column: "f_val_idx", op: "="}},
+		params:     rangeParams("epoch", "f_epoch"),
+	},
+	{
+		pattern:    "GET /api/v1/validators/{val_idx}/status",
+		table:      "t_validator_last_status",
+		orderBy:    []string{"f_epoch"},
+		pathValues: map[string]param{"val_idx": {column: "f_val_idx", op: "="}},
+	},
+	{
+		pattern: "GET /api/v1/proposer_duties",
+		table:   "t_proposer_duties",
+		final:   true,
+		orderBy: []string{"f_proposer_slot"},
+		params: mergeParams(
+			rangeParams("slot", "f_proposer_slot"),
+			map[string]param{"val_idx": {column: "f_val_idx", op: "="}}),
+	},
+	{
+		pattern: "GET /api/v1/blocks",
+		table:   "t_block_metrics",
+		final:   true,
+		orderBy: []string{"f_slot"},
+		params: mergeParams(
+			rangeParams("slot", "f_slot"),
+			rangeParams("epoch", "f_epoch"),
+			map[string]param{"proposer_index": {column: "f_proposer_index", op: "="}}),
+	},
+	{
+		pattern:    "GET /api/v1/blocks/{slot}",
+		table:      "t_block_metrics",
+		final:      true,
+		orderBy:    []string{"f_slot"},
+		pathValues: map[string]param{"slot": {column: "f_slot", op: "="}},
+	},
+	{
+		pattern: "GET /api/v1/epochs",
+		table:   "t_epoch_metrics_summary",
+		final:   true,
+		orderBy: []string{"f_epoch"},
+		params:  rangeParams("epoch", "f_epoch"),
+	},
+	{
+		pattern:    "GET /api/v1/epochs/{epoch}",
+		table:      "t_epoch_metrics_summary",
+		final:      true,
+		orderBy:    []string{"f_epoch"},
+		pathValues: map[string]param{"epoch": {column: "f_epoch", op: "="}},
+	},
+	{
+		pattern: "GET /api/v1/pools",
+		table:   "t_pool_summary",
+		final:   true,
+		orderBy: []string{"f_epoch", "f_pool_name"},
+		params: mergeParams(
+			rangeParams("epoch", "f_epoch"),
+			map[string]param{"pool_name": {column: "f_pool_name", op: "=", text: true}}),
+	},
+	{
+		pattern: "GET /api/v1/orphans",
+		table:   "t_orphans",
+		final:   true,
+		orderBy: []string{"f_slot"},
+		params: mergeParams(
+			rangeParams("slot", "f_slot"),
+			rangeParams("epoch", "f_epoch"),
+			map[string]param{"proposer_index": {column: "f_proposer_index", op: "="}}),
+	},
+}
@@ -0,0 +1,474 @@
+package server
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/migalabs/goteth/pkg/db"
+	"github.com/migalabs/goteth/pkg/spec"
+	"github.com/migalabs/goteth/pkg/spec/metrics"
+	"github.com/pkg/errors"
+)
+
+// The rewards endpoints of the Beacon API, served with the same JSON schemas from the
+// tables filled by the analyzer, see https://ethereum.github.io/beacon-APIs/#/Rewards
+
+var (
+	errNotFound    = errors.New("not found")
+	errInvalidID   = errors.New("invalid block id")
+	errUnsupported = errors.New("not supported") // the indexed data is not enough to compute the rewards
+)
+
+// rewardsResponse is the envelope of the Beacon API responses.
+// The data is finalized if it comes from before the finalized checkpoint of the last indexed epoch
+type rewardsResponse struct {
+	ExecutionOptimistic bool `json:"execution_optimistic"`
+	Finalized           bool `json:"finalized"`
+	Data                any  `json:"data"`
+}
+
+type attestationRewards struct {
+	IdealRewards []idealAttestationReward `json:"ideal_rewards"`
+	TotalRewards []totalAttestationReward `json:"total_rewards"`
+}
+
+type idealAttestationReward struct {
+	EffectiveBalance uint64 `json:"effective_balance,string"`
+	Head             int64  `json:"head,string"`
+	Target           int64  `json:"target,string"`
+	Source           int64  `json:"source,string"`
+	Inactivity       int64  `json:"inactivity,string"`
+}
+
+type totalAttestationReward struct {
+	ValidatorIndex uint64 `json:"validator_index,string"`
+	Head           int64  `json:"head,string"`
+	Target         int64  `json:"target,string"`
+	Source         int64  `json:"source,string"`
+	Inactivity     int64  `json:"inactivity,string"`
+}
+
+type syncCommitteeReward struct {
+	ValidatorIndex uint64 `json:"validator_index,string"`
+	Reward         int64  `json:"reward,string"`
+}
+
+func (s *Server) registerRewards(mux *http.ServeMux) {
+	mux.HandleFunc("POST /eth/v1/beacon/rewards/attestations/{epoch}", s.handleAttestationRewards)
+	mux.HandleFunc("GET /eth/v1/beacon/rewards/blocks/{block_id}", s.handleBlockRewards)
+	mux.HandleFunc("POST /eth/v1/beacon/rewards/sync_committee/{block_id}", s.handleSyncCommitteeRewards)
+}
+
+func (s *Server) handleAttestationRewards(w http.ResponseWriter, r *http.Request) {
+	epoch, err := strconv.ParseUint(r.PathValue("epoch"), 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "epoch must be a positive number")
+		return
+	}
+	validators, err := readValidators(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	rewards, err := s.attestationRewards(phase0.Epoch(epoch), validators)
+	finalized := false
+	if err == nil {
+		// the rewards are applied by the epoch transition into epoch+2
+		finalized, err = s.isFinalized(spec.ComputeStartSlotAtEpoch(phase0.Epoch(epoch + 2)))
+	}
+	s.writeRewards(w, rewards, finalized, err)
+}
+
+func (s *Server) handleBlockRewards(w http.ResponseWriter, r *http.Request) {
+	slot, err := s.blockSlot(r.PathValue("block_id"))
+	if err != nil {
+		s.writeRewards(w, nil, false, err)
+		return
+	}
+	rewards, err := s.blockRewards(slot)
+	finalized := false
+	if err == nil {
+		finalized, err = s.isFinalized(slot)
+	}
+	s.writeRewards(w, rewards, finalized, err)
+}
+
+func (s *Server) handleSyncCommitteeRewards(w http.ResponseWriter, r *http.Request) {
+	slot, err := s.blockSlot(r.PathValue("block_id"))
+	if err != nil {
+		s.writeRewards(w, nil, false, err)
+		return
+	}
+	validators, err := readValidators(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	rewards, err := s.syncCommitteeRewards(slot, validators)
+	finalized := false
+	if err == nil {
+		finalized, err = s.isFinalized(slot)
+	}
+	s.writeRewards(w, rewards, finalized, err)
+}
+
+func (s *Server) writeRewards(w http.ResponseWriter, data any, finalized bool, err error) {
+	switch {
+	case errors.Is(err, errInvalidID):
+		writeError(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, errNotFound):
+		writeError(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, errUnsupported):
+		writeError(w, http.StatusNotImplemented, err.Error())
+	case err != nil:
+		log.Errorf("could not read rewards: %s", err)
+		writeError(w, http.StatusInternalServerError, "could not read the database")
+	default:
+		writeJSON(w, http.StatusOK, rewardsResponse{Finalized: finalized, Data: data})
+	}
+}
+
+// isFinalized returns whether the slot is at or before the finalized checkpoint of the
+// last indexed epoch
+func (s *Server) isFinalized(slot phase0.Slot) (bool, error) {
+	finalizedEpoch, err := s.finalizedEpoch()
+	if errors.Is(err, errNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return slot <= spec.ComputeStartSlotAtEpoch(finalizedEpoch), nil
+}
+
+// finalizedEpoch returns the finalized checkpoint of the last indexed epoch
+func (s *Server) finalizedEpoch() (phase0.Epoch, error) {
+	lastEpoch, err := s.selectOne(db.Query{Table: "t_epoch_metrics_summary", Final: true, OrderBy: []string{"f_epoch"}, Desc: true})
+	if err != nil {
+		return 0, err
+	}
+	return phase0.Epoch(lastEpoch.Uint64("f_finalized_epoch")), nil
+}
+
+// attestationRewards rebuilds the rewards of the attestations to the given epoch, which are
+// applied when the epoch transition at the end of epoch+1 is processed:
+//   - t_validator_rewards_summary at epoch+2 keeps the flags of the attestations and the inactivity penalties
+//   - t_validator_rewards_summary at epoch+1 keeps the effective balances and slashed status used
+//   - t_epoch_metrics_summary at epoch keeps the attesting balances of each flag
+//   - t_epoch_metrics_summary at epoch+1 keeps the total active balance used
+//   - t_epoch_metrics_summary at epoch+2 keeps the finalized checkpoint after the transition,
+//     which tells whether the chain was in an inactivity leak
+//
+// The rewards of the phase0 attestations are not supported
+func (s *Server) attestationRewards(epoch phase0.Epoch, validators []uint64) (attestationRewards, error) {
+	if uint64(epoch)+1 < spec.AltairForkEpoch {
+		return attestationRewards{}, errors.Wrapf(errUnsupported, "epoch %d: the rewards of phase0 attestations", epoch)
+	}
+	attestingEpoch, err := s.selectOne(db.Query{Table: "t_epoch_metrics_summary", Final: true, Filters: []db.Filter{
+		{Column: "f_epoch", Op: "=", Value: uint64(epoch)}}})
+	if err != nil {
+		return attestationRewards{}, err
+	}
+	processingEpoch, err := s.selectOne(db.Query{Table: "t_epoch_metrics_summary", Final: true, Filters: []db.Filter{
+		{Column: "f_epoch", Op: "=", Value: uint64(epoch + 1)}}})
+	if err != nil {
+		return attestationRewards{}, err
+	}
+	finalityEpoch, err := s.selectOne(db.Query{Table: "t_epoch_metrics_summary", Final: true, Filters: []db.Filter{
+		{Column: "f_epoch", Op: "=", Value: uint64(epoch + 2)}}})
+	if err != nil {
+		return attestationRewards{}, err
+	}
+	finalizedEpoch := finalityEpoch.Uint64("f_finalized_epoch")
+	if finalizedEpoch == 0 && uint64(epoch) > spec.MinEpochsToInactivityPenalty {
+		return attestationRewards{}, errors.Wrapf(errUnsupported, "epoch %d was indexed without its finalized checkpoint", epoch+2)
+	}
+
+	flagRows, err := s.selectValidatorRewards(epoch+2, validators)
+	if err != nil {
+		return attestationRewards{}, err
+	}
+	balanceRows, err := s.selectValidatorRewards(epoch+1, validators)
+	if err != nil {
+		return attestationRewards{}, err
+	}
+	balances := make(map[uint64]db.Row, len(balanceRows))
+	for _, row := range balanceRows {
+		balances[row.Uint64("f_val_idx")] = row
+	}
+
+	totalActiveBalance := phase0.Gwei(processingEpoch.Uint64("f_total_effective_balance_eth") * spec.EffectiveBalanceInc)
+	calculator := flagRewards{
+		baseRewardPerInc: metrics.AltairMetrics{}.GetBaseRewardPerInc(totalActiveBalance),
+		totalActiveInc:   processingEpoch.Uint64("f_total_effective_balance_eth"),
+		inLeak:           uint64(epoch) > finalizedEpoch+spec.MinEpochsToInactivityPenalty,
+		attestingInc: [3]uint64{
+			attestingEpoch.Uint64("f_source_att_effective_balance_eth"),
+			attestingEpoch.Uint64("f_target_att_effective_balance_eth"),
+			attestingEpoch.Uint64("f_head_att_effective_balance_eth"),
+		},
+	}
+
+	// the ideal rewards of every effective balance, not only the ones of the validators
+	maxEffectiveBalance := spec.MaxEffectiveInc * spec.EffectiveBalanceInc
+	if uint64(epoch)+1 >= spec.ElectraForkEpoch {
+		maxEffectiveBalance = spec.MaxEffectiveBalanceElectra
+	}
+	result := attestationRewards{
+		IdealRewards: make([]idealAttestationReward, 0, maxEffectiveBalance/spec.EffectiveBalanceInc),
+		TotalRewards: make([]totalAttestationReward, 0),
+	}
+	for effectiveBalance := spec.EffectiveBalanceInc; effectiveBalance <= maxEffectiveBalance; effectiveBalance += spec.EffectiveBalanceInc {
+		result.IdealRewards = append(result.IdealRewards, calculator.ideal(effectiveBalance))
+	}
+
+	for _, row := range flagRows {
+		balanceRow, ok := balances[row.Uint64("f_val_idx")]
+		// only the validators active in the epoch had to attest
+		if !ok || row.Uint64("f_max_att_reward") == 0 {
+			continue
+		}
+		effectiveBalance := balanceRow.Uint64("f_effective_balance")
+		ideal := calculator.ideal(effectiveBalance)
+
+		// slashed validators are penalized as if they missed every flag
+		slashed := balanceRow.Uint64("f_status") == uint64(spec.SLASHED_STATUS)
+		total := totalAttestationReward{ValidatorIndex: row.Uint64("f_val_idx")}
+		total.Source = calculator.actual(effectiveBalance, spec.AttSourceFlagIndex, ideal.Source, slashed || row["f_missing_source"] == true)
+		total.Target = calculator.actual(effectiveBalance, spec.AttTargetFlagIndex, ideal.Target, slashed || row["f_missing_target"] == true)
+		total.Head = calculator.actual(effectiveBalance, spec.AttHeadFlagIndex, ideal.Head, slashed || row["f_missing_head"] == true)
+		total.Inactivity = -row.Int64("f_inactivity_penalty")
+		result.TotalRewards = append(result.TotalRewards, total)
+	}
+	return result, nil
+}
+
+// flagRewards applies get_flag_index_deltas to a validator
+type flagRewards struct {
+	baseRewardPerInc phase0.Gwei
+	totalActiveInc   uint64
+	attestingInc     [3]uint64 // per flag
+	inLeak           bool      // the flags are not rewarded during an inactivity leak, only penalized
+}
+
+func (f flagRewards) baseReward(effectiveBalance uint64) uint64 {
+	return uint64(f.baseRewardPerInc) * (effectiveBalance / spec.EffectiveBalanceInc)
+}
+
+func (f flagRewards) ideal(effectiveBalance uint64) idealAttestationReward {
+	result := idealAttestationReward{EffectiveBalance: effectiveBalance}
+	if f.totalActiveInc == 0 || f.inLeak {
+		return result
+	}
+	rewards := [3]int64{}
+	for i := range rewards {
+		numerator := uint64(spec.ParticipatingFlagsWeight[i]) * f.baseReward(effectiveBalance) * f.attestingInc[i]
+		rewards[i] = int64(numerator / (f.totalActiveInc * spec.WeightDenominator))
+	}
+	result.Source = rewards[spec.AttSourceFlagIndex]
+	result.Target = rewards[spec.AttTargetFlagIndex]
+	result.Head = rewards[spec.AttHeadFlagIndex]
+	return result
+}
+
+// actual returns the ideal reward if the flag was achieved, the penalty otherwise (there is none for the head)
+func (f flagRewards) actual(effectiveBalance uint64, flagIndex int, ideal int64, missing bool) int64 {
+	if !missing {
+		return ideal
+	}
+	if flagIndex == spec.AttHeadFlagIndex {
+		return 0
+	}
+	return -int64(uint64(spec.ParticipatingFlagsWeight[flagIndex]) * f.baseReward(effectiveBalance) / spec.WeightDenominator)
+}
+
+// proposedBlock returns the t_block_metrics row of the slot, errNotFound if the slot was missed
+func (s *Server) proposedBlock(slot phase0.Slot) (db.Row, error) {
+	block, err := s.selectOne(db.Query{Table: "t_block_metrics", Final: true, Filters: []db.Filter{
+		{Column: "f_slot", Op: "=", Value: uint64(slot)}}})
+	if err != nil {
+		return nil, err
+	}
+	if block["f_proposed"] != true {
+		return nil, errors.Wrapf(errNotFound, "slot %d was missed", slot)
+	}
+	return block, nil
+}
+
+func (s *Server) blockRewards(slot phase0.Slot) (spec.BlockRewardsContent, error) {
+	block, err := s.proposedBlock(slot)
+	if err != nil {
+		return spec.BlockRewardsContent{}, err
+	}
+	rewards, err := s.selectOne(db.Query{Table: "t_block_rewards", Final: true, Filters: []db.Filter{
+		{Column: "f_slot", Op: "=", Value: uint64(slot)}}})
+	if err != nil {
+		return spec.BlockRewardsContent{}, err
+	}
+	return spec.BlockRewardsContent{
+		ProposerIndex:     block.Uint64("f_proposer_index"),
+		Total:             rewards.Uint64("f_cl_manual_reward"),
+		Attestations:      rewards.Uint64("f_cl_attestations_reward"),
+		SyncAggregate:     rewards.Uint64("f_cl_sync_aggregate_reward"),
+		ProposerSlashings: rewards.Uint64("f_cl_proposer_slashings_reward"),
+		AttesterSlashings: rewards.Uint64("f_cl_attester_slashings_reward"),
+	}, nil
+}
+
+func (s *Server) syncCommitteeRewards(slot phase0.Slot, validators []uint64) ([]syncCommitteeReward, error) {
+	if _, err := s.proposedBlock(slot); err != nil {
+		return nil, err
+	}
+
+	filters := []db.Filter{{Column: "f_slot", Op: "=", Value: uint64(slot)}}
+	if len(validators) > 0 {
+		filters = append(filters, db.Filter{Column: "f_val_idx", Op: "in", Value: validators})
+	}
+	rows, err := s.reader.SelectRows(db.Query{
+		Table:   "t_sync_committee_participation",
+		Final:   true,
+		Filters: filters,
+		OrderBy: []string{"f_committee_position"},
+		Limit:   math.MaxInt32,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// a validator can hold several positions of the committee
+	result := make([]syncCommitteeReward, 0)
+	positions := make(map[uint64]int)
+	for _, row := range rows {
+		valIdx := row.Uint64("f_val_idx")
+		i, ok := positions[valIdx]
+		if !ok {
+			i = len(result)
+			positions[valIdx] = i
+			result = append(result, syncCommitteeReward{ValidatorIndex: valIdx})
+		}
+		result[i].Reward += row.Int64("f_reward")
+	}
+	return result, nil
+}
+
+func (s *Server) selectValidatorRewards(epoch phase0.Epoch, validators []uint64) ([]db.Row, error) {
+	filters := []db.Filter{{Column: "f_epoch", Op: "=", Value: uint64(epoch)}}
+	if len(validators) > 0 {
+		filters = append(filters, db.Filter{Column: "f_val_idx", Op: "in", Value: validators})
+	}
+	return s.reader.SelectRows(db.Query{
+		Table:   "t_validator_rewards_summary",
+		Final:   true,
+		Filters: filters,
+		OrderBy: []string{"f_val_idx"},
+		Limit:   math.MaxInt32,
+	})
+}
+
+// selectOne returns the first row of the query, errNotFound if there is none
+func (s *Server) selectOne(query db.Query) (db.Row, error) {
+	query.Limit = 1
+	rows, err := s.reader.SelectRows(query)
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		if len(query.Filters) == 0 {
+			return nil, errors.Wrapf(errNotFound, "no %s data", query.Table)
+		}
+		return nil, errors.Wrapf(errNotFound, "no %s data for %v", query.Table, query.Filters[0].Value)
+	}
+	return rows[0], nil
+}
+
+// readValidators returns the validator indices of the request body, empty for all the validators
+func readValidators(r *http.Request) ([]uint64, error) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, err
+	}
+	if len(strings.TrimSpace(string(body))) == 0 {
+		return nil, nil
+	}
+	var ids []string
+	if err := json.Unmarshal(body, &ids); err != nil {
+		return nil, errors.New("the body must be an array of validator indices")
+	}
+	validators := make([]uint64, 0, len(ids))
+	for _, id := range ids {
+		if strings.HasPrefix(id, "0x") {
+			return nil, errors.Errorf("validator %s: only validator indices are supported", id)
+		}
+		valIdx, err := strconv.ParseUint(id, 10, 64)
+		if err != nil {
+			return nil, errors.Errorf("invalid validator %s", id)
+		}
+		validators = append(validators, valIdx)
+	}
+	return validators, nil
+}
+
+// blockSlot returns the slot of the block_id of the Beacon API: head, finalized, a block
+// root or a slot. head and finalized are the last blocks indexed at or before them.
+// genesis is refused, its block has no proposer nor sync committee rewards
+func (s *Server) blockSlot(blockID string) (phase0.Slot, error) {
+	switch blockID {
+	case "genesis":
+		return 0, errors.Wrap(errInvalidID, "the genesis block has no rewards")
+	case "head":
+		return s.lastProposedSlot(math.MaxInt64)
+	case "finalized":
+		finalizedEpoch, err := s.finalizedEpoch()
+		if err != nil {
+			return 0, err
+		}
+		return s.lastProposedSlot(uint64(spec.ComputeStartSlotAtEpoch(finalizedEpoch)))
+	}
+
+	if strings.HasPrefix(blockID, "0x") {
+		root, err := hex.DecodeString(blockID[2:])
+		if err != nil || len(root) != len(phase0.Root{}) {
+			return 0, errors.Wrapf(errInvalidID, "%s", blockID)
+		}
+		block, err := s.selectOne(db.Query{Table: "t_block_metrics", Final: true, Filters: []db.Filter{
+			{Column: "f_block_root", Op: "=", Value: phase0.Root(root).String()}}})
+		if err != nil {
+			return 0, err
+		}
+		return phase0.Slot(block.Uint64("f_slot")), nil
+	}
+
+	slot, err := strconv.ParseUint(blockID, 10, 64)
+	if err != nil {
+		return 0, errors.Wrapf(errInvalidID, "%s", blockID)
+	}
+	return phase0.Slot(slot), nil
+}
+
+// lastProposedSlot returns the slot of the last block indexed at or before maxSlot
+func (s *Server) lastProposedSlot(maxSlot uint64) (phase0.Slot, error) {
+	// a whole epoch of missed slots is not looked past
+	blocks, err := s.reader.SelectRows(db.Query{
+		Table:   "t_block_metrics",
+		Final:   true,
+		Filters: []db.Filter{{Column: "f_slot", Op: "<=", Value: maxSlot}},
+		OrderBy: []string{"f_slot"},
+		Desc:    true,
+		Limit:   int(spec.SlotsPerEpoch),
+	})
+	if err != nil {
+		return 0, err
+	}
+	for _, block := range blocks {
+		if block["f_proposed"] == true {
+			return phase0.Slot(block.Uint64("f_slot")), nil
+		}
+	}
+	return 0, errors.Wrap(errNotFound, "no block indexed")
+}
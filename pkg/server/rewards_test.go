@@ -0,0 +1,212 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/attestantio/go-eth2-client/spec/altair"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/migalabs/goteth/pkg/db"
+	"github.com/migalabs/goteth/pkg/spec"
+	"github.com/prysmaticlabs/go-bitfield"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testRewardsServer indexes the attestations to epoch 10 and to epoch 20, during an inactivity
+// leak (total active balance of 64000 ETH, so the base reward per increment is 8000 Gwei),
+// and the block at slot 384. Altair starts at epoch 5 and epoch 15 is the last finalized
+func testRewardsServer(t *testing.T) *httptest.Server {
+	altairForkEpoch := spec.AltairForkEpoch
+	spec.AltairForkEpoch = 5
+	t.Cleanup(func() { spec.AltairForkEpoch = altairForkEpoch })
+	sink := db.NewMemorySink()
+
+	require.NoError(t, sink.PersistEpochs([]spec.Epoch{
+		{Epoch: 10, SourceAttEffectiveBalance: 48000, TargetAttEffectiveBalance: 40000, HeadAttEffectiveBalance: 32000, FinalizedEpoch: 8},
+		{Epoch: 11, TotalEffectiveBalance: 64000, FinalizedEpoch: 9},
+		{Epoch: 12, FinalizedEpoch: 10},
+		{Epoch: 20, SourceAttEffectiveBalance: 48000, TargetAttEffectiveBalance: 40000, HeadAttEffectiveBalance: 32000, FinalizedEpoch: 15},
+		{Epoch: 21, TotalEffectiveBalance: 64000, FinalizedEpoch: 15},
+		{Epoch: 22, FinalizedEpoch: 15},
+	}))
+
+	eb := phase0.Gwei(32 * spec.EffectiveBalanceInc)
+	require.NoError(t, sink.PersistValidatorRewards([]spec.ValidatorRewards{
+		{ValidatorIndex: 1, Epoch: 11, EffectiveBalance: eb, Status: spec.ACTIVE_STATUS},
+		{ValidatorIndex: 2, Epoch: 11, EffectiveBalance: eb, Status: spec.ACTIVE_STATUS},
+		{ValidatorIndex: 3, Epoch: 11, EffectiveBalance: eb / 2, Status: spec.ACTIVE_STATUS},
+		{ValidatorIndex: 4, Epoch: 11, EffectiveBalance: eb, Status: spec.SLASHED_STATUS},
+		{ValidatorIndex: 5, Epoch: 11, EffectiveBalance: eb, Status: spec.QUEUE_STATUS},
+		{ValidatorIndex: 1, Epoch: 12, AttestationReward: 1},
+		{ValidatorIndex: 2, Epoch: 12, AttestationReward: 1, MissingTarget: true, MissingHead: true},
+		{ValidatorIndex: 3, Epoch: 12, AttestationReward: 1},
+		{ValidatorIndex: 4, Epoch: 12, AttestationReward: 1},
+		{ValidatorIndex: 5, Epoch: 12}, // not active in epoch 10
+		{ValidatorIndex: 1, Epoch: 21, EffectiveBalance: eb, Status: spec.ACTIVE_STATUS},
+		{ValidatorIndex: 2, Epoch: 21, EffectiveBalance: eb, Status: spec.ACTIVE_STATUS},
+		{ValidatorIndex: 1, Epoch: 22, AttestationReward: 1},
+		{ValidatorIndex: 2, Epoch: 22, AttestationReward: 1, MissingTarget: true, MissingHead: true, InactivityPenalty: 5000},
+	}))
+
+	require.NoError(t, sink.PersistBlocks([]spec.AgnosticBlock{
+		{
+			Slot:          384,
+			Root:          phase0.Root{1},
+			ProposerIndex: 7,
+			Proposed:      true,
+			Attestations:  []*phase0.Attestation{},
+			SyncAggregate: &altair.SyncAggregate{SyncCommitteeBits: bitfield.NewBitvector512()},
+		},
+		{
+			Slot:          385,
+			ProposerIndex: 8,
+			Attestations:  []*phase0.Attestation{},
+			SyncAggregate: &altair.SyncAggregate{SyncCommitteeBits: bitfield.NewBitvector512()},
+		},
+	}))
+	require.NoError(t, sink.PersistBlockRewards([]db.BlockReward{{
+		Slot:           384,
+		CLManualReward: 1500,
+		CLRewardComponents: spec.BlockRewardComponents{
+			Attestations:      1000,
+			SyncAggregate:     300,
+			AttesterSlashings: 200,
+		},
+	}}))
+	require.NoError(t, sink.PersistSyncCommitteeDuties([]spec.SyncCommitteeDuty{
+		{Slot: 384, Epoch: 12, ValIdx: 1, CommitteePosition: 0, Participated: true, Reward: 20},
+		{Slot: 384, Epoch: 12, ValIdx: 2, CommitteePosition: 1, Participated: false, Reward: -20},
+		{Slot: 384, Epoch: 12, ValIdx: 1, CommitteePosition: 2, Participated: true, Reward: 20},
+	}))
+
+	srv := httptest.NewServer(New(context.Background(), sink, 0).Handler())
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func request(t *testing.T, method string, url string, body string) (int, map[string]any) {
+	req, err := http.NewRequest(method, url, bytes.NewBufferString(body))
+	require.NoError(t, err)
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	result := make(map[string]any)
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+	return resp.StatusCode, result
+}
+
+func TestAttestationRewards(t *testing.T) {
+	srv := testRewardsServer(t)
+
+	status, body := request(t, http.MethodPost, srv.URL+"/eth/v1/beacon/rewards/attestations/10", `[]`)
+	require.Equal(t, http.StatusOK, status)
+	assert.Equal(t, false, body["execution_optimistic"])
+	assert.Equal(t, true, body["finalized"])
+	data := body["data"].(map[string]any)
+	assert.Equal(t, []any{
+		map[string]any{"validator_index": "1", "head": "28000", "target": "65000", "source": "42000", "inactivity": "0"},
+		map[string]any{"validator_index": "2", "head": "0", "target": "-104000", "source": "42000", "inactivity": "0"},
+		map[string]any{"validator_index": "3", "head": "14000", "target": "32500", "source": "21000", "inactivity": "0"},
+		map[string]any{"validator_index": "4", "head": "0", "target": "-104000", "source": "-56000", "inactivity": "0"},
+	}, data["total_rewards"])
+	// every effective balance up to MAX_EFFECTIVE_BALANCE
+	idealRewards := data["ideal_rewards"].([]any)
+	require.Len(t, idealRewards, 32)
+	assert.Equal(t, map[string]any{"effective_balance": "1000000000", "head": "875", "target": "2031", "source": "1312", "inactivity": "0"}, idealRewards[0])
+	assert.Equal(t, map[string]any{"effective_balance": "16000000000", "head": "14000", "target": "32500", "source": "21000", "inactivity": "0"}, idealRewards[15])
+	assert.Equal(t, map[string]any{"effective_balance": "32000000000", "head": "28000", "target": "65000", "source": "42000", "inactivity": "0"}, idealRewards[31])
+
+	// the ideal rewards do not depend on the validators requested
+	status, body = request(t, http.MethodPost, srv.URL+"/eth/v1/beacon/rewards/attestations/10", `["2"]`)
+	require.Equal(t, http.StatusOK, status)
+	data = body["data"].(map[string]any)
+	assert.Len(t, data["total_rewards"], 1)
+	assert.Len(t, data["ideal_rewards"], 32)
+
+	status, _ = request(t, http.MethodPost, srv.URL+"/eth/v1/beacon/rewards/attestations/11", ``)
+	assert.Equal(t, http.StatusNotFound, status)
+
+	// before Altair
+	status, _ = request(t, http.MethodPost, srv.URL+"/eth/v1/beacon/rewards/attestations/3", ``)
+	assert.Equal(t, http.StatusNotImplemented, status)
+
+	status, _ = request(t, http.MethodPost, srv.URL+"/eth/v1/beacon/rewards/attestations/10", `["0xabcd"]`)
+	assert.Equal(t, http.StatusBadRequest, status)
+}
+
+func TestAttestationRewardsInactivityLeak(t *testing.T) {
+	srv := testRewardsServer(t)
+
+	// the flags are not rewarded, the validators that missed the target pay the inactivity penalty
+	status, body := request(t, http.MethodPost, srv.URL+"/eth/v1/beacon/rewards/attestations/20", `[]`)
+	require.Equal(t, http.StatusOK, status)
+	assert.Equal(t, false, body["finalized"])
+	data := body["data"].(map[string]any)
+	assert.Equal(t, []any{
+		map[string]any{"validator_index": "1", "head": "0", "target": "0", "source": "0", "inactivity": "0"},
+		map[string]any{"validator_index": "2", "head": "0", "target": "-104000", "source": "0", "inactivity": "-5000"},
+	}, data["total_rewards"])
+	idealRewards := data["ideal_rewards"].([]any)
+	require.Len(t, idealRewards, 32)
+	assert.Equal(t, map[string]any{"effective_balance": "32000000000", "head": "0", "target": "0", "source": "0", "inactivity": "0"}, idealRewards[31])
+}
+
+func TestBlockRewards(t *testing.T) {
+	srv := testRewardsServer(t)
+
+	status, body := request(t, http.MethodGet, srv.URL+"/eth/v1/beacon/rewards/blocks/384", ``)
+	require.Equal(t, http.StatusOK, status)
+	assert.Equal(t, map[string]any{
+		"proposer_index":     "7",
+		"total":              "1500",
+		"attestations":       "1000",
+		"sync_aggregate":     "300",
+		"proposer_slashings": "0",
+		"attester_slashings": "200",
+	}, body["data"])
+
+	assert.Equal(t, true, body["finalized"])
+
+	status, _ = request(t, http.MethodGet, srv.URL+"/eth/v1/beacon/rewards/blocks/385", ``)
+	assert.Equal(t, http.StatusNotFound, status)
+
+	// the last proposed block, as slot 385 was missed
+	for _, blockID := range []string{"head", "finalized", phase0.Root{1}.String()} {
+		status, body = request(t, http.MethodGet, srv.URL+"/eth/v1/beacon/rewards/blocks/"+blockID, ``)
+		require.Equal(t, http.StatusOK, status, blockID)
+		assert.Equal(t, "7", body["data"].(map[string]any)["proposer_index"], blockID)
+	}
+
+	for blockID, expected := range map[string]int{
+		"genesis":               http.StatusBadRequest, // no rewards
+		phase0.Root{2}.String(): http.StatusNotFound,
+		"0x01":                  http.StatusBadRequest,
+		"justified":             http.StatusBadRequest,
+	} {
+		status, _ = request(t, http.MethodGet, srv.URL+"/eth/v1/beacon/rewards/blocks/"+blockID, ``)
+		assert.Equal(t, expected, status, blockID)
+	}
+}
+
+func TestSyncCommitteeRewards(t *testing.T) {
+	srv := testRewardsServer(t)
+
+	status, body := request(t, http.MethodPost, srv.URL+"/eth/v1/beacon/rewards/sync_committee/384", ``)
+	require.Equal(t, http.StatusOK, status)
+	assert.Equal(t, []any{
+		map[string]any{"validator_index": "1", "reward": "40"},
+		map[string]any{"validator_index": "2", "reward": "-20"},
+	}, body["data"])
+
+	status, body = request(t, http.MethodPost, srv.URL+"/eth/v1/beacon/rewards/sync_committee/384", `["2"]`)
+	require.Equal(t, http.StatusOK, status)
+	assert.Equal(t, []any{
+		map[string]any{"validator_index": "2", "reward": "-20"},
+	}, body["data"])
+}
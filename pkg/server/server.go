@@ -0,0 +1,188 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/migalabs/goteth/pkg/db"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	log = logrus.WithField(
+		"module", "server",
+	)
+
+	DefaultLimit = 100
+	MaxLimit     = 1000
+)
+
+// Server serves the indexed tables as a REST/JSON API
+type Server struct {
+	ctx    context.Context
+	reader db.Reader
+	srv    *http.Server
+}
+
+func New(ctx context.Context, reader db.Reader, port int) *Server {
+	s := &Server{
+		ctx:    ctx,
+		reader: reader,
+	}
+	s.srv = &http.Server{
+		Addr:              fmt.Sprintf("0.0.0.0:%d", port),
+		Handler:           s.Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	return s
+}
+
+// Handler returns the routes of the API
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	for _, res := range resources {
+		mux.HandleFunc(res.pattern, s.handleResource(res))
+	}
+	s.registerRewards(mux)
+	return mux
+}
+
+// Run serves the API until the context is done
+func (s *Server) Run() error {
+	go func() {
+		<-s.ctx.Done()
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		s.srv.Shutdown(ctx)
+	}()
+
+	log.Infof("query API listening on: %s", s.srv.Addr)
+	err := s.srv.ListenAndServe()
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return err
+}
+
+// response is the body of every successful request
+type response struct {
+	Data   []db.Row `json:"data"`
+	Limit  int      `json:"limit"`
+	Offset int      `json:"offset"`
+}
+
+func (s *Server) handleResource(res resource) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		query, err := res.query(r)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		rows, err := s.reader.SelectRows(query)
+		if err != nil {
+			log.Errorf("could not read %s: %s", res.table, err)
+			writeError(w, http.StatusInternalServerError, "could not read the database")
+			return
+		}
+		writeJSON(w, http.StatusOK, response{
+			Data:   rows,
+			Limit:  query.Limit,
+			Offset: query.Offset,
+		})
+	}
+}
+
+// query builds the database query from the path values and query parameters of the request
+func (res resource) query(r *http.Request) (db.Query, error) {
+	query := db.Query{
+		Table:   res.table,
+		Final:   res.final,
+		OrderBy: res.orderBy,
+		Limit:   DefaultLimit,
+	}
+
+	for _, name := range sortedNames(res.pathValues) {
+		filter, err := res.pathValues[name].filter(name, r.PathValue(name))
+		if err != nil {
+			return db.Query{}, err
+		}
+		query.Filters = append(query.Filters, filter)
+	}
+
+	values := r.URL.Query()
+	for _, name := range sortedNames(res.params) {
+		if !values.Has(name) {
+			continue
+		}
+		filter, err := res.params[name].filter(name, values.Get(name))
+		if err != nil {
+			return db.Query{}, err
+		}
+		query.Filters = append(query.Filters, filter)
+	}
+
+	var err error
+	if values.Has("limit") {
+		query.Limit, err = strconv.Atoi(values.Get("limit"))
+		if err != nil || query.Limit <= 0 || query.Limit > MaxLimit {
+			return db.Query{}, errors.Errorf("limit must be between 1 and %d", MaxLimit)
+		}
+	}
+	if values.Has("offset") {
+		query.Offset, err = strconv.Atoi(values.Get("offset"))
+		if err != nil || query.Offset < 0 {
+			return db.Query{}, errors.New("offset must be a positive number")
+		}
+	}
+	switch values.Get("order") {
+	case "", "asc":
+	case "desc":
+		query.Desc = true
+	default:
+		return db.Query{}, errors.New("order must be asc or desc")
+	}
+	return query, nil
+}
+
+func (p param) filter(name string, value string) (db.Filter, error) {
+	if p.text {
+		return db.Filter{Column: p.column, Op: p.op, Value: value}, nil
+	}
+	number, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		return db.Filter{}, errors.Errorf("%s must be a positive number", name)
+	}
+	return db.Filter{Column: p.column, Op: p.op, Value: number}, nil
+}
+
+// sortedNames keeps the order of the filters stable between requests
+func sortedNames(params map[string]param) []string {
+	names := make([]string, 0, len(params))
+	for name := range params {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		log.Errorf("could not write response: %s", err)
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]any{
+		"code":    status,
+		"message": message,
+	})
+}
@@ -0,0 +1,132 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/attestantio/go-eth2-client/spec/altair"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/migalabs/goteth/pkg/db"
+	"github.com/migalabs/goteth/pkg/spec"
+	"github.com/prysmaticlabs/go-bitfield"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testResponse struct {
+	Data    []map[string]any `json:"data"`
+	Limit   int              `json:"limit"`
+	Offset  int              `json:"offset"`
+	Code    int              `json:"code"`
+	Message string           `json:"message"`
+}
+
+func testServer(t *testing.T) *httptest.Server {
+	sink := db.NewMemorySink()
+
+	rewards := make([]spec.ValidatorRewards, 0)
+	for epoch := phase0.Epoch(10); epoch < 20; epoch++ {
+		for valIdx := phase0.ValidatorIndex(0); valIdx < 2; valIdx++ {
+			rewards = append(rewards, spec.ValidatorRewards{
+				ValidatorIndex: valIdx,
+				Epoch:          epoch,
+				Reward:         int64(epoch),
+			})
+		}
+	}
+	require.NoError(t, sink.PersistValidatorRewards(rewards))
+	block := func(slot phase0.Slot, proposer phase0.ValidatorIndex) spec.AgnosticBlock {
+		return spec.AgnosticBlock{
+			Slot:          slot,
+			ProposerIndex: proposer,
+			Proposed:      true,
+			Attestations:  []*phase0.Attestation{},
+			SyncAggregate: &altair.SyncAggregate{SyncCommitteeBits: bitfield.NewBitvector512()},
+		}
+	}
+	require.NoError(t, sink.PersistBlocks([]spec.AgnosticBlock{
+		block(320, 1),
+		block(321, 2),
+		block(352, 1),
+	}))
+
+	srv := httptest.NewServer(New(context.Background(), sink, 0).Handler())
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func get(t *testing.T, srv *httptest.Server, path string) (int, testResponse) {
+	resp, err := http.Get(srv.URL + path)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	var body testResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	return resp.StatusCode, body
+}
+
+func column(rows []map[string]any, name string) []float64 {
+	values := make([]float64, 0, len(rows))
+	for _, row := range rows {
+		values = append(values, row[name].(float64))
+	}
+	return values
+}
+
+func TestValidatorRewards(t *testing.T) {
+	srv := testServer(t)
+
+	status, body := get(t, srv, "/api/v1/validators/1/rewards?from_epoch=12&to_epoch=15")
+	require.Equal(t, http.StatusOK, status)
+	assert.Equal(t, []float64{12, 13, 14, 15}, column(body.Data, "f_epoch"))
+	assert.Equal(t, []float64{1, 1, 1, 1}, column(body.Data, "f_val_idx"))
+	assert.Equal(t, DefaultLimit, body.Limit)
+
+	// pagination in descending order
+	status, body = get(t, srv, "/api/v1/validators/0/rewards?order=desc&limit=3&offset=2")
+	require.Equal(t, http.StatusOK, status)
+	assert.Equal(t, []float64{17, 16, 15}, column(body.Data, "f_epoch"))
+	assert.Equal(t, 3, body.Limit)
+	assert.Equal(t, 2, body.Offset)
+
+	status, body = get(t, srv, "/api/v1/validators/5/rewards")
+	require.Equal(t, http.StatusOK, status)
+	assert.Empty(t, body.Data)
+}
+
+func TestBlocks(t *testing.T) {
+	srv := testServer(t)
+
+	status, body := get(t, srv, "/api/v1/blocks?proposer_index=1")
+	require.Equal(t, http.StatusOK, status)
+	assert.Equal(t, []float64{320, 352}, column(body.Data, "f_slot"))
+
+	status, body = get(t, srv, "/api/v1/blocks?from_slot=321")
+	require.Equal(t, http.StatusOK, status)
+	assert.Equal(t, []float64{321, 352}, column(body.Data, "f_slot"))
+
+	status, body = get(t, srv, "/api/v1/blocks/321")
+	require.Equal(t, http.StatusOK, status)
+	assert.Equal(t, []float64{2}, column(body.Data, "f_proposer_index"))
+}
+
+func TestInvalidParams(t *testing.T) {
+	srv := testServer(t)
+
+	for _, path := range []string{
+		"/api/v1/validators/abc/rewards",
+		"/api/v1/blocks?from_slot=-1",
+		"/api/v1/blocks?limit=0",
+		"/api/v1/blocks?limit=5000",
+		"/api/v1/blocks?offset=-3",
+		"/api/v1/blocks?order=random",
+	} {
+		status, body := get(t, srv, path)
+		assert.Equal(t, http.StatusBadRequest, status, path)
+		assert.Equal(t, http.StatusBadRequest, body.Code, path)
+		assert.NotEmpty(t, body.Message, path)
+	}
+}
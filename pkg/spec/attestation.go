@@ -0,0 +1,80 @@
+package spec
+
+import (
+	"fmt"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+)
+
+// ValidatorAttestation is the attestation duty of a validator in an epoch
+// and the blocks where its vote was included
+type ValidatorAttestation struct {
+	ValIdx             phase0.ValidatorIndex
+	Epoch              phase0.Epoch
+	AttSlot            phase0.Slot
+	CommitteeIndex     phase0.CommitteeIndex
+	CommitteePosition  uint64 // position of the validator inside the committee
+	Included           bool
+	InclusionSlot      phase0.Slot // slot of the first block including the vote
+	InclusionBlockRoot phase0.Root // root of the first block including the vote
+	InclusionDelay     uint64
+	Aggregates         []string // every aggregate including the vote, as <block slot>:<attestation index in the block>
+	IncludedLate       bool     // the vote was included, but never with the minimum inclusion delay
+}
+
+func (f ValidatorAttestation) Type() ModelType {
+	return ValidatorAttestationModel
+}
+
+// TrackValidatorAttestations (re)initializes the attestation duties of the epoch
+// from the beacon committees, so the inclusions are filled when the attestations
+// of the state are processed (see AddAttestationInclusion)
+func (p *AgnosticState) TrackValidatorAttestations() {
+	p.ValidatorAttestations = make([]*ValidatorAttestation, len(p.Validators))
+
+	for _, committee := range p.EpochStructs.BeaconCommittees {
+		for position, valIdx := range committee.Validators {
+			if int(valIdx) >= len(p.ValidatorAttestations) {
+				continue
+			}
+			p.ValidatorAttestations[valIdx] = &ValidatorAttestation{
+				ValIdx:            valIdx,
+				Epoch:             p.Epoch,
+				AttSlot:           committee.Slot,
+				CommitteeIndex:    committee.Index,
+				CommitteePosition: uint64(position),
+				Aggregates:        make([]string, 0),
+			}
+		}
+	}
+}
+
+// AddAttestationInclusion registers the vote of the validator included in the block,
+// blocks are expected in ascending order. It does nothing if the state is not tracking attestations
+func (p *AgnosticState) AddAttestationInclusion(valIdx phase0.ValidatorIndex, block *AgnosticBlock, attIndex int) {
+	if int(valIdx) >= len(p.ValidatorAttestations) || p.ValidatorAttestations[valIdx] == nil {
+		return
+	}
+	attestation := p.ValidatorAttestations[valIdx]
+	attestation.Aggregates = append(attestation.Aggregates, fmt.Sprintf("%d:%d", block.Slot, attIndex))
+
+	if attestation.Included {
+		return // only the first inclusion counts
+	}
+	attestation.Included = true
+	attestation.InclusionSlot = block.Slot
+	attestation.InclusionBlockRoot = block.Root
+	attestation.InclusionDelay = uint64(block.Slot - attestation.AttSlot)
+	attestation.IncludedLate = attestation.InclusionDelay > MinInclusionDelay
+}
+
+// GetValidatorAttestations returns the tracked attestation duties of the epoch
+func (p AgnosticState) GetValidatorAttestations() []ValidatorAttestation {
+	result := make([]ValidatorAttestation, 0)
+	for _, attestation := range p.ValidatorAttestations {
+		if attestation != nil {
+			result = append(result, *attestation)
+		}
+	}
+	return result
+}
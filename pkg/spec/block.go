@@ -42,6 +42,9 @@ type AgnosticBlock struct {
 	CompressionTime       time.Duration
 	DecompressionTime     time.Duration
 	ManualReward          phase0.Gwei
+
+	ManualRewardComponents BlockRewardComponents // ManualReward split by component
+
 	// Electra
 	ElectraAttestations      []*electra.Attestation
 	ElectraAttesterSlashings []*electra.AttesterSlashing
@@ -63,6 +66,14 @@ type AgnosticExecutionPayload struct {
 	PayloadSize          uint32
 }
 
+// BlockRewardComponents is the consensus reward of a block proposer split as in /eth/v1/beacon/rewards/blocks
+type BlockRewardComponents struct {
+	Attestations      phase0.Gwei
+	SyncAggregate     phase0.Gwei
+	ProposerSlashings phase0.Gwei
+	AttesterSlashings phase0.Gwei
+}
+
 func (f AgnosticBlock) Type() ModelType {
 	return BlockModel
 }
@@ -0,0 +1,153 @@
+package spec
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// chainSpecValues are the spec keys read by goteth and the value each one overwrites
+var chainSpecValues = map[string]*uint64{
+	"SLOTS_PER_EPOCH":                           &SlotsPerEpoch,
+	"SECONDS_PER_SLOT":                          &SlotSeconds,
+	"SLOTS_PER_HISTORICAL_ROOT":                 &SlotsPerHistoricalRoot,
+	"EFFECTIVE_BALANCE_INCREMENT":               &EffectiveBalanceInc,
+	"BASE_REWARD_FACTOR":                        &BaseRewardFactor,
+	"PROPOSER_REWARD_QUOTIENT":                  &ProposerRewardQuotient,
+	"WHISTLEBLOWER_REWARD_QUOTIENT":             &WhistleBlowerRewardQuotient,
+	"MIN_INCLUSION_DELAY":                       &MinInclusionDelay,
+	"CHURN_LIMIT_QUOTIENT":                      &ChurnLimitQuotient,
+	"SHARD_COMMITTEE_PERIOD":                    &ShardCommitteePeriod,
+	"SYNC_COMMITTEE_SIZE":                       &SyncCommitteeSize,
+	"MIN_PER_EPOCH_CHURN_LIMIT_ELECTRA":         &MinPerEpochChurnLimitElectra,
+	"MAX_PER_EPOCH_ACTIVATION_EXIT_CHURN_LIMIT": &MaxPerEpochActivationExitChurnLimitElectra,
+	"MIN_ACTIVATION_BALANCE":                    &MinActivationBalance,
+	"MAX_PENDING_DEPOSITS_PER_EPOCH":            &MaxPendingDepositsPerEpoch,
+	"ALTAIR_FORK_EPOCH":                         &AltairForkEpoch,
+	"INACTIVITY_SCORE_BIAS":                     &InactivityScoreBias,
+	"ELECTRA_FORK_EPOCH":                        &ElectraForkEpoch,
+	"MAX_EFFECTIVE_BALANCE_ELECTRA":             &MaxEffectiveBalanceElectra,
+}
+
+// presets are the preset values that change between presets, the config files of the
+// consensus specs only include the PRESET_BASE and not the preset values
+var presets = map[string]map[string]uint64{
+	"mainnet": {
+		"SLOTS_PER_EPOCH":           32,
+		"SLOTS_PER_HISTORICAL_ROOT": 8192,
+		"SYNC_COMMITTEE_SIZE":       512,
+	},
+	"minimal": {
+		"SLOTS_PER_EPOCH":           8,
+		"SLOTS_PER_HISTORICAL_ROOT": 64,
+		"SYNC_COMMITTEE_SIZE":       32,
+	},
+}
+
+// ApplyChainSpec overwrites the chain spec values with the given ones, as returned by
+// /eth/v1/config/spec or read from a config file. Keys not used by goteth are ignored.
+// Every value is validated before any is overwritten, so on error the spec is unchanged.
+// It has to be called before any state is processed
+func ApplyChainSpec(values map[string]any) error {
+	parsed := make(map[string]uint64, len(chainSpecValues))
+	for key, target := range chainSpecValues {
+		parsed[key] = *target
+	}
+
+	if presetBase, ok := values["PRESET_BASE"]; ok {
+		preset, ok := presets[strings.Trim(fmt.Sprint(presetBase), "'\"")]
+		if !ok {
+			return errors.Errorf("unknown preset %v", presetBase)
+		}
+		for key, value := range preset {
+			parsed[key] = value
+		}
+	}
+
+	for key := range chainSpecValues {
+		value, ok := values[key]
+		if !ok {
+			continue
+		}
+		parsedValue, err := specUint64(value)
+		if err != nil {
+			return errors.Wrapf(err, "invalid %s", key)
+		}
+		parsed[key] = parsedValue
+	}
+
+	// newer clients give the slot duration in milliseconds
+	if value, ok := values["SLOT_DURATION_MS"]; ok {
+		slotMs, err := specUint64(value)
+		if err != nil {
+			return errors.Wrap(err, "invalid SLOT_DURATION_MS")
+		}
+		parsed["SECONDS_PER_SLOT"] = slotMs / 1000
+	}
+
+	if parsed["SLOTS_PER_EPOCH"] == 0 || parsed["SECONDS_PER_SLOT"] == 0 ||
+		parsed["SLOTS_PER_HISTORICAL_ROOT"] == 0 || parsed["EFFECTIVE_BALANCE_INCREMENT"] == 0 {
+		return errors.New("chain spec values cannot be 0")
+	}
+
+	maxEffectiveInc := MaxEffectiveInc
+	if value, ok := values["MAX_EFFECTIVE_BALANCE"]; ok {
+		maxEffectiveBalance, err := specUint64(value)
+		if err != nil {
+			return errors.Wrap(err, "invalid MAX_EFFECTIVE_BALANCE")
+		}
+		maxEffectiveInc = maxEffectiveBalance / parsed["EFFECTIVE_BALANCE_INCREMENT"]
+	}
+
+	// a spec without name (i.e. a devnet) is not the default network any more,
+	// so nothing is selected by name for it
+	configName := ""
+	if value, ok := values["CONFIG_NAME"]; ok {
+		configName = strings.Trim(fmt.Sprint(value), "'\"")
+	}
+
+	for key, target := range chainSpecValues {
+		*target = parsed[key]
+	}
+	MaxEffectiveInc = maxEffectiveInc
+	ConfigName = configName
+	return nil
+}
+
+// ReadChainSpecFile reads a config file in the format of the consensus specs
+// (i.e. config.yaml of the network), preset values can be included in the same file
+func ReadChainSpecFile(path string) (map[string]any, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	values := make(map[string]any)
+	err = yaml.Unmarshal(content, &values)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not parse chain spec file")
+	}
+	return values, nil
+}
+
+func specUint64(value any) (uint64, error) {
+	switch v := value.(type) {
+	case uint64:
+		return v, nil
+	case int:
+		if v < 0 {
+			return 0, errors.Errorf("negative value %d", v)
+		}
+		return uint64(v), nil
+	case time.Duration:
+		return uint64(v / time.Second), nil
+	case string:
+		return strconv.ParseUint(strings.Trim(v, "'\""), 10, 64)
+	default:
+		return 0, errors.Errorf("unexpected type %T", value)
+	}
+}
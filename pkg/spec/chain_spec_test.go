@@ -0,0 +1,107 @@
+package spec_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/migalabs/goteth/pkg/spec"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// restoreChainSpec puts back the mainnet values at the end of the test
+func restoreChainSpec(t *testing.T) {
+	configName, slotsPerEpoch, slotSeconds := spec.ConfigName, spec.SlotsPerEpoch, spec.SlotSeconds
+	slotsPerHistoricalRoot, syncCommitteeSize := spec.SlotsPerHistoricalRoot, spec.SyncCommitteeSize
+	maxEffectiveInc, churnLimitQuotient := spec.MaxEffectiveInc, spec.ChurnLimitQuotient
+	altairForkEpoch := spec.AltairForkEpoch
+	t.Cleanup(func() {
+		spec.AltairForkEpoch = altairForkEpoch
+		spec.ConfigName, spec.SlotsPerEpoch, spec.SlotSeconds = configName, slotsPerEpoch, slotSeconds
+		spec.SlotsPerHistoricalRoot, spec.SyncCommitteeSize = slotsPerHistoricalRoot, syncCommitteeSize
+		spec.MaxEffectiveInc, spec.ChurnLimitQuotient = maxEffectiveInc, churnLimitQuotient
+	})
+}
+
+func TestApplyChainSpec(t *testing.T) {
+	restoreChainSpec(t)
+
+	// values as parsed by go-eth2-client
+	err := spec.ApplyChainSpec(map[string]any{
+		"CONFIG_NAME":                 "gnosis",
+		"PRESET_BASE":                 "gnosis",
+		"SLOTS_PER_EPOCH":             uint64(16),
+		"SECONDS_PER_SLOT":            5 * time.Second,
+		"MAX_EFFECTIVE_BALANCE":       uint64(32_000_000_000),
+		"EFFECTIVE_BALANCE_INCREMENT": uint64(1_000_000_000),
+		"GENESIS_FORK_VERSION":        phase0.Version{0, 0, 0, 0x64},
+	})
+	assert.Error(t, err) // unknown preset
+
+	err = spec.ApplyChainSpec(map[string]any{
+		"CONFIG_NAME":                 "gnosis",
+		"SLOTS_PER_EPOCH":             uint64(16),
+		"SECONDS_PER_SLOT":            5 * time.Second,
+		"MAX_EFFECTIVE_BALANCE":       uint64(32_000_000_000),
+		"EFFECTIVE_BALANCE_INCREMENT": uint64(1_000_000_000),
+		"GENESIS_FORK_VERSION":        phase0.Version{0, 0, 0, 0x64},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "gnosis", spec.ConfigName)
+	assert.Equal(t, uint64(16), spec.SlotsPerEpoch)
+	assert.Equal(t, uint64(5), spec.SlotSeconds)
+	assert.Equal(t, uint64(32), spec.MaxEffectiveInc)
+	assert.Equal(t, phase0.Epoch(2), spec.EpochAtSlot(32))
+	assert.Equal(t, phase0.Slot(48), spec.ComputeStartSlotAtEpoch(3))
+
+	err = spec.ApplyChainSpec(map[string]any{"SLOTS_PER_EPOCH": uint64(0)})
+	assert.Error(t, err)
+
+	// a spec with an invalid value is not applied at all
+	err = spec.ApplyChainSpec(map[string]any{
+		"CONFIG_NAME":          "minimal",
+		"PRESET_BASE":          "minimal",
+		"CHURN_LIMIT_QUOTIENT": "not a number",
+	})
+	assert.Error(t, err)
+	assert.Equal(t, "gnosis", spec.ConfigName)
+	assert.Equal(t, uint64(16), spec.SlotsPerEpoch)
+	assert.Equal(t, uint64(32), spec.MaxEffectiveInc)
+
+	// the name is not kept from a previous spec
+	err = spec.ApplyChainSpec(map[string]any{
+		"SLOTS_PER_EPOCH":  uint64(32),
+		"SECONDS_PER_SLOT": uint64(12),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "", spec.ConfigName)
+}
+
+func TestReadChainSpecFile(t *testing.T) {
+	restoreChainSpec(t)
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	content := `# Minimal config
+PRESET_BASE: 'minimal'
+CONFIG_NAME: 'minimal'
+GENESIS_FORK_VERSION: 0x00000001
+SECONDS_PER_SLOT: 6
+CHURN_LIMIT_QUOTIENT: 32
+`
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+
+	values, err := spec.ReadChainSpecFile(path)
+	require.NoError(t, err)
+	require.NoError(t, spec.ApplyChainSpec(values))
+
+	assert.Equal(t, "minimal", spec.ConfigName)
+	assert.Equal(t, uint64(8), spec.SlotsPerEpoch) // from the preset
+	assert.Equal(t, uint64(64), spec.SlotsPerHistoricalRoot)
+	assert.Equal(t, uint64(32), spec.SyncCommitteeSize)
+	assert.Equal(t, uint64(6), spec.SlotSeconds)
+	assert.Equal(t, uint64(32), spec.ChurnLimitQuotient)
+	assert.Equal(t, phase0.Epoch(2), spec.EpochAtSlot(16))
+}
@@ -25,17 +25,7 @@ Phase0
 */
 
 const (
-	MaxEffectiveInc             = 32
-	BaseRewardFactor            = 64
-	BaseRewardPerEpoch          = 4
-	EffectiveBalanceInc         = 1000000000
-	SlotsPerEpoch               = 32
-	ProposerRewardQuotient      = 8
-	SlotsPerHistoricalRoot      = 8192
-	SlotSeconds                 = 12
-	EpochSlots                  = 32
-	WhistleBlowerRewardQuotient = 512
-	MinInclusionDelay           = 1
+	BaseRewardPerEpoch = 4
 
 	AttSourceFlagIndex = 0
 	AttTargetFlagIndex = 1
@@ -43,11 +33,40 @@ const (
 
 	Eth1AddressWithdrawalPrefix = 0x01
 
-	ChurnLimitQuotient uint64 = 1 << 16
-
 	FarFutureEpoch uint64 = 1<<64 - 1
+)
+
+/*
+Chain spec: preset and configuration values of the network.
+They default to mainnet and are overwritten at startup with the spec of the
+beacon node or a config file (see ApplyChainSpec), so any network or preset works
+*/
+var (
+	ConfigName                  string = "mainnet"
+	SlotsPerEpoch               uint64 = 32
+	SlotSeconds                 uint64 = 12
+	SlotsPerHistoricalRoot      uint64 = 8192
+	EffectiveBalanceInc         uint64 = 1_000_000_000
+	MaxEffectiveInc             uint64 = 32 // MAX_EFFECTIVE_BALANCE / EFFECTIVE_BALANCE_INCREMENT
+	BaseRewardFactor            uint64 = 64
+	ProposerRewardQuotient      uint64 = 8
+	WhistleBlowerRewardQuotient uint64 = 512
+	MinInclusionDelay           uint64 = 1
+	ChurnLimitQuotient          uint64 = 1 << 16
+	ShardCommitteePeriod        uint64 = 256
+
+	SyncCommitteeSize uint64 = 512
+
+	MinPerEpochChurnLimitElectra               uint64 = 128_000_000_000 // Gwei(2**7 * 10**9)
+	MaxPerEpochActivationExitChurnLimitElectra uint64 = 256_000_000_000 // Gwei(2**8 * 10**9)
+	MinActivationBalance                       uint64 = 32_000_000_000  // Gwei(2**5 * 10**9)
+	MaxPendingDepositsPerEpoch                 uint64 = 16              // 2**4
+
+	AltairForkEpoch     uint64 = 74240
+	InactivityScoreBias uint64 = 4
 
-	ShardCommitteePeriod uint64 = 256
+	ElectraForkEpoch           uint64 = 364032
+	MaxEffectiveBalanceElectra uint64 = 2_048_000_000_000 // Gwei(2**11 * 10**9)
 )
 
 /*
@@ -62,7 +81,11 @@ const (
 	SyncRewardWeight  = 2
 	ProposerWeight    = 8
 	WeightDenominator = 64
-	SyncCommitteeSize = 512
+
+	// https://github.com/ethereum/consensus-specs/blob/dev/specs/altair/beacon-chain.md#inactivity-penalties
+	MinEpochsToInactivityPenalty       = 4
+	InactivityPenaltyQuotientAltair    = 3 * (1 << 24)
+	InactivityPenaltyQuotientBellatrix = 1 << 24
 )
 
 // Electra
@@ -73,16 +96,9 @@ const (
 	PendingConsolidationsLimit     uint64 = 1 << 18
 	PendingPartialWithdrawalsLimit uint64 = 1 << 27 // uint64(2**27) (= 134,217,728)
 
-	MinPerEpochChurnLimitElectra               uint64 = 128_000_000_000 // Gwei(2**7 * 10**9)
-	MaxPerEpochActivationExitChurnLimitElectra uint64 = 256_000_000_000 // Gwei(2**8 * 10**9)
-
-	MinActivationBalance uint64 = 32_000_000_000 // Gwei(2**5 * 10**9)
-
 	// https://github.com/ethereum/consensus-specs/blob/dev/specs/electra/beacon-chain.md#misc
 	FullExitRequestAmount          uint64 = 0
 	UnsetDepositRequestsStartIndex uint64 = 1<<64 - 1 //uint64(2**64 - 1)
-
-	MaxPendingDepositsPerEpoch uint64 = 16 // 2**4
 )
 
 var (
@@ -118,6 +134,9 @@ const (
 	ConsolidationRequestModel
 	WithdrawalRequestModel
 	DepositRequestModel
+	ValidatorAttestationModel
+	SyncCommitteeDutyModel
+	ValidatorLabelModel
 )
 
 type ValidatorStatus int8
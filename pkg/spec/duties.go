@@ -32,7 +32,7 @@ func (p EpochDuties) GetValList(slot phase0.Slot, committeeIndex phase0.Committe
 }
 
 func GetEffectiveBalance(balance float64) float64 {
-	return math.Min(MaxEffectiveInc*EffectiveBalanceInc, balance)
+	return math.Min(float64(MaxEffectiveInc*EffectiveBalanceInc), balance)
 }
 
 type ValVote struct {
@@ -37,6 +37,7 @@ type Epoch struct {
 	WithdrawalRequestsNum         int
 	ConsolidationsProcessedNum    uint64
 	ConsolidationsProcessedAmount phase0.Gwei
+	FinalizedEpoch                phase0.Epoch // of the finalized checkpoint at the end of the epoch
 }
 
 func (f Epoch) Type() ModelType {
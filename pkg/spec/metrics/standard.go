@@ -110,11 +110,11 @@ func (s StateMetricsBase) ExportToEpoch() local_spec.Epoch {
 		NumValidators:                 len(s.CurrentState.Validators),
 		NumCompoundingVals:            s.CurrentState.GetCompoundingValsNum(),
 		TotalBalance:                  float32(s.CurrentState.TotalActiveRealBalance) / float32(local_spec.EffectiveBalanceInc),
-		AttEffectiveBalance:           s.NextState.AttestingBalance[local_spec.AttTargetFlagIndex] / local_spec.EffectiveBalanceInc,
-		SourceAttEffectiveBalance:     s.NextState.AttestingBalance[local_spec.AttSourceFlagIndex] / local_spec.EffectiveBalanceInc,
-		TargetAttEffectiveBalance:     s.NextState.AttestingBalance[local_spec.AttTargetFlagIndex] / local_spec.EffectiveBalanceInc,
-		HeadAttEffectiveBalance:       s.NextState.AttestingBalance[local_spec.AttHeadFlagIndex] / local_spec.EffectiveBalanceInc,
-		TotalEffectiveBalance:         s.CurrentState.TotalActiveBalance / local_spec.EffectiveBalanceInc,
+		AttEffectiveBalance:           s.NextState.AttestingBalance[local_spec.AttTargetFlagIndex] / phase0.Gwei(local_spec.EffectiveBalanceInc),
+		SourceAttEffectiveBalance:     s.NextState.AttestingBalance[local_spec.AttSourceFlagIndex] / phase0.Gwei(local_spec.EffectiveBalanceInc),
+		TargetAttEffectiveBalance:     s.NextState.AttestingBalance[local_spec.AttTargetFlagIndex] / phase0.Gwei(local_spec.EffectiveBalanceInc),
+		HeadAttEffectiveBalance:       s.NextState.AttestingBalance[local_spec.AttHeadFlagIndex] / phase0.Gwei(local_spec.EffectiveBalanceInc),
+		TotalEffectiveBalance:         s.CurrentState.TotalActiveBalance / phase0.Gwei(local_spec.EffectiveBalanceInc),
 		MissingSource:                 int(s.NextState.GetMissingFlagCount(int(altair.TimelySourceFlagIndex))),
 		MissingTarget:                 int(s.NextState.GetMissingFlagCount(int(altair.TimelyTargetFlagIndex))),
 		MissingHead:                   int(s.NextState.GetMissingFlagCount(int(altair.TimelyHeadFlagIndex))),
@@ -135,5 +135,6 @@ func (s StateMetricsBase) ExportToEpoch() local_spec.Epoch {
 		WithdrawalRequestsNum:         int(len(s.CurrentState.WithdrawalRequests)),
 		ConsolidationsProcessedNum:    uint64(len(s.CurrentState.ConsolidationsProcessed)),
 		ConsolidationsProcessedAmount: s.CurrentState.ConsolidationsProcessedAmount,
+		FinalizedEpoch:                s.CurrentState.CurrentFinalizedCheckpoint.Epoch,
 	}
 }
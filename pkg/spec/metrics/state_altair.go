@@ -3,6 +3,7 @@ package metrics
 import (
 	"math"
 
+	eth2spec "github.com/attestantio/go-eth2-client/spec"
 	"github.com/attestantio/go-eth2-client/spec/phase0"
 
 	"github.com/migalabs/goteth/pkg/spec"
@@ -69,7 +70,7 @@ func (p *AltairMetrics) ProcessInclusionDelays() {
 		for _, attestation := range block.Attestations {
 			attSlot := attestation.Data.Slot
 			// Calculate inclusion delays only for attestations corresponding to slots from the previous epoch
-			attSlotNotInPrevEpoch := attSlot < phase0.Slot(prevState.Epoch)*spec.SlotsPerEpoch || attSlot >= phase0.Slot(currentState.Epoch)*spec.SlotsPerEpoch
+			attSlotNotInPrevEpoch := attSlot < spec.ComputeStartSlotAtEpoch(prevState.Epoch) || attSlot >= spec.ComputeStartSlotAtEpoch(currentState.Epoch)
 			if attSlotNotInPrevEpoch {
 				continue
 			}
@@ -117,7 +118,7 @@ func (p AltairMetrics) ProcessAttestations() {
 
 	for _, block := range blockList {
 
-		for _, attestation := range block.Attestations {
+		for attIndex, attestation := range block.Attestations {
 
 			attReward := phase0.Gwei(0)
 			slot := attestation.Data.Slot
@@ -126,7 +127,7 @@ func (p AltairMetrics) ProcessAttestations() {
 				epochParticipation = currentEpochParticipation
 			}
 
-			if slot < phase0.Slot(currentState.Epoch)*spec.SlotsPerEpoch {
+			if slot < spec.ComputeStartSlotAtEpoch(currentState.Epoch) {
 				continue
 			}
 
@@ -149,6 +150,7 @@ func (p AltairMetrics) ProcessAttestations() {
 
 				if slotInEpoch(slot, currentState.Epoch) {
 					currentState.ValidatorAttestationIncluded[valIdx] = true
+					currentState.AddAttestationInclusion(valIdx, block, attIndex)
 				}
 
 				// we are only counting rewards at NextState
@@ -176,12 +178,13 @@ func (p AltairMetrics) ProcessAttestations() {
 			}
 
 			// only process rewards for blocks in NextState
-			if block.Slot >= phase0.Slot(nextState.Epoch)*spec.SlotsPerEpoch {
+			if block.Slot >= spec.ComputeStartSlotAtEpoch(nextState.Epoch) {
 				denominator := phase0.Gwei((spec.WeightDenominator - spec.ProposerWeight) * spec.WeightDenominator / spec.ProposerWeight)
 				attReward = attReward / denominator
 
 				p.baseMetrics.MaxBlockRewards[block.ProposerIndex] += attReward
 				block.ManualReward += attReward
+				block.ManualRewardComponents.Attestations += attReward
 			}
 
 		}
@@ -193,9 +196,9 @@ func (p AltairMetrics) ProcessAttestations() {
 func (p *AltairMetrics) ProcessSyncAggregates() {
 	nextState := p.baseMetrics.NextState
 
-	totalActiveInc := nextState.TotalActiveBalance / spec.EffectiveBalanceInc
+	totalActiveInc := nextState.TotalActiveBalance / phase0.Gwei(spec.EffectiveBalanceInc)
 	totalBaseRewards := p.GetBaseRewardPerInc(nextState.TotalActiveBalance) * totalActiveInc
-	maxParticipantRewards := totalBaseRewards * phase0.Gwei(spec.SyncRewardWeight) / phase0.Gwei(spec.WeightDenominator) / spec.SlotsPerEpoch
+	maxParticipantRewards := totalBaseRewards * phase0.Gwei(spec.SyncRewardWeight) / phase0.Gwei(spec.WeightDenominator) / phase0.Gwei(spec.SlotsPerEpoch)
 	participantReward := maxParticipantRewards / phase0.Gwei(spec.SyncCommitteeSize) // this is the participantReward for a single slot
 	proposerReward := phase0.Gwei(participantReward * spec.ProposerWeight / (spec.WeightDenominator - spec.ProposerWeight))
 
@@ -209,17 +212,34 @@ func (p *AltairMetrics) ProcessSyncAggregates() {
 		committeeIndices[i] = allPubkeys[pubkey]
 	}
 
+	nextState.SyncCommitteeDuties = make([]spec.SyncCommitteeDuty, 0, len(nextState.Blocks)*len(committeeIndices))
 	for _, block := range nextState.Blocks {
 		for participantIndex := uint64(0); participantIndex < block.SyncAggregate.SyncCommitteeBits.Len(); participantIndex++ {
 			participationBit := block.SyncAggregate.SyncCommitteeBits.BitAt(uint64(participantIndex))
 			if participationBit {
 				block.ManualReward += proposerReward
+				block.ManualRewardComponents.SyncAggregate += proposerReward
 				valIdx := committeeIndices[participantIndex]
 				p.SyncCommitteeParticipation[valIdx] += 1
 			}
+			// members are only rewarded or penalized when the sync aggregate is processed in a block
+			if block.Proposed && participantIndex < uint64(len(committeeIndices)) {
+				reward := int64(participantReward)
+				if !participationBit {
+					reward = -reward
+				}
+				nextState.SyncCommitteeDuties = append(nextState.SyncCommitteeDuties, spec.SyncCommitteeDuty{
+					Slot:              block.Slot,
+					Epoch:             nextState.Epoch,
+					ValIdx:            committeeIndices[participantIndex],
+					CommitteePosition: participantIndex,
+					Participated:      participationBit,
+					Reward:            reward,
+				})
+			}
 		}
 	}
-	maxSyncCommitteeReward := participantReward * phase0.Gwei(spec.SlotsPerEpoch-len(nextState.MissedBlocks))
+	maxSyncCommitteeReward := participantReward * phase0.Gwei(int(spec.SlotsPerEpoch)-len(nextState.MissedBlocks))
 	for _, committeeIndex := range committeeIndices {
 		p.MaxSyncCommitteeRewards[committeeIndex] += maxSyncCommitteeReward
 	}
@@ -245,10 +265,10 @@ func (p AltairMetrics) GetMaxFlagIndexDeltas() {
 					continue
 				}
 				// apply formula
-				attestingBalanceInc := currentState.AttestingBalance[i] / spec.EffectiveBalanceInc
+				attestingBalanceInc := currentState.AttestingBalance[i] / phase0.Gwei(spec.EffectiveBalanceInc)
 
 				flagReward := phase0.Gwei(spec.ParticipatingFlagsWeight[i]) * baseReward * attestingBalanceInc
-				flagReward = flagReward / ((phase0.Gwei(currentState.TotalActiveBalance / spec.EffectiveBalanceInc)) * phase0.Gwei(spec.WeightDenominator))
+				flagReward = flagReward / ((phase0.Gwei(currentState.TotalActiveBalance / phase0.Gwei(spec.EffectiveBalanceInc))) * phase0.Gwei(spec.WeightDenominator))
 				maxFlagsReward += flagReward
 			}
 		}
@@ -314,6 +334,7 @@ func (p AltairMetrics) GetMaxReward(valIdx phase0.ValidatorIndex) (spec.Validato
 		MissingSource:                       flags[spec.AttSourceFlagIndex],
 		MissingTarget:                       flags[spec.AttTargetFlagIndex],
 		MissingHead:                         flags[spec.AttHeadFlagIndex],
+		InactivityPenalty:                   p.GetInactivityPenalty(valIdx),
 		Status:                              nextState.GetValStatus(valIdx),
 		ProposerApiReward:                   proposerApiReward,
 		ProposerManualReward:                proposerManualReward,
@@ -323,8 +344,37 @@ func (p AltairMetrics) GetMaxReward(valIdx phase0.ValidatorIndex) (spec.Validato
 
 }
 
+// https://github.com/ethereum/consensus-specs/blob/dev/specs/altair/beacon-chain.md#inactivity-penalty-deltas
+// The epoch transition of the current state updates the inactivity scores before applying
+// them, so the scores used are the ones of the next state
+func (p AltairMetrics) GetInactivityPenalty(valIdx phase0.ValidatorIndex) phase0.Gwei {
+	nextState := p.baseMetrics.NextState
+	currentState := p.baseMetrics.CurrentState
+	prevEpoch := p.baseMetrics.PrevState.Epoch
+	if int(valIdx) >= len(currentState.Validators) || int(valIdx) >= len(nextState.InactivityScores) {
+		return 0
+	}
+
+	validator := currentState.Validators[valIdx]
+	eligible := spec.IsActive(*validator, prevEpoch) || (validator.Slashed && prevEpoch+1 < validator.WithdrawableEpoch)
+	if !eligible {
+		return 0
+	}
+	// only the validators that did not attest the target are penalized
+	if !validator.Slashed && !currentState.MissingFlags(valIdx)[spec.AttTargetFlagIndex] {
+		return 0
+	}
+
+	quotient := uint64(spec.InactivityPenaltyQuotientBellatrix)
+	if currentState.Version == eth2spec.DataVersionAltair {
+		quotient = spec.InactivityPenaltyQuotientAltair
+	}
+	penaltyNumerator := uint64(validator.EffectiveBalance) * nextState.InactivityScores[valIdx]
+	return phase0.Gwei(penaltyNumerator / (spec.InactivityScoreBias * quotient))
+}
+
 func (p AltairMetrics) GetBaseReward(valIdx phase0.ValidatorIndex, effectiveBalance phase0.Gwei, totalEffectiveBalance phase0.Gwei) phase0.Gwei {
-	effectiveBalanceInc := effectiveBalance / spec.EffectiveBalanceInc
+	effectiveBalanceInc := effectiveBalance / phase0.Gwei(spec.EffectiveBalanceInc)
 	return p.GetBaseRewardPerInc(totalEffectiveBalance) * effectiveBalanceInc
 }
 
@@ -363,13 +413,13 @@ func (p AltairMetrics) getParticipationFlags(attestation phase0.Attestation, inc
 	matchingTarget := matchingSource && targetRoot == attestation.Data.Target.Root
 	matchingHead := matchingTarget && attestation.Data.BeaconBlockRoot == headRoot
 
-	if matchingSource && (inclusionDelay <= int(math.Sqrt(spec.SlotsPerEpoch))) {
+	if matchingSource && (inclusionDelay <= int(math.Sqrt(float64(spec.SlotsPerEpoch)))) {
 		result[spec.AttSourceFlagIndex] = true
 	}
-	if matchingTarget && (inclusionDelay <= spec.SlotsPerEpoch) {
+	if matchingTarget && (inclusionDelay <= int(spec.SlotsPerEpoch)) {
 		result[spec.AttTargetFlagIndex] = true
 	}
-	if matchingHead && (inclusionDelay <= spec.MinInclusionDelay) {
+	if matchingHead && (inclusionDelay <= int(spec.MinInclusionDelay)) {
 		result[spec.AttHeadFlagIndex] = true
 	}
 
@@ -384,20 +434,20 @@ func (p AltairMetrics) isFlagPossible(valIdx phase0.ValidatorIndex, flagIndex in
 
 	switch flagIndex { // for every flag there is a max inclusion delay to obtain a reward
 	case spec.AttSourceFlagIndex: // 5
-		maxInclusionDelay = int(math.Sqrt(spec.SlotsPerEpoch))
+		maxInclusionDelay = int(math.Sqrt(float64(spec.SlotsPerEpoch)))
 	case spec.AttTargetFlagIndex: // 32
-		maxInclusionDelay = spec.SlotsPerEpoch
+		maxInclusionDelay = int(spec.SlotsPerEpoch)
 	case spec.AttHeadFlagIndex: // 1
-		maxInclusionDelay = spec.MinInclusionDelay
+		maxInclusionDelay = int(spec.MinInclusionDelay)
 	default:
 		log.Fatalf("provided flag index %d is not known", flagIndex)
 	}
 
 	// look for any block proposed => the attester could have achieved it
 	for slot := attSlot + 1; slot <= (attSlot + phase0.Slot(maxInclusionDelay)); slot++ {
-		slotInEpoch := slot % spec.SlotsPerEpoch
+		slotInEpoch := slot % phase0.Slot(spec.SlotsPerEpoch)
 		block := prevState.Blocks[slotInEpoch]
-		if slot >= phase0.Slot(currentState.Epoch*spec.SlotsPerEpoch) {
+		if slot >= spec.ComputeStartSlotAtEpoch(currentState.Epoch) {
 			block = currentState.Blocks[slotInEpoch]
 		}
 
@@ -410,5 +460,5 @@ func (p AltairMetrics) isFlagPossible(valIdx phase0.ValidatorIndex, flagIndex in
 }
 
 func (p AltairMetrics) maxInclusionDelay(_ phase0.ValidatorIndex) int {
-	return spec.SlotsPerEpoch
+	return int(spec.SlotsPerEpoch)
 }
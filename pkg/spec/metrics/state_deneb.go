@@ -68,7 +68,7 @@ func (p DenebMetrics) ProcessAttestations() {
 
 	for _, block := range blockList {
 
-		for _, attestation := range block.Attestations {
+		for attIndex, attestation := range block.Attestations {
 
 			attReward := phase0.Gwei(0)
 			slot := attestation.Data.Slot
@@ -77,7 +77,7 @@ func (p DenebMetrics) ProcessAttestations() {
 				epochParticipation = currentEpochParticipation
 			}
 
-			if slot < phase0.Slot(p.baseMetrics.CurrentState.Epoch)*spec.SlotsPerEpoch {
+			if slot < spec.ComputeStartSlotAtEpoch(p.baseMetrics.CurrentState.Epoch) {
 				continue
 			}
 
@@ -100,6 +100,7 @@ func (p DenebMetrics) ProcessAttestations() {
 
 				if slotInEpoch(slot, p.baseMetrics.CurrentState.Epoch) {
 					p.baseMetrics.CurrentState.ValidatorAttestationIncluded[valIdx] = true
+					p.baseMetrics.CurrentState.AddAttestationInclusion(valIdx, block, attIndex)
 				}
 
 				// we are only counting rewards at NextState
@@ -127,12 +128,13 @@ func (p DenebMetrics) ProcessAttestations() {
 			}
 
 			// only process rewards for blocks in NextState
-			if block.Slot >= phase0.Slot(p.baseMetrics.NextState.Epoch)*spec.SlotsPerEpoch {
+			if block.Slot >= spec.ComputeStartSlotAtEpoch(p.baseMetrics.NextState.Epoch) {
 				denominator := phase0.Gwei((spec.WeightDenominator - spec.ProposerWeight) * spec.WeightDenominator / spec.ProposerWeight)
 				attReward = attReward / denominator
 
 				p.baseMetrics.MaxBlockRewards[block.ProposerIndex] += attReward
 				block.ManualReward += attReward
+				block.ManualRewardComponents.Attestations += attReward
 			}
 
 		}
@@ -146,7 +148,7 @@ func (p *DenebMetrics) ProcessInclusionDelays() {
 		for _, attestation := range block.Attestations {
 			attSlot := attestation.Data.Slot
 			// Calculate inclusion delays only for attestations corresponding to slots from the previous epoch
-			attSlotNotInPrevEpoch := attSlot < phase0.Slot(p.baseMetrics.PrevState.Epoch)*spec.SlotsPerEpoch || attSlot >= phase0.Slot(p.baseMetrics.CurrentState.Epoch)*spec.SlotsPerEpoch
+			attSlotNotInPrevEpoch := attSlot < spec.ComputeStartSlotAtEpoch(p.baseMetrics.PrevState.Epoch) || attSlot >= spec.ComputeStartSlotAtEpoch(p.baseMetrics.CurrentState.Epoch)
 			if attSlotNotInPrevEpoch {
 				continue
 			}
@@ -192,10 +194,10 @@ func (p DenebMetrics) GetMaxFlagIndexDeltas() {
 					continue
 				}
 				// apply formula
-				attestingBalanceInc := p.baseMetrics.CurrentState.AttestingBalance[i] / spec.EffectiveBalanceInc
+				attestingBalanceInc := p.baseMetrics.CurrentState.AttestingBalance[i] / phase0.Gwei(spec.EffectiveBalanceInc)
 
 				flagReward := phase0.Gwei(spec.ParticipatingFlagsWeight[i]) * baseReward * attestingBalanceInc
-				flagReward = flagReward / ((phase0.Gwei(p.baseMetrics.CurrentState.TotalActiveBalance / spec.EffectiveBalanceInc)) * phase0.Gwei(spec.WeightDenominator))
+				flagReward = flagReward / ((phase0.Gwei(p.baseMetrics.CurrentState.TotalActiveBalance / phase0.Gwei(spec.EffectiveBalanceInc))) * phase0.Gwei(spec.WeightDenominator))
 				maxFlagsReward += flagReward
 			}
 		}
@@ -225,17 +227,17 @@ func (p DenebMetrics) getParticipationFlags(attestation phase0.Attestation, incl
 	// the worst case scenario is an attestation to the slot 31, which gives a max inclusion delay of 32
 	// the best case scenario is an attestation to the slot 0, which gives a max inclusion delay of 64
 	// https://github.com/ethereum/consensus-specs/blob/dev/specs/deneb/beacon-chain.md#modified-get_attestation_participation_flag_indices
-	includedInEpoch := phase0.Epoch(includedInBlock.Slot / spec.SlotsPerEpoch)
-	attestationEpoch := phase0.Epoch(attestation.Data.Slot / spec.SlotsPerEpoch)
+	includedInEpoch := spec.EpochAtSlot(includedInBlock.Slot)
+	attestationEpoch := spec.EpochAtSlot(attestation.Data.Slot)
 	targetInclusionOk := includedInEpoch-attestationEpoch <= 1
 
-	if matchingSource && (inclusionDelay <= int(math.Sqrt(spec.SlotsPerEpoch))) {
+	if matchingSource && (inclusionDelay <= int(math.Sqrt(float64(spec.SlotsPerEpoch)))) {
 		result[0] = true
 	}
 	if matchingTarget && targetInclusionOk {
 		result[1] = true
 	}
-	if matchingHead && (inclusionDelay <= spec.MinInclusionDelay) {
+	if matchingHead && (inclusionDelay <= int(spec.MinInclusionDelay)) {
 		result[2] = true
 	}
 
@@ -249,11 +251,11 @@ func (p DenebMetrics) isFlagPossible(valIdx phase0.ValidatorIndex, flagIndex int
 	switch flagIndex { // for every flag there is a max inclusion delay to obtain a reward
 
 	case spec.AttSourceFlagIndex: // 5
-		maxInclusionDelay = int(math.Sqrt(spec.SlotsPerEpoch))
+		maxInclusionDelay = int(math.Sqrt(float64(spec.SlotsPerEpoch)))
 
 	case spec.AttTargetFlagIndex: // until end of next epoch
-		remainingSlotsInEpoch := spec.SlotsPerEpoch - int(attSlot%spec.SlotsPerEpoch)
-		maxInclusionDelay = spec.SlotsPerEpoch + remainingSlotsInEpoch
+		remainingSlotsInEpoch := int(spec.SlotsPerEpoch) - int(attSlot%phase0.Slot(spec.SlotsPerEpoch))
+		maxInclusionDelay = int(spec.SlotsPerEpoch) + remainingSlotsInEpoch
 
 	case spec.AttHeadFlagIndex: // 1
 		maxInclusionDelay = 1
@@ -263,9 +265,9 @@ func (p DenebMetrics) isFlagPossible(valIdx phase0.ValidatorIndex, flagIndex int
 
 	// look for any block proposed => the attester could have achieved it
 	for slot := attSlot + 1; slot <= (attSlot + phase0.Slot(maxInclusionDelay)); slot++ {
-		slotInEpoch := slot % spec.SlotsPerEpoch
+		slotInEpoch := slot % phase0.Slot(spec.SlotsPerEpoch)
 		block := p.baseMetrics.PrevState.Blocks[slotInEpoch]
-		if slot >= phase0.Slot(p.baseMetrics.CurrentState.Epoch*spec.SlotsPerEpoch) {
+		if slot >= spec.ComputeStartSlotAtEpoch(p.baseMetrics.CurrentState.Epoch) {
 			block = p.baseMetrics.CurrentState.Blocks[slotInEpoch]
 		}
 
@@ -283,7 +285,7 @@ func (p DenebMetrics) maxInclusionDelay(valIdx phase0.ValidatorIndex) int {
 
 	slot := p.baseMetrics.PrevState.EpochStructs.ValidatorAttSlot[valIdx]
 
-	slotsUntilEpochEnd := spec.SlotsPerEpoch - (slot % spec.SlotsPerEpoch) - 1
+	slotsUntilEpochEnd := phase0.Slot(spec.SlotsPerEpoch) - (slot % phase0.Slot(spec.SlotsPerEpoch)) - 1
 
-	return spec.SlotsPerEpoch + int(slotsUntilEpochEnd)
+	return int(spec.SlotsPerEpoch) + int(slotsUntilEpochEnd)
 }
@@ -448,7 +448,7 @@ func (p ElectraMetrics) ProcessAttestations() {
 
 	for _, block := range blockList {
 
-		for _, attestation := range block.ElectraAttestations {
+		for attIndex, attestation := range block.ElectraAttestations {
 
 			attReward := phase0.Gwei(0)
 			slot := attestation.Data.Slot
@@ -457,7 +457,7 @@ func (p ElectraMetrics) ProcessAttestations() {
 				epochParticipation = currentEpochParticipation
 			}
 
-			if slot < phase0.Slot(p.baseMetrics.CurrentState.Epoch)*spec.SlotsPerEpoch {
+			if slot < spec.ComputeStartSlotAtEpoch(p.baseMetrics.CurrentState.Epoch) {
 				continue
 			}
 
@@ -475,6 +475,7 @@ func (p ElectraMetrics) ProcessAttestations() {
 
 				if slotInEpoch(slot, p.baseMetrics.CurrentState.Epoch) {
 					p.baseMetrics.CurrentState.ValidatorAttestationIncluded[valIdx] = true
+					p.baseMetrics.CurrentState.AddAttestationInclusion(valIdx, block, attIndex)
 				}
 
 				// we are only counting rewards at NextState
@@ -501,12 +502,13 @@ func (p ElectraMetrics) ProcessAttestations() {
 			}
 
 			// only process rewards for blocks in NextState
-			if block.Slot >= phase0.Slot(p.baseMetrics.NextState.Epoch)*spec.SlotsPerEpoch {
+			if block.Slot >= spec.ComputeStartSlotAtEpoch(p.baseMetrics.NextState.Epoch) {
 				denominator := phase0.Gwei((spec.WeightDenominator - spec.ProposerWeight) * spec.WeightDenominator / spec.ProposerWeight)
 				attReward = attReward / denominator
 
 				p.baseMetrics.MaxBlockRewards[block.ProposerIndex] += attReward
 				block.ManualReward += attReward
+				block.ManualRewardComponents.Attestations += attReward
 			}
 
 		}
@@ -520,7 +522,7 @@ func (p *ElectraMetrics) ProcessInclusionDelays() {
 		for _, attestation := range block.ElectraAttestations {
 			attSlot := attestation.Data.Slot
 			// Calculate inclusion delays only for attestations corresponding to slots from the previous epoch
-			attSlotNotInPrevEpoch := attSlot < phase0.Slot(p.baseMetrics.PrevState.Epoch)*spec.SlotsPerEpoch || attSlot >= phase0.Slot(p.baseMetrics.CurrentState.Epoch)*spec.SlotsPerEpoch
+			attSlotNotInPrevEpoch := attSlot < spec.ComputeStartSlotAtEpoch(p.baseMetrics.PrevState.Epoch) || attSlot >= spec.ComputeStartSlotAtEpoch(p.baseMetrics.CurrentState.Epoch)
 			if attSlotNotInPrevEpoch {
 				continue
 			}
@@ -573,17 +575,17 @@ func (p ElectraMetrics) getParticipationFlags(attestation electra.Attestation, i
 	// the worst case scenario is an attestation to the slot 31, which gives a max inclusion delay of 32
 	// the best case scenario is an attestation to the slot 0, which gives a max inclusion delay of 64
 	// https://github.com/ethereum/consensus-specs/blob/dev/specs/deneb/beacon-chain.md#modified-get_attestation_participation_flag_indices
-	includedInEpoch := phase0.Epoch(includedInBlock.Slot / spec.SlotsPerEpoch)
-	attestationEpoch := phase0.Epoch(attestation.Data.Slot / spec.SlotsPerEpoch)
+	includedInEpoch := spec.EpochAtSlot(includedInBlock.Slot)
+	attestationEpoch := spec.EpochAtSlot(attestation.Data.Slot)
 	targetInclusionOk := includedInEpoch-attestationEpoch <= 1
 
-	if matchingSource && (inclusionDelay <= int(math.Sqrt(spec.SlotsPerEpoch))) {
+	if matchingSource && (inclusionDelay <= int(math.Sqrt(float64(spec.SlotsPerEpoch)))) {
 		result[0] = true
 	}
 	if matchingTarget && targetInclusionOk {
 		result[1] = true
 	}
-	if matchingHead && (inclusionDelay <= spec.MinInclusionDelay) {
+	if matchingHead && (inclusionDelay <= int(spec.MinInclusionDelay)) {
 		result[2] = true
 	}
 
@@ -797,10 +799,11 @@ func (p *ElectraMetrics) ProcessSlashings() {
 					valid = true
 					state.NewAttesterSlashings += 1
 					slashedEffBalance := state.Validators[idx].EffectiveBalance
-					wbr := slashedEffBalance / spec.WhistleBlowerRewardQuotient
+					wbr := slashedEffBalance / phase0.Gwei(spec.WhistleBlowerRewardQuotient)
 					pr := wbr * spec.ProposerWeight / spec.WeightDenominator
 					blockWhistleBlowerReward += wbr
 					blockProposerReward += pr
+					block.ManualRewardComponents.AttesterSlashings += wbr
 				}
 				state.Slashings = append(state.Slashings,
 					spec.AgnosticSlashing{
@@ -821,10 +824,11 @@ func (p *ElectraMetrics) ProcessSlashings() {
 				valid = true
 				state.NewProposerSlashings += 1
 				slashedEffBalance := state.Validators[slashedValidatorIdx].EffectiveBalance
-				wbr := slashedEffBalance / spec.WhistleBlowerRewardQuotient
+				wbr := slashedEffBalance / phase0.Gwei(spec.WhistleBlowerRewardQuotient)
 				pr := wbr * spec.ProposerWeight / spec.WeightDenominator
 				blockWhistleBlowerReward += wbr
 				blockProposerReward += pr
+				block.ManualRewardComponents.ProposerSlashings += wbr
 			}
 			slashing := spec.AgnosticSlashing{
 				SlashedValidator: slashedValidatorIdx,
@@ -64,10 +64,11 @@ func (p *Phase0Metrics) ProcessSlashings() {
 					valid = true
 					state.NewAttesterSlashings += 1
 					slashedEffBalance := state.Validators[idx].EffectiveBalance
-					wbr := slashedEffBalance / spec.WhistleBlowerRewardQuotient
+					wbr := slashedEffBalance / phase0.Gwei(spec.WhistleBlowerRewardQuotient)
 					pr := wbr * spec.ProposerWeight / spec.WeightDenominator
 					blockWhistleBlowerReward += wbr
 					blockProposerReward += pr
+					block.ManualRewardComponents.AttesterSlashings += wbr
 				}
 				state.Slashings = append(state.Slashings,
 					spec.AgnosticSlashing{
@@ -88,10 +89,11 @@ func (p *Phase0Metrics) ProcessSlashings() {
 				valid = true
 				state.NewProposerSlashings += 1
 				slashedEffBalance := state.Validators[slashedValidatorIdx].EffectiveBalance
-				wbr := slashedEffBalance / spec.WhistleBlowerRewardQuotient
+				wbr := slashedEffBalance / phase0.Gwei(spec.WhistleBlowerRewardQuotient)
 				pr := wbr * spec.ProposerWeight / spec.WeightDenominator
 				blockWhistleBlowerReward += wbr
 				blockProposerReward += pr
+				block.ManualRewardComponents.ProposerSlashings += wbr
 			}
 			slashing := spec.AgnosticSlashing{
 				SlashedValidator: slashedValidatorIdx,
@@ -155,6 +157,7 @@ func (p *Phase0Metrics) GetInclusionDelayDeltas() {
 					proposerReward := p.GetProposerReward(attestingValIdx)
 					p.baseMetrics.MaxBlockRewards[proposerIndex] += proposerReward
 					inclusionBlock.ManualReward += proposerReward
+					inclusionBlock.ManualRewardComponents.Attestations += proposerReward
 
 					// add attester rewards
 					maxAttesterReward := p.GetBaseReward(attestingValIdx) - proposerReward
@@ -177,7 +180,7 @@ func (p *Phase0Metrics) GetInclusionDelayDeltas() {
 
 	for valIdx, inclusionDelay := range p.baseMetrics.InclusionDelays {
 		if inclusionDelay == 0 {
-			p.baseMetrics.InclusionDelays[valIdx] = spec.SlotsPerEpoch + 1
+			p.baseMetrics.InclusionDelays[valIdx] = int(spec.SlotsPerEpoch) + 1
 		}
 	}
 }
@@ -200,10 +203,10 @@ func (p *Phase0Metrics) GetMaxAttComponentDeltas() {
 			previousAttestedBalance := p.baseMetrics.CurrentState.AttestingBalance[i]
 
 			// participationRate per flag ==> previousAttestBalance / TotalActiveBalance
-			singleReward := baseReward * (previousAttestedBalance / spec.EffectiveBalanceInc)
+			singleReward := baseReward * (previousAttestedBalance / phase0.Gwei(spec.EffectiveBalanceInc))
 
 			// for each flag, we add baseReward * participationRate
-			maxReward += singleReward / (p.baseMetrics.CurrentState.TotalActiveBalance / spec.EffectiveBalanceInc)
+			maxReward += singleReward / (p.baseMetrics.CurrentState.TotalActiveBalance / phase0.Gwei(spec.EffectiveBalanceInc))
 		}
 		p.baseMetrics.MaxAttesterRewards[phase0.ValidatorIndex(valIdx)] += maxReward
 
@@ -249,7 +252,7 @@ func (p Phase0Metrics) GetMaxReward(valIdx phase0.ValidatorIndex) (spec.Validato
 
 // https://github.com/ethereum/consensus-specs/blob/dev/specs/phase0/beacon-chain.md#helper-functions-1
 func (p Phase0Metrics) IsCorrectSource() bool {
-	epoch := phase0.Epoch(p.baseMetrics.NextState.Slot / spec.SlotsPerEpoch)
+	epoch := spec.EpochAtSlot(p.baseMetrics.NextState.Slot)
 	if epoch == p.baseMetrics.NextState.Epoch || epoch == p.baseMetrics.CurrentState.Epoch {
 		return true
 	}
@@ -260,9 +263,8 @@ func (p Phase0Metrics) IsCorrectSource() bool {
 func (p Phase0Metrics) IsCorrectTarget(attestation phase0.PendingAttestation) bool {
 	target := attestation.Data.Target.Root
 
-	slot := p.baseMetrics.CurrentState.Slot / spec.SlotsPerEpoch
-	slot = slot * spec.SlotsPerEpoch
-	expected := p.baseMetrics.CurrentState.BlockRoots[slot%spec.SlotsPerHistoricalRoot]
+	slot := spec.ComputeStartSlotAtEpoch(spec.EpochAtSlot(p.baseMetrics.CurrentState.Slot))
+	expected := p.baseMetrics.CurrentState.BlockRoots[slot%phase0.Slot(spec.SlotsPerHistoricalRoot)]
 
 	res := bytes.Compare(target[:], expected[:])
 
@@ -273,7 +275,7 @@ func (p Phase0Metrics) IsCorrectTarget(attestation phase0.PendingAttestation) bo
 func (p Phase0Metrics) IsCorrectHead(attestation phase0.PendingAttestation) bool {
 	head := attestation.Data.BeaconBlockRoot
 
-	index := attestation.Data.Slot % spec.SlotsPerHistoricalRoot
+	index := attestation.Data.Slot % phase0.Slot(spec.SlotsPerHistoricalRoot)
 	expected := p.baseMetrics.NextState.BlockRoots[index]
 
 	res := bytes.Compare(head[:], expected[:])
@@ -289,7 +291,7 @@ func (p Phase0Metrics) GetBaseReward(valIdx phase0.ValidatorIndex) phase0.Gwei {
 
 	sqrt := math.Sqrt(float64(p.baseMetrics.CurrentState.TotalActiveBalance))
 	denom := spec.BaseRewardPerEpoch * sqrt
-	num := (valEffectiveBalance * spec.BaseRewardFactor)
+	num := (valEffectiveBalance * phase0.Gwei(spec.BaseRewardFactor))
 
 	baseReward = phase0.Gwei(num) / phase0.Gwei(denom)
 
@@ -337,5 +339,5 @@ func orderAttestationsBySlot(attestations []*phase0.PendingAttestation) []*phase
 }
 
 func (p Phase0Metrics) GetProposerReward(attesterValIdx phase0.ValidatorIndex) phase0.Gwei {
-	return phase0.Gwei(p.GetBaseReward(attesterValIdx) / spec.ProposerRewardQuotient)
+	return phase0.Gwei(p.GetBaseReward(attesterValIdx) / phase0.Gwei(spec.ProposerRewardQuotient))
 }
@@ -9,20 +9,20 @@ import (
 )
 
 func (s StateMetricsBase) GetStateAtSlot(slot phase0.Slot) (*spec.AgnosticState, error) {
-	if slot >= phase0.Slot(s.PrevState.Epoch)*spec.SlotsPerEpoch &&
-		slot < phase0.Slot(s.CurrentState.Epoch)*spec.SlotsPerEpoch {
+	if slot >= spec.ComputeStartSlotAtEpoch(s.PrevState.Epoch) &&
+		slot < spec.ComputeStartSlotAtEpoch(s.CurrentState.Epoch) {
 		// slot in PrevEpoch
 		return s.PrevState, nil
 	}
 
-	if slot >= phase0.Slot(s.CurrentState.Epoch)*spec.SlotsPerEpoch &&
-		slot < phase0.Slot(s.NextState.Epoch)*spec.SlotsPerEpoch {
+	if slot >= spec.ComputeStartSlotAtEpoch(s.CurrentState.Epoch) &&
+		slot < spec.ComputeStartSlotAtEpoch(s.NextState.Epoch) {
 		// slot in CurrentEpoch
 		return s.CurrentState, nil
 	}
 
-	if slot >= phase0.Slot(s.NextState.Epoch)*spec.SlotsPerEpoch &&
-		slot < phase0.Slot(s.NextState.Epoch+1)*spec.SlotsPerEpoch {
+	if slot >= spec.ComputeStartSlotAtEpoch(s.NextState.Epoch) &&
+		slot < spec.ComputeStartSlotAtEpoch(s.NextState.Epoch+1) {
 		// slot in NextEpoch
 		return s.NextState, nil
 	}
@@ -49,22 +49,22 @@ func (p AltairMetrics) GetJustifiedRootfromSlot(slot phase0.Slot) (phase0.Root,
 }
 
 func (s StateMetricsBase) GetBlockFromSlot(slot phase0.Slot) (*spec.AgnosticBlock, error) {
-	if slot >= phase0.Slot(s.PrevState.Epoch)*spec.SlotsPerEpoch &&
-		slot < phase0.Slot(s.CurrentState.Epoch)*spec.SlotsPerEpoch {
+	if slot >= spec.ComputeStartSlotAtEpoch(s.PrevState.Epoch) &&
+		slot < spec.ComputeStartSlotAtEpoch(s.CurrentState.Epoch) {
 		// slot in PrevEpoch
-		return s.PrevState.Blocks[slot%spec.SlotsPerEpoch], nil
+		return s.PrevState.Blocks[slot%phase0.Slot(spec.SlotsPerEpoch)], nil
 	}
 
-	if slot >= phase0.Slot(s.CurrentState.Epoch)*spec.SlotsPerEpoch &&
-		slot < phase0.Slot(s.NextState.Epoch)*spec.SlotsPerEpoch {
+	if slot >= spec.ComputeStartSlotAtEpoch(s.CurrentState.Epoch) &&
+		slot < spec.ComputeStartSlotAtEpoch(s.NextState.Epoch) {
 		// slot in CurrentEpochEpoch
-		return s.CurrentState.Blocks[slot%spec.SlotsPerEpoch], nil
+		return s.CurrentState.Blocks[slot%phase0.Slot(spec.SlotsPerEpoch)], nil
 	}
 
-	if slot >= phase0.Slot(s.NextState.Epoch)*spec.SlotsPerEpoch &&
-		slot < phase0.Slot(s.NextState.Epoch+1)*spec.SlotsPerEpoch {
+	if slot >= spec.ComputeStartSlotAtEpoch(s.NextState.Epoch) &&
+		slot < spec.ComputeStartSlotAtEpoch(s.NextState.Epoch+1) {
 		// slot in NextEpoch
-		return s.NextState.Blocks[slot%spec.SlotsPerEpoch], nil
+		return s.NextState.Blocks[slot%phase0.Slot(spec.SlotsPerEpoch)], nil
 	}
 
 	return &spec.AgnosticBlock{}, errors.New("could not get block from any epoch")
@@ -73,7 +73,7 @@ func (s StateMetricsBase) GetBlockFromSlot(slot phase0.Slot) (*spec.AgnosticBloc
 // Returns the closest proposed block backwards from the given slot
 func (s StateMetricsBase) GetBestInclusionDelay(slot phase0.Slot) (int, error) {
 
-	minSlot := phase0.Slot(s.PrevState.Epoch * spec.SlotsPerEpoch)
+	minSlot := spec.ComputeStartSlotAtEpoch(s.PrevState.Epoch)
 
 	for i := slot; i > minSlot; i-- {
 		block, err := s.GetBlockFromSlot(i)
@@ -101,8 +101,8 @@ func countTrue(arr []bool) int {
 }
 
 func slotInEpoch(slot phase0.Slot, epoch phase0.Epoch) bool {
-	if slot >= phase0.Slot(epoch)*spec.SlotsPerEpoch &&
-		slot < phase0.Slot(epoch+1)*spec.SlotsPerEpoch {
+	if slot >= spec.ComputeStartSlotAtEpoch(epoch) &&
+		slot < spec.ComputeStartSlotAtEpoch(epoch+1) {
 		return true
 	}
 	return false
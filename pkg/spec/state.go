@@ -27,6 +27,7 @@ type AgnosticState struct {
 	PrevEpochCorrectFlags        [][]bool                     // one aray per flag
 	PrevAttestations             []*phase0.PendingAttestation // array of attestations (currently only for Phase0)
 	ValidatorAttestationIncluded []bool                       // one per validator, if the validator's attestation was included
+	ValidatorAttestations        []*ValidatorAttestation      // one per validator, only filled when tracked (see TrackValidatorAttestations)
 	NumAttestations              int                          // number of attestations in the epoch
 	NumActiveVals                uint                         // number of active validators in the epoch
 	NumExitedVals                uint                         // number of exited validators in the epoch
@@ -44,10 +45,12 @@ type AgnosticState struct {
 	TotalDepositsAmount          phase0.Gwei                  // total amount of deposits
 	CurrentJustifiedCheckpoint   phase0.Checkpoint            // the latest justified checkpoint
 	CurrentFinalizedCheckpoint   phase0.Checkpoint            // the latest finalized checkpoint
+	InactivityScores             []uint64                     // one per validator, from Altair
 	LatestBlockHeader            *phase0.BeaconBlockHeader
-	SyncCommitteeParticipation   uint64 // Tracks sync committee participation
-	NewProposerSlashings         int    // number of new proposer slashings
-	NewAttesterSlashings         int    // number of new attester slashings
+	SyncCommitteeParticipation   uint64              // Tracks sync committee participation
+	SyncCommitteeDuties          []SyncCommitteeDuty // one per sync committee member and proposed block, filled by the metrics bundle
+	NewProposerSlashings         int                 // number of new proposer slashings
+	NewAttesterSlashings         int                 // number of new attester slashings
 	Slashings                    []AgnosticSlashing
 	// Electra
 	ConsolidationRequests         []ConsolidationRequest
@@ -260,10 +263,10 @@ func IsActive(validator phase0.Validator, epoch phase0.Epoch) bool {
 
 // We use blockroots to track missed blocks. When there is a missed block, the block root is repeated
 func (p *AgnosticState) TrackMissingBlocks() {
-	firstSlotOfEpoch := phase0.Slot(p.Epoch * SlotsPerEpoch)
-	lastSlotOfEpoch := phase0.Slot(p.Epoch*SlotsPerEpoch + SlotsPerEpoch - 1)
-	firstIndex := firstSlotOfEpoch % SlotsPerHistoricalRoot // first slot of the epoch
-	lastIndex := lastSlotOfEpoch % SlotsPerHistoricalRoot   // last slot of the epoch
+	firstSlotOfEpoch := ComputeStartSlotAtEpoch(p.Epoch)
+	lastSlotOfEpoch := ComputeStartSlotAtEpoch(p.Epoch+1) - 1
+	firstIndex := firstSlotOfEpoch % phase0.Slot(SlotsPerHistoricalRoot) // first slot of the epoch
+	lastIndex := lastSlotOfEpoch % phase0.Slot(SlotsPerHistoricalRoot)   // last slot of the epoch
 	p.MissedBlocks = make([]phase0.Slot, 0)
 
 	for i := firstIndex; i < lastIndex; i++ {
@@ -278,7 +281,7 @@ func (p *AgnosticState) TrackMissingBlocks() {
 
 		if res == 0 {
 			// both consecutive roots were the same ==> missed block
-			slot := i - firstIndex + phase0.Slot(p.Epoch*SlotsPerEpoch) // delta + start of the epoch
+			slot := i - firstIndex + ComputeStartSlotAtEpoch(p.Epoch) // delta + start of the epoch
 			p.MissedBlocks = append(p.MissedBlocks, slot)
 		}
 	}
@@ -364,7 +367,7 @@ func (p AgnosticState) GetValStatus(valIdx phase0.ValidatorIndex) ValidatorStatu
 // https://github.com/ethereum/consensus-specs/blob/dev/specs/phase0/beacon-chain.md#get_block_root
 func (p AgnosticState) GetBlockRoot(epoch phase0.Epoch) phase0.Root {
 
-	firstSlotInEpoch := phase0.Slot(epoch * SlotsPerEpoch)
+	firstSlotInEpoch := ComputeStartSlotAtEpoch(epoch)
 
 	return p.GetBlockRootAtSlot(firstSlotInEpoch)
 }
@@ -372,7 +375,7 @@ func (p AgnosticState) GetBlockRoot(epoch phase0.Epoch) phase0.Root {
 // https://github.com/ethereum/consensus-specs/blob/dev/specs/phase0/beacon-chain.md#get_block_root_at_slot
 func (p AgnosticState) GetBlockRootAtSlot(slot phase0.Slot) phase0.Root {
 
-	return p.BlockRoots[slot%SlotsPerHistoricalRoot]
+	return p.BlockRoots[uint64(slot)%SlotsPerHistoricalRoot]
 }
 
 // https://github.com/ethereum/consensus-specs/blob/dev/specs/phase0/beacon-chain.md#get_block_root_at_slot
@@ -396,12 +399,13 @@ func NewPhase0State(bstate spec.VersionedBeaconState, duties EpochDuties) Agnost
 		Balances:                   balances,
 		Validators:                 bstate.Phase0.Validators,
 		EpochStructs:               duties,
-		Epoch:                      phase0.Epoch(bstate.Phase0.Slot / SlotsPerEpoch),
+		Epoch:                      EpochAtSlot(bstate.Phase0.Slot),
 		Slot:                       phase0.Slot(bstate.Phase0.Slot),
 		BlockRoots:                 bstate.Phase0.BlockRoots,
 		PrevAttestations:           bstate.Phase0.PreviousEpochAttestations,
 		GenesisTimestamp:           bstate.Phase0.GenesisTime,
 		CurrentJustifiedCheckpoint: *bstate.Phase0.CurrentJustifiedCheckpoint,
+		CurrentFinalizedCheckpoint: *bstate.Phase0.FinalizedCheckpoint,
 		LatestBlockHeader:          bstate.Phase0.LatestBlockHeader,
 	}
 
@@ -419,12 +423,14 @@ func NewAltairState(bstate spec.VersionedBeaconState, duties EpochDuties) Agnost
 		Balances:                   bstate.Altair.Balances,
 		Validators:                 bstate.Altair.Validators,
 		EpochStructs:               duties,
-		Epoch:                      phase0.Epoch(bstate.Altair.Slot / SlotsPerEpoch),
+		Epoch:                      EpochAtSlot(bstate.Altair.Slot),
 		Slot:                       bstate.Altair.Slot,
 		BlockRoots:                 bstate.Altair.BlockRoots,
 		SyncCommittee:              *bstate.Altair.CurrentSyncCommittee,
 		GenesisTimestamp:           bstate.Altair.GenesisTime,
 		CurrentJustifiedCheckpoint: *bstate.Altair.CurrentJustifiedCheckpoint,
+		CurrentFinalizedCheckpoint: *bstate.Altair.FinalizedCheckpoint,
+		InactivityScores:           bstate.Altair.InactivityScores,
 		LatestBlockHeader:          bstate.Altair.LatestBlockHeader,
 	}
 
@@ -470,12 +476,14 @@ func NewBellatrixState(bstate spec.VersionedBeaconState, duties EpochDuties) Agn
 		Balances:                   bstate.Bellatrix.Balances,
 		Validators:                 bstate.Bellatrix.Validators,
 		EpochStructs:               duties,
-		Epoch:                      phase0.Epoch(bstate.Bellatrix.Slot / SlotsPerEpoch),
+		Epoch:                      EpochAtSlot(bstate.Bellatrix.Slot),
 		Slot:                       bstate.Bellatrix.Slot,
 		BlockRoots:                 bstate.Bellatrix.BlockRoots,
 		SyncCommittee:              *bstate.Bellatrix.CurrentSyncCommittee,
 		GenesisTimestamp:           bstate.Bellatrix.GenesisTime,
 		CurrentJustifiedCheckpoint: *bstate.Bellatrix.CurrentJustifiedCheckpoint,
+		CurrentFinalizedCheckpoint: *bstate.Bellatrix.FinalizedCheckpoint,
+		InactivityScores:           bstate.Bellatrix.InactivityScores,
 		LatestBlockHeader:          bstate.Bellatrix.LatestBlockHeader,
 	}
 
@@ -494,12 +502,14 @@ func NewCapellaState(bstate spec.VersionedBeaconState, duties EpochDuties) Agnos
 		Balances:                   bstate.Capella.Balances,
 		Validators:                 bstate.Capella.Validators,
 		EpochStructs:               duties,
-		Epoch:                      phase0.Epoch(bstate.Capella.Slot / SlotsPerEpoch),
+		Epoch:                      EpochAtSlot(bstate.Capella.Slot),
 		Slot:                       bstate.Capella.Slot,
 		BlockRoots:                 bstate.Capella.BlockRoots,
 		SyncCommittee:              *bstate.Capella.CurrentSyncCommittee,
 		GenesisTimestamp:           bstate.Capella.GenesisTime,
 		CurrentJustifiedCheckpoint: *bstate.Capella.CurrentJustifiedCheckpoint,
+		CurrentFinalizedCheckpoint: *bstate.Capella.FinalizedCheckpoint,
+		InactivityScores:           bstate.Capella.InactivityScores,
 		LatestBlockHeader:          bstate.Capella.LatestBlockHeader,
 	}
 
@@ -518,12 +528,14 @@ func NewDenebState(bstate spec.VersionedBeaconState, duties EpochDuties) Agnosti
 		Balances:                   bstate.Deneb.Balances,
 		Validators:                 bstate.Deneb.Validators,
 		EpochStructs:               duties,
-		Epoch:                      phase0.Epoch(bstate.Deneb.Slot / SlotsPerEpoch),
+		Epoch:                      EpochAtSlot(bstate.Deneb.Slot),
 		Slot:                       bstate.Deneb.Slot,
 		BlockRoots:                 bstate.Deneb.BlockRoots,
 		SyncCommittee:              *bstate.Deneb.CurrentSyncCommittee,
 		GenesisTimestamp:           bstate.Deneb.GenesisTime,
 		CurrentJustifiedCheckpoint: *bstate.Deneb.CurrentJustifiedCheckpoint,
+		CurrentFinalizedCheckpoint: *bstate.Deneb.FinalizedCheckpoint,
+		InactivityScores:           bstate.Deneb.InactivityScores,
 		LatestBlockHeader:          bstate.Deneb.LatestBlockHeader,
 	}
 
@@ -541,12 +553,13 @@ func NewElectraState(bstate spec.VersionedBeaconState, duties EpochDuties) Agnos
 		Balances:                   bstate.Electra.Balances,
 		Validators:                 bstate.Electra.Validators,
 		EpochStructs:               duties,
-		Epoch:                      phase0.Epoch(bstate.Electra.Slot / SlotsPerEpoch),
+		Epoch:                      EpochAtSlot(bstate.Electra.Slot),
 		Slot:                       bstate.Electra.Slot,
 		BlockRoots:                 bstate.Electra.BlockRoots,
 		SyncCommittee:              *bstate.Electra.CurrentSyncCommittee,
 		GenesisTimestamp:           bstate.Electra.GenesisTime,
 		CurrentJustifiedCheckpoint: *bstate.Electra.CurrentJustifiedCheckpoint,
+		InactivityScores:           bstate.Electra.InactivityScores,
 		LatestBlockHeader:          bstate.Electra.LatestBlockHeader,
 		PendingConsolidations:      bstate.Electra.PendingConsolidations,
 		PendingPartialWithdrawals:  bstate.Electra.PendingPartialWithdrawals,
@@ -571,12 +584,13 @@ func NewFuluState(bstate spec.VersionedBeaconState, duties EpochDuties) Agnostic
 		Balances:                   bstate.Fulu.Balances,
 		Validators:                 bstate.Fulu.Validators,
 		EpochStructs:               duties,
-		Epoch:                      phase0.Epoch(bstate.Fulu.Slot / SlotsPerEpoch),
+		Epoch:                      EpochAtSlot(bstate.Fulu.Slot),
 		Slot:                       bstate.Fulu.Slot,
 		BlockRoots:                 bstate.Fulu.BlockRoots,
 		SyncCommittee:              *bstate.Fulu.CurrentSyncCommittee,
 		GenesisTimestamp:           bstate.Fulu.GenesisTime,
 		CurrentJustifiedCheckpoint: *bstate.Fulu.CurrentJustifiedCheckpoint,
+		InactivityScores:           bstate.Fulu.InactivityScores,
 		LatestBlockHeader:          bstate.Fulu.LatestBlockHeader,
 		PendingConsolidations:      bstate.Fulu.PendingConsolidations,
 		PendingPartialWithdrawals:  bstate.Fulu.PendingPartialWithdrawals,
@@ -0,0 +1,17 @@
+package spec
+
+import "github.com/attestantio/go-eth2-client/spec/phase0"
+
+// SyncCommitteeDuty is the participation of a sync committee member in the sync aggregate of a block
+type SyncCommitteeDuty struct {
+	Slot              phase0.Slot
+	Epoch             phase0.Epoch
+	ValIdx            phase0.ValidatorIndex
+	CommitteePosition uint64 // position of the member in the sync committee (0 to 511)
+	Participated      bool
+	Reward            int64 // participant reward, negative (penalty) when the member did not participate
+}
+
+func (f SyncCommitteeDuty) Type() ModelType {
+	return SyncCommitteeDutyModel
+}
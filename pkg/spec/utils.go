@@ -34,11 +34,11 @@ func ComputeStartSlotAtEpoch(epoch phase0.Epoch) phase0.Slot {
 }
 
 func FirstSlotInEpoch(slot phase0.Slot) phase0.Slot {
-	return slot / SlotsPerEpoch * SlotsPerEpoch
+	return slot / phase0.Slot(SlotsPerEpoch) * phase0.Slot(SlotsPerEpoch)
 }
 
 func EpochAtSlot(slot phase0.Slot) phase0.Epoch {
-	return phase0.Epoch(slot / SlotsPerEpoch)
+	return phase0.Epoch(uint64(slot) / SlotsPerEpoch)
 }
 
 func HexStringAddressIsValid(address string) bool {
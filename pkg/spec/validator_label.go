@@ -0,0 +1,15 @@
+package spec
+
+import "github.com/attestantio/go-eth2-client/spec/phase0"
+
+// ValidatorLabel is the pool (or entity) a validator belongs to, used to build the pool summaries
+type ValidatorLabel struct {
+	ValIdx    phase0.ValidatorIndex
+	PublicKey phase0.BLSPubKey
+	PoolName  string
+	Pool      string // extra name for sub categorization
+}
+
+func (f ValidatorLabel) Type() ModelType {
+	return ValidatorLabelModel
+}
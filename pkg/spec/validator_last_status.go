@@ -41,7 +41,7 @@ func (f ValidatorLastStatus) Type() ModelType {
 }
 
 func (f ValidatorLastStatus) BalanceToEth() float32 {
-	return float32(f.CurrentBalance) / EffectiveBalanceInc
+	return float32(f.CurrentBalance) / float32(EffectiveBalanceInc)
 }
 
 func (f ValidatorLastStatus) WithdrawalCredentialsString() string {
@@ -23,6 +23,7 @@ type ValidatorRewards struct {
 	MissingSource                       bool
 	MissingTarget                       bool
 	MissingHead                         bool
+	InactivityPenalty                   phase0.Gwei // applied to the attestations of Epoch-2, from Altair
 	Status                              ValidatorStatus
 	ProposerApiReward                   phase0.Gwei
 	ProposerManualReward                phase0.Gwei
@@ -34,7 +35,7 @@ func (f ValidatorRewards) Type() ModelType {
 }
 
 func (f ValidatorRewards) BalanceToEth() float32 {
-	return float32(f.ValidatorBalance) / EffectiveBalanceInc
+	return float32(f.ValidatorBalance) / float32(EffectiveBalanceInc)
 }
 
 func (f ValidatorRewards) ToArray() []any {
@@ -59,6 +60,7 @@ func (f ValidatorRewards) ToArray() []any {
 		f.MissingSource,
 		f.MissingTarget,
 		f.MissingHead,
+		f.InactivityPenalty,
 		f.Status,
 		f.InclusionDelay,
 	}
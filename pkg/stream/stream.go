@@ -0,0 +1,262 @@
+package stream
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/migalabs/goteth/pkg/db"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	log = logrus.WithField(
+		"module", "stream",
+	)
+
+	EndpointUrl = "stream"
+
+	// events buffered per subscriber, the events of slower subscribers are dropped
+	SubscriberBuffer = 256
+	writeTimeout     = 10 * time.Second
+)
+
+// Event types, published once the rows are persisted
+const (
+	BlockEvent            = "block"             // t_block_metrics
+	EpochEvent            = "epoch"             // t_epoch_metrics_summary
+	ValidatorRewardsEvent = "validator_rewards" // t_validator_rewards_summary
+	ReorgEvent            = "reorg"             // t_reorgs, once the reorged slots were rewritten
+	OrphanEvent           = "orphan"            // t_orphans
+)
+
+var eventTypes = []string{BlockEvent, EpochEvent, ValidatorRewardsEvent, ReorgEvent, OrphanEvent}
+
+// Event is the message sent to the subscribers, rows have the columns of the table
+type Event struct {
+	Type string   `json:"type"`
+	Rows []db.Row `json:"rows"`
+}
+
+// Filter selects the events of a subscriber, empty sets match everything.
+// Validators are matched against the f_val_idx or f_proposer_index columns
+type Filter struct {
+	Types      map[string]bool
+	Validators map[uint64]bool
+}
+
+func (f Filter) matchesType(eventType string) bool {
+	return len(f.Types) == 0 || f.Types[eventType]
+}
+
+// apply returns the rows of the event the subscriber is interested in
+func (f Filter) apply(rows []db.Row) []db.Row {
+	if len(f.Validators) == 0 {
+		return rows
+	}
+	result := make([]db.Row, 0)
+	for _, row := range rows {
+		column := "f_val_idx"
+		if _, ok := row[column]; !ok {
+			column = "f_proposer_index"
+		}
+		if _, ok := row[column]; !ok || f.Validators[row.Uint64(column)] {
+			result = append(result, row)
+		}
+	}
+	return result
+}
+
+type subscriber struct {
+	filter Filter
+	events chan Event
+}
+
+// Publisher streams the persisted data to the WebSocket subscribers.
+// A nil Publisher ignores every event, so it can be called when streaming is disabled
+type Publisher struct {
+	ctx         context.Context
+	port        int
+	mu          sync.RWMutex
+	subscribers map[*subscriber]struct{}
+	upgrader    websocket.Upgrader
+}
+
+func NewPublisher(ctx context.Context, port int) *Publisher {
+	return &Publisher{
+		ctx:         ctx,
+		port:        port,
+		subscribers: make(map[*subscriber]struct{}),
+		upgrader: websocket.Upgrader{
+			CheckOrigin: func(r *http.Request) bool { return true },
+		},
+	}
+}
+
+// Start serves the stream at ws://0.0.0.0:<port>/stream until the context is done
+func (p *Publisher) Start() {
+	mux := http.NewServeMux()
+	mux.Handle("/"+EndpointUrl, p)
+	srv := &http.Server{
+		Addr:              fmt.Sprintf("0.0.0.0:%d", p.port),
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go func() {
+		<-p.ctx.Done()
+		srv.Close()
+	}()
+	go func() {
+		err := srv.ListenAndServe()
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Errorf("stream server stopped: %s", err)
+		}
+	}()
+	log.Infof("streaming events on: ws://%s/%s", srv.Addr, EndpointUrl)
+}
+
+// Publish sends the rows to the subscribers of the event type. The rows are only
+// built when there is any subscriber for the type
+func (p *Publisher) Publish(eventType string, rows func() ([]db.Row, error)) {
+	if p == nil {
+		return
+	}
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	var eventRows []db.Row
+	for sub := range p.subscribers {
+		if !sub.filter.matchesType(eventType) {
+			continue
+		}
+		if eventRows == nil {
+			var err error
+			eventRows, err = rows()
+			if err != nil {
+				log.Errorf("could not build %s event: %s", eventType, err)
+				return
+			}
+		}
+		subRows := sub.filter.apply(eventRows)
+		if len(subRows) == 0 {
+			continue
+		}
+		select {
+		case sub.events <- Event{Type: eventType, Rows: subRows}:
+		default:
+			log.Warnf("stream subscriber is too slow, dropping %s event", eventType)
+		}
+	}
+}
+
+// Subscribe registers a new subscriber, the returned function removes it
+func (p *Publisher) Subscribe(filter Filter) (<-chan Event, func()) {
+	sub := &subscriber{
+		filter: filter,
+		events: make(chan Event, SubscriberBuffer),
+	}
+	p.mu.Lock()
+	p.subscribers[sub] = struct{}{}
+	p.mu.Unlock()
+
+	return sub.events, func() {
+		p.mu.Lock()
+		delete(p.subscribers, sub)
+		p.mu.Unlock()
+	}
+}
+
+// ServeHTTP upgrades the request to a WebSocket and streams the events matching the
+// filters of the query: types=block,epoch&validators=1,2
+func (p *Publisher) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	filter, err := ParseFilter(r.URL.Query().Get("types"), r.URL.Query().Get("validators"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	conn, err := p.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		log.Debugf("could not upgrade stream connection: %s", err)
+		return
+	}
+	defer conn.Close()
+
+	events, unsubscribe := p.Subscribe(filter)
+	defer unsubscribe()
+	log.Debugf("new stream subscriber: %s", r.RemoteAddr)
+
+	// the subscriber does not send messages, reading detects when it disconnects
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	for {
+		select {
+		case <-p.ctx.Done():
+			conn.WriteControl(websocket.CloseMessage,
+				websocket.FormatCloseMessage(websocket.CloseGoingAway, "shutting down"), time.Now().Add(writeTimeout))
+			return
+		case <-closed:
+			return
+		case event := <-events:
+			conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+			if err := conn.WriteJSON(event); err != nil {
+				log.Debugf("stream subscriber %s disconnected: %s", r.RemoteAddr, err)
+				return
+			}
+		}
+	}
+}
+
+// ParseFilter reads the comma separated event types and validator indices
+func ParseFilter(types string, validators string) (Filter, error) {
+	filter := Filter{
+		Types:      make(map[string]bool),
+		Validators: make(map[uint64]bool),
+	}
+	for _, eventType := range splitList(types) {
+		if !isEventType(eventType) {
+			return Filter{}, errors.Errorf("unknown event type %s, expected one of %s", eventType, strings.Join(eventTypes, ","))
+		}
+		filter.Types[eventType] = true
+	}
+	for _, validator := range splitList(validators) {
+		valIdx, err := strconv.ParseUint(validator, 10, 64)
+		if err != nil {
+			return Filter{}, errors.Errorf("invalid validator index %s", validator)
+		}
+		filter.Validators[valIdx] = true
+	}
+	return filter, nil
+}
+
+func isEventType(eventType string) bool {
+	for _, t := range eventTypes {
+		if t == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+func splitList(list string) []string {
+	items := make([]string, 0)
+	for _, item := range strings.Split(list, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
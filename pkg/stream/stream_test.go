@@ -0,0 +1,126 @@
+package stream
+
+import (
+	"context"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/migalabs/goteth/pkg/db"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func rewardRows() ([]db.Row, error) {
+	return []db.Row{
+		{"f_val_idx": uint64(1), "f_epoch": uint64(10)},
+		{"f_val_idx": uint64(2), "f_epoch": uint64(10)},
+		{"f_val_idx": uint64(3), "f_epoch": uint64(10)},
+	}, nil
+}
+
+func receive(t *testing.T, events <-chan Event) Event {
+	t.Helper()
+	select {
+	case event := <-events:
+		return event
+	case <-time.After(time.Second):
+		t.Fatal("no event received")
+		return Event{}
+	}
+}
+
+func TestParseFilter(t *testing.T) {
+	filter, err := ParseFilter("block, reorg", "1,2")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]bool{BlockEvent: true, ReorgEvent: true}, filter.Types)
+	assert.Equal(t, map[uint64]bool{1: true, 2: true}, filter.Validators)
+
+	filter, err = ParseFilter("", "")
+	require.NoError(t, err)
+	assert.True(t, filter.matchesType(OrphanEvent))
+
+	_, err = ParseFilter("blocks", "")
+	assert.Error(t, err)
+	_, err = ParseFilter("", "0x12")
+	assert.Error(t, err)
+}
+
+func TestPublishFilters(t *testing.T) {
+	p := NewPublisher(context.Background(), 0)
+
+	all, unsubscribeAll := p.Subscribe(Filter{})
+	defer unsubscribeAll()
+	validators, unsubscribeValidators := p.Subscribe(Filter{
+		Types:      map[string]bool{ValidatorRewardsEvent: true, EpochEvent: true},
+		Validators: map[uint64]bool{2: true},
+	})
+	defer unsubscribeValidators()
+
+	p.Publish(ValidatorRewardsEvent, rewardRows)
+	assert.Len(t, receive(t, all).Rows, 3)
+	event := receive(t, validators)
+	assert.Equal(t, ValidatorRewardsEvent, event.Type)
+	require.Len(t, event.Rows, 1)
+	assert.Equal(t, uint64(2), event.Rows[0].Uint64("f_val_idx"))
+
+	// blocks of other proposers are not sent, rows without validator columns always are
+	p.Publish(BlockEvent, func() ([]db.Row, error) {
+		return []db.Row{{"f_slot": uint64(320), "f_proposer_index": uint64(5)}}, nil
+	})
+	p.Publish(EpochEvent, func() ([]db.Row, error) {
+		return []db.Row{{"f_epoch": uint64(10)}}, nil
+	})
+	assert.Equal(t, BlockEvent, receive(t, all).Type)
+	assert.Equal(t, EpochEvent, receive(t, all).Type)
+	assert.Equal(t, EpochEvent, receive(t, validators).Type)
+	assert.Empty(t, validators)
+
+	// rows are not built when nobody is subscribed to the type
+	unsubscribeAll()
+	p.Publish(OrphanEvent, func() ([]db.Row, error) {
+		t.Fatal("rows built without subscribers")
+		return nil, nil
+	})
+
+	var disabled *Publisher
+	disabled.Publish(BlockEvent, rewardRows)
+}
+
+func TestWebSocketStream(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	p := NewPublisher(ctx, 0)
+	srv := httptest.NewServer(p)
+	defer srv.Close()
+
+	url := "ws" + strings.TrimPrefix(srv.URL, "http") + "?types=validator_rewards&validators=3"
+	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	// wait until the connection is subscribed
+	require.Eventually(t, func() bool {
+		p.mu.RLock()
+		defer p.mu.RUnlock()
+		return len(p.subscribers) == 1
+	}, time.Second, 10*time.Millisecond)
+
+	p.Publish(BlockEvent, rewardRows)
+	p.Publish(ValidatorRewardsEvent, rewardRows)
+
+	var event Event
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	require.NoError(t, conn.ReadJSON(&event))
+	assert.Equal(t, ValidatorRewardsEvent, event.Type)
+	require.Len(t, event.Rows, 1)
+	assert.EqualValues(t, 3, event.Rows[0]["f_val_idx"])
+
+	resp, err := srv.Client().Get(srv.URL + "?types=unknown")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, 400, resp.StatusCode)
+}
@@ -0,0 +1,155 @@
+package mockbeacon
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	apiv1 "github.com/attestantio/go-eth2-client/api/v1"
+)
+
+// Event is a message sent to the /eth/v1/events subscribers of its topic
+type Event struct {
+	Topic string          `json:"topic"`
+	Data  json.RawMessage `json:"data"`
+}
+
+type subscriber struct {
+	topics map[string]bool
+	events chan Event
+	done   chan struct{}
+}
+
+func (s *Server) handleEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, "streaming not supported")
+		return
+	}
+
+	sub := &subscriber{
+		topics: make(map[string]bool),
+		events: make(chan Event),
+		done:   make(chan struct{}),
+	}
+	for _, topics := range r.URL.Query()["topics"] {
+		for _, topic := range strings.Split(topics, ",") {
+			sub.topics[topic] = true
+		}
+	}
+	if len(sub.topics) == 0 {
+		writeError(w, http.StatusBadRequest, "no topics given")
+		return
+	}
+
+	s.mu.Lock()
+	s.subscribers[sub] = struct{}{}
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		delete(s.subscribers, sub)
+		s.mu.Unlock()
+		close(sub.done)
+	}()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	for {
+		select {
+		case event := <-sub.events:
+			fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Topic, event.Data)
+			flusher.Flush()
+		case <-r.Context().Done():
+			return
+		case <-s.done:
+			return
+		}
+	}
+}
+
+// Subscribers returns the number of open event streams including the topic
+func (s *Server) Subscribers(topic string) int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	count := 0
+	for sub := range s.subscribers {
+		if sub.topics[topic] {
+			count++
+		}
+	}
+	return count
+}
+
+// Publish sends the event to the current subscribers of the topic,
+// it returns once every subscriber received it
+func (s *Server) Publish(topic string, data any) error {
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	s.publish(Event{Topic: topic, Data: encoded})
+	return nil
+}
+
+func (s *Server) publish(event Event) {
+	s.mu.RLock()
+	subs := make([]*subscriber, 0, len(s.subscribers))
+	for sub := range s.subscribers {
+		if sub.topics[event.Topic] {
+			subs = append(subs, sub)
+		}
+	}
+	s.mu.RUnlock()
+
+	for _, sub := range subs {
+		select {
+		case sub.events <- event:
+		case <-sub.done:
+		case <-s.done:
+		}
+	}
+}
+
+func (s *Server) PublishHead(event *apiv1.HeadEvent) error {
+	return s.Publish("head", event)
+}
+
+func (s *Server) PublishFinalizedCheckpoint(event *apiv1.FinalizedCheckpointEvent) error {
+	return s.Publish("finalized_checkpoint", event)
+}
+
+func (s *Server) PublishChainReorg(event *apiv1.ChainReorgEvent) error {
+	return s.Publish("chain_reorg", event)
+}
+
+func (s *Server) PublishBlobSidecar(event *apiv1.BlobSidecarEvent) error {
+	return s.Publish("blob_sidecar", event)
+}
+
+// AddEvent appends an event to the fixture events, without publishing it
+func (s *Server) AddEvent(topic string, data any) error {
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.events = append(s.events, Event{Topic: topic, Data: encoded})
+	return nil
+}
+
+// PublishFixtureEvents publishes the events of the loaded fixtures, in order
+func (s *Server) PublishFixtureEvents() {
+	s.mu.RLock()
+	events := append([]Event(nil), s.events...)
+	s.mu.RUnlock()
+
+	for _, event := range events {
+		s.publish(event)
+	}
+}
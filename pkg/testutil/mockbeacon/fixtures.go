@@ -0,0 +1,358 @@
+package mockbeacon
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	apiv1 "github.com/attestantio/go-eth2-client/api/v1"
+	"github.com/attestantio/go-eth2-client/spec"
+	"github.com/attestantio/go-eth2-client/spec/deneb"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	local_spec "github.com/migalabs/goteth/pkg/spec"
+)
+
+// Fixtures directory layout, every file is optional:
+//
+//	genesis.json                  data of /eth/v1/beacon/genesis
+//	spec.json                     data of /eth/v1/config/spec
+//	finality.json                 data of /eth/v1/beacon/states/{state_id}/finality_checkpoints
+//	state_roots.json              {"<slot>": "0x..."}, overrides the computed state roots
+//	blocks/<slot>.<fork>.ssz      signed blocks, i.e. blocks/100.deneb.ssz
+//	states/<slot>.<fork>.ssz      beacon states
+//	committees/<epoch>.json       data of /eth/v1/beacon/states/{state_id}/committees
+//	proposer_duties/<epoch>.json  data of /eth/v1/validator/duties/proposer/{epoch}
+//	block_rewards/<slot>.json     data of /eth/v1/beacon/rewards/blocks/{block_id}
+//	blob_sidecars/<slot>.json     data of /eth/v1/beacon/blob_sidecars/{block_id}
+//	events.jsonl                  one {"topic": "head", "data": {...}} per line, see PublishFixtureEvents
+
+var (
+	genesisFixture        = "genesis.json"
+	specFixture           = "spec.json"
+	finalityFixture       = "finality.json"
+	stateRootsFixture     = "state_roots.json"
+	blocksFixtures        = "blocks"
+	statesFixtures        = "states"
+	committeesFixtures    = "committees"
+	proposerDutiesFixture = "proposer_duties"
+	blockRewardsFixtures  = "block_rewards"
+	blobSidecarsFixtures  = "blob_sidecars"
+	eventsFixture         = "events.jsonl"
+)
+
+// Load adds the chain in the fixtures directory to the server
+func (s *Server) Load(dir string) error {
+	if _, err := os.Stat(dir); err != nil {
+		return err
+	}
+
+	genesis := &apiv1.Genesis{}
+	if ok, err := readJSONFixture(filepath.Join(dir, genesisFixture), genesis); err != nil {
+		return err
+	} else if ok {
+		s.SetGenesis(genesis)
+	}
+	config := make(map[string]string)
+	if ok, err := readJSONFixture(filepath.Join(dir, specFixture), &config); err != nil {
+		return err
+	} else if ok {
+		s.SetSpec(config)
+	}
+	finality := &apiv1.Finality{}
+	if ok, err := readJSONFixture(filepath.Join(dir, finalityFixture), finality); err != nil {
+		return err
+	} else if ok {
+		s.SetFinality(finality)
+	}
+	stateRoots := make(map[string]string)
+	if _, err := readJSONFixture(filepath.Join(dir, stateRootsFixture), &stateRoots); err != nil {
+		return err
+	}
+	for key, value := range stateRoots {
+		slot, err := strconv.ParseUint(key, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid slot %s in %s: %w", key, stateRootsFixture, err)
+		}
+		var root phase0.Root
+		if err := root.UnmarshalJSON([]byte(`"` + value + `"`)); err != nil {
+			return fmt.Errorf("invalid root for slot %s in %s: %w", key, stateRootsFixture, err)
+		}
+		s.SetStateRoot(phase0.Slot(slot), root)
+	}
+
+	err := walkSSZFixtures(filepath.Join(dir, blocksFixtures), func(version spec.DataVersion, data []byte) error {
+		block, obj, err := newBlock(version)
+		if err != nil {
+			return err
+		}
+		if err := obj.UnmarshalSSZ(data); err != nil {
+			return err
+		}
+		return s.AddBlock(block)
+	})
+	if err != nil {
+		return err
+	}
+	err = walkSSZFixtures(filepath.Join(dir, statesFixtures), func(version spec.DataVersion, data []byte) error {
+		state, obj, err := newState(version)
+		if err != nil {
+			return err
+		}
+		if err := obj.UnmarshalSSZ(data); err != nil {
+			return err
+		}
+		return s.AddState(state)
+	})
+	if err != nil {
+		return err
+	}
+
+	err = walkJSONFixtures(filepath.Join(dir, committeesFixtures), func(epoch uint64, data []byte) error {
+		committees := make([]*apiv1.BeaconCommittee, 0)
+		if err := json.Unmarshal(data, &committees); err != nil {
+			return err
+		}
+		s.SetCommittees(phase0.Epoch(epoch), committees)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	err = walkJSONFixtures(filepath.Join(dir, proposerDutiesFixture), func(epoch uint64, data []byte) error {
+		duties := make([]*apiv1.ProposerDuty, 0)
+		if err := json.Unmarshal(data, &duties); err != nil {
+			return err
+		}
+		s.SetProposerDuties(phase0.Epoch(epoch), duties)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	err = walkJSONFixtures(filepath.Join(dir, blockRewardsFixtures), func(slot uint64, data []byte) error {
+		var rewards local_spec.BlockRewardsContent
+		if err := json.Unmarshal(data, &rewards); err != nil {
+			return err
+		}
+		s.SetBlockRewards(phase0.Slot(slot), rewards)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	err = walkJSONFixtures(filepath.Join(dir, blobSidecarsFixtures), func(slot uint64, data []byte) error {
+		sidecars := make([]*deneb.BlobSidecar, 0)
+		if err := json.Unmarshal(data, &sidecars); err != nil {
+			return err
+		}
+		s.SetBlobSidecars(phase0.Slot(slot), sidecars)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return s.loadEvents(filepath.Join(dir, eventsFixture))
+}
+
+func readJSONFixture(path string, value any) (bool, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if err := json.Unmarshal(data, value); err != nil {
+		return false, fmt.Errorf("unable to parse %s: %w", path, err)
+	}
+	return true, nil
+}
+
+// walkSSZFixtures calls fn for every <slot>.<fork>.ssz file in dir
+func walkSSZFixtures(dir string, fn func(version spec.DataVersion, data []byte) error) error {
+	files, err := filepath.Glob(filepath.Join(dir, "*.ssz"))
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		parts := strings.Split(filepath.Base(file), ".")
+		if len(parts) != 3 {
+			return fmt.Errorf("invalid fixture name %s, expected <slot>.<fork>.ssz", file)
+		}
+		version, err := spec.DataVersionFromString(parts[1])
+		if err != nil {
+			return fmt.Errorf("invalid fork in fixture %s: %w", file, err)
+		}
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		if err := fn(version, data); err != nil {
+			return fmt.Errorf("unable to load fixture %s: %w", file, err)
+		}
+	}
+	return nil
+}
+
+// walkJSONFixtures calls fn for every <slot or epoch>.json file in dir
+func walkJSONFixtures(dir string, fn func(key uint64, data []byte) error) error {
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		key, err := strconv.ParseUint(strings.TrimSuffix(filepath.Base(file), ".json"), 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid fixture name %s: %w", file, err)
+		}
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		if err := fn(key, data); err != nil {
+			return fmt.Errorf("unable to load fixture %s: %w", file, err)
+		}
+	}
+	return nil
+}
+
+func (s *Server) loadEvents(path string) error {
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	events := make([]Event, 0)
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		var event Event
+		if err := json.Unmarshal([]byte(line), &event); err != nil {
+			return fmt.Errorf("unable to parse event in %s: %w", path, err)
+		}
+		events = append(events, event)
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.events = append(s.events, events...)
+	return nil
+}
+
+// Save writes the chain of the server into dir with the fixtures layout,
+// so a scenario built in Go can be checked in and loaded with Load
+func (s *Server) Save(dir string) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if err := writeJSONFixture(filepath.Join(dir, genesisFixture), s.genesis); err != nil {
+		return err
+	}
+	if err := writeJSONFixture(filepath.Join(dir, specFixture), s.spec); err != nil {
+		return err
+	}
+	if err := writeJSONFixture(filepath.Join(dir, finalityFixture), s.finality); err != nil {
+		return err
+	}
+	if len(s.stateRoots) > 0 {
+		stateRoots := make(map[string]string, len(s.stateRoots))
+		for slot, root := range s.stateRoots {
+			stateRoots[fmt.Sprintf("%d", slot)] = fmt.Sprintf("%#x", root)
+		}
+		if err := writeJSONFixture(filepath.Join(dir, stateRootsFixture), stateRoots); err != nil {
+			return err
+		}
+	}
+
+	for slot, block := range s.blocks {
+		obj, err := blockObject(block)
+		if err != nil {
+			return err
+		}
+		if err := writeSSZFixture(filepath.Join(dir, blocksFixtures), uint64(slot), block.Version, obj); err != nil {
+			return err
+		}
+	}
+	for slot, state := range s.states {
+		obj, err := stateObject(state)
+		if err != nil {
+			return err
+		}
+		if err := writeSSZFixture(filepath.Join(dir, statesFixtures), uint64(slot), state.Version, obj); err != nil {
+			return err
+		}
+	}
+	for epoch, committees := range s.committees {
+		if err := writeJSONFixture(filepath.Join(dir, committeesFixtures, fmt.Sprintf("%d.json", epoch)), committees); err != nil {
+			return err
+		}
+	}
+	for epoch, duties := range s.proposerDuties {
+		if err := writeJSONFixture(filepath.Join(dir, proposerDutiesFixture, fmt.Sprintf("%d.json", epoch)), duties); err != nil {
+			return err
+		}
+	}
+	for slot, rewards := range s.blockRewards {
+		if err := writeJSONFixture(filepath.Join(dir, blockRewardsFixtures, fmt.Sprintf("%d.json", slot)), rewards); err != nil {
+			return err
+		}
+	}
+	for slot, sidecars := range s.blobSidecars {
+		if err := writeJSONFixture(filepath.Join(dir, blobSidecarsFixtures, fmt.Sprintf("%d.json", slot)), sidecars); err != nil {
+			return err
+		}
+	}
+
+	if len(s.events) > 0 {
+		lines := make([]string, 0, len(s.events))
+		for _, event := range s.events {
+			line, err := json.Marshal(event)
+			if err != nil {
+				return err
+			}
+			lines = append(lines, string(line))
+		}
+		if err := os.WriteFile(filepath.Join(dir, eventsFixture), []byte(strings.Join(lines, "\n")+"\n"), 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeJSONFixture(path string, value any) error {
+	data, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+func writeSSZFixture(dir string, slot uint64, version spec.DataVersion, obj sszObject) error {
+	data, err := obj.MarshalSSZ()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, fmt.Sprintf("%d.%s.ssz", slot, version)), data, 0644)
+}
@@ -0,0 +1,346 @@
+package mockbeacon_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	v1 "github.com/attestantio/go-eth2-client/api/v1"
+	eth2_client_spec "github.com/attestantio/go-eth2-client/spec"
+	"github.com/attestantio/go-eth2-client/spec/altair"
+	"github.com/attestantio/go-eth2-client/spec/bellatrix"
+	"github.com/attestantio/go-eth2-client/spec/deneb"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/holiman/uint256"
+	"github.com/migalabs/goteth/pkg/clientapi"
+	"github.com/migalabs/goteth/pkg/db"
+	"github.com/migalabs/goteth/pkg/events"
+	"github.com/migalabs/goteth/pkg/spec"
+	"github.com/migalabs/goteth/pkg/testutil/mockbeacon"
+	"github.com/prysmaticlabs/go-bitfield"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	testValidators  = 16
+	testGenesisTime = uint64(1606824023)
+)
+
+func testBlock(slot phase0.Slot, graffiti string) *eth2_client_spec.VersionedSignedBeaconBlock {
+	block := &deneb.SignedBeaconBlock{
+		Message: &deneb.BeaconBlock{
+			Slot:          slot,
+			ProposerIndex: phase0.ValidatorIndex(uint64(slot) % uint64(testValidators)),
+			StateRoot:     phase0.Root{byte(slot), 0xaa},
+			Body: &deneb.BeaconBlockBody{
+				ETH1Data: &phase0.ETH1Data{
+					BlockHash: make([]byte, 32),
+				},
+				SyncAggregate: &altair.SyncAggregate{
+					SyncCommitteeBits: bitfield.NewBitvector512(),
+				},
+				ExecutionPayload: &deneb.ExecutionPayload{
+					BlockNumber:   uint64(slot),
+					Timestamp:     testGenesisTime + uint64(slot)*spec.SlotSeconds,
+					BaseFeePerGas: uint256.NewInt(7),
+					Transactions:  make([]bellatrix.Transaction, 0),
+				},
+			},
+		},
+	}
+	copy(block.Message.Body.Graffiti[:], graffiti)
+	block.Message.Body.ExecutionPayload.BlockHash[0] = byte(slot)
+	return &eth2_client_spec.VersionedSignedBeaconBlock{
+		Version: eth2_client_spec.DataVersionDeneb,
+		Deneb:   block,
+	}
+}
+
+// testState is a Deneb state with every vector at its SSZ size
+func testState(slot phase0.Slot) *eth2_client_spec.VersionedBeaconState {
+	validators := make([]*phase0.Validator, testValidators)
+	balances := make([]phase0.Gwei, testValidators)
+	participation := make([]altair.ParticipationFlags, testValidators)
+	for i := range validators {
+		validators[i] = &phase0.Validator{
+			PublicKey:             phase0.BLSPubKey{byte(i + 1)},
+			WithdrawalCredentials: make([]byte, 32),
+			EffectiveBalance:      32_000_000_000,
+			ExitEpoch:             phase0.Epoch(spec.FarFutureEpoch),
+			WithdrawableEpoch:     phase0.Epoch(spec.FarFutureEpoch),
+		}
+		balances[i] = phase0.Gwei(32_000_000_000 + i)
+		participation[i] = 0b111
+	}
+	syncCommittee := &altair.SyncCommittee{
+		Pubkeys: make([]phase0.BLSPubKey, spec.SyncCommitteeSize),
+	}
+
+	return &eth2_client_spec.VersionedBeaconState{
+		Version: eth2_client_spec.DataVersionDeneb,
+		Deneb: &deneb.BeaconState{
+			GenesisTime:                  testGenesisTime,
+			Slot:                         slot,
+			Fork:                         &phase0.Fork{},
+			LatestBlockHeader:            &phase0.BeaconBlockHeader{Slot: slot},
+			BlockRoots:                   make([]phase0.Root, spec.SlotsPerHistoricalRoot),
+			StateRoots:                   make([]phase0.Root, spec.SlotsPerHistoricalRoot),
+			ETH1Data:                     &phase0.ETH1Data{BlockHash: make([]byte, 32)},
+			Validators:                   validators,
+			Balances:                     balances,
+			RANDAOMixes:                  make([]phase0.Root, 65536),
+			Slashings:                    make([]phase0.Gwei, 8192),
+			PreviousEpochParticipation:   participation,
+			CurrentEpochParticipation:    participation,
+			JustificationBits:            bitfield.NewBitvector4(),
+			PreviousJustifiedCheckpoint:  &phase0.Checkpoint{},
+			CurrentJustifiedCheckpoint:   &phase0.Checkpoint{},
+			FinalizedCheckpoint:          &phase0.Checkpoint{},
+			InactivityScores:             make([]uint64, testValidators),
+			CurrentSyncCommittee:         syncCommittee,
+			NextSyncCommittee:            syncCommittee,
+			LatestExecutionPayloadHeader: &deneb.ExecutionPayloadHeader{BaseFeePerGas: uint256.NewInt(7)},
+		},
+	}
+}
+
+// testServer serves blocks from 0 to 40 with slot 37 missed, and the state at slot 31
+func testServer(t *testing.T) *mockbeacon.Server {
+	server := mockbeacon.New()
+	t.Cleanup(server.Close)
+
+	for slot := phase0.Slot(0); slot <= 40; slot++ {
+		require.NoError(t, server.AddBlock(testBlock(slot, "mockbeacon")))
+	}
+	server.RemoveBlock(37)
+	require.NoError(t, server.AddState(testState(31)))
+
+	committee := &v1.BeaconCommittee{Slot: 3, Index: 0}
+	for i := 0; i < testValidators; i++ {
+		committee.Validators = append(committee.Validators, phase0.ValidatorIndex(i))
+	}
+	server.SetCommittees(0, []*v1.BeaconCommittee{committee})
+	server.SetProposerDuties(1, []*v1.ProposerDuty{{Slot: 37, ValidatorIndex: 9}})
+	server.SetBlockRewards(10, spec.BlockRewardsContent{ProposerIndex: 10, Total: 100, Attestations: 90, SyncAggregate: 10})
+
+	block32, err := testBlock(32, "mockbeacon").Root()
+	require.NoError(t, err)
+	server.SetFinality(&v1.Finality{
+		Finalized:         &phase0.Checkpoint{Epoch: 1, Root: block32},
+		Justified:         &phase0.Checkpoint{Epoch: 1, Root: block32},
+		PreviousJustified: &phase0.Checkpoint{},
+	})
+	return server
+}
+
+func testClient(t *testing.T, server *mockbeacon.Server) *clientapi.APIClient {
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	cli, err := clientapi.NewAPIClient(ctx, server.URL(), 1,
+		clientapi.WithDBMetrics(db.DBMetrics{Block: true, APIRewards: true}))
+	require.NoError(t, err)
+	return cli
+}
+
+func TestAPIClientBlocks(t *testing.T) {
+	server := testServer(t)
+	cli := testClient(t, server)
+
+	block, err := cli.RequestBeaconBlock(10)
+	require.NoError(t, err)
+	assert.True(t, block.Proposed)
+	assert.Equal(t, phase0.ValidatorIndex(10), block.ProposerIndex)
+	assert.Equal(t, phase0.Root{10, 0xaa}, block.StateRoot) // the state root of the block
+	assert.Equal(t, uint64(100), block.Reward.Data.Total)
+
+	missed, err := cli.RequestBeaconBlock(37)
+	require.NoError(t, err)
+	assert.False(t, missed.Proposed)
+	assert.Equal(t, phase0.ValidatorIndex(9), missed.ProposerIndex)
+
+	assert.Equal(t, block.Root, cli.RequestBlockRoot(10))
+	assert.Equal(t, phase0.Root{}, cli.RequestBlockRoot(37))
+	assert.Equal(t, phase0.Slot(40), cli.RequestCurrentHead())
+
+	finalized, err := cli.RequestFinalizedBeaconBlock()
+	require.NoError(t, err)
+	assert.Equal(t, phase0.Slot(32), finalized.Slot)
+
+	blobs, err := cli.RequestBlobSidecars(10)
+	require.NoError(t, err)
+	assert.Empty(t, blobs)
+	chainSpec, err := cli.RequestChainSpec()
+	require.NoError(t, err)
+	assert.Equal(t, "mainnet", chainSpec["CONFIG_NAME"])
+	assert.Equal(t, uint64(32), chainSpec["SLOTS_PER_EPOCH"])
+}
+
+func TestAPIClientStates(t *testing.T) {
+	server := testServer(t)
+	cli := testClient(t, server)
+
+	state, err := cli.RequestBeaconState(31)
+	require.NoError(t, err)
+	assert.Equal(t, phase0.Slot(31), state.Slot)
+	assert.Len(t, state.Validators, testValidators)
+	assert.Equal(t, phase0.Gwei(32_000_000_003), state.Balances[3])
+
+	expectedRoot, err := testState(31).Deneb.HashTreeRoot()
+	require.NoError(t, err)
+	assert.Equal(t, phase0.Root(expectedRoot), state.StateRoot)
+
+	// committees and duties are requested along with the state
+	assert.Equal(t, phase0.Slot(3), state.EpochStructs.ValidatorAttSlot[5])
+	assert.Len(t, state.EpochStructs.ProposerDuties, 32)
+
+	byRoot, err := cli.RequestBeaconStateByRoot(31, state.StateRoot)
+	require.NoError(t, err)
+	assert.Equal(t, state.Balances, byRoot.Balances)
+
+	validators, err := cli.RequestValidators("31")
+	require.NoError(t, err)
+	require.Len(t, validators, testValidators)
+	assert.Equal(t, phase0.BLSPubKey{4}, validators[3].PublicKey)
+
+	_, err = cli.RequestBeaconState(63)
+	require.Error(t, err)
+}
+
+func TestFailuresAndJSON(t *testing.T) {
+	server := testServer(t)
+	server.SetJSONOnly(true)
+	cli := testClient(t, server)
+	assert.False(t, cli.Capabilities().SSZ)
+
+	// the blocks and states are decoded from the JSON answered
+	state, err := cli.RequestBeaconState(31)
+	require.NoError(t, err)
+	assert.Equal(t, phase0.Gwei(32_000_000_003), state.Balances[3])
+
+	server.SetFailure(mockbeacon.RouteStateRoot, http.StatusInternalServerError)
+	requests := server.Requests(mockbeacon.RouteStateRoot)
+	_, err = cli.RequestStateRoot(31)
+	require.Error(t, err)
+	assert.Equal(t, requests+1, server.Requests(mockbeacon.RouteStateRoot))
+
+	server.SetFailure(mockbeacon.RouteStateRoot, http.StatusOK)
+	root, err := cli.RequestStateRoot(31)
+	require.NoError(t, err)
+	assert.Equal(t, state.StateRoot, root)
+}
+
+func TestEvents(t *testing.T) {
+	server := testServer(t)
+	cli := testClient(t, server)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	eventsObj := events.NewEventsObj(ctx, cli)
+	eventsObj.SubscribeToHeadEvents()
+	eventsObj.SubscribeToReorgsEvents()
+	require.Eventually(t, func() bool {
+		return server.Subscribers("head") == 1 && server.Subscribers("chain_reorg") == 1
+	}, 10*time.Second, 10*time.Millisecond)
+
+	require.NoError(t, server.PublishHead(&v1.HeadEvent{Slot: 41, State: phase0.Root{41}}))
+	select {
+	case event := <-eventsObj.HeadChan:
+		assert.Equal(t, phase0.Slot(41), event.HeadEvent.Slot)
+		assert.Equal(t, phase0.Root{41}, event.HeadEvent.State)
+	case <-time.After(5 * time.Second):
+		t.Fatal("head event not received")
+	}
+
+	go server.PublishChainReorg(&v1.ChainReorgEvent{Slot: 41, Depth: 2})
+	select {
+	case event := <-eventsObj.ReorgChan:
+		assert.Equal(t, phase0.Slot(41), event.Slot)
+		assert.Equal(t, uint64(2), event.Depth)
+	case <-time.After(5 * time.Second):
+		t.Fatal("chain_reorg event not received")
+	}
+}
+
+func TestFixtures(t *testing.T) {
+	dir := t.TempDir()
+	original := testServer(t)
+	require.NoError(t, original.AddEvent("head", &v1.HeadEvent{Slot: 41}))
+	require.NoError(t, original.Save(dir))
+
+	server, err := mockbeacon.NewFromFixtures(dir)
+	require.NoError(t, err)
+	t.Cleanup(server.Close)
+	cli := testClient(t, server)
+
+	block, err := cli.RequestBeaconBlock(10)
+	require.NoError(t, err)
+	assert.Equal(t, testBlock(10, "mockbeacon").Deneb.Message.Body.Graffiti, block.Graffiti)
+	assert.Equal(t, uint64(100), block.Reward.Data.Total)
+
+	missed, err := cli.RequestBeaconBlock(37)
+	require.NoError(t, err)
+	assert.False(t, missed.Proposed)
+	assert.Equal(t, phase0.ValidatorIndex(9), missed.ProposerIndex)
+
+	finalized, err := cli.RequestFinalizedBeaconBlock()
+	require.NoError(t, err)
+	assert.Equal(t, phase0.Slot(32), finalized.Slot)
+
+	state, err := cli.RequestBeaconState(31)
+	require.NoError(t, err)
+	assert.Equal(t, phase0.Slot(3), state.EpochStructs.ValidatorAttSlot[5])
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	eventsObj := events.NewEventsObj(ctx, cli)
+	eventsObj.SubscribeToHeadEvents()
+	require.Eventually(t, func() bool {
+		return server.Subscribers("head") == 1
+	}, 10*time.Second, 10*time.Millisecond)
+
+	server.PublishFixtureEvents()
+	select {
+	case event := <-eventsObj.HeadChan:
+		assert.Equal(t, phase0.Slot(41), event.HeadEvent.Slot)
+	case <-time.After(5 * time.Second):
+		t.Fatal("head event not received")
+	}
+}
+
+func TestEventsFailover(t *testing.T) {
+	defaultInterval := clientapi.HealthCheckInterval
+	clientapi.HealthCheckInterval = 50 * time.Millisecond
+	t.Cleanup(func() { clientapi.HealthCheckInterval = defaultInterval })
+
+	servers := []*mockbeacon.Server{testServer(t), testServer(t)}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	cli, err := clientapi.NewAPIClient(ctx, servers[0].URL()+","+servers[1].URL(), 1)
+	require.NoError(t, err)
+
+	eventsObj := events.NewEventsObj(ctx, cli)
+	eventsObj.SubscribeToHeadEvents()
+	require.Eventually(t, func() bool {
+		return servers[0].Subscribers("head")+servers[1].Subscribers("head") == 1
+	}, 10*time.Second, 10*time.Millisecond)
+	subscribed, other := servers[0], servers[1]
+	if servers[1].Subscribers("head") == 1 {
+		subscribed, other = other, subscribed
+	}
+
+	// the node falls behind, the events are received from the other one
+	subscribed.SetHead(10)
+	require.Eventually(t, func() bool {
+		return subscribed.Subscribers("head") == 0 && other.Subscribers("head") == 1
+	}, 10*time.Second, 10*time.Millisecond)
+
+	require.NoError(t, other.PublishHead(&v1.HeadEvent{Slot: 41}))
+	select {
+	case event := <-eventsObj.HeadChan:
+		assert.Equal(t, phase0.Slot(41), event.HeadEvent.Slot)
+	case <-time.After(5 * time.Second):
+		t.Fatal("head event not received")
+	}
+}
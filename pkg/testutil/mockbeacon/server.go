@@ -0,0 +1,717 @@
+// Package mockbeacon is a beacon node serving the subset of the beacon API
+// used by goteth, so the analyzer can be tested against a real HTTP server.
+// The chain is loaded from fixture files (see Load) or built with the Add/Set
+// methods, and events are published to the /eth/v1/events SSE subscribers.
+// Several servers can be given to the client as the nodes of a failover list,
+// each one failing its routes on demand (see SetFailure).
+package mockbeacon
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	apiv1 "github.com/attestantio/go-eth2-client/api/v1"
+	"github.com/attestantio/go-eth2-client/spec"
+	"github.com/attestantio/go-eth2-client/spec/deneb"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	local_spec "github.com/migalabs/goteth/pkg/spec"
+)
+
+var (
+	DefaultGenesisTime = time.Unix(1606824023, 0) // mainnet
+	NodeVersion        = "mockbeacon/v0.1.0"
+
+	// DefaultSpec is served by /eth/v1/config/spec, go-eth2-client reads it i.e. to request duties
+	DefaultSpec = map[string]string{
+		"CONFIG_NAME":                      "mainnet",
+		"SECONDS_PER_SLOT":                 "12",
+		"SLOTS_PER_EPOCH":                  "32",
+		"SYNC_COMMITTEE_SIZE":              "512",
+		"EPOCHS_PER_SYNC_COMMITTEE_PERIOD": "256",
+		"MAX_EFFECTIVE_BALANCE":            "32000000000",
+		"EFFECTIVE_BALANCE_INCREMENT":      "1000000000",
+	}
+
+	contentTypeSSZ  = "application/octet-stream"
+	contentTypeJSON = "application/json"
+)
+
+// Server is a beacon node backed by an in-memory chain.
+// Roots are resolved like in a real node: the state root of a slot is the one
+// set with SetStateRoot, else the root of the state added at that slot, else
+// the state root of the block at that slot.
+type Server struct {
+	mu   sync.RWMutex
+	srv  *httptest.Server
+	done chan struct{}
+	once sync.Once
+
+	genesis        *apiv1.Genesis
+	spec           map[string]string
+	head           *phase0.Slot // nil follows the highest block
+	syncing        bool
+	jsonOnly       bool // SSZ is not served, as in nodes without SSZ support
+	finality       *apiv1.Finality
+	blocks         map[phase0.Slot]*spec.VersionedSignedBeaconBlock
+	blockRoots     map[phase0.Slot]phase0.Root
+	states         map[phase0.Slot]*spec.VersionedBeaconState
+	stateHashes    map[phase0.Slot]phase0.Root
+	stateRoots     map[phase0.Slot]phase0.Root // overrides the computed state roots
+	committees     map[phase0.Epoch][]*apiv1.BeaconCommittee
+	proposerDuties map[phase0.Epoch][]*apiv1.ProposerDuty
+	blockRewards   map[phase0.Slot]local_spec.BlockRewardsContent
+	blobSidecars   map[phase0.Slot][]*deneb.BlobSidecar
+	events         []Event // loaded from the fixtures or added with AddEvent, see PublishFixtureEvents
+
+	subscribers map[*subscriber]struct{}
+
+	failures map[string]int // route path -> status code answered instead
+	requests map[string]int // route path -> requests received
+}
+
+// New starts an empty beacon node, it has to be closed with Close
+func New() *Server {
+	s := &Server{
+		done: make(chan struct{}),
+		genesis: &apiv1.Genesis{
+			GenesisTime: DefaultGenesisTime,
+		},
+		spec: DefaultSpec,
+		finality: &apiv1.Finality{
+			Finalized:         &phase0.Checkpoint{},
+			Justified:         &phase0.Checkpoint{},
+			PreviousJustified: &phase0.Checkpoint{},
+		},
+		blocks:         make(map[phase0.Slot]*spec.VersionedSignedBeaconBlock),
+		blockRoots:     make(map[phase0.Slot]phase0.Root),
+		states:         make(map[phase0.Slot]*spec.VersionedBeaconState),
+		stateHashes:    make(map[phase0.Slot]phase0.Root),
+		stateRoots:     make(map[phase0.Slot]phase0.Root),
+		committees:     make(map[phase0.Epoch][]*apiv1.BeaconCommittee),
+		proposerDuties: make(map[phase0.Epoch][]*apiv1.ProposerDuty),
+		blockRewards:   make(map[phase0.Slot]local_spec.BlockRewardsContent),
+		blobSidecars:   make(map[phase0.Slot][]*deneb.BlobSidecar),
+		subscribers:    make(map[*subscriber]struct{}),
+		failures:       make(map[string]int),
+		requests:       make(map[string]int),
+	}
+	s.srv = httptest.NewServer(s.routes())
+	return s
+}
+
+// NewFromFixtures starts a beacon node serving the chain in the fixtures directory
+func NewFromFixtures(dir string) (*Server, error) {
+	s := New()
+	if err := s.Load(dir); err != nil {
+		s.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+// URL is the endpoint to give to the beacon API client
+func (s *Server) URL() string {
+	return s.srv.URL
+}
+
+// Close disconnects the event subscribers and stops the server
+func (s *Server) Close() {
+	s.once.Do(func() {
+		close(s.done)
+		s.srv.Close()
+	})
+}
+
+// Routes of the beacon API served, the paths given to SetFailure and Requests
+const (
+	RouteSyncing      = "/eth/v1/node/syncing"
+	RouteVersion      = "/eth/v1/node/version"
+	RouteGenesis      = "/eth/v1/beacon/genesis"
+	RouteSpec         = "/eth/v1/config/spec"
+	RouteBlock        = "/eth/v2/beacon/blocks/{block_id}"
+	RouteBlockRoot    = "/eth/v1/beacon/blocks/{block_id}/root"
+	RouteHeader       = "/eth/v1/beacon/headers/{block_id}"
+	RouteState        = "/eth/v2/debug/beacon/states/{state_id}"
+	RouteStateRoot    = "/eth/v1/beacon/states/{state_id}/root"
+	RouteFinality     = "/eth/v1/beacon/states/{state_id}/finality_checkpoints"
+	RouteCommittees   = "/eth/v1/beacon/states/{state_id}/committees"
+	RouteProposers    = "/eth/v1/validator/duties/proposer/{epoch}"
+	RouteBlockRewards = "/eth/v1/beacon/rewards/blocks/{block_id}"
+	RouteBlobSidecars = "/eth/v1/beacon/blob_sidecars/{block_id}"
+	RouteEvents       = "/eth/v1/events"
+)
+
+func (s *Server) routes() *http.ServeMux {
+	mux := http.NewServeMux()
+	s.handle(mux, RouteSyncing, s.handleSyncing)
+	s.handle(mux, RouteVersion, s.handleVersion)
+	s.handle(mux, RouteGenesis, s.handleGenesis)
+	s.handle(mux, RouteSpec, s.handleSpec)
+	s.handle(mux, RouteBlock, s.handleBlock)
+	s.handle(mux, RouteBlockRoot, s.handleBlockRoot)
+	s.handle(mux, RouteHeader, s.handleHeader)
+	s.handle(mux, RouteState, s.handleState)
+	s.handle(mux, RouteStateRoot, s.handleStateRoot)
+	s.handle(mux, RouteFinality, s.handleFinality)
+	s.handle(mux, RouteCommittees, s.handleCommittees)
+	s.handle(mux, RouteProposers, s.handleProposerDuties)
+	s.handle(mux, RouteBlockRewards, s.handleBlockRewards)
+	s.handle(mux, RouteBlobSidecars, s.handleBlobSidecars)
+	s.handle(mux, RouteEvents, s.handleEvents)
+	return mux
+}
+
+// handle registers the handler of the route, counting its requests and answering
+// the failure set for it instead if any
+func (s *Server) handle(mux *http.ServeMux, route string, handler http.HandlerFunc) {
+	mux.HandleFunc("GET "+route, func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		s.requests[route]++
+		status, failing := s.failures[route]
+		s.mu.Unlock()
+		if failing {
+			writeError(w, status, "failure set for the route")
+			return
+		}
+		handler(w, r)
+	})
+}
+
+// SetFailure answers the status code (i.e. 500 or 404) to every request to the route,
+// until it is set back to http.StatusOK
+func (s *Server) SetFailure(route string, status int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if status == http.StatusOK {
+		delete(s.failures, route)
+		return
+	}
+	s.failures[route] = status
+}
+
+// Requests returns the number of requests received by the route, failed ones included
+func (s *Server) Requests(route string) int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.requests[route]
+}
+
+// ---- chain setup ----
+
+// AddBlock adds (or replaces, to simulate a reorg) the block at its slot
+func (s *Server) AddBlock(block *spec.VersionedSignedBeaconBlock) error {
+	slot, err := block.Slot()
+	if err != nil {
+		return err
+	}
+	root, err := block.Root()
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.blocks[slot] = block
+	s.blockRoots[slot] = root
+	return nil
+}
+
+// Block returns the block served at the slot
+func (s *Server) Block(slot phase0.Slot) (*spec.VersionedSignedBeaconBlock, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	block, ok := s.blocks[slot]
+	return block, ok
+}
+
+// RemoveBlock leaves the slot empty (missed block)
+func (s *Server) RemoveBlock(slot phase0.Slot) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.blocks, slot)
+	delete(s.blockRoots, slot)
+}
+
+// AddState adds (or replaces) the state at its slot
+func (s *Server) AddState(state *spec.VersionedBeaconState) error {
+	slot, err := state.Slot()
+	if err != nil {
+		return err
+	}
+	obj, err := stateObject(state)
+	if err != nil {
+		return err
+	}
+	root, err := obj.HashTreeRoot()
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.states[slot] = state
+	s.stateHashes[slot] = root
+	return nil
+}
+
+// SetStateRoot overrides the state root served for the slot
+func (s *Server) SetStateRoot(slot phase0.Slot, root phase0.Root) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.stateRoots[slot] = root
+}
+
+// SetSyncing sets the sync status answered by /eth/v1/node/syncing
+func (s *Server) SetSyncing(syncing bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.syncing = syncing
+}
+
+// SetJSONOnly answers the blocks and states as JSON even when SSZ is requested
+func (s *Server) SetJSONOnly(jsonOnly bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.jsonOnly = jsonOnly
+}
+
+// SetHead fixes the head slot, by default it is the highest block
+func (s *Server) SetHead(slot phase0.Slot) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.head = &slot
+}
+
+func (s *Server) SetGenesis(genesis *apiv1.Genesis) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.genesis = genesis
+}
+
+// SetSpec replaces the config served by /eth/v1/config/spec
+func (s *Server) SetSpec(spec map[string]string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.spec = spec
+}
+
+func (s *Server) SetFinality(finality *apiv1.Finality) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.finality = finality
+}
+
+func (s *Server) SetCommittees(epoch phase0.Epoch, committees []*apiv1.BeaconCommittee) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.committees[epoch] = committees
+}
+
+// SetProposerDuties sets the duties of the epoch, by default they are taken from the blocks
+func (s *Server) SetProposerDuties(epoch phase0.Epoch, duties []*apiv1.ProposerDuty) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.proposerDuties[epoch] = duties
+}
+
+func (s *Server) SetBlockRewards(slot phase0.Slot, rewards local_spec.BlockRewardsContent) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.blockRewards[slot] = rewards
+}
+
+func (s *Server) SetBlobSidecars(slot phase0.Slot, sidecars []*deneb.BlobSidecar) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.blobSidecars[slot] = sidecars
+}
+
+// ---- id resolution, the lock has to be held ----
+
+func (s *Server) headSlot() phase0.Slot {
+	if s.head != nil {
+		return *s.head
+	}
+	head := phase0.Slot(0)
+	for slot := range s.blocks {
+		if slot > head {
+			head = slot
+		}
+	}
+	return head
+}
+
+func (s *Server) checkpointSlot(checkpoint *phase0.Checkpoint) phase0.Slot {
+	for slot, root := range s.blockRoots {
+		if root == checkpoint.Root && checkpoint.Root != (phase0.Root{}) {
+			return slot
+		}
+	}
+	return phase0.Slot(uint64(checkpoint.Epoch) * local_spec.SlotsPerEpoch)
+}
+
+// resolveSlot translates a block or state id (head, genesis, finalized,
+// justified, slot or root) into a slot
+func (s *Server) resolveSlot(id string, rootOf func(phase0.Slot) (phase0.Root, bool), slots func() []phase0.Slot) (phase0.Slot, bool) {
+	switch id {
+	case "head":
+		return s.headSlot(), true
+	case "genesis":
+		return 0, true
+	case "finalized":
+		return s.checkpointSlot(s.finality.Finalized), true
+	case "justified":
+		return s.checkpointSlot(s.finality.Justified), true
+	}
+	if strings.HasPrefix(id, "0x") {
+		for _, slot := range slots() {
+			if root, ok := rootOf(slot); ok && fmt.Sprintf("%#x", root) == id {
+				return slot, true
+			}
+		}
+		return 0, false
+	}
+	slot, err := strconv.ParseUint(id, 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return phase0.Slot(slot), true
+}
+
+func (s *Server) resolveBlock(id string) (*spec.VersionedSignedBeaconBlock, bool) {
+	slot, ok := s.resolveSlot(id,
+		func(slot phase0.Slot) (phase0.Root, bool) {
+			root, ok := s.blockRoots[slot]
+			return root, ok
+		},
+		func() []phase0.Slot { return sortedKeys(s.blockRoots) })
+	if !ok {
+		return nil, false
+	}
+	block, ok := s.blocks[slot]
+	return block, ok
+}
+
+func (s *Server) stateRoot(slot phase0.Slot) (phase0.Root, bool) {
+	if root, ok := s.stateRoots[slot]; ok {
+		return root, true
+	}
+	if root, ok := s.stateHashes[slot]; ok {
+		return root, true
+	}
+	if block, ok := s.blocks[slot]; ok {
+		root, err := block.StateRoot()
+		return root, err == nil
+	}
+	return phase0.Root{}, false
+}
+
+func (s *Server) resolveState(id string) (phase0.Slot, bool) {
+	return s.resolveSlot(id, s.stateRoot, func() []phase0.Slot {
+		slots := sortedKeys(s.stateRoots)
+		slots = append(slots, sortedKeys(s.stateHashes)...)
+		return append(slots, sortedKeys(s.blocks)...)
+	})
+}
+
+func sortedKeys[V any](m map[phase0.Slot]V) []phase0.Slot {
+	keys := make([]phase0.Slot, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+	return keys
+}
+
+// ---- handlers ----
+
+func writeJSON(w http.ResponseWriter, response any) {
+	w.Header().Set("Content-Type", contentTypeJSON)
+	json.NewEncoder(w).Encode(response)
+}
+
+func writeData(w http.ResponseWriter, data any) {
+	writeJSON(w, map[string]any{
+		"execution_optimistic": false,
+		"finalized":            false,
+		"data":                 data,
+	})
+}
+
+func writeError(w http.ResponseWriter, code int, message string) {
+	w.Header().Set("Content-Type", contentTypeJSON)
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(map[string]any{
+		"code":    code,
+		"message": message,
+	})
+}
+
+func (s *Server) acceptsSSZ(r *http.Request) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return !s.jsonOnly && strings.Contains(r.Header.Get("Accept"), contentTypeSSZ)
+}
+
+// writeVersioned writes a block or state as SSZ if accepted, JSON otherwise
+func (s *Server) writeVersioned(w http.ResponseWriter, r *http.Request, version spec.DataVersion, obj sszObject) {
+	w.Header().Set("Eth-Consensus-Version", version.String())
+	if !s.acceptsSSZ(r) {
+		writeJSON(w, map[string]any{
+			"version":              version.String(),
+			"execution_optimistic": false,
+			"finalized":            false,
+			"data":                 obj,
+		})
+		return
+	}
+	data, err := obj.MarshalSSZ()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	w.Header().Set("Content-Type", contentTypeSSZ)
+	w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+	w.Write(data)
+}
+
+func (s *Server) handleSyncing(w http.ResponseWriter, r *http.Request) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	writeData(w, map[string]any{
+		"head_slot":     fmt.Sprintf("%d", s.headSlot()),
+		"sync_distance": "0",
+		"is_syncing":    s.syncing,
+		"is_optimistic": false,
+		"el_offline":    false,
+	})
+}
+
+func (s *Server) handleVersion(w http.ResponseWriter, r *http.Request) {
+	writeData(w, map[string]any{"version": NodeVersion})
+}
+
+func (s *Server) handleGenesis(w http.ResponseWriter, r *http.Request) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	writeData(w, s.genesis)
+}
+
+func (s *Server) handleSpec(w http.ResponseWriter, r *http.Request) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	writeData(w, s.spec)
+}
+
+func (s *Server) handleBlock(w http.ResponseWriter, r *http.Request) {
+	s.mu.RLock()
+	block, ok := s.resolveBlock(r.PathValue("block_id"))
+	s.mu.RUnlock()
+	if !ok {
+		writeError(w, http.StatusNotFound, "block not found")
+		return
+	}
+	obj, err := blockObject(block)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	s.writeVersioned(w, r, block.Version, obj)
+}
+
+func (s *Server) handleBlockRoot(w http.ResponseWriter, r *http.Request) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	block, ok := s.resolveBlock(r.PathValue("block_id"))
+	if !ok {
+		writeError(w, http.StatusNotFound, "block not found")
+		return
+	}
+	root, _ := block.Root()
+	writeData(w, map[string]any{"root": fmt.Sprintf("%#x", root)})
+}
+
+func (s *Server) handleHeader(w http.ResponseWriter, r *http.Request) {
+	s.mu.RLock()
+	block, ok := s.resolveBlock(r.PathValue("block_id"))
+	s.mu.RUnlock()
+	if !ok {
+		writeError(w, http.StatusNotFound, "block not found")
+		return
+	}
+
+	header, err := blockHeader(block)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	root, _ := block.Root()
+	writeData(w, &apiv1.BeaconBlockHeader{
+		Root:      root,
+		Canonical: true,
+		Header: &phase0.SignedBeaconBlockHeader{
+			Message: header,
+		},
+	})
+}
+
+func blockHeader(block *spec.VersionedSignedBeaconBlock) (*phase0.BeaconBlockHeader, error) {
+	var err error
+	header := &phase0.BeaconBlockHeader{}
+	if header.Slot, err = block.Slot(); err != nil {
+		return nil, err
+	}
+	if header.ProposerIndex, err = block.ProposerIndex(); err != nil {
+		return nil, err
+	}
+	if header.ParentRoot, err = block.ParentRoot(); err != nil {
+		return nil, err
+	}
+	if header.StateRoot, err = block.StateRoot(); err != nil {
+		return nil, err
+	}
+	if header.BodyRoot, err = block.BodyRoot(); err != nil {
+		return nil, err
+	}
+	return header, nil
+}
+
+func (s *Server) handleState(w http.ResponseWriter, r *http.Request) {
+	s.mu.RLock()
+	slot, ok := s.resolveState(r.PathValue("state_id"))
+	state, found := s.states[slot]
+	s.mu.RUnlock()
+	if !ok || !found {
+		writeError(w, http.StatusNotFound, "state not found")
+		return
+	}
+	obj, err := stateObject(state)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	s.writeVersioned(w, r, state.Version, obj)
+}
+
+func (s *Server) handleStateRoot(w http.ResponseWriter, r *http.Request) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	slot, ok := s.resolveState(r.PathValue("state_id"))
+	if !ok {
+		writeError(w, http.StatusNotFound, "state not found")
+		return
+	}
+	root, ok := s.stateRoot(slot)
+	if !ok {
+		writeError(w, http.StatusNotFound, "state not found")
+		return
+	}
+	writeData(w, map[string]any{"root": fmt.Sprintf("%#x", root)})
+}
+
+func (s *Server) handleFinality(w http.ResponseWriter, r *http.Request) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	writeData(w, s.finality)
+}
+
+func (s *Server) handleCommittees(w http.ResponseWriter, r *http.Request) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	slot, ok := s.resolveState(r.PathValue("state_id"))
+	if !ok {
+		writeError(w, http.StatusNotFound, "state not found")
+		return
+	}
+	epoch := local_spec.EpochAtSlot(slot)
+	if value := r.URL.Query().Get("epoch"); value != "" {
+		parsed, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "invalid epoch")
+			return
+		}
+		epoch = phase0.Epoch(parsed)
+	}
+
+	committees := make([]*apiv1.BeaconCommittee, 0)
+	for _, committee := range s.committees[epoch] {
+		if value := r.URL.Query().Get("slot"); value != "" && value != fmt.Sprintf("%d", committee.Slot) {
+			continue
+		}
+		committees = append(committees, committee)
+	}
+	writeData(w, committees)
+}
+
+func (s *Server) handleProposerDuties(w http.ResponseWriter, r *http.Request) {
+	parsed, err := strconv.ParseUint(r.PathValue("epoch"), 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid epoch")
+		return
+	}
+	epoch := phase0.Epoch(parsed)
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	duties, ok := s.proposerDuties[epoch]
+	if !ok {
+		duties = make([]*apiv1.ProposerDuty, 0)
+		firstSlot := local_spec.ComputeStartSlotAtEpoch(epoch)
+		for slot := firstSlot; slot < firstSlot+phase0.Slot(local_spec.SlotsPerEpoch); slot++ {
+			block, ok := s.blocks[slot]
+			if !ok {
+				continue
+			}
+			proposer, _ := block.ProposerIndex()
+			duties = append(duties, &apiv1.ProposerDuty{
+				Slot:           slot,
+				ValidatorIndex: proposer,
+			})
+		}
+	}
+	writeJSON(w, map[string]any{
+		"dependent_root":       fmt.Sprintf("%#x", phase0.Root{}),
+		"execution_optimistic": false,
+		"data":                 duties,
+	})
+}
+
+func (s *Server) handleBlockRewards(w http.ResponseWriter, r *http.Request) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	block, ok := s.resolveBlock(r.PathValue("block_id"))
+	if !ok {
+		writeError(w, http.StatusNotFound, "block not found")
+		return
+	}
+	slot, _ := block.Slot()
+	rewards, ok := s.blockRewards[slot]
+	if !ok {
+		writeError(w, http.StatusNotFound, "block rewards not found")
+		return
+	}
+	writeJSON(w, local_spec.BlockRewards{Data: rewards})
+}
+
+func (s *Server) handleBlobSidecars(w http.ResponseWriter, r *http.Request) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	block, ok := s.resolveBlock(r.PathValue("block_id"))
+	if !ok {
+		writeError(w, http.StatusNotFound, "block not found")
+		return
+	}
+	slot, _ := block.Slot()
+	sidecars, ok := s.blobSidecars[slot]
+	if !ok {
+		sidecars = make([]*deneb.BlobSidecar, 0)
+	}
+	writeData(w, sidecars)
+}
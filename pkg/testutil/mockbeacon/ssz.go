@@ -0,0 +1,115 @@
+package mockbeacon
+
+import (
+	"fmt"
+
+	"github.com/attestantio/go-eth2-client/spec"
+	"github.com/attestantio/go-eth2-client/spec/altair"
+	"github.com/attestantio/go-eth2-client/spec/bellatrix"
+	"github.com/attestantio/go-eth2-client/spec/capella"
+	"github.com/attestantio/go-eth2-client/spec/deneb"
+	"github.com/attestantio/go-eth2-client/spec/electra"
+	"github.com/attestantio/go-eth2-client/spec/fulu"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+)
+
+// sszObject is implemented by every fork specific block and state
+type sszObject interface {
+	MarshalSSZ() ([]byte, error)
+	UnmarshalSSZ(buf []byte) error
+	HashTreeRoot() ([32]byte, error)
+}
+
+// blockObject returns the fork specific block inside the versioned one
+func blockObject(block *spec.VersionedSignedBeaconBlock) (sszObject, error) {
+	var obj sszObject
+	switch block.Version {
+	case spec.DataVersionPhase0:
+		obj = block.Phase0
+	case spec.DataVersionAltair:
+		obj = block.Altair
+	case spec.DataVersionBellatrix:
+		obj = block.Bellatrix
+	case spec.DataVersionCapella:
+		obj = block.Capella
+	case spec.DataVersionDeneb:
+		obj = block.Deneb
+	case spec.DataVersionElectra:
+		obj = block.Electra
+	case spec.DataVersionFulu:
+		obj = block.Fulu
+	default:
+		return nil, fmt.Errorf("unsupported block version %s", block.Version)
+	}
+	return obj, nil
+}
+
+// newBlock returns an empty versioned block and the fork specific block to decode into
+func newBlock(version spec.DataVersion) (*spec.VersionedSignedBeaconBlock, sszObject, error) {
+	block := &spec.VersionedSignedBeaconBlock{Version: version}
+	switch version {
+	case spec.DataVersionPhase0:
+		block.Phase0 = &phase0.SignedBeaconBlock{}
+	case spec.DataVersionAltair:
+		block.Altair = &altair.SignedBeaconBlock{}
+	case spec.DataVersionBellatrix:
+		block.Bellatrix = &bellatrix.SignedBeaconBlock{}
+	case spec.DataVersionCapella:
+		block.Capella = &capella.SignedBeaconBlock{}
+	case spec.DataVersionDeneb:
+		block.Deneb = &deneb.SignedBeaconBlock{}
+	case spec.DataVersionElectra:
+		block.Electra = &electra.SignedBeaconBlock{}
+	case spec.DataVersionFulu:
+		block.Fulu = &electra.SignedBeaconBlock{}
+	}
+	obj, err := blockObject(block)
+	return block, obj, err
+}
+
+// stateObject returns the fork specific state inside the versioned one
+func stateObject(state *spec.VersionedBeaconState) (sszObject, error) {
+	var obj sszObject
+	switch state.Version {
+	case spec.DataVersionPhase0:
+		obj = state.Phase0
+	case spec.DataVersionAltair:
+		obj = state.Altair
+	case spec.DataVersionBellatrix:
+		obj = state.Bellatrix
+	case spec.DataVersionCapella:
+		obj = state.Capella
+	case spec.DataVersionDeneb:
+		obj = state.Deneb
+	case spec.DataVersionElectra:
+		obj = state.Electra
+	case spec.DataVersionFulu:
+		obj = state.Fulu
+	default:
+		return nil, fmt.Errorf("unsupported state version %s", state.Version)
+	}
+	return obj, nil
+}
+
+// newState returns an empty versioned state and the fork specific state to decode into
+func newState(version spec.DataVersion) (*spec.VersionedBeaconState, sszObject, error) {
+	state := &spec.VersionedBeaconState{Version: version}
+	switch version {
+	case spec.DataVersionPhase0:
+		state.Phase0 = &phase0.BeaconState{}
+	case spec.DataVersionAltair:
+		state.Altair = &altair.BeaconState{}
+	case spec.DataVersionBellatrix:
+		state.Bellatrix = &bellatrix.BeaconState{}
+	case spec.DataVersionCapella:
+		state.Capella = &capella.BeaconState{}
+	case spec.DataVersionDeneb:
+		state.Deneb = &deneb.BeaconState{}
+	case spec.DataVersionElectra:
+		state.Electra = &electra.BeaconState{}
+	case spec.DataVersionFulu:
+		state.Fulu = &fulu.BeaconState{}
+	}
+	obj, err := stateObject(state)
+	return state, obj, err
+}
@@ -0,0 +1,206 @@
+// Package mocknats is a NATS server implementing the part of the protocol used
+// by the nats client of the goteth publisher (CONNECT, PUB, PING/PONG), so the
+// published messages can be checked without a real broker.
+package mocknats
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+var (
+	DefaultMaxPayload = 1024 * 1024
+)
+
+// Message is a message received by the server
+type Message struct {
+	Subject string
+	Data    []byte
+}
+
+// Server accepts every connection, or only the ones with the token set with RequireToken
+type Server struct {
+	listener net.Listener
+	wg       sync.WaitGroup
+
+	mu         sync.Mutex
+	token      string
+	maxPayload int
+	conns      map[net.Conn]struct{}
+	messages   []Message
+	connects   int
+}
+
+// New starts a server on a random local port, it has to be closed with Close
+func New() (*Server, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+	s := &Server{
+		listener:   listener,
+		maxPayload: DefaultMaxPayload,
+		conns:      make(map[net.Conn]struct{}),
+	}
+	s.wg.Add(1)
+	go s.accept()
+	return s, nil
+}
+
+// Url returns the url of the server, nats://127.0.0.1:<port>
+func (s *Server) Url() string {
+	return "nats://" + s.listener.Addr().String()
+}
+
+// RequireToken refuses the connections without the given auth token
+func (s *Server) RequireToken(token string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.token = token
+}
+
+// SetMaxPayload sets the max payload announced to the clients
+func (s *Server) SetMaxPayload(maxPayload int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.maxPayload = maxPayload
+}
+
+// Messages returns the messages received so far, in order
+func (s *Server) Messages() []Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Message(nil), s.messages...)
+}
+
+// MessagesOn returns the messages received on the subject
+func (s *Server) MessagesOn(subject string) []Message {
+	result := make([]Message, 0)
+	for _, msg := range s.Messages() {
+		if msg.Subject == subject {
+			result = append(result, msg)
+		}
+	}
+	return result
+}
+
+// Connects returns the number of connections accepted so far
+func (s *Server) Connects() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.connects
+}
+
+// DropConnections closes the open connections, like a server restart
+func (s *Server) DropConnections() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for conn := range s.conns {
+		conn.Close()
+	}
+}
+
+func (s *Server) Close() {
+	s.listener.Close()
+	s.DropConnections()
+	s.wg.Wait()
+}
+
+func (s *Server) accept() {
+	defer s.wg.Done()
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		s.mu.Lock()
+		s.conns[conn] = struct{}{}
+		s.mu.Unlock()
+
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			s.serve(conn)
+
+			s.mu.Lock()
+			delete(s.conns, conn)
+			s.mu.Unlock()
+			conn.Close()
+		}()
+	}
+}
+
+func (s *Server) serve(conn net.Conn) {
+	s.mu.Lock()
+	token, maxPayload := s.token, s.maxPayload
+	s.mu.Unlock()
+
+	info, _ := json.Marshal(map[string]any{
+		"server_id":     "mocknats",
+		"version":       "2.10.0",
+		"proto":         1,
+		"max_payload":   maxPayload,
+		"auth_required": token != "",
+	})
+	fmt.Fprintf(conn, "INFO %s\r\n", info)
+
+	reader := bufio.NewReader(conn)
+	connected := false
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		op, args, _ := strings.Cut(line, " ")
+
+		switch strings.ToUpper(op) {
+		case "CONNECT":
+			var options struct {
+				AuthToken string `json:"auth_token"`
+			}
+			if err := json.Unmarshal([]byte(args), &options); err != nil {
+				fmt.Fprintf(conn, "-ERR 'Invalid Connect'\r\n")
+				return
+			}
+			if token != "" && options.AuthToken != token {
+				fmt.Fprintf(conn, "-ERR 'Authorization Violation'\r\n")
+				return
+			}
+			connected = true
+			s.mu.Lock()
+			s.connects++
+			s.mu.Unlock()
+		case "PING":
+			fmt.Fprintf(conn, "PONG\r\n")
+		case "PONG":
+		case "PUB":
+			fields := strings.Fields(args)
+			if !connected || len(fields) < 2 {
+				fmt.Fprintf(conn, "-ERR 'Unknown Protocol Operation'\r\n")
+				return
+			}
+			size, err := strconv.Atoi(fields[len(fields)-1])
+			if err != nil || size > maxPayload {
+				fmt.Fprintf(conn, "-ERR 'Maximum Payload Violation'\r\n")
+				return
+			}
+			data := make([]byte, size+2) // payload and \r\n
+			if _, err := io.ReadFull(reader, data); err != nil {
+				return
+			}
+			s.mu.Lock()
+			s.messages = append(s.messages, Message{Subject: fields[0], Data: data[:size]})
+			s.mu.Unlock()
+		default:
+			fmt.Fprintf(conn, "-ERR 'Unknown Protocol Operation'\r\n")
+			return
+		}
+	}
+}
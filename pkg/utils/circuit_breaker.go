@@ -0,0 +1,61 @@
+package utils
+
+import (
+	"sync"
+	"time"
+)
+
+// CircuitBreaker skips an endpoint for a cooldown period after a number of consecutive
+// failures, instead of waiting for it to time out on every request
+type CircuitBreaker struct {
+	threshold int
+	cooldown  time.Duration
+
+	mu              sync.Mutex
+	consecutiveFail int
+	openUntil       time.Time
+}
+
+func NewCircuitBreaker(threshold int, cooldown time.Duration) *CircuitBreaker {
+	return &CircuitBreaker{
+		threshold: threshold,
+		cooldown:  cooldown,
+	}
+}
+
+// IsOpen returns true if the circuit breaker is open (the endpoint should be skipped)
+func (c *CircuitBreaker) IsOpen() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.consecutiveFail < c.threshold {
+		return false
+	}
+	if time.Now().After(c.openUntil) {
+		// Cooldown expired — allow one probe attempt (half-open)
+		c.consecutiveFail = c.threshold - 1
+		return false
+	}
+	return true
+}
+
+// RecordResult updates the circuit breaker after a request, it returns true if the
+// failure opened it
+func (c *CircuitBreaker) RecordResult(failed bool) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !failed {
+		c.consecutiveFail = 0
+		return false
+	}
+	c.consecutiveFail++
+	if c.consecutiveFail < c.threshold {
+		return false
+	}
+	c.openUntil = time.Now().Add(c.cooldown)
+	return true
+}
+
+// Cooldown returns how long the endpoint is skipped once the circuit breaker opens
+func (c *CircuitBreaker) Cooldown() time.Duration {
+	return c.cooldown
+}
@@ -0,0 +1,28 @@
+package utils
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	breaker := NewCircuitBreaker(2, time.Hour)
+	assert.False(t, breaker.RecordResult(true))
+	assert.False(t, breaker.IsOpen())
+	assert.True(t, breaker.RecordResult(true), "opened by the second failure in a row")
+	assert.True(t, breaker.IsOpen())
+
+	// once the cooldown expires a single request is let through
+	breaker.openUntil = time.Now().Add(-time.Second)
+	assert.False(t, breaker.IsOpen())
+	assert.True(t, breaker.RecordResult(true), "the probe failed")
+	assert.True(t, breaker.IsOpen())
+
+	breaker.openUntil = time.Now().Add(-time.Second)
+	assert.False(t, breaker.IsOpen())
+	assert.False(t, breaker.RecordResult(false))
+	assert.False(t, breaker.RecordResult(true), "a success resets the failures")
+	assert.False(t, breaker.IsOpen())
+}
@@ -2,6 +2,7 @@ package utils
 
 import (
 	"bufio"
+	"encoding/hex"
 	"fmt"
 	"os"
 	"strconv"
@@ -151,3 +152,78 @@ type PoolKeys struct {
 	PoolName string
 	ValIdxs  []phase0.ValidatorIndex
 }
+
+// TrackedValidators is the set of validators to index, given by index or by public key
+type TrackedValidators struct {
+	Indexes map[phase0.ValidatorIndex]bool
+	Pubkeys map[phase0.BLSPubKey]bool
+}
+
+// Contains returns whether the validator is tracked, a nil set tracks every validator
+func (t *TrackedValidators) Contains(valIdx phase0.ValidatorIndex, pubkey phase0.BLSPubKey) bool {
+	if t == nil {
+		return true
+	}
+	return t.Indexes[valIdx] || t.Pubkeys[pubkey]
+}
+
+func (t *TrackedValidators) Len() int {
+	if t == nil {
+		return 0
+	}
+	return len(t.Indexes) + len(t.Pubkeys)
+}
+
+// ReadValidatorsFile reads the validators to track, one per line, as a validator index
+// or a 0x prefixed public key. Only the first column is read, so the custom pools file
+// (val_idx,custom_pool) is also valid. Empty lines and lines starting with # are skipped
+func ReadValidatorsFile(validatorsFile string) (*TrackedValidators, error) {
+	log.Info("Reading tracked validators from: ", validatorsFile)
+	tracked := &TrackedValidators{
+		Indexes: make(map[phase0.ValidatorIndex]bool),
+		Pubkeys: make(map[phase0.BLSPubKey]bool),
+	}
+
+	file, err := os.Open(validatorsFile)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(strings.Split(scanner.Text(), ",")[0])
+
+		if line == "" || strings.HasPrefix(line, "#") || line == "val_idx" {
+			continue
+		}
+
+		if strings.HasPrefix(line, "0x") {
+			pubkeyBytes, err := hex.DecodeString(line[2:])
+			if err != nil || len(pubkeyBytes) != phase0.PublicKeyLength {
+				return nil, errors.Errorf("could not parse public key at line %d: %s", lineNum, line)
+			}
+			tracked.Pubkeys[phase0.BLSPubKey(pubkeyBytes)] = true
+			continue
+		}
+
+		valIdx, err := strconv.ParseUint(line, 10, 64)
+		if err != nil {
+			return nil, errors.Wrap(err, fmt.Sprintf("could not parse validator index at line %d", lineNum))
+		}
+		tracked.Indexes[phase0.ValidatorIndex(valIdx)] = true
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if tracked.Len() == 0 {
+		return nil, errors.Errorf("no validators found in %s", validatorsFile)
+	}
+
+	log.Infof("Done reading %d tracked validators from %s", tracked.Len(), validatorsFile)
+	return tracked, nil
+}
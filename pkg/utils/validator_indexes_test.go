@@ -0,0 +1,41 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeValidatorsFile(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), "validators.csv")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	return path
+}
+
+func TestReadValidatorsFile(t *testing.T) {
+	pubkey := phase0.BLSPubKey{0xaa, 0xbb}
+	path := writeValidatorsFile(t, "# our validators\nval_idx,custom_pool\n12,pool_a\n\n 7 \n"+pubkey.String()+"\n")
+
+	tracked, err := ReadValidatorsFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, 3, tracked.Len())
+	assert.True(t, tracked.Contains(12, phase0.BLSPubKey{}))
+	assert.True(t, tracked.Contains(7, phase0.BLSPubKey{}))
+	assert.True(t, tracked.Contains(100, pubkey))
+	assert.False(t, tracked.Contains(100, phase0.BLSPubKey{}))
+
+	// a nil set tracks every validator
+	var all *TrackedValidators
+	assert.True(t, all.Contains(100, phase0.BLSPubKey{}))
+}
+
+func TestReadValidatorsFileErrors(t *testing.T) {
+	for _, content := range []string{"", "# nothing\n", "abc\n", "0x1234\n"} {
+		_, err := ReadValidatorsFile(writeValidatorsFile(t, content))
+		assert.Error(t, err, content)
+	}
+}
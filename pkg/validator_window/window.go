@@ -23,7 +23,7 @@ var (
 
 type ValidatorWindowRunner struct {
 	ctx              context.Context
-	dbClient         *db.DBService  // client to communicate with psql
+	dbClient         db.Sink        // storage backend where metrics are persisted
 	eventsObj        events.Events  // object to receive signals from beacon node (needed to trigger the deletes)
 	stop             bool           // used to know if the tool should stop
 	windowEpochSize  int            // number of epochs of data to maintain in the database
@@ -35,18 +35,11 @@ func NewValidatorWindow(
 	iConfig config.ValidatorWindowConfig) (*ValidatorWindowRunner, error) {
 
 	// database
-	idbClient, err := db.New(pCtx, iConfig.DBUrl)
+	idbClient, err := db.NewSink(pCtx, iConfig.DBUrl)
 	if err != nil {
 		return &ValidatorWindowRunner{
 			ctx: pCtx,
-		}, errors.Wrap(err, "unable to generate DB Client.")
-	}
-	err = idbClient.Connect()
-
-	if err != nil {
-		return &ValidatorWindowRunner{
-			ctx: pCtx,
-		}, errors.Wrap(err, "unable to connect DB Client.")
+		}, errors.Wrap(err, "unable to init DB Client.")
 	}
 
 	// beacon node